@@ -0,0 +1,14 @@
+package authentication
+
+import (
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+)
+
+// Authenticator verifies credentials against an external identity source like a directory
+type Authenticator interface {
+	// Authenticate returns the user identified by the credentials or ErrUnauthorized if they are not valid
+	Authenticate(email, password string) (*models.ExternalUser, *fcerror.Error)
+	// ListUsers returns all users known to the identity source to keep local users in sync
+	ListUsers() ([]*models.ExternalUser, *fcerror.Error)
+}
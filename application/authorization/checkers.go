@@ -16,12 +16,13 @@ func EnforceSystem(ctx *Context) *fcerror.Error {
 	}
 }
 
+// EnforceAdmin requires the admin role, prefer enforcing a specific permission with Enforce
 func EnforceAdmin(ctx *Context) *fcerror.Error {
 	switch ctx.Type {
 	case ContextTypeSystem:
 		return nil
 	case ContextTypeUser:
-		if ctx.User.IsAdmin {
+		if ctx.User.HasRole(models.RoleAdmin) && (ctx.Scope == nil || !ctx.Scope.IsRestricted()) {
 			return nil
 		}
 		return fcerror.NewErrorSkipFunc(fcerror.ErrForbidden, nil)
@@ -39,16 +40,48 @@ func EnforceUser(ctx *Context) *fcerror.Error {
 	}
 }
 
-func EnforceSelf(ctx *Context, targetUserID models.UserID) *fcerror.Error {
-	switch ctx.Type {
-	case ContextTypeSystem:
-		return nil
-	case ContextTypeUser:
-		if ctx.User.IsAdmin || ctx.User.ID == targetUserID {
-			return nil
-		}
+// EnforceWriteScope rejects contexts of read-only access tokens
+func EnforceWriteScope(ctx *Context) *fcerror.Error {
+	if ctx.Scope != nil && ctx.Scope.ReadOnly {
 		return fcerror.NewErrorSkipFunc(fcerror.ErrForbidden, nil)
-	default:
+	}
+	return nil
+}
+
+// EnforceFullScope rejects contexts of access tokens which are read-only or limited to a folder
+func EnforceFullScope(ctx *Context) *fcerror.Error {
+	if ctx.Scope != nil && ctx.Scope.IsRestricted() {
+		return fcerror.NewErrorSkipFunc(fcerror.ErrForbidden, nil)
+	}
+	return nil
+}
+
+// EnforceNoAccessToken rejects all contexts authenticated by an access token, e.g. to keep tokens from creating new tokens
+func EnforceNoAccessToken(ctx *Context) *fcerror.Error {
+	if ctx.Scope != nil {
+		return fcerror.NewErrorSkipFunc(fcerror.ErrForbidden, nil)
+	}
+	return nil
+}
+
+// EnforceFileDropCreate only allows creating new files directly in the target folder of the file drop
+func EnforceFileDropCreate(ctx *Context, node *models.Node) *fcerror.Error {
+	if ctx.Type != ContextTypeFileDrop {
 		return fcerror.NewErrorSkipFunc(fcerror.ErrUnauthorized, nil)
 	}
+	if node.Type != models.NodeTypeFile || node.ParentNodeID == nil || *node.ParentNodeID != ctx.FileDrop.NodeID {
+		return fcerror.NewErrorSkipFunc(fcerror.ErrForbidden, nil)
+	}
+	return nil
+}
+
+// EnforceFileDropUpload only allows uploading to files created through the file drop
+func EnforceFileDropUpload(ctx *Context, nodeID models.NodeID) *fcerror.Error {
+	if ctx.Type != ContextTypeFileDrop {
+		return fcerror.NewErrorSkipFunc(fcerror.ErrUnauthorized, nil)
+	}
+	if _, ok := ctx.fileDropNodeIDs[nodeID]; !ok {
+		return fcerror.NewErrorSkipFunc(fcerror.ErrForbidden, nil)
+	}
+	return nil
 }
@@ -8,11 +8,22 @@ const (
 	ContextTypeSystem ContextType = iota
 	ContextTypeAnonymous
 	ContextTypeUser
+	ContextTypeFileDrop
 )
 
 type Context struct {
-	Type ContextType
-	User *models.User
+	Type     ContextType
+	User     *models.User
+	FileDrop *models.FileDrop
+
+	// Scope is set if the user is authenticated by an access token
+	Scope *models.TokenScope
+
+	// ClientIP is the address the request came from, it is recorded in the audit log
+	ClientIP string
+
+	// Nodes created through the file drop in this context, only those may be uploaded to
+	fileDropNodeIDs map[models.NodeID]struct{}
 }
 
 func NewSystem() *Context {
@@ -23,6 +34,24 @@ func NewUser(user *models.User) *Context {
 	return &Context{Type: ContextTypeUser, User: user}
 }
 
+// NewAccessToken creates a user context limited to the scope of the access token
+func NewAccessToken(user *models.User, scope *models.TokenScope) *Context {
+	return &Context{Type: ContextTypeUser, User: user, Scope: scope}
+}
+
 func NewAnonymous() *Context {
 	return &Context{Type: ContextTypeAnonymous}
 }
+
+// NewFileDrop creates a context for anonymous uploads acting as the owner of the file drop
+func NewFileDrop(owner *models.User, fileDrop *models.FileDrop) *Context {
+	return &Context{Type: ContextTypeFileDrop, User: owner, FileDrop: fileDrop, fileDropNodeIDs: map[models.NodeID]struct{}{}}
+}
+
+// AddFileDropNode allows uploading to a node that was created through the file drop of this context
+func (ctx *Context) AddFileDropNode(nodeID models.NodeID) {
+	if ctx.Type != ContextTypeFileDrop {
+		return
+	}
+	ctx.fileDropNodeIDs[nodeID] = struct{}{}
+}
@@ -0,0 +1,85 @@
+package authorization
+
+import (
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+)
+
+type Permission string
+
+const (
+	PermissionReadUsers     Permission = "users:read"
+	PermissionUpdateUsers   Permission = "users:update"
+	PermissionCreateUsers   Permission = "users:create"
+	PermissionDisableUsers  Permission = "users:disable"
+	PermissionDeleteUsers   Permission = "users:delete"
+	PermissionManageLogins  Permission = "logins:manage"
+	PermissionManageRoles   Permission = "roles:manage"
+	PermissionReadAuditLog  Permission = "audit:read"
+	PermissionManageGroups  Permission = "groups:manage"
+	PermissionManageStorage Permission = "storage:manage"
+)
+
+// rolePermissions lists the permissions granted by each role; admins are granted all permissions
+var rolePermissions = map[models.Role][]Permission{
+	models.RoleUserManager:  {PermissionReadUsers, PermissionUpdateUsers, PermissionCreateUsers, PermissionDisableUsers, PermissionDeleteUsers, PermissionManageLogins},
+	models.RoleAuditor:      {PermissionReadUsers, PermissionReadAuditLog},
+	models.RoleStorageAdmin: {PermissionManageGroups, PermissionManageStorage},
+}
+
+// ownerPermissions are granted to every user for resources owned by that user
+var ownerPermissions = map[Permission]bool{
+	PermissionReadUsers:   true,
+	PermissionUpdateUsers: true,
+}
+
+// Resource is the object a permission is enforced for
+type Resource struct {
+	OwnerID models.UserID
+}
+
+// UserResource describes a user as resource, owned by that user
+func UserResource(userID models.UserID) *Resource {
+	return &Resource{OwnerID: userID}
+}
+
+// Enforce checks that the context has the permission for the resource, which may be nil for global permissions
+func Enforce(ctx *Context, permission Permission, resource *Resource) *fcerror.Error {
+	switch ctx.Type {
+	case ContextTypeSystem:
+		return nil
+	case ContextTypeUser:
+		if resource != nil && resource.OwnerID == ctx.User.ID && ownerPermissions[permission] {
+			return nil
+		}
+		if HasPermission(ctx, permission) {
+			return nil
+		}
+		return fcerror.NewErrorSkipFunc(fcerror.ErrForbidden, nil)
+	default:
+		return fcerror.NewErrorSkipFunc(fcerror.ErrUnauthorized, nil)
+	}
+}
+
+// HasPermission reports whether one of the roles of the context user grants the permission.
+// Roles are not available to restricted access tokens and to file drops acting as their owner.
+func HasPermission(ctx *Context, permission Permission) bool {
+	if ctx.Type == ContextTypeSystem {
+		return true
+	}
+	if ctx.Type != ContextTypeUser || ctx.User == nil || (ctx.Scope != nil && ctx.Scope.IsRestricted()) {
+		return false
+	}
+
+	for _, role := range ctx.User.Roles() {
+		if role == models.RoleAdmin {
+			return true
+		}
+		for _, rolePermission := range rolePermissions[role] {
+			if rolePermission == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
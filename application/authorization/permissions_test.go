@@ -0,0 +1,167 @@
+package authorization
+
+import (
+	"testing"
+
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var allPermissions = []Permission{
+	PermissionReadUsers,
+	PermissionUpdateUsers,
+	PermissionCreateUsers,
+	PermissionDisableUsers,
+	PermissionDeleteUsers,
+	PermissionManageLogins,
+	PermissionManageRoles,
+	PermissionReadAuditLog,
+	PermissionManageGroups,
+	PermissionManageStorage,
+}
+
+func TestHasPermissionOfRoles(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    *models.User
+		granted []Permission
+	}{
+		{name: "No role", user: &models.User{ID: "user"}},
+		{name: "Admin flag", user: &models.User{ID: "user", IsAdmin: true}, granted: allPermissions},
+		{name: "Assigned admin role", user: &models.User{ID: "user", AssignedRoles: []models.Role{models.RoleAdmin}}},
+		{name: "User manager", user: &models.User{ID: "user", AssignedRoles: []models.Role{models.RoleUserManager}}, granted: []Permission{PermissionReadUsers, PermissionUpdateUsers, PermissionCreateUsers, PermissionDisableUsers, PermissionDeleteUsers, PermissionManageLogins}},
+		{name: "Auditor", user: &models.User{ID: "user", AssignedRoles: []models.Role{models.RoleAuditor}}, granted: []Permission{PermissionReadUsers, PermissionReadAuditLog}},
+		{name: "Storage admin", user: &models.User{ID: "user", AssignedRoles: []models.Role{models.RoleStorageAdmin}}, granted: []Permission{PermissionManageGroups, PermissionManageStorage}},
+		{name: "Combined roles", user: &models.User{ID: "user", AssignedRoles: []models.Role{models.RoleAuditor, models.RoleStorageAdmin}}, granted: []Permission{PermissionReadUsers, PermissionReadAuditLog, PermissionManageGroups, PermissionManageStorage}},
+		{name: "Unknown role", user: &models.User{ID: "user", AssignedRoles: []models.Role{"UNKNOWN"}}},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			ctx := NewUser(test.user)
+			for _, permission := range allPermissions {
+				expected := containsPermission(test.granted, permission)
+				assert.Equal(t, expected, HasPermission(ctx, permission), "Wrong result of HasPermission for %s", permission)
+
+				fcerr := Enforce(ctx, permission, nil)
+				if expected {
+					assert.Nil(t, fcerr, "Enforce rejected granted permission %s", permission)
+				} else {
+					require.NotNil(t, fcerr, "Enforce accepted permission %s which was not granted", permission)
+					assert.EqualValues(t, fcerror.ErrForbidden, fcerr.ID, "Enforce failed with wrong error for %s", permission)
+				}
+			}
+		})
+	}
+}
+
+func TestEnforceOwnerPermissions(t *testing.T) {
+	owner := &models.User{ID: "owner"}
+	ownResource := UserResource(owner.ID)
+	otherResource := UserResource("other")
+
+	for _, permission := range allPermissions {
+		t.Run(string(permission), func(t *testing.T) {
+			fcerr := Enforce(NewUser(owner), permission, ownResource)
+			if ownerPermissions[permission] {
+				assert.Nil(t, fcerr, "Owner was rejected for own resource")
+			} else {
+				require.NotNil(t, fcerr, "Owner was granted a permission which is not owner-only")
+				assert.EqualValues(t, fcerror.ErrForbidden, fcerr.ID, "Enforce failed with wrong error")
+			}
+
+			fcerr = Enforce(NewUser(owner), permission, otherResource)
+			require.NotNil(t, fcerr, "Owner permission was granted for the resource of another user")
+			assert.EqualValues(t, fcerror.ErrForbidden, fcerr.ID, "Enforce failed with wrong error")
+
+			fcerr = Enforce(NewUser(owner), permission, nil)
+			require.NotNil(t, fcerr, "Owner permission was granted without resource")
+			assert.EqualValues(t, fcerror.ErrForbidden, fcerr.ID, "Enforce failed with wrong error")
+		})
+	}
+}
+
+func TestEnforceContextTypes(t *testing.T) {
+	tests := []struct {
+		name          string
+		ctx           *Context
+		hasPermission bool
+		expectedErr   fcerror.ErrorID
+	}{
+		{name: "System", ctx: NewSystem(), hasPermission: true},
+		{name: "Anonymous", ctx: NewAnonymous(), expectedErr: fcerror.ErrUnauthorized},
+		{name: "File drop", ctx: NewFileDrop(&models.User{ID: "owner", IsAdmin: true}, &models.FileDrop{}), expectedErr: fcerror.ErrUnauthorized},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			for _, permission := range allPermissions {
+				assert.Equal(t, test.hasPermission, HasPermission(test.ctx, permission), "Wrong result of HasPermission for %s", permission)
+
+				for _, resource := range []*Resource{nil, UserResource("owner")} {
+					fcerr := Enforce(test.ctx, permission, resource)
+					if test.expectedErr == 0 {
+						assert.Nil(t, fcerr, "Enforce rejected %s", permission)
+						continue
+					}
+					require.NotNil(t, fcerr, "Enforce accepted %s", permission)
+					assert.Equal(t, test.expectedErr, fcerr.ID, "Enforce failed with wrong error for %s", permission)
+				}
+			}
+		})
+	}
+}
+
+func TestEnforceAccessTokenScopes(t *testing.T) {
+	admin := &models.User{ID: "admin", IsAdmin: true}
+
+	tests := []struct {
+		name       string
+		scope      *models.TokenScope
+		restricted bool
+	}{
+		{name: "Unrestricted scope", scope: &models.TokenScope{}},
+		{name: "Read-only scope", scope: &models.TokenScope{ReadOnly: true}, restricted: true},
+		{name: "Folder scope", scope: &models.TokenScope{FolderID: "folder"}, restricted: true},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			ctx := NewAccessToken(admin, test.scope)
+			for _, permission := range allPermissions {
+				assert.Equal(t, !test.restricted, HasPermission(ctx, permission), "Wrong result of HasPermission for %s", permission)
+
+				fcerr := Enforce(ctx, permission, nil)
+				if !test.restricted {
+					assert.Nil(t, fcerr, "Enforce rejected %s for unrestricted token", permission)
+				} else {
+					require.NotNil(t, fcerr, "Restricted token kept role permission %s", permission)
+					assert.EqualValues(t, fcerror.ErrForbidden, fcerr.ID, "Enforce failed with wrong error for %s", permission)
+				}
+
+				// Owner permissions do not depend on roles, so restricted tokens keep them
+				fcerr = Enforce(ctx, permission, UserResource(admin.ID))
+				if !test.restricted || ownerPermissions[permission] {
+					assert.Nil(t, fcerr, "Enforce rejected %s for own resource", permission)
+				} else {
+					require.NotNil(t, fcerr, "Restricted token kept role permission %s for own resource", permission)
+				}
+			}
+		})
+	}
+}
+
+func containsPermission(permissions []Permission, permission Permission) bool {
+	for _, granted := range permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -12,10 +12,48 @@ const (
 	NeoPersistenceKey = PersistencePluginKey("Neo")
 )
 
+type MailPluginKey string
+
+const (
+	LogMailKey  = MailPluginKey("log")
+	SMTPMailKey = MailPluginKey("smtp")
+)
+
+type RegistrationMode string
+
+const (
+	RegistrationModeOpen           = RegistrationMode("open")
+	RegistrationModeDisabled       = RegistrationMode("disabled")
+	RegistrationModeInviteOnly     = RegistrationMode("invite_only")
+	RegistrationModeAllowedDomains = RegistrationMode("allowed_domains")
+)
+
 type Config interface {
 	GetSessionTokenLength() int
 	GetSessionExpirationDuration() time.Duration
+	GetSessionLifetimeConfig() *SessionLifetimeConfig
+	GetSessionCookieConfig() *SessionCookieConfig
 	GetSessionCleanupInterval() time.Duration
+	GetExternalUserSyncInterval() time.Duration
+	GetLoginThrottleConfig() *LoginThrottleConfig
+	GetTokenSigningSecret() string
+	GetPasswordResetExpiration() time.Duration
+	GetEmailVerificationExpiration() time.Duration
+	GetEmailVerificationRequired() bool
+	GetPasswordHashingConfig() *utils.PasswordHashingConfig
+	GetPasswordPolicyConfig() *PasswordPolicyConfig
+	GetRegistrationConfig() *RegistrationConfig
+
+	GetShareCleanupInterval() time.Duration
+	GetDataExportExpiration() time.Duration
+	GetAvatarMaxSize() int64
+
+	GetOIDCConfig() *OIDCConfig
+	GetLDAPConfig() *LDAPConfig
+	GetWebAuthnConfig() *WebAuthnConfig
+
+	GetPublicURL() string
+	GetMailConfig() *MailConfig
 
 	GetDBUsername() string
 	GetDBPassword() string
@@ -26,3 +64,114 @@ type Config interface {
 
 	GetLoggingConfig() *utils.LoggingConfig
 }
+
+// MailConfig configures how mails to users are sent
+type MailConfig struct {
+	Plugin MailPluginKey
+	From   string
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	// File the log plugin appends mails to; mails are only logged if empty
+	LogPath string
+}
+
+// RegistrationConfig configures who can register an account without an admin
+type RegistrationConfig struct {
+	Mode RegistrationMode
+	// Email domains allowed to register in the allowed domains mode; users with an invite can register with any email
+	AllowedDomains []string
+	// Time an invite code is valid if the admin does not set an expiration
+	InviteExpiration time.Duration
+}
+
+// PasswordPolicyConfig configures the requirements new passwords of users have to fulfill
+type PasswordPolicyConfig struct {
+	MinLength        int
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireDigit     bool
+	// Any character which is neither a letter nor a digit
+	RequireSymbol bool
+}
+
+// SessionLifetimeConfig configures how long sessions stay valid besides the expiration after the last usage
+type SessionLifetimeConfig struct {
+	// Time after the login after which a session expires regardless of its usage; 0 disables the limit
+	MaxLifetime time.Duration
+
+	// Expiration after the last usage and maximum lifetime of sessions created with remember me
+	RememberMeExpiration  time.Duration
+	RememberMeMaxLifetime time.Duration
+}
+
+// SessionCookieConfig configures the cookies browsers can authenticate with instead of the authorization header
+type SessionCookieConfig struct {
+	Enabled bool
+	// Either lax or strict
+	SameSite string
+	// Only send the cookies over HTTPS; derived from the public URL
+	Secure bool
+}
+
+// LoginThrottleConfig configures the protection of the login against brute-force attacks
+type LoginThrottleConfig struct {
+	// Wait time after the first failure which is doubled with every further failure
+	BackoffBase time.Duration
+	MaxBackoff  time.Duration
+
+	// Number of failures after which an account or IP is locked; 0 disables the lockout
+	AccountLockoutThreshold int
+	IPLockoutThreshold      int
+	// Time a lockout lasts and failures are remembered
+	LockoutDuration time.Duration
+}
+
+// OIDCConfig configures the login with an external OpenID Connect identity provider
+type OIDCConfig struct {
+	Enabled      bool
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	// Names of the ID token claims mapped to the user
+	EmailClaim     string
+	FirstNameClaim string
+	LastNameClaim  string
+}
+
+// LDAPConfig configures the authentication against an LDAP directory
+type LDAPConfig struct {
+	Enabled  bool
+	URL      string
+	StartTLS bool
+
+	// Service account used to search for users
+	BindDN       string
+	BindPassword string
+
+	BaseDN string
+	// Filter to find a user, %s is replaced by the escaped email or a wildcard to list all users
+	UserFilter string
+
+	EmailAttribute     string
+	FirstNameAttribute string
+	LastNameAttribute  string
+	GroupAttribute     string
+	// Members of this group are admins; admin rights are not managed by the directory if empty
+	AdminGroupDN string
+}
+
+// WebAuthnConfig configures the relying party security keys and passkeys are registered for
+type WebAuthnConfig struct {
+	// Domain the credentials are bound to; derived from the public URL if empty
+	RPID   string
+	RPName string
+	// Origin the browser reports for this server; derived from the public URL if empty
+	Origin string
+}
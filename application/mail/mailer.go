@@ -0,0 +1,10 @@
+package mail
+
+import (
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+)
+
+type Mailer interface {
+	SendMail(mail *models.Mail) *fcerror.Error
+}
@@ -0,0 +1,168 @@
+package manager
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/application/persistence"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/google/uuid"
+)
+
+// AuditManager records security relevant actions in an append-only log
+type AuditManager interface {
+	Record(authCtx *authorization.Context, event *models.AuditEvent)
+	GetAuditLog(authCtx *authorization.Context, filter *models.AuditFilter, after *models.AuditEventID) ([]*models.AuditEvent, *fcerror.Error)
+	ExportAuditLog(authCtx *authorization.Context, filter *models.AuditFilter, writer io.Writer) *fcerror.Error
+	Close()
+}
+
+const (
+	auditLogPageSize    = 50
+	auditExportPageSize = 500
+)
+
+func NewAuditManager(cfg config.Config, auditPersistence persistence.AuditPersistenceController, managers *Managers) AuditManager {
+	auditMgr := &auditManager{
+		cfg:              cfg,
+		auditPersistence: auditPersistence,
+		managers:         managers,
+		logger:           utils.CreateLogger(cfg.GetLoggingConfig()),
+	}
+
+	managers.Audit = auditMgr
+	return auditMgr
+}
+
+type auditManager struct {
+	cfg              config.Config
+	auditPersistence persistence.AuditPersistenceController
+	managers         *Managers
+	logger           utils.Logger
+}
+
+var _ AuditManager = &auditManager{}
+
+func (mgr *auditManager) Close() {
+}
+
+// Record stores the event with the actor and client of the context if the event does not name them itself.
+// Failures are only logged, so auditing never fails the audited action.
+func (mgr *auditManager) Record(authCtx *authorization.Context, event *models.AuditEvent) {
+	event.ID = models.AuditEventID(uuid.NewString())
+	event.Time = utils.GetCurrentTime()
+	if event.Outcome == "" {
+		event.Outcome = models.AuditOutcomeSuccess
+	}
+	// File drops act as their owner, but the upload is done by an anonymous visitor
+	if event.ActorID == "" && authCtx.Type == authorization.ContextTypeUser {
+		event.ActorID = authCtx.User.ID
+	}
+	if event.ClientIP == "" {
+		event.ClientIP = authCtx.ClientIP
+	}
+
+	trans, fcerr := mgr.auditPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { _ = trans.Finish(fcerr) }()
+
+	fcerr = trans.SaveAuditEvent(event)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("event", event).Error("Failed to save audit event - ignore for now")
+	}
+}
+
+// GetAuditLog returns a page of events from new to old, the next page starts after the last event of the previous one
+func (mgr *auditManager) GetAuditLog(authCtx *authorization.Context, filter *models.AuditFilter, after *models.AuditEventID) (events []*models.AuditEvent, fcerr *fcerror.Error) {
+	fcerr = authorization.Enforce(authCtx, authorization.PermissionReadAuditLog, nil)
+	if fcerr != nil {
+		return
+	}
+
+	return mgr.getAuditEvents(filter, after, auditLogPageSize)
+}
+
+// ExportAuditLog writes all events matching the filter as a JSON array, nothing is written if the permission is missing
+func (mgr *auditManager) ExportAuditLog(authCtx *authorization.Context, filter *models.AuditFilter, writer io.Writer) (fcerr *fcerror.Error) {
+	fcerr = authorization.Enforce(authCtx, authorization.PermissionReadAuditLog, nil)
+	if fcerr != nil {
+		return
+	}
+
+	_, err := io.WriteString(writer, "[")
+	if err != nil {
+		return fcerror.NewError(fcerror.ErrAuditExportFailed, err)
+	}
+
+	var after *models.AuditEventID
+	first := true
+	for {
+		events, fcerr := mgr.getAuditEvents(filter, after, auditExportPageSize)
+		if fcerr != nil {
+			return fcerr
+		}
+
+		for _, event := range events {
+			eventJSON, err := json.Marshal(event)
+			if err != nil {
+				return fcerror.NewError(fcerror.ErrAuditExportFailed, err)
+			}
+			if !first {
+				eventJSON = append([]byte(","), eventJSON...)
+			}
+			first = false
+			if _, err = writer.Write(eventJSON); err != nil {
+				return fcerror.NewError(fcerror.ErrAuditExportFailed, err)
+			}
+		}
+
+		if len(events) < auditExportPageSize {
+			break
+		}
+		after = &events[len(events)-1].ID
+	}
+
+	_, err = io.WriteString(writer, "]")
+	if err != nil {
+		return fcerror.NewError(fcerror.ErrAuditExportFailed, err)
+	}
+	return
+}
+
+func (mgr *auditManager) getAuditEvents(filter *models.AuditFilter, after *models.AuditEventID, limit int) (events []*models.AuditEvent, fcerr *fcerror.Error) {
+	trans, fcerr := mgr.auditPersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer trans.Close()
+
+	events, fcerr = trans.GetAuditEvents(filter, after, limit)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to get audit events")
+	}
+	return
+}
+
+// withOutcome sets the outcome of the event from the error the audited action finished with
+func withOutcome(event *models.AuditEvent, fcerr *fcerror.Error) *models.AuditEvent {
+	if fcerr == nil {
+		event.Outcome = models.AuditOutcomeSuccess
+		return event
+	}
+
+	event.Outcome = models.AuditOutcomeFailure
+	if event.Details != "" {
+		event.Details += "; "
+	}
+	event.Details += fcerr.Description
+	return event
+}
@@ -1,34 +1,90 @@
 package manager
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/freecloudio/server/application/authentication"
 	"github.com/freecloudio/server/application/authorization"
 	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/application/mail"
 	"github.com/freecloudio/server/application/persistence"
 	"github.com/freecloudio/server/domain/models"
 	"github.com/freecloudio/server/domain/models/fcerror"
 	"github.com/freecloudio/server/utils"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
 // AuthManager contains all use cases related to authentication and user management
 type AuthManager interface {
-	Login(email, password string) (*models.Session, *fcerror.Error)
+	Login(email, password string, rememberMe bool, client *models.SessionClient) (*models.LoginResult, *fcerror.Error)
+	LoginExternal(externalUser *models.ExternalUser, client *models.SessionClient) (*models.Session, *fcerror.Error)
+	LinkExternalAccount(authCtx *authorization.Context, password, code string) (*models.User, *fcerror.Error)
+	CompleteLoginChallenge(challengeToken models.Token, code string, client *models.SessionClient) (*models.Session, *fcerror.Error)
+	CompleteLoginChallengeWebAuthn(challengeToken models.Token, assertion *models.WebAuthnAssertion, client *models.SessionClient) (*models.Session, *fcerror.Error)
+	BeginWebAuthnLogin(email string) (*models.WebAuthnRequestOptions, *fcerror.Error)
+	WebAuthnLogin(assertion *models.WebAuthnAssertion, client *models.SessionClient) (*models.Session, *fcerror.Error)
 	Logout(token models.Token) *fcerror.Error
-	VerifyToken(token models.Token) (*models.User, *fcerror.Error)
-	CreateNewSession(userID models.UserID) (*models.Session, *fcerror.Error)
+	VerifyToken(token models.Token, client *models.SessionClient) (*models.User, *fcerror.Error)
+	CreateNewSession(userID models.UserID, rememberMe bool, client *models.SessionClient) (*models.Session, *fcerror.Error)
+	GetOwnSessions(authCtx *authorization.Context, currentToken models.Token) ([]*models.Session, *fcerror.Error)
+	RevokeSession(authCtx *authorization.Context, sessionID models.SessionID) *fcerror.Error
+	RevokeAllOtherSessions(authCtx *authorization.Context, currentToken models.Token) *fcerror.Error
+	RevokeAllSessionsOfUser(authCtx *authorization.Context, userID models.UserID) *fcerror.Error
+	UnlockLogin(authCtx *authorization.Context, userID models.UserID) *fcerror.Error
+	EnrollTOTP(authCtx *authorization.Context) (string, *fcerror.Error)
+	ConfirmTOTP(authCtx *authorization.Context, code string) ([]string, *fcerror.Error)
+	DisableTOTP(authCtx *authorization.Context, code string) *fcerror.Error
+	BeginWebAuthnRegistration(authCtx *authorization.Context) (*models.WebAuthnCreationOptions, *fcerror.Error)
+	FinishWebAuthnRegistration(authCtx *authorization.Context, name string, attestation *models.WebAuthnAttestation) (*models.WebAuthnCredential, *fcerror.Error)
+	GetOwnWebAuthnCredentials(authCtx *authorization.Context) ([]*models.WebAuthnCredential, *fcerror.Error)
+	DeleteWebAuthnCredential(authCtx *authorization.Context, credentialID models.WebAuthnCredentialID) *fcerror.Error
+	CreateAccessToken(authCtx *authorization.Context, accessToken *models.AccessToken) (models.Token, *fcerror.Error)
+	GetOwnAccessTokens(authCtx *authorization.Context) ([]*models.AccessToken, *fcerror.Error)
+	RevokeAccessToken(authCtx *authorization.Context, accessTokenID models.AccessTokenID) *fcerror.Error
+	VerifyAccessToken(token models.Token) (*models.User, *models.TokenScope, *fcerror.Error)
+	RequestPasswordReset(email string) *fcerror.Error
+	ResetPassword(token, password string) *fcerror.Error
+	RequestEmailVerification(email string) *fcerror.Error
+	VerifyEmail(token string) *fcerror.Error
 	Close()
 }
 
-func NewAuthManager(cfg config.Config, authPersistence persistence.AuthPersistenceController, managers *Managers) AuthManager {
+const (
+	// Minimum time between two writes of the last usage of a session from the same client
+	sessionUsageUpdateInterval = time.Minute
+
+	loginChallengeExpiration  = 5 * time.Minute
+	maxLoginChallengeAttempts = 5
+	totpIssuer                = "freecloud"
+	totpAllowedSkew           = 1
+	recoveryCodeCount         = 10
+	accessTokenLength         = 40
+)
+
+func NewAuthManager(cfg config.Config, authPersistence persistence.AuthPersistenceController, authenticators []authentication.Authenticator, mailer mail.Mailer, passwordHashers *utils.PasswordHashers, managers *Managers) AuthManager {
 	authMgr := &authManager{
 		cfg:             cfg,
 		authPersistence: authPersistence,
+		authenticators:  authenticators,
+		mailer:          mailer,
+		passwordHashers: passwordHashers,
+		tokens:          newTokenService(cfg.GetSessionTokenLength()),
+		loginThrottle:   newLoginThrottle(cfg.GetLoginThrottleConfig()),
 		managers:        managers,
 		done:            make(chan struct{}),
 		logger:          utils.CreateLogger(cfg.GetLoggingConfig()),
 	}
+	authMgr.tokenSecret = loadTokenSecret(cfg, authMgr.logger)
+	authMgr.hashPlainSessionTokens()
 	go authMgr.cleanupExpiredSessionsRoutine()
+	if len(authenticators) > 0 {
+		go authMgr.syncExternalUsersRoutine()
+	}
 
 	managers.Auth = authMgr
 	return authMgr
@@ -37,13 +93,47 @@ func NewAuthManager(cfg config.Config, authPersistence persistence.AuthPersisten
 type authManager struct {
 	cfg             config.Config
 	authPersistence persistence.AuthPersistenceController
+	authenticators  []authentication.Authenticator
+	mailer          mail.Mailer
+	passwordHashers *utils.PasswordHashers
+	tokens          *tokenService
+	tokenSecret     []byte
+	loginThrottle   *loginThrottle
 	managers        *Managers
 	done            chan struct{}
 	logger          utils.Logger
 }
 
+// hashPlainSessionTokens migrates sessions created before only the hashes of their tokens were stored
+func (mgr *authManager) hashPlainSessionTokens() {
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { _ = trans.Finish(fcerr) }()
+
+	tokens, fcerr := trans.GetPlainSessionTokens()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to get sessions with plain tokens")
+		return
+	}
+
+	for _, token := range tokens {
+		fcerr = trans.HashSessionToken(token, mgr.tokens.hash(token))
+		if fcerr != nil {
+			mgr.logger.WithError(fcerr).Error("Failed to hash plain session token")
+			return
+		}
+	}
+	if len(tokens) > 0 {
+		mgr.logger.WithField("count", len(tokens)).Info("Hashed plain session tokens")
+	}
+}
+
 func (mgr *authManager) Close() {
-	mgr.done <- struct{}{}
+	// Closing instead of sending stops all background routines
+	close(mgr.done)
 }
 
 func (mgr *authManager) cleanupExpiredSessionsRoutine() {
@@ -77,27 +167,408 @@ func (mgr *authManager) cleanupExpiredSessions() {
 		mgr.logger.WithError(fcerr).Error("Failed to delete expired sessions")
 		return
 	}
+
+	fcerr = trans.DeleteExpiredLoginChallenges()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to delete expired login challenges")
+		return
+	}
+
+	fcerr = trans.DeleteExpiredEmailTokens()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to delete expired email tokens")
+		return
+	}
+
+	fcerr = trans.DeleteExpiredWebAuthnChallenges()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to delete expired security key challenges")
+		return
+	}
+
+	mgr.loginThrottle.cleanup()
+}
+
+func (mgr *authManager) syncExternalUsersRoutine() {
+	interval := mgr.cfg.GetExternalUserSyncInterval()
+	mgr.logger.WithField("interval", interval).Debug("Starting external user sync")
+
+	mgr.syncExternalUsers()
+	ticker := time.NewTicker(interval)
+	for {
+		select {
+		case <-mgr.done:
+			return
+		case <-ticker.C:
+			mgr.syncExternalUsers()
+		}
+	}
+}
+
+// syncExternalUsers updates all existing users from the external authenticators; new users are only created on their first login
+func (mgr *authManager) syncExternalUsers() {
+	mgr.logger.Debug("Syncing external users")
+
+	for _, authenticator := range mgr.authenticators {
+		externalUsers, fcerr := authenticator.ListUsers()
+		if fcerr != nil {
+			mgr.logger.WithError(fcerr).Error("Failed to list users of external authenticator")
+			continue
+		}
+
+		for _, externalUser := range externalUsers {
+			_, fcerr = mgr.managers.User.SyncExternalUser(externalUser)
+			if fcerr != nil && fcerr.ID != fcerror.ErrUserNotFound && fcerr.ID != fcerror.ErrExternalUserNotLinked {
+				mgr.logger.WithError(fcerr).WithField("email", externalUser.Email).Error("Failed to sync external user")
+			}
+		}
+	}
 }
 
-func (mgr *authManager) Login(email, password string) (token *models.Session, fcerr *fcerror.Error) {
-	user, fcerr := mgr.managers.User.GetUserByEmail(authorization.NewSystem(), email)
+func (mgr *authManager) Login(email, password string, rememberMe bool, client *models.SessionClient) (result *models.LoginResult, fcerr *fcerror.Error) {
+	var user *models.User
+	defer func() {
+		// A pending second factor is audited when the challenge is completed
+		if result == nil || result.Session != nil {
+			mgr.auditLogin(email, user, client, fcerr)
+		}
+	}()
+
+	clientIP := getClientIP(client)
+	fcerr = mgr.beginLoginAttempt(email, clientIP)
 	if fcerr != nil {
-		mgr.logger.WithError(fcerr).WithField("email", email).Warn("User with given email not found for login")
-		fcerr = fcerror.NewError(fcerror.ErrUnauthorized, nil)
 		return
 	}
 
-	err := utils.ValidateScryptPassword(password, user.Password)
+	user, fcerr = mgr.authenticate(email, password)
+	mgr.loginThrottle.finishLogin(email, clientIP, fcerr != nil && fcerr.ID == fcerror.ErrUnauthorized)
+	if fcerr != nil {
+		return
+	}
+
+	// Checked only after the password, so the verification state of an account is not revealed to anyone
+	if mgr.cfg.GetEmailVerificationRequired() && !user.EmailVerified {
+		fcerr = fcerror.NewError(fcerror.ErrEmailNotVerified, nil)
+		return
+	}
+	if user.Disabled {
+		fcerr = fcerror.NewError(fcerror.ErrUserDisabled, nil)
+		return
+	}
+
+	totpEnabled, credentials, fcerr := mgr.getSecondFactors(user.ID)
+	if fcerr != nil {
+		return
+	}
+	if totpEnabled || len(credentials) > 0 {
+		challenge, fcerr := mgr.createLoginChallenge(user.ID, rememberMe, credentials)
+		if fcerr != nil {
+			return nil, fcerr
+		}
+		return &models.LoginResult{Challenge: challenge}, nil
+	}
+
+	session, fcerr := mgr.CreateNewSession(user.ID, rememberMe, client)
+	if fcerr != nil {
+		return
+	}
+	mgr.loginThrottle.resetAccount(email)
+	return &models.LoginResult{Session: session}, nil
+}
+
+// auditLogin records a login attempt, the user is only known if the credentials were valid
+func (mgr *authManager) auditLogin(email string, user *models.User, client *models.SessionClient, fcerr *fcerror.Error) {
+	event := &models.AuditEvent{
+		Action:     models.AuditActionLogin,
+		ActorEmail: email,
+		ClientIP:   getClientIP(client),
+	}
+	if user != nil {
+		event.ActorID = user.ID
+		event.ActorEmail = user.Email
+		event.TargetType = models.AuditTargetTypeUser
+		event.TargetID = string(user.ID)
+	}
+	mgr.managers.Audit.Record(authorization.NewSystem(), withOutcome(event, fcerr))
+}
+
+func getClientIP(client *models.SessionClient) string {
+	if client == nil {
+		return ""
+	}
+	return client.ClientIP
+}
+
+// beginLoginAttempt reserves a login attempt or returns ErrTooManyAttempts if the account or the IP has to wait before it.
+// Reserved attempts have to be finished with loginThrottle.finishLogin.
+func (mgr *authManager) beginLoginAttempt(email, clientIP string) (fcerr *fcerror.Error) {
+	wait := mgr.loginThrottle.beginLogin(email, clientIP)
+	if wait <= 0 {
+		return
+	}
+
+	mgr.logger.WithFields(logrus.Fields{"email": email, "clientIP": clientIP, "wait": wait}).Warn("Login attempt throttled")
+	return fcerror.NewError(fcerror.ErrTooManyAttempts, fmt.Errorf("next attempt allowed in %v", wait.Round(time.Second)))
+}
+
+// authenticate validates the credentials of local users against their password only.
+// Users linked to external identity sources and unknown users are validated against every external authenticator instead.
+func (mgr *authManager) authenticate(email, password string) (user *models.User, fcerr *fcerror.Error) {
+	user, fcerr = mgr.managers.User.GetUserByEmail(authorization.NewSystem(), email)
+	if fcerr == nil && !user.External {
+		needsRehash, err := mgr.passwordHashers.Validate(password, user.Password)
+		if err == nil {
+			if needsRehash {
+				mgr.rehashPassword(user, password)
+			}
+			return
+		}
+		mgr.logger.WithError(err).WithField("email", email).Warn("Failed to authenticate user for login")
+		return nil, fcerror.NewError(fcerror.ErrUnauthorized, nil)
+	} else if fcerr != nil && fcerr.ID != fcerror.ErrUserNotFound {
+		return nil, fcerr
+	}
+
+	for _, authenticator := range mgr.authenticators {
+		externalUser, authErr := authenticator.Authenticate(email, password)
+		if authErr != nil {
+			if authErr.ID != fcerror.ErrUnauthorized {
+				mgr.logger.WithError(authErr).WithField("email", email).Error("External authenticator failed")
+			}
+			continue
+		}
+
+		// The entry of the identity source may carry another email than the one used for the login
+		user, fcerr = mgr.managers.User.ProvisionExternalUser(externalUser)
+		if fcerr != nil && fcerr.ID == fcerror.ErrExternalUserNotLinked {
+			mgr.logger.WithField("email", email).Warn("External authenticator returned a local user which is not linked")
+			return nil, fcerror.NewError(fcerror.ErrUnauthorized, nil)
+		}
+		return
+	}
+
+	mgr.logger.WithField("email", email).Warn("Failed to authenticate user for login")
+	return nil, fcerror.NewError(fcerror.ErrUnauthorized, nil)
+}
+
+// rehashPassword replaces a password hash of an outdated algorithm or with outdated parameters, failures do not fail the login
+func (mgr *authManager) rehashPassword(user *models.User, password string) {
+	_, fcerr := mgr.managers.User.UpdateUser(authorization.NewSystem(), user.ID, &models.UserUpdate{Password: &password})
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", user.ID).Error("Failed to rehash outdated password - ignore for now")
+		return
+	}
+	mgr.logger.WithField("userID", user.ID).Info("Rehashed outdated password of user")
+}
+
+// getSecondFactors returns whether TOTP is enabled and which security keys are registered for the user
+func (mgr *authManager) getSecondFactors(userID models.UserID) (totpEnabled bool, credentials []*models.WebAuthnCredential, fcerr *fcerror.Error) {
+	trans, fcerr := mgr.authPersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer trans.Close()
+
+	totp, fcerr := trans.GetTOTP(userID)
+	if fcerr == nil {
+		totpEnabled = totp.Confirmed
+	} else if fcerr.ID != fcerror.ErrTOTPNotFound {
+		mgr.logger.WithError(fcerr).WithField("userID", userID).Error("Failed to get TOTP of user")
+		return
+	}
+
+	credentials, fcerr = trans.GetWebAuthnCredentialsOfUser(userID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", userID).Error("Failed to get security keys of user")
+	}
+	return
+}
+
+// createLoginChallenge issues a challenge for the second factor, which can be answered by one of the given security keys as well
+func (mgr *authManager) createLoginChallenge(userID models.UserID, rememberMe bool, credentials []*models.WebAuthnCredential) (challenge *models.LoginChallenge, fcerr *fcerror.Error) {
+	token, _, err := mgr.tokens.newToken()
 	if err != nil {
-		mgr.logger.WithError(err).WithField("email", email).Warn("Failed to validate password")
-		fcerr = fcerror.NewError(fcerror.ErrUnauthorized, nil)
+		fcerr = fcerror.NewError(fcerror.ErrUnknown, err)
+		mgr.logger.WithError(fcerr).Error("Failed to generate login challenge token")
+		return
+	}
+	challenge = &models.LoginChallenge{
+		Token:      token,
+		UserID:     userID,
+		ValidUntil: utils.GetTimeIn(loginChallengeExpiration),
+		RememberMe: rememberMe,
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = trans.SaveLoginChallenge(challenge)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to save login challenge")
+		return
+	}
+
+	if len(credentials) > 0 {
+		webAuthnChallenge, fcerr := mgr.createWebAuthnChallenge(trans, userID, models.WebAuthnChallengePurposeSecondFactor)
+		if fcerr != nil {
+			return nil, fcerr
+		}
+		challenge.WebAuthnOptions = mgr.webAuthnRequestOptions(webAuthnChallenge, credentials, "discouraged")
+	}
+	return
+}
+
+func (mgr *authManager) CompleteLoginChallenge(challengeToken models.Token, code string, client *models.SessionClient) (session *models.Session, fcerr *fcerror.Error) {
+	return mgr.completeLoginChallenge(challengeToken, client, func(trans persistence.AuthPersistenceReadWriteTransaction, userID models.UserID) (bool, *fcerror.Error) {
+		return mgr.verifySecondFactor(trans, userID, code)
+	})
+}
+
+// secondFactorVerifier checks the answer to a login challenge of the user within the transaction consuming the challenge
+type secondFactorVerifier func(trans persistence.AuthPersistenceReadWriteTransaction, userID models.UserID) (valid bool, fcerr *fcerror.Error)
+
+func (mgr *authManager) completeLoginChallenge(challengeToken models.Token, client *models.SessionClient, verify secondFactorVerifier) (session *models.Session, fcerr *fcerror.Error) {
+	var user *models.User
+	defer func() { mgr.auditLogin("", user, client, fcerr) }()
+
+	clientIP := getClientIP(client)
+	fcerr = mgr.beginLoginAttempt("", clientIP)
+	if fcerr != nil {
+		return
+	}
+
+	challenge, valid, fcerr := mgr.checkLoginChallenge(challengeToken, verify)
+	mgr.loginThrottle.finishLogin("", clientIP, fcerr == nil && !valid)
+	if fcerr != nil {
+		return
+	}
+
+	// Failed second factors count against the account, so the next login with the password gets throttled
+	email := ""
+	user, userErr := mgr.managers.User.GetUserByID(authorization.NewSystem(), challenge.UserID)
+	if userErr == nil {
+		email = user.Email
+	} else {
+		user = &models.User{ID: challenge.UserID}
+		mgr.logger.WithError(userErr).WithField("userID", challenge.UserID).Error("Failed to get user of login challenge for throttling")
+	}
+	if !valid {
+		mgr.loginThrottle.failLogin(email, "")
+		fcerr = fcerror.NewError(fcerror.ErrSecondFactorInvalid, nil)
+		return
+	}
+	if user.Disabled {
+		fcerr = fcerror.NewError(fcerror.ErrUserDisabled, nil)
+		return
+	}
+
+	session, fcerr = mgr.CreateNewSession(challenge.UserID, challenge.RememberMe, client)
+	if fcerr != nil {
+		return
+	}
+	if email != "" {
+		mgr.loginThrottle.resetAccount(email)
+	}
+	return
+}
+
+// checkLoginChallenge verifies the answer to the challenge and consumes the challenge if it is valid.
+// Failed attempts are counted and the challenge is dropped after too many of them.
+func (mgr *authManager) checkLoginChallenge(challengeToken models.Token, verify secondFactorVerifier) (challenge *models.LoginChallenge, valid bool, fcerr *fcerror.Error) {
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	challenge, fcerr = trans.GetLoginChallenge(challengeToken)
+	if fcerr != nil {
+		if fcerr.ID != fcerror.ErrLoginChallengeNotFound {
+			mgr.logger.WithError(fcerr).Error("Failed to get login challenge")
+		}
+		return
+	}
+
+	if utils.GetCurrentTime().After(challenge.ValidUntil) {
+		fcerr = fcerror.NewError(fcerror.ErrLoginChallengeExpired, nil)
+		return
+	}
+
+	valid, fcerr = verify(trans, challenge.UserID)
+	if fcerr != nil {
+		return
+	}
+
+	challenge.Attempts++
+	if valid || challenge.Attempts >= maxLoginChallengeAttempts {
+		fcerr = trans.DeleteLoginChallenge(challengeToken)
+	} else {
+		fcerr = trans.UpdateLoginChallengeAttempts(challenge)
+	}
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to update login challenge")
+	}
+	return
+}
+
+// verifySecondFactor accepts either a current TOTP code which was not used before or one of the recovery codes
+func (mgr *authManager) verifySecondFactor(trans persistence.AuthPersistenceReadWriteTransaction, userID models.UserID, code string) (valid bool, fcerr *fcerror.Error) {
+	totp, fcerr := trans.GetTOTP(userID)
+	if fcerr != nil {
+		if fcerr.ID != fcerror.ErrTOTPNotFound {
+			mgr.logger.WithError(fcerr).WithField("userID", userID).Error("Failed to get TOTP of user")
+		}
+		return
+	}
+	if !totp.Confirmed {
+		fcerr = fcerror.NewError(fcerror.ErrTOTPNotFound, nil)
+		return
+	}
+
+	step, valid, err := utils.ValidateTOTPCode(totp.Secret, code, utils.GetCurrentTime(), totpAllowedSkew)
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrUnknown, err)
+		mgr.logger.WithError(fcerr).WithField("userID", userID).Error("Failed to validate TOTP code")
+		return
+	}
+	if valid && step > totp.LastUsedStep {
+		totp.LastUsedStep = step
+		fcerr = trans.SaveTOTP(totp)
+		if fcerr != nil {
+			mgr.logger.WithError(fcerr).WithField("userID", userID).Error("Failed to save last used TOTP step")
+		}
 		return
 	}
 
-	return mgr.CreateNewSession(user.ID)
+	valid, fcerr = trans.UseRecoveryCode(userID, utils.HashRecoveryCode(code))
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", userID).Error("Failed to use recovery code")
+	}
+	return
 }
 
 func (mgr *authManager) Logout(token models.Token) (fcerr *fcerror.Error) {
+	var session *models.Session
+	defer func() {
+		if session == nil {
+			return
+		}
+		// The client of the session was just updated by the verification of the token
+		mgr.managers.Audit.Record(authorization.NewSystem(), withOutcome(&models.AuditEvent{
+			Action:     models.AuditActionLogout,
+			ActorID:    session.UserID,
+			ClientIP:   session.ClientIP,
+			TargetType: models.AuditTargetTypeUser,
+			TargetID:   string(session.UserID),
+		}, fcerr))
+	}()
+
 	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
 	if fcerr != nil {
 		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
@@ -105,7 +576,14 @@ func (mgr *authManager) Logout(token models.Token) (fcerr *fcerror.Error) {
 	}
 	defer func() { fcerr = trans.Finish(fcerr) }()
 
-	fcerr = trans.DeleteSessionByToken(token)
+	tokenHash := mgr.tokens.hash(token)
+	session, fcerr = trans.GetSessionByToken(tokenHash)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Token not found for logout")
+		return
+	}
+
+	fcerr = trans.DeleteSessionByToken(tokenHash)
 	if fcerr != nil {
 		mgr.logger.WithError(fcerr).Error("Failed to delete token")
 		return
@@ -114,46 +592,708 @@ func (mgr *authManager) Logout(token models.Token) (fcerr *fcerror.Error) {
 	return
 }
 
-func (mgr *authManager) VerifyToken(token models.Token) (user *models.User, fcerr *fcerror.Error) {
+func (mgr *authManager) VerifyToken(token models.Token, client *models.SessionClient) (user *models.User, fcerr *fcerror.Error) {
 	authTrans, fcerr := mgr.authPersistence.StartReadTransaction()
 	if fcerr != nil {
 		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
 		return
 	}
-	defer authTrans.Close()
 
-	session, fcerr := authTrans.GetSessionByToken(token)
+	session, fcerr := authTrans.GetSessionByToken(mgr.tokens.hash(token))
+	authTrans.Close()
 	if fcerr != nil {
 		mgr.logger.WithError(fcerr).Error("Token not found or failed to verify")
 		return
 	}
 
-	if time.Now().After(session.ValidUntil) {
+	now := utils.GetCurrentTime()
+	if now.After(session.ValidUntil) || now.After(mgr.getSessionValidUntil(session, now)) {
 		fcerr = fcerror.NewError(fcerror.ErrSessionExpired, nil)
 		return
 	}
 
-	return mgr.managers.User.GetUserByID(authorization.NewUser(&models.User{ID: session.UserID}), session.UserID)
+	user, fcerr = mgr.managers.User.GetUserByID(authorization.NewUser(&models.User{ID: session.UserID}), session.UserID)
+	if fcerr != nil {
+		return
+	}
+	if user.Disabled {
+		return nil, fcerror.NewError(fcerror.ErrUserDisabled, nil)
+	}
+
+	mgr.updateSessionUsage(session, client)
+	return
 }
 
-func (mgr *authManager) CreateNewSession(userID models.UserID) (session *models.Session, fcerr *fcerror.Error) {
-	session = &models.Session{
-		Token:      models.Token(utils.GenerateRandomString(mgr.cfg.GetSessionTokenLength())),
-		ValidUntil: utils.GetTimeIn(mgr.cfg.GetSessionExpirationDuration()),
-		UserID:     userID,
+// getSessionLifetime returns the expiration after the last usage and the maximum lifetime of the session
+func (mgr *authManager) getSessionLifetime(rememberMe bool) (expiration, maxLifetime time.Duration) {
+	lifetimeCfg := mgr.cfg.GetSessionLifetimeConfig()
+	if rememberMe {
+		return lifetimeCfg.RememberMeExpiration, lifetimeCfg.RememberMeMaxLifetime
+	}
+	return mgr.cfg.GetSessionExpirationDuration(), lifetimeCfg.MaxLifetime
+}
+
+// getSessionValidUntil returns until when the session is valid if it is used at the given time.
+// Sessions created before their creation was stored keep their fixed expiration.
+func (mgr *authManager) getSessionValidUntil(session *models.Session, usedAt time.Time) time.Time {
+	if session.Created.IsZero() {
+		return session.ValidUntil
+	}
+
+	expiration, maxLifetime := mgr.getSessionLifetime(session.RememberMe)
+	validUntil := usedAt.Add(expiration)
+	if maxLifetime > 0 {
+		if maxValidUntil := session.Created.Add(maxLifetime); validUntil.After(maxValidUntil) {
+			validUntil = maxValidUntil
+		}
+	}
+	return validUntil
+}
+
+// updateSessionUsage records the usage of a session and extends its validity.
+// To limit writes this is only done if the client changed or some time passed since the last update.
+func (mgr *authManager) updateSessionUsage(session *models.Session, client *models.SessionClient) {
+	if client == nil {
+		client = &models.SessionClient{UserAgent: session.UserAgent, ClientIP: session.ClientIP}
+	}
+	now := utils.GetCurrentTime()
+	if now.Sub(session.LastUsed) < sessionUsageUpdateInterval && client.UserAgent == session.UserAgent && client.ClientIP == session.ClientIP {
+		return
 	}
 
+	session.LastUsed = now
+	session.ValidUntil = mgr.getSessionValidUntil(session, now)
+	session.UserAgent = client.UserAgent
+	session.ClientIP = client.ClientIP
+
 	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
 	if fcerr != nil {
 		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
 		return
 	}
-	defer func() { fcerr = trans.Finish(fcerr) }()
+	defer func() { _ = trans.Finish(fcerr) }()
 
-	fcerr = trans.SaveSession(session)
+	fcerr = trans.UpdateSessionUsage(session)
 	if fcerr != nil {
-		mgr.logger.WithError(fcerr).Error("Failed to save user")
+		mgr.logger.WithError(fcerr).Warn("Failed to update session usage - ignore for now")
+	}
+}
+
+// LoginExternal creates a session for a user already authenticated by an external identity provider.
+// Second factors are left to the identity provider; unknown users are provisioned just in time.
+func (mgr *authManager) LoginExternal(externalUser *models.ExternalUser, client *models.SessionClient) (session *models.Session, fcerr *fcerror.Error) {
+	var user *models.User
+	defer func() { mgr.auditLogin(externalUser.Email, user, client, fcerr) }()
+
+	if externalUser.Email == "" {
+		fcerr = fcerror.NewError(fcerror.ErrExternalLoginFailed, errors.New("external user has no email"))
 		return
 	}
-	return session, nil
+
+	user, fcerr = mgr.managers.User.ProvisionExternalUser(externalUser)
+	if fcerr != nil {
+		return
+	}
+	if user.Disabled {
+		fcerr = fcerror.NewError(fcerror.ErrUserDisabled, nil)
+		return
+	}
+
+	return mgr.CreateNewSession(user.ID, false, client)
+}
+
+// LinkExternalAccount hands the own account over to the external identity sources, which log in users by their email.
+// The local password and second factor have to be confirmed, as logins of linked users skip both of them.
+// Security keys cannot confirm the link, users with only security keys have to enable TOTP first.
+func (mgr *authManager) LinkExternalAccount(authCtx *authorization.Context, password, code string) (user *models.User, fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceNoAccessToken(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Only users can link their account"))
+		return
+	}
+
+	user, fcerr = mgr.managers.User.GetUserByID(authorization.NewSystem(), authCtx.User.ID)
+	if fcerr != nil {
+		return
+	}
+	if user.External {
+		user.Password = ""
+		return
+	}
+
+	fcerr = mgr.confirmLocalCredentials(user, password, code)
+	if fcerr != nil {
+		return
+	}
+
+	linked := true
+	user, fcerr = mgr.managers.User.UpdateUser(authorization.NewSystem(), user.ID, &models.UserUpdate{External: &linked})
+	if fcerr != nil {
+		return
+	}
+	mgr.logger.WithField("userID", user.ID).Info("Linked user to external identity sources")
+	user.Password = ""
+	return
+}
+
+// confirmLocalCredentials checks the password and, if enabled, the second factor of the user.
+// Failures count against the account like failed logins.
+func (mgr *authManager) confirmLocalCredentials(user *models.User, password, code string) (fcerr *fcerror.Error) {
+	fcerr = mgr.beginLoginAttempt(user.Email, "")
+	if fcerr != nil {
+		return
+	}
+	defer func() {
+		failed := fcerr != nil && (fcerr.ID == fcerror.ErrPasswordConfirmationFailed || fcerr.ID == fcerror.ErrSecondFactorInvalid)
+		mgr.loginThrottle.finishLogin(user.Email, "", failed)
+	}()
+
+	_, err := mgr.passwordHashers.Validate(password, user.Password)
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrPasswordConfirmationFailed, err)
+		return
+	}
+
+	totpEnabled, credentials, fcerr := mgr.getSecondFactors(user.ID)
+	if fcerr != nil {
+		return
+	}
+	if totpEnabled || len(credentials) > 0 {
+		fcerr = mgr.confirmSecondFactor(user.ID, code)
+	}
+	return
+}
+
+// confirmSecondFactor checks the TOTP or recovery code of the user
+func (mgr *authManager) confirmSecondFactor(userID models.UserID, code string) (fcerr *fcerror.Error) {
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	valid, fcerr := mgr.verifySecondFactor(trans, userID, code)
+	if fcerr != nil && fcerr.ID != fcerror.ErrTOTPNotFound {
+		return
+	}
+	if !valid {
+		fcerr = fcerror.NewError(fcerror.ErrSecondFactorInvalid, nil)
+	}
+	return
+}
+
+func (mgr *authManager) CreateNewSession(userID models.UserID, rememberMe bool, client *models.SessionClient) (session *models.Session, fcerr *fcerror.Error) {
+	token, tokenHash, err := mgr.tokens.newToken()
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrUnknown, err)
+		mgr.logger.WithError(fcerr).Error("Failed to generate session token")
+		return
+	}
+
+	currTime := utils.GetCurrentTime()
+	session = &models.Session{
+		ID:         models.SessionID(uuid.NewString()),
+		Token:      token,
+		TokenHash:  tokenHash,
+		UserID:     userID,
+		Created:    currTime,
+		LastUsed:   currTime,
+		RememberMe: rememberMe,
+	}
+	session.ValidUntil = mgr.getSessionValidUntil(session, currTime)
+	if client != nil {
+		session.UserAgent = client.UserAgent
+		session.ClientIP = client.ClientIP
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = trans.SaveSession(session)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to save user")
+		return
+	}
+	return session, nil
+}
+
+func (mgr *authManager) GetOwnSessions(authCtx *authorization.Context, currentToken models.Token) (sessions []*models.Session, fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		return []*models.Session{}, nil
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer trans.Close()
+
+	sessions, fcerr = trans.GetSessionsOfUser(authCtx.User.ID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", authCtx.User.ID).Error("Failed to get sessions of user")
+		return
+	}
+
+	currentTokenHash := mgr.tokens.hash(currentToken)
+	for _, session := range sessions {
+		session.Current = currentToken != "" && session.TokenHash == currentTokenHash
+	}
+	return
+}
+
+func (mgr *authManager) RevokeSession(authCtx *authorization.Context, sessionID models.SessionID) (fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		fcerr = fcerror.NewError(fcerror.ErrSessionNotFound, nil)
+		return
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = trans.DeleteSessionByID(authCtx.User.ID, sessionID)
+	if fcerr != nil && fcerr.ID != fcerror.ErrSessionNotFound {
+		mgr.logger.WithError(fcerr).WithField("sessionID", sessionID).Error("Failed to delete session")
+	}
+	return
+}
+
+func (mgr *authManager) RevokeAllOtherSessions(authCtx *authorization.Context, currentToken models.Token) (fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil || currentToken == "" {
+		fcerr = fcerror.NewError(fcerror.ErrUnauthorized, nil)
+		return
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = trans.DeleteSessionsOfUser(authCtx.User.ID, mgr.tokens.hash(currentToken))
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", authCtx.User.ID).Error("Failed to delete other sessions of user")
+	}
+	return
+}
+
+// UnlockLogin removes the lockout and backoff of the user's account after failed logins
+func (mgr *authManager) UnlockLogin(authCtx *authorization.Context, userID models.UserID) (fcerr *fcerror.Error) {
+	fcerr = authorization.Enforce(authCtx, authorization.PermissionManageLogins, nil)
+	if fcerr != nil {
+		return
+	}
+
+	user, fcerr := mgr.managers.User.GetUserByID(authCtx, userID)
+	if fcerr != nil {
+		return
+	}
+
+	mgr.loginThrottle.resetAccount(user.Email)
+	mgr.logger.WithField("userID", userID).Info("Unlocked login of user")
+	return
+}
+
+func (mgr *authManager) RevokeAllSessionsOfUser(authCtx *authorization.Context, userID models.UserID) (fcerr *fcerror.Error) {
+	fcerr = authorization.Enforce(authCtx, authorization.PermissionManageLogins, nil)
+	if fcerr != nil {
+		return
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = trans.DeleteSessionsOfUser(userID, "")
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", userID).Error("Failed to delete sessions of user")
+	}
+	return
+}
+
+func (mgr *authManager) EnrollTOTP(authCtx *authorization.Context) (uri string, fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Two-factor authentication can only be enrolled by users"))
+		return
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	existing, fcerr := trans.GetTOTP(authCtx.User.ID)
+	if fcerr == nil && existing.Confirmed {
+		fcerr = fcerror.NewError(fcerror.ErrTOTPAlreadyEnabled, nil)
+		return
+	} else if fcerr != nil && fcerr.ID != fcerror.ErrTOTPNotFound {
+		mgr.logger.WithError(fcerr).WithField("userID", authCtx.User.ID).Error("Failed to get TOTP of user")
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrUnknown, err)
+		mgr.logger.WithError(fcerr).Error("Failed to generate TOTP secret")
+		return
+	}
+
+	// The secret is only used for logins after it was confirmed with a first code
+	totp := &models.TOTP{
+		UserID:  authCtx.User.ID,
+		Created: utils.GetCurrentTime(),
+		Secret:  secret,
+	}
+	fcerr = trans.SaveTOTP(totp)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", authCtx.User.ID).Error("Failed to save TOTP")
+		return
+	}
+
+	return utils.BuildTOTPURI(totpIssuer, authCtx.User.Email, secret), nil
+}
+
+func (mgr *authManager) ConfirmTOTP(authCtx *authorization.Context, code string) (recoveryCodes []string, fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		fcerr = fcerror.NewError(fcerror.ErrTOTPNotFound, nil)
+		return
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	totp, fcerr := trans.GetTOTP(authCtx.User.ID)
+	if fcerr != nil {
+		if fcerr.ID != fcerror.ErrTOTPNotFound {
+			mgr.logger.WithError(fcerr).WithField("userID", authCtx.User.ID).Error("Failed to get TOTP of user")
+		}
+		return
+	}
+	if totp.Confirmed {
+		fcerr = fcerror.NewError(fcerror.ErrTOTPAlreadyEnabled, nil)
+		return
+	}
+
+	step, valid, err := utils.ValidateTOTPCode(totp.Secret, code, utils.GetCurrentTime(), totpAllowedSkew)
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrUnknown, err)
+		mgr.logger.WithError(fcerr).WithField("userID", authCtx.User.ID).Error("Failed to validate TOTP code")
+		return
+	}
+	if !valid {
+		fcerr = fcerror.NewError(fcerror.ErrSecondFactorInvalid, nil)
+		return
+	}
+
+	totp.Confirmed = true
+	totp.LastUsedStep = step
+	fcerr = trans.SaveTOTP(totp)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", authCtx.User.ID).Error("Failed to save TOTP")
+		return
+	}
+
+	recoveryCodes, err = utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrUnknown, err)
+		mgr.logger.WithError(fcerr).Error("Failed to generate recovery codes")
+		return
+	}
+	codeHashes := make([]string, len(recoveryCodes))
+	for it, recoveryCode := range recoveryCodes {
+		codeHashes[it] = utils.HashRecoveryCode(recoveryCode)
+	}
+
+	fcerr = trans.SaveRecoveryCodes(authCtx.User.ID, codeHashes)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", authCtx.User.ID).Error("Failed to save recovery codes")
+		return nil, fcerr
+	}
+	return
+}
+
+func (mgr *authManager) DisableTOTP(authCtx *authorization.Context, code string) (fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		fcerr = fcerror.NewError(fcerror.ErrTOTPNotFound, nil)
+		return
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	valid, fcerr := mgr.verifySecondFactor(trans, authCtx.User.ID, code)
+	if fcerr != nil {
+		return
+	}
+	if !valid {
+		fcerr = fcerror.NewError(fcerror.ErrSecondFactorInvalid, nil)
+		return
+	}
+
+	fcerr = trans.DeleteTOTP(authCtx.User.ID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", authCtx.User.ID).Error("Failed to delete TOTP")
+	}
+	return
+}
+
+func (mgr *authManager) CreateAccessToken(authCtx *authorization.Context, accessToken *models.AccessToken) (token models.Token, fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceNoAccessToken(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Access tokens can only be created by users"))
+		return
+	}
+
+	accessToken.Name = strings.TrimSpace(accessToken.Name)
+	if accessToken.Name == "" {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Access token needs a name"))
+		return
+	}
+	if accessToken.ExpiresAt != nil && accessToken.ExpiresAt.Before(utils.GetCurrentTime()) {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Access token expiration date lies in the past"))
+		return
+	}
+	if accessToken.FolderID != "" {
+		folder, fcerr := mgr.managers.Node.GetNodeByID(authCtx, accessToken.FolderID)
+		if fcerr != nil {
+			return "", fcerr
+		}
+		if folder.Type != models.NodeTypeFolder {
+			return "", fcerror.NewError(fcerror.ErrBadRequest, errors.New("Access tokens can only be limited to folders"))
+		}
+	}
+
+	randomToken, err := utils.GenerateSecureRandomString(accessTokenLength)
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrUnknown, err)
+		mgr.logger.WithError(fcerr).Error("Failed to generate access token")
+		return
+	}
+	token = models.Token(models.AccessTokenPrefix + randomToken)
+
+	accessToken.ID = models.AccessTokenID(uuid.NewString())
+	accessToken.TokenHash = utils.HashToken(string(token))
+	accessToken.UserID = authCtx.User.ID
+	accessToken.Created = utils.GetCurrentTime()
+	accessToken.LastUsed = nil
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return "", fcerr
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = trans.SaveAccessToken(accessToken)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", authCtx.User.ID).Error("Failed to save access token")
+		return "", fcerr
+	}
+	return
+}
+
+func (mgr *authManager) GetOwnAccessTokens(authCtx *authorization.Context) (accessTokens []*models.AccessToken, fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceNoAccessToken(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		return []*models.AccessToken{}, nil
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer trans.Close()
+
+	accessTokens, fcerr = trans.GetAccessTokensOfUser(authCtx.User.ID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", authCtx.User.ID).Error("Failed to get access tokens of user")
+	}
+	return
+}
+
+func (mgr *authManager) RevokeAccessToken(authCtx *authorization.Context, accessTokenID models.AccessTokenID) (fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceNoAccessToken(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		fcerr = fcerror.NewError(fcerror.ErrAccessTokenNotFound, nil)
+		return
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = trans.DeleteAccessToken(authCtx.User.ID, accessTokenID)
+	if fcerr != nil && fcerr.ID != fcerror.ErrAccessTokenNotFound {
+		mgr.logger.WithError(fcerr).WithField("accessTokenID", accessTokenID).Error("Failed to delete access token")
+	}
+	return
+}
+
+func (mgr *authManager) VerifyAccessToken(token models.Token) (user *models.User, scope *models.TokenScope, fcerr *fcerror.Error) {
+	authTrans, fcerr := mgr.authPersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+
+	accessToken, fcerr := authTrans.GetAccessTokenByHash(utils.HashToken(string(token)))
+	authTrans.Close()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Access token not found or failed to verify")
+		return
+	}
+
+	if accessToken.ExpiresAt != nil && utils.GetCurrentTime().After(*accessToken.ExpiresAt) {
+		fcerr = fcerror.NewError(fcerror.ErrAccessTokenExpired, nil)
+		return
+	}
+
+	mgr.updateAccessTokenUsage(accessToken)
+
+	user, fcerr = mgr.managers.User.GetUserByID(authorization.NewUser(&models.User{ID: accessToken.UserID}), accessToken.UserID)
+	if fcerr != nil {
+		return
+	}
+	if user.Disabled {
+		return nil, nil, fcerror.NewError(fcerror.ErrUserDisabled, nil)
+	}
+	return user, accessToken.Scope(), nil
+}
+
+func (mgr *authManager) updateAccessTokenUsage(accessToken *models.AccessToken) {
+	now := utils.GetCurrentTime()
+	if accessToken.LastUsed != nil && now.Sub(*accessToken.LastUsed) < sessionUsageUpdateInterval {
+		return
+	}
+	accessToken.LastUsed = &now
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { _ = trans.Finish(fcerr) }()
+
+	fcerr = trans.UpdateAccessTokenLastUsed(accessToken)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Warn("Failed to update access token usage - ignore for now")
+	}
 }
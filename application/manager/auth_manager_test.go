@@ -0,0 +1,753 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/freecloudio/server/application/authentication"
+	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/mock"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	authTestEmail    = "user@example.com"
+	authTestPassword = "password"
+)
+
+type authMocks struct {
+	cfg             *mock.MockConfig
+	authPersistence *mock.MockAuthPersistenceController
+	authTrans       *mock.MockAuthPersistenceReadWriteTransaction
+	userMgr         *mock.MockUserManager
+	throttleCfg     *config.LoginThrottleConfig
+	lifetimeCfg     *config.SessionLifetimeConfig
+	user            *models.User
+	authMgr         *authManager
+}
+
+// createAuthMocks builds the auth manager without its background routines, so they do not interfere with the expected calls
+func createAuthMocks(t *testing.T, mockCtrl *gomock.Controller) *authMocks {
+	hashers, err := utils.NewPasswordHashers(&utils.PasswordHashingConfig{
+		Algorithm:       utils.Argon2idAlgorithm,
+		Argon2idTime:    1,
+		Argon2idMemory:  64,
+		Argon2idThreads: 1,
+		ScryptN:         2,
+		ScryptR:         1,
+		ScryptP:         1,
+	})
+	require.Nil(t, err, "Failed to create password hashers")
+	passwordHash, err := hashers.Hash(authTestPassword)
+	require.Nil(t, err, "Failed to hash password")
+
+	mocks := &authMocks{
+		cfg:             mock.NewMockConfig(mockCtrl),
+		authPersistence: mock.NewMockAuthPersistenceController(mockCtrl),
+		authTrans:       mock.NewMockAuthPersistenceReadWriteTransaction(mockCtrl),
+		userMgr:         mock.NewMockUserManager(mockCtrl),
+		throttleCfg:     &config.LoginThrottleConfig{LockoutDuration: time.Hour},
+		lifetimeCfg:     &config.SessionLifetimeConfig{},
+		user:            &models.User{ID: "user", Email: authTestEmail, Password: passwordHash, EmailVerified: true},
+	}
+	mocks.cfg.EXPECT().GetEmailVerificationRequired().Return(false).AnyTimes()
+	mocks.cfg.EXPECT().GetSessionExpirationDuration().Return(time.Hour).AnyTimes()
+	mocks.cfg.EXPECT().GetSessionLifetimeConfig().Return(mocks.lifetimeCfg).AnyTimes()
+	auditMgr := mock.NewMockAuditManager(mockCtrl)
+	auditMgr.EXPECT().Record(gomock.Any(), gomock.Any()).AnyTimes()
+
+	mocks.authMgr = &authManager{
+		cfg:             mocks.cfg,
+		authPersistence: mocks.authPersistence,
+		passwordHashers: hashers,
+		tokens:          newTokenService(32),
+		tokenSecret:     []byte("secret"),
+		loginThrottle:   newLoginThrottle(mocks.throttleCfg),
+		managers:        &Managers{User: mocks.userMgr, Audit: auditMgr},
+		done:            make(chan struct{}),
+		logger:          utils.CreateLogger(&utils.LoggingConfig{}),
+	}
+	return mocks
+}
+
+// expectNoSecondFactor expects the lookup of the second factors of a user without any
+func (mocks *authMocks) expectNoSecondFactor() {
+	mocks.authPersistence.EXPECT().StartReadTransaction().Return(mocks.authTrans, nil).Times(1)
+	mocks.authTrans.EXPECT().GetTOTP(mocks.user.ID).Return(nil, fcerror.NewError(fcerror.ErrTOTPNotFound, nil)).Times(1)
+	mocks.authTrans.EXPECT().GetWebAuthnCredentialsOfUser(mocks.user.ID).Return([]*models.WebAuthnCredential{}, nil).Times(1)
+	mocks.authTrans.EXPECT().Close().Return(nil).Times(1)
+}
+
+// expectSessionCreation expects a session to be saved and returns the saved session once it is
+func (mocks *authMocks) expectSessionCreation() *models.Session {
+	saved := &models.Session{}
+	mocks.authPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.authTrans, nil).Times(1)
+	mocks.authTrans.EXPECT().SaveSession(gomock.Any()).DoAndReturn(func(session *models.Session) *fcerror.Error {
+		*saved = *session
+		return nil
+	}).Times(1)
+	mocks.authTrans.EXPECT().Finish(nil).Return(nil).Times(1)
+	return saved
+}
+
+func TestLoginLockout(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mocks := createAuthMocks(t, mockCtrl)
+	mocks.throttleCfg.AccountLockoutThreshold = 3
+	client := &models.SessionClient{ClientIP: "10.0.0.1"}
+
+	// The credentials are not checked anymore once the account is locked
+	mocks.userMgr.EXPECT().GetUserByEmail(gomock.Any(), authTestEmail).Return(mocks.user, nil).Times(3)
+	for it := 0; it < 3; it++ {
+		_, fcerr := mocks.authMgr.Login(authTestEmail, "wrong", false, client)
+		require.NotNil(t, fcerr, "Wrong password accepted")
+		assert.EqualValues(t, fcerror.ErrUnauthorized, fcerr.ID, "Wrong error for wrong password")
+	}
+
+	result, fcerr := mocks.authMgr.Login(authTestEmail, authTestPassword, false, client)
+	assert.Nil(t, result, "Locked account logged in")
+	require.NotNil(t, fcerr, "Locked account logged in")
+	assert.EqualValues(t, fcerror.ErrTooManyAttempts, fcerr.ID, "Wrong error for locked account")
+}
+
+func TestLoginResetsThrottleOnSuccess(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mocks := createAuthMocks(t, mockCtrl)
+	mocks.throttleCfg.AccountLockoutThreshold = 3
+
+	mocks.userMgr.EXPECT().GetUserByEmail(gomock.Any(), authTestEmail).Return(mocks.user, nil).Times(5)
+	mocks.expectNoSecondFactor()
+	mocks.expectSessionCreation()
+
+	for it := 0; it < 2; it++ {
+		_, fcerr := mocks.authMgr.Login(authTestEmail, "wrong", false, nil)
+		require.NotNil(t, fcerr, "Wrong password accepted")
+	}
+	result, fcerr := mocks.authMgr.Login(authTestEmail, authTestPassword, false, nil)
+	require.Nil(t, fcerr, "Failed to login")
+	assert.NotNil(t, result.Session, "Missing session after login")
+
+	// Without the reset the second failure would lock the account
+	for it := 0; it < 2; it++ {
+		_, fcerr = mocks.authMgr.Login(authTestEmail, "wrong", false, nil)
+		require.NotNil(t, fcerr, "Wrong password accepted")
+		assert.EqualValues(t, fcerror.ErrUnauthorized, fcerr.ID, "Failures were not reset by the successful login")
+	}
+}
+
+func TestCreateNewSessionLifetime(t *testing.T) {
+	tests := []struct {
+		name        string
+		rememberMe  bool
+		lifetimeCfg config.SessionLifetimeConfig
+		expectedIn  time.Duration
+	}{
+		{name: "Expiration after usage", expectedIn: time.Hour},
+		{name: "Limited by max lifetime", lifetimeCfg: config.SessionLifetimeConfig{MaxLifetime: 30 * time.Minute}, expectedIn: 30 * time.Minute},
+		{name: "Remember me", rememberMe: true, lifetimeCfg: config.SessionLifetimeConfig{MaxLifetime: 30 * time.Minute, RememberMeExpiration: 30 * 24 * time.Hour}, expectedIn: 30 * 24 * time.Hour},
+		{name: "Remember me limited by its max lifetime", rememberMe: true, lifetimeCfg: config.SessionLifetimeConfig{RememberMeExpiration: 30 * 24 * time.Hour, RememberMeMaxLifetime: 7 * 24 * time.Hour}, expectedIn: 7 * 24 * time.Hour},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createAuthMocks(t, mockCtrl)
+			*mocks.lifetimeCfg = test.lifetimeCfg
+			saved := mocks.expectSessionCreation()
+
+			session, fcerr := mocks.authMgr.CreateNewSession(mocks.user.ID, test.rememberMe, nil)
+			require.Nil(t, fcerr, "Failed to create session")
+			assert.Equal(t, test.rememberMe, saved.RememberMe, "Wrong remember me flag of saved session")
+			assert.WithinDuration(t, utils.GetTimeIn(test.expectedIn), saved.ValidUntil, time.Minute, "Wrong expiration of saved session")
+			assert.NotEmpty(t, session.Token, "Missing token of created session")
+			assert.NotEqual(t, string(session.Token), saved.TokenHash, "Token saved in plain")
+		})
+	}
+}
+
+func TestVerifyTokenSessionExpiration(t *testing.T) {
+	day := 24 * time.Hour
+	now := utils.GetCurrentTime()
+
+	tests := []struct {
+		name          string
+		session       *models.Session
+		lifetimeCfg   config.SessionLifetimeConfig
+		expectedErr   fcerror.ErrorID
+		expectedUntil *time.Time
+	}{
+		{
+			name:          "Sliding expiration extended",
+			session:       &models.Session{Created: now.Add(-2 * time.Hour), LastUsed: now.Add(-30 * time.Minute), ValidUntil: now.Add(30 * time.Minute)},
+			expectedUntil: timePtr(now.Add(time.Hour)),
+		},
+		{
+			name:        "Expired after inactivity",
+			session:     &models.Session{Created: now.Add(-3 * time.Hour), LastUsed: now.Add(-2 * time.Hour), ValidUntil: now.Add(-time.Hour)},
+			expectedErr: fcerror.ErrSessionExpired,
+		},
+		{
+			name:        "Max lifetime reached",
+			session:     &models.Session{Created: now.Add(-25 * time.Hour), LastUsed: now.Add(-10 * time.Minute), ValidUntil: now.Add(50 * time.Minute)},
+			lifetimeCfg: config.SessionLifetimeConfig{MaxLifetime: day},
+			expectedErr: fcerror.ErrSessionExpired,
+		},
+		{
+			name:          "Extension limited by max lifetime",
+			session:       &models.Session{Created: now.Add(-23*time.Hour - 30*time.Minute), LastUsed: now.Add(-10 * time.Minute), ValidUntil: now.Add(30 * time.Minute)},
+			lifetimeCfg:   config.SessionLifetimeConfig{MaxLifetime: day},
+			expectedUntil: timePtr(now.Add(30 * time.Minute)),
+		},
+		{
+			name:          "Remember me survives inactivity",
+			session:       &models.Session{Created: now.Add(-10 * day), LastUsed: now.Add(-3 * day), ValidUntil: now.Add(27 * day), RememberMe: true},
+			lifetimeCfg:   config.SessionLifetimeConfig{MaxLifetime: day, RememberMeExpiration: 30 * day, RememberMeMaxLifetime: 90 * day},
+			expectedUntil: timePtr(now.Add(30 * day)),
+		},
+		{
+			name:        "Remember me max lifetime reached",
+			session:     &models.Session{Created: now.Add(-91 * day), LastUsed: now.Add(-time.Hour), ValidUntil: now.Add(29 * day), RememberMe: true},
+			lifetimeCfg: config.SessionLifetimeConfig{RememberMeExpiration: 30 * day, RememberMeMaxLifetime: 90 * day},
+			expectedErr: fcerror.ErrSessionExpired,
+		},
+		{
+			name:    "Recent usage not written",
+			session: &models.Session{Created: now.Add(-time.Hour), LastUsed: now.Add(-10 * time.Second), ValidUntil: now.Add(time.Hour)},
+		},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createAuthMocks(t, mockCtrl)
+			*mocks.lifetimeCfg = test.lifetimeCfg
+			test.session.UserID = mocks.user.ID
+
+			mocks.authPersistence.EXPECT().StartReadTransaction().Return(mocks.authTrans, nil).Times(1)
+			mocks.authTrans.EXPECT().GetSessionByToken(gomock.Any()).Return(test.session, nil).Times(1)
+			mocks.authTrans.EXPECT().Close().Return(nil).Times(1)
+			if test.expectedErr == 0 {
+				mocks.userMgr.EXPECT().GetUserByID(gomock.Any(), mocks.user.ID).Return(mocks.user, nil).Times(1)
+			}
+			if test.expectedUntil != nil {
+				mocks.authPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.authTrans, nil).Times(1)
+				mocks.authTrans.EXPECT().UpdateSessionUsage(test.session).Return(nil).Times(1)
+				mocks.authTrans.EXPECT().Finish(nil).Return(nil).Times(1)
+			}
+
+			user, fcerr := mocks.authMgr.VerifyToken("token", nil)
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Expired session accepted")
+				assert.Equal(t, test.expectedErr, fcerr.ID, "Wrong error for expired session")
+				return
+			}
+			require.Nil(t, fcerr, "Valid session rejected")
+			assert.Equal(t, mocks.user.ID, user.ID, "Wrong user of session")
+			if test.expectedUntil != nil {
+				assert.WithinDuration(t, *test.expectedUntil, test.session.ValidUntil, time.Minute, "Wrong extended expiration")
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestLoginPendingSecondFactor(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mocks := createAuthMocks(t, mockCtrl)
+	mocks.userMgr.EXPECT().GetUserByEmail(gomock.Any(), authTestEmail).Return(mocks.user, nil).Times(1)
+	mocks.authPersistence.EXPECT().StartReadTransaction().Return(mocks.authTrans, nil).Times(1)
+	mocks.authTrans.EXPECT().GetTOTP(mocks.user.ID).Return(&models.TOTP{UserID: mocks.user.ID, Confirmed: true}, nil).Times(1)
+	mocks.authTrans.EXPECT().GetWebAuthnCredentialsOfUser(mocks.user.ID).Return([]*models.WebAuthnCredential{}, nil).Times(1)
+	mocks.authTrans.EXPECT().Close().Return(nil).Times(1)
+
+	var savedChallenge *models.LoginChallenge
+	mocks.authPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.authTrans, nil).Times(1)
+	mocks.authTrans.EXPECT().SaveLoginChallenge(gomock.Any()).DoAndReturn(func(challenge *models.LoginChallenge) *fcerror.Error {
+		savedChallenge = challenge
+		return nil
+	}).Times(1)
+	mocks.authTrans.EXPECT().Finish(nil).Return(nil).Times(1)
+
+	result, fcerr := mocks.authMgr.Login(authTestEmail, authTestPassword, true, nil)
+	require.Nil(t, fcerr, "Failed to login")
+	assert.Nil(t, result.Session, "Session created before the second factor")
+	require.NotNil(t, result.Challenge, "Missing challenge for the second factor")
+	require.NotNil(t, savedChallenge, "Challenge not saved")
+	assert.Equal(t, result.Challenge.Token, savedChallenge.Token, "Wrong challenge returned")
+	assert.Equal(t, mocks.user.ID, savedChallenge.UserID, "Wrong user of challenge")
+	assert.True(t, savedChallenge.RememberMe, "Remember me not passed on to the challenge")
+	assert.Nil(t, savedChallenge.WebAuthnOptions, "Security key options without security keys")
+}
+
+func TestCompleteLoginChallenge(t *testing.T) {
+	secret, err := utils.GenerateTOTPSecret()
+	require.Nil(t, err, "Failed to generate TOTP secret")
+	currentStep := utils.GetTOTPStep(utils.GetCurrentTime())
+	currentCode, err := utils.GenerateTOTPCode(secret, currentStep)
+	require.Nil(t, err, "Failed to generate TOTP code")
+
+	tests := []struct {
+		name             string
+		code             string
+		attempts         int64
+		expired          bool
+		lastUsedStep     int64
+		challengeErr     *fcerror.Error
+		recoveryCodeUsed bool
+		expectedErr      fcerror.ErrorID
+		expectConsumed   bool
+	}{
+		{name: "Valid TOTP code", code: currentCode, expectConsumed: true},
+		{name: "Recovery code", code: "recovery-code", recoveryCodeUsed: true, expectConsumed: true},
+		{name: "Reused TOTP code", code: currentCode, lastUsedStep: currentStep + totpAllowedSkew, expectedErr: fcerror.ErrSecondFactorInvalid},
+		{name: "Wrong code", code: "wrong-code", expectedErr: fcerror.ErrSecondFactorInvalid},
+		{name: "Wrong code at last attempt", code: "wrong-code", attempts: maxLoginChallengeAttempts - 1, expectedErr: fcerror.ErrSecondFactorInvalid, expectConsumed: true},
+		{name: "Expired challenge", code: currentCode, expired: true, expectedErr: fcerror.ErrLoginChallengeExpired},
+		{name: "Unknown challenge", code: currentCode, challengeErr: fcerror.NewError(fcerror.ErrLoginChallengeNotFound, nil), expectedErr: fcerror.ErrLoginChallengeNotFound},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createAuthMocks(t, mockCtrl)
+			mocks.throttleCfg.AccountLockoutThreshold = 1
+			challenge := &models.LoginChallenge{Token: "challenge", UserID: mocks.user.ID, ValidUntil: utils.GetTimeIn(time.Minute), Attempts: test.attempts, RememberMe: true}
+			if test.expired {
+				challenge.ValidUntil = utils.GetTimeIn(-time.Minute)
+			}
+
+			mocks.authPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.authTrans, nil).Times(1)
+			mocks.authTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWithArg).Times(1)
+			if test.challengeErr != nil {
+				mocks.authTrans.EXPECT().GetLoginChallenge(challenge.Token).Return(nil, test.challengeErr).Times(1)
+			} else {
+				mocks.authTrans.EXPECT().GetLoginChallenge(challenge.Token).Return(challenge, nil).Times(1)
+			}
+			if !test.expired && test.challengeErr == nil {
+				totp := &models.TOTP{UserID: mocks.user.ID, Secret: secret, Confirmed: true, LastUsedStep: test.lastUsedStep}
+				mocks.authTrans.EXPECT().GetTOTP(mocks.user.ID).Return(totp, nil).Times(1)
+				if test.code == currentCode && test.lastUsedStep == 0 {
+					mocks.authTrans.EXPECT().SaveTOTP(totp).Return(nil).Times(1)
+				} else {
+					mocks.authTrans.EXPECT().UseRecoveryCode(mocks.user.ID, utils.HashRecoveryCode(test.code)).Return(test.recoveryCodeUsed, nil).Times(1)
+				}
+				if test.expectConsumed {
+					mocks.authTrans.EXPECT().DeleteLoginChallenge(challenge.Token).Return(nil).Times(1)
+				} else {
+					mocks.authTrans.EXPECT().UpdateLoginChallengeAttempts(challenge).Return(nil).Times(1)
+				}
+				mocks.userMgr.EXPECT().GetUserByID(gomock.Any(), mocks.user.ID).Return(mocks.user, nil).Times(1)
+			}
+			var saved *models.Session
+			if test.expectedErr == 0 {
+				saved = mocks.expectSessionCreation()
+			}
+
+			session, fcerr := mocks.authMgr.CompleteLoginChallenge(challenge.Token, test.code, nil)
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Login challenge completed")
+				assert.Equal(t, test.expectedErr, fcerr.ID, "Wrong error for failed login challenge")
+				assert.Nil(t, session, "Session created for failed login challenge")
+				if test.expectedErr == fcerror.ErrSecondFactorInvalid {
+					assert.True(t, mocks.authMgr.loginThrottle.checkLogin(authTestEmail, "") > 0, "Failed second factor not counted against the account")
+					assert.Equal(t, test.attempts+1, challenge.Attempts, "Failed attempt not counted")
+				}
+				return
+			}
+			require.Nil(t, fcerr, "Failed to complete login challenge")
+			assert.Equal(t, mocks.user.ID, session.UserID, "Session created for wrong user")
+			assert.True(t, saved.RememberMe, "Remember me of the challenge not passed on to the session")
+		})
+	}
+}
+
+// mailerFunc lets tests answer sent mails with a function
+type mailerFunc func(mail *models.Mail) *fcerror.Error
+
+func (fn mailerFunc) SendMail(mail *models.Mail) *fcerror.Error {
+	return fn(mail)
+}
+
+func TestRequestPasswordResetHidesFailures(t *testing.T) {
+	tests := []struct {
+		name       string
+		userErr    *fcerror.Error
+		external   bool
+		tokenErr   *fcerror.Error
+		mailErr    *fcerror.Error
+		expectMail bool
+	}{
+		{name: "Mail sent", expectMail: true},
+		{name: "Unknown email", userErr: fcerror.NewError(fcerror.ErrUserNotFound, nil)},
+		{name: "External user", external: true},
+		{name: "Saving token fails", tokenErr: fcerror.NewError(fcerror.ErrDBWriteFailed, nil)},
+		{name: "Sending mail fails", mailErr: fcerror.NewError(fcerror.ErrMailSendFailed, nil), expectMail: true},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createAuthMocks(t, mockCtrl)
+			mocks.cfg.EXPECT().GetPasswordResetExpiration().Return(time.Hour).AnyTimes()
+			mocks.cfg.EXPECT().GetPublicURL().Return("https://cloud.example.com").AnyTimes()
+			var sentMail *models.Mail
+			mocks.authMgr.mailer = mailerFunc(func(mail *models.Mail) *fcerror.Error {
+				sentMail = mail
+				return test.mailErr
+			})
+
+			mocks.user.External = test.external
+			if test.userErr != nil {
+				mocks.userMgr.EXPECT().GetUserByEmail(gomock.Any(), authTestEmail).Return(nil, test.userErr).Times(1)
+			} else {
+				mocks.userMgr.EXPECT().GetUserByEmail(gomock.Any(), authTestEmail).Return(mocks.user, nil).Times(1)
+			}
+			if test.userErr == nil && !test.external {
+				mocks.authPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.authTrans, nil).Times(1)
+				mocks.authTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWithArg).Times(1)
+				mocks.authTrans.EXPECT().DeleteEmailTokensOfUser(mocks.user.ID, models.EmailTokenPurposePasswordReset).Return(nil).Times(1)
+				mocks.authTrans.EXPECT().SaveEmailToken(gomock.Any()).Return(test.tokenErr).Times(1)
+			}
+
+			fcerr := mocks.authMgr.RequestPasswordReset(authTestEmail)
+			assert.Nil(t, fcerr, "Response reveals whether the account exists")
+			if test.expectMail {
+				if assert.NotNil(t, sentMail, "Reset mail not sent") {
+					assert.Equal(t, authTestEmail, sentMail.To, "Reset mail sent to wrong address")
+				}
+			} else {
+				assert.Nil(t, sentMail, "Reset mail sent")
+			}
+		})
+	}
+}
+
+func TestLinkExternalAccount(t *testing.T) {
+	secret, err := utils.GenerateTOTPSecret()
+	require.Nil(t, err, "Failed to generate TOTP secret")
+	currentCode, err := utils.GenerateTOTPCode(secret, utils.GetTOTPStep(utils.GetCurrentTime()))
+	require.Nil(t, err, "Failed to generate TOTP code")
+
+	tests := []struct {
+		name        string
+		password    string
+		code        string
+		totpEnabled bool
+		external    bool
+		expectedErr fcerror.ErrorID
+	}{
+		{name: "Linked without second factor", password: authTestPassword},
+		{name: "Linked with TOTP code", password: authTestPassword, code: currentCode, totpEnabled: true},
+		{name: "Wrong password", password: "wrong", expectedErr: fcerror.ErrPasswordConfirmationFailed},
+		{name: "Missing TOTP code", password: authTestPassword, totpEnabled: true, expectedErr: fcerror.ErrSecondFactorInvalid},
+		{name: "Already linked", password: "wrong", external: true},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createAuthMocks(t, mockCtrl)
+			mocks.throttleCfg.AccountLockoutThreshold = 1
+			mocks.user.External = test.external
+			mocks.userMgr.EXPECT().GetUserByID(gomock.Any(), mocks.user.ID).Return(mocks.user, nil).Times(1)
+
+			passwordValid := test.password == authTestPassword
+			if !test.external && passwordValid {
+				if test.totpEnabled {
+					totp := &models.TOTP{UserID: mocks.user.ID, Secret: secret, Confirmed: true}
+					mocks.authPersistence.EXPECT().StartReadTransaction().Return(mocks.authTrans, nil).Times(1)
+					mocks.authTrans.EXPECT().GetTOTP(mocks.user.ID).Return(totp, nil).Times(2)
+					mocks.authTrans.EXPECT().GetWebAuthnCredentialsOfUser(mocks.user.ID).Return([]*models.WebAuthnCredential{}, nil).Times(1)
+					mocks.authTrans.EXPECT().Close().Return(nil).Times(1)
+					mocks.authPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.authTrans, nil).Times(1)
+					mocks.authTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWithArg).Times(1)
+					if test.code == currentCode {
+						mocks.authTrans.EXPECT().SaveTOTP(totp).Return(nil).Times(1)
+					} else {
+						mocks.authTrans.EXPECT().UseRecoveryCode(mocks.user.ID, utils.HashRecoveryCode(test.code)).Return(false, nil).Times(1)
+					}
+				} else {
+					mocks.expectNoSecondFactor()
+				}
+			}
+			if test.expectedErr == 0 && !test.external {
+				linked := true
+				mocks.userMgr.EXPECT().UpdateUser(gomock.Any(), mocks.user.ID, &models.UserUpdate{External: &linked}).DoAndReturn(
+					func(authCtx *authorization.Context, userID models.UserID, update *models.UserUpdate) (*models.User, *fcerror.Error) {
+						return &models.User{ID: userID, Email: authTestEmail, External: true}, nil
+					}).Times(1)
+			}
+
+			user, fcerr := mocks.authMgr.LinkExternalAccount(authorization.NewUser(mocks.user), test.password, test.code)
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Account linked without confirmation")
+				assert.EqualValues(t, test.expectedErr, fcerr.ID, "Wrong error for failed confirmation")
+				assert.True(t, mocks.authMgr.loginThrottle.checkLogin(authTestEmail, "") > 0, "Failed confirmation not counted against the account")
+				return
+			}
+			require.Nil(t, fcerr, "Failed to link account")
+			assert.True(t, user.External, "Account not linked")
+			assert.Empty(t, user.Password, "Password hash returned")
+		})
+	}
+}
+
+// authenticatorFunc lets tests answer the credentials checks of an external authenticator with a function
+type authenticatorFunc func(email, password string) (*models.ExternalUser, *fcerror.Error)
+
+func (fn authenticatorFunc) Authenticate(email, password string) (*models.ExternalUser, *fcerror.Error) {
+	return fn(email, password)
+}
+
+func (fn authenticatorFunc) ListUsers() ([]*models.ExternalUser, *fcerror.Error) {
+	return nil, nil
+}
+
+func TestAuthenticateWithExternalAuthenticator(t *testing.T) {
+	tests := []struct {
+		name            string
+		password        string
+		userExternal    bool
+		userUnknown     bool
+		directoryValid  bool
+		provisionErr    *fcerror.Error
+		expectDirectory bool
+		expectedErr     fcerror.ErrorID
+	}{
+		{name: "Local password", password: authTestPassword, directoryValid: true},
+		{name: "Local user not checked against directory", password: "directory-password", directoryValid: true, expectedErr: fcerror.ErrUnauthorized},
+		{name: "Unknown user provisioned", password: "directory-password", userUnknown: true, directoryValid: true, expectDirectory: true},
+		{name: "Directory entry of unlinked local user", password: "directory-password", userUnknown: true, directoryValid: true, expectDirectory: true, provisionErr: fcerror.NewError(fcerror.ErrExternalUserNotLinked, nil), expectedErr: fcerror.ErrUnauthorized},
+		{name: "Linked user checked against directory", password: "directory-password", userExternal: true, directoryValid: true, expectDirectory: true},
+		{name: "Local password of linked user ignored", password: authTestPassword, userExternal: true, expectDirectory: true, expectedErr: fcerror.ErrUnauthorized},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createAuthMocks(t, mockCtrl)
+			mocks.user.External = test.userExternal
+			directoryCalled := false
+			mocks.authMgr.authenticators = []authentication.Authenticator{authenticatorFunc(func(email, password string) (*models.ExternalUser, *fcerror.Error) {
+				directoryCalled = true
+				if !test.directoryValid || password != "directory-password" {
+					return nil, fcerror.NewError(fcerror.ErrUnauthorized, nil)
+				}
+				return &models.ExternalUser{Email: email}, nil
+			})}
+
+			if test.userUnknown {
+				mocks.userMgr.EXPECT().GetUserByEmail(gomock.Any(), authTestEmail).Return(nil, fcerror.NewError(fcerror.ErrUserNotFound, nil)).Times(1)
+			} else {
+				mocks.userMgr.EXPECT().GetUserByEmail(gomock.Any(), authTestEmail).Return(mocks.user, nil).Times(1)
+			}
+			if test.expectDirectory && test.directoryValid {
+				provisioned := &models.User{ID: "external", Email: authTestEmail, External: true}
+				if test.provisionErr != nil {
+					provisioned = nil
+				}
+				mocks.userMgr.EXPECT().ProvisionExternalUser(&models.ExternalUser{Email: authTestEmail}).Return(provisioned, test.provisionErr).Times(1)
+			}
+
+			user, fcerr := mocks.authMgr.authenticate(authTestEmail, test.password)
+			assert.Equal(t, test.expectDirectory, directoryCalled, "Wrong use of the external authenticator")
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Invalid credentials accepted")
+				assert.EqualValues(t, test.expectedErr, fcerr.ID, "Wrong error for invalid credentials")
+				assert.Nil(t, user, "User returned for invalid credentials")
+				return
+			}
+			require.Nil(t, fcerr, "Valid credentials rejected")
+			require.NotNil(t, user, "No user returned")
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	newPassword := "new-password"
+
+	tests := []struct {
+		name        string
+		tokenEmail  string
+		external    bool
+		expectedErr fcerror.ErrorID
+	}{
+		{name: "Password reset", tokenEmail: authTestEmail},
+		{name: "Email changed since the token was sent", tokenEmail: "old@example.com", expectedErr: fcerror.ErrEmailTokenInvalid},
+		{name: "User linked since the token was sent", tokenEmail: authTestEmail, external: true, expectedErr: fcerror.ErrEmailTokenInvalid},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createAuthMocks(t, mockCtrl)
+			mocks.cfg.EXPECT().GetPasswordPolicyConfig().Return(&config.PasswordPolicyConfig{MinLength: 8}).AnyTimes()
+			mocks.user.External = test.external
+
+			// The token is created for the address it is mailed to, which may differ from the current one
+			var emailToken *models.EmailToken
+			mocks.authPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.authTrans, nil).Times(2)
+			mocks.authTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWithArg).Times(2)
+			mocks.authTrans.EXPECT().DeleteEmailTokensOfUser(mocks.user.ID, models.EmailTokenPurposePasswordReset).Return(nil).Times(1)
+			mocks.authTrans.EXPECT().SaveEmailToken(gomock.Any()).DoAndReturn(func(token *models.EmailToken) *fcerror.Error {
+				emailToken = token
+				return nil
+			}).Times(1)
+			token, fcerr := mocks.authMgr.createEmailToken(&models.User{ID: mocks.user.ID, Email: test.tokenEmail}, models.EmailTokenPurposePasswordReset, time.Hour)
+			require.Nil(t, fcerr, "Failed to create email token")
+
+			mocks.authTrans.EXPECT().GetEmailToken(emailToken.TokenHash).Return(emailToken, nil).Times(1)
+			mocks.authTrans.EXPECT().DeleteEmailToken(emailToken.TokenHash).Return(nil).Times(1)
+			mocks.userMgr.EXPECT().GetUserByID(gomock.Any(), mocks.user.ID).Return(mocks.user, nil).Times(1)
+			if test.expectedErr == 0 {
+				mocks.userMgr.EXPECT().UpdateUser(gomock.Any(), mocks.user.ID, &models.UserUpdate{Password: &newPassword}).Return(mocks.user, nil).Times(1)
+				mocks.authPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.authTrans, nil).Times(1)
+				mocks.authTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWithArg).Times(1)
+				mocks.authTrans.EXPECT().DeleteSessionsOfUser(mocks.user.ID, "").Return(nil).Times(1)
+			}
+
+			fcerr = mocks.authMgr.ResetPassword(token, newPassword)
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Password reset with invalid token")
+				assert.EqualValues(t, test.expectedErr, fcerr.ID, "Wrong error for invalid token")
+				return
+			}
+			assert.Nil(t, fcerr, "Failed to reset password")
+		})
+	}
+}
+
+func TestGetOwnSessionsMarksCurrent(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mocks := createAuthMocks(t, mockCtrl)
+	currentToken, currentHash, err := mocks.authMgr.tokens.newToken()
+	require.Nil(t, err, "Failed to create token")
+	sessions := []*models.Session{{ID: "current", UserID: mocks.user.ID, TokenHash: currentHash}, {ID: "other", UserID: mocks.user.ID, TokenHash: "other"}}
+
+	mocks.authPersistence.EXPECT().StartReadTransaction().Return(mocks.authTrans, nil).Times(1)
+	mocks.authTrans.EXPECT().GetSessionsOfUser(mocks.user.ID).Return(sessions, nil).Times(1)
+	mocks.authTrans.EXPECT().Close().Return(nil).Times(1)
+
+	result, fcerr := mocks.authMgr.GetOwnSessions(authorization.NewUser(mocks.user), currentToken)
+	require.Nil(t, fcerr, "Failed to get own sessions")
+	require.Len(t, result, 2, "Wrong number of sessions")
+	assert.True(t, result[0].Current, "Session of the current token not marked as current")
+	assert.False(t, result[1].Current, "Other session marked as current")
+}
+
+func TestRevokeSessionOfOtherUser(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mocks := createAuthMocks(t, mockCtrl)
+	otherSessionID := models.SessionID("other-session")
+
+	// The session is only looked up among the sessions of the context user, so those of others are not found
+	mocks.authPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.authTrans, nil).Times(1)
+	mocks.authTrans.EXPECT().DeleteSessionByID(mocks.user.ID, otherSessionID).Return(fcerror.NewError(fcerror.ErrSessionNotFound, nil)).Times(1)
+	mocks.authTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWithArg).Times(1)
+
+	fcerr := mocks.authMgr.RevokeSession(authorization.NewUser(mocks.user), otherSessionID)
+	require.NotNil(t, fcerr, "Session of other user revoked")
+	assert.EqualValues(t, fcerror.ErrSessionNotFound, fcerr.ID, "Wrong error for session of other user")
+}
+
+func TestRevokeAllOtherSessionsKeepsCurrent(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mocks := createAuthMocks(t, mockCtrl)
+	currentToken, currentHash, err := mocks.authMgr.tokens.newToken()
+	require.Nil(t, err, "Failed to create token")
+
+	mocks.authPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.authTrans, nil).Times(1)
+	mocks.authTrans.EXPECT().DeleteSessionsOfUser(mocks.user.ID, currentHash).Return(nil).Times(1)
+	mocks.authTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWithArg).Times(1)
+
+	fcerr := mocks.authMgr.RevokeAllOtherSessions(authorization.NewUser(mocks.user), currentToken)
+	assert.Nil(t, fcerr, "Failed to revoke other sessions")
+
+	// Without the current token all sessions would be revoked
+	fcerr = mocks.authMgr.RevokeAllOtherSessions(authorization.NewUser(mocks.user), "")
+	require.NotNil(t, fcerr, "Sessions revoked without current token")
+	assert.EqualValues(t, fcerror.ErrUnauthorized, fcerr.ID, "Wrong error for missing current token")
+}
+
+func TestRevokeAllSessionsOfUserPermissions(t *testing.T) {
+	targetID := models.UserID("target")
+
+	tests := []struct {
+		name        string
+		authCtx     *authorization.Context
+		expectedErr fcerror.ErrorID
+	}{
+		{name: "Admin", authCtx: authorization.NewUser(&models.User{ID: "admin", IsAdmin: true})},
+		{name: "User manager", authCtx: authorization.NewUser(&models.User{ID: "manager", AssignedRoles: []models.Role{models.RoleUserManager}})},
+		{name: "Auditor", authCtx: authorization.NewUser(&models.User{ID: "auditor", AssignedRoles: []models.Role{models.RoleAuditor}}), expectedErr: fcerror.ErrForbidden},
+		{name: "Target user", authCtx: authorization.NewUser(&models.User{ID: targetID}), expectedErr: fcerror.ErrForbidden},
+		{name: "Restricted token of admin", authCtx: authorization.NewAccessToken(&models.User{ID: "admin", IsAdmin: true}, &models.TokenScope{ReadOnly: true}), expectedErr: fcerror.ErrForbidden},
+		{name: "Anonymous", authCtx: authorization.NewAnonymous(), expectedErr: fcerror.ErrUnauthorized},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createAuthMocks(t, mockCtrl)
+			if test.expectedErr == 0 {
+				mocks.authPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.authTrans, nil).Times(1)
+				mocks.authTrans.EXPECT().DeleteSessionsOfUser(targetID, "").Return(nil).Times(1)
+				mocks.authTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWithArg).Times(1)
+			}
+
+			fcerr := mocks.authMgr.RevokeAllSessionsOfUser(test.authCtx, targetID)
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Sessions revoked without permission")
+				assert.Equal(t, test.expectedErr, fcerr.ID, "Wrong error for missing permission")
+				return
+			}
+			assert.Nil(t, fcerr, "Failed to revoke sessions of user")
+		})
+	}
+}
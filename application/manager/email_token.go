@@ -0,0 +1,253 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+const emailTokenNonceLength = 16
+
+// emailTokenPayload is signed into the mailed token, so forged or altered tokens are rejected before any lookup
+type emailTokenPayload struct {
+	Purpose    models.EmailTokenPurpose `json:"p"`
+	UserID     models.UserID            `json:"u"`
+	ValidUntil int64                    `json:"x"`
+	Nonce      string                   `json:"n"`
+}
+
+func (mgr *authManager) RequestPasswordReset(email string) (fcerr *fcerror.Error) {
+	user, fcerr := mgr.managers.User.GetUserByEmail(authorization.NewSystem(), email)
+	if fcerr != nil {
+		// Unknown emails are not reported to not reveal which accounts exist
+		mgr.logger.WithField("email", email).Info("Password reset requested for unknown email")
+		return nil
+	}
+	// Passwords of linked users are managed by the external identity source and not accepted locally
+	if user.External {
+		mgr.logger.WithField("userID", user.ID).Info("Password reset requested for external user")
+		return nil
+	}
+
+	// Failures are only logged, as reporting them would reveal that the account exists as well
+	token, fcerr := mgr.createEmailToken(user, models.EmailTokenPurposePasswordReset, mgr.cfg.GetPasswordResetExpiration())
+	if fcerr != nil {
+		return nil
+	}
+
+	_ = mgr.sendTokenMail(user, "Reset your freecloud password", "reset-password", token,
+		"a reset of your password was requested. Use the following link to choose a new password:",
+		mgr.cfg.GetPasswordResetExpiration())
+	return nil
+}
+
+func (mgr *authManager) ResetPassword(token, password string) (fcerr *fcerror.Error) {
+	v := &validator{}
+	v.validatePassword("password", password, mgr.cfg.GetPasswordPolicyConfig())
+	fcerr = v.err()
+	if fcerr != nil {
+		return
+	}
+
+	emailToken, fcerr := mgr.useEmailToken(token, models.EmailTokenPurposePasswordReset)
+	if fcerr != nil {
+		return
+	}
+
+	userUpdate := &models.UserUpdate{Password: &password}
+	user, fcerr := mgr.managers.User.GetUserByID(authorization.NewSystem(), emailToken.UserID)
+	if fcerr != nil {
+		return
+	}
+	// The token only proves access to the address it was mailed to, which has to still belong to a local user
+	if user.Email != emailToken.Email {
+		fcerr = fcerror.NewError(fcerror.ErrEmailTokenInvalid, errors.New("Email changed since the token was sent"))
+		return
+	}
+	if user.External {
+		fcerr = fcerror.NewError(fcerror.ErrEmailTokenInvalid, errors.New("User was linked to an external identity source since the token was sent"))
+		return
+	}
+	if !user.EmailVerified {
+		verified := true
+		userUpdate.EmailVerified = &verified
+	}
+
+	_, fcerr = mgr.managers.User.UpdateUser(authorization.NewSystem(), emailToken.UserID, userUpdate)
+	if fcerr != nil {
+		return
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = trans.DeleteSessionsOfUser(emailToken.UserID, "")
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", emailToken.UserID).Error("Failed to delete sessions of user after password reset")
+		return
+	}
+
+	mgr.loginThrottle.resetAccount(user.Email)
+	mgr.logger.WithField("userID", emailToken.UserID).Info("Reset password of user")
+	return
+}
+
+func (mgr *authManager) RequestEmailVerification(email string) (fcerr *fcerror.Error) {
+	user, fcerr := mgr.managers.User.GetUserByEmail(authorization.NewSystem(), email)
+	if fcerr != nil {
+		mgr.logger.WithField("email", email).Info("Email verification requested for unknown email")
+		return nil
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	token, fcerr := mgr.createEmailToken(user, models.EmailTokenPurposeVerification, mgr.cfg.GetEmailVerificationExpiration())
+	if fcerr != nil {
+		return
+	}
+
+	return mgr.sendTokenMail(user, "Verify your freecloud email", "verify-email", token,
+		"please confirm that this is your email address by opening the following link:",
+		mgr.cfg.GetEmailVerificationExpiration())
+}
+
+func (mgr *authManager) VerifyEmail(token string) (fcerr *fcerror.Error) {
+	emailToken, fcerr := mgr.useEmailToken(token, models.EmailTokenPurposeVerification)
+	if fcerr != nil {
+		return
+	}
+
+	user, fcerr := mgr.managers.User.GetUserByID(authorization.NewSystem(), emailToken.UserID)
+	if fcerr != nil {
+		return
+	}
+	// The token only verifies the address it was mailed to
+	if user.Email != emailToken.Email {
+		fcerr = fcerror.NewError(fcerror.ErrEmailTokenInvalid, errors.New("Email changed since the token was sent"))
+		return
+	}
+	if user.EmailVerified {
+		return
+	}
+
+	verified := true
+	_, fcerr = mgr.managers.User.UpdateUser(authorization.NewSystem(), user.ID, &models.UserUpdate{EmailVerified: &verified})
+	if fcerr != nil {
+		return
+	}
+
+	mgr.logger.WithField("userID", user.ID).Info("Verified email of user")
+	return
+}
+
+// createEmailToken signs a new token for the user and replaces all previous tokens with the same purpose
+func (mgr *authManager) createEmailToken(user *models.User, purpose models.EmailTokenPurpose, expiration time.Duration) (token string, fcerr *fcerror.Error) {
+	nonce, err := utils.GenerateSecureRandomString(emailTokenNonceLength)
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrUnknown, err)
+		mgr.logger.WithError(fcerr).Error("Failed to generate email token nonce")
+		return
+	}
+
+	validUntil := utils.GetTimeIn(expiration)
+	token, err = utils.SignPayload(&emailTokenPayload{Purpose: purpose, UserID: user.ID, ValidUntil: validUntil.Unix(), Nonce: nonce}, mgr.tokenSecret)
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrUnknown, err)
+		mgr.logger.WithError(fcerr).Error("Failed to sign email token")
+		return
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = trans.DeleteEmailTokensOfUser(user.ID, purpose)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", user.ID).Error("Failed to delete previous email tokens of user")
+		return "", fcerr
+	}
+
+	fcerr = trans.SaveEmailToken(&models.EmailToken{
+		TokenHash:  utils.HashToken(token),
+		UserID:     user.ID,
+		Purpose:    purpose,
+		Email:      user.Email,
+		ValidUntil: validUntil,
+	})
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", user.ID).Error("Failed to save email token")
+		return "", fcerr
+	}
+	return
+}
+
+// useEmailToken validates the token and consumes it, so every token can only be used once
+func (mgr *authManager) useEmailToken(token string, purpose models.EmailTokenPurpose) (emailToken *models.EmailToken, fcerr *fcerror.Error) {
+	payload := &emailTokenPayload{}
+	err := utils.VerifySignedPayload(token, mgr.tokenSecret, payload)
+	if err != nil || payload.Purpose != purpose {
+		mgr.logger.WithError(err).Warn("Received invalid email token")
+		fcerr = fcerror.NewError(fcerror.ErrEmailTokenInvalid, err)
+		return
+	}
+	if utils.GetCurrentTime().After(time.Unix(payload.ValidUntil, 0)) {
+		fcerr = fcerror.NewError(fcerror.ErrEmailTokenExpired, nil)
+		return
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	tokenHash := utils.HashToken(token)
+	emailToken, fcerr = trans.GetEmailToken(tokenHash)
+	if fcerr != nil {
+		if fcerr.ID != fcerror.ErrEmailTokenInvalid {
+			mgr.logger.WithError(fcerr).Error("Failed to get email token")
+		}
+		return
+	}
+	if emailToken.Purpose != purpose || emailToken.UserID != payload.UserID {
+		fcerr = fcerror.NewError(fcerror.ErrEmailTokenInvalid, nil)
+		return
+	}
+
+	fcerr = trans.DeleteEmailToken(tokenHash)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", emailToken.UserID).Error("Failed to delete used email token")
+		return nil, fcerr
+	}
+	return
+}
+
+func (mgr *authManager) sendTokenMail(user *models.User, subject, path, token, text string, expiration time.Duration) (fcerr *fcerror.Error) {
+	link := fmt.Sprintf("%s/%s?token=%s", strings.TrimSuffix(mgr.cfg.GetPublicURL(), "/"), path, url.QueryEscape(token))
+	body := fmt.Sprintf("Hello %s,\n\n%s\n\n%s\n\nThe link is valid for %v and can only be used once. If you did not request this, you can ignore this mail.\n",
+		user.FirstName, text, link, expiration)
+
+	fcerr = mgr.mailer.SendMail(&models.Mail{To: user.Email, Subject: subject, Body: body})
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithFields(logrus.Fields{"userID": user.ID, "subject": subject}).Error("Failed to send mail")
+	}
+	return
+}
@@ -0,0 +1,400 @@
+package manager
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/application/persistence"
+	"github.com/freecloudio/server/application/storage"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type ExportManager interface {
+	RequestDataExport(authCtx *authorization.Context) (*models.DataExport, *fcerror.Error)
+	GetOwnDataExports(authCtx *authorization.Context) ([]*models.DataExport, *fcerror.Error)
+	OpenDataExport(token string) (*models.DataExport, io.ReadCloser, int64, *fcerror.Error)
+	Close()
+}
+
+// NewExportManager creates the manager of data exports. Exports are only kept until the next restart,
+// leftover ZIPs of a previous run are deleted.
+func NewExportManager(cfg config.Config, nodePersistence persistence.NodePersistenceController, fileStorage storage.FileStorageController, managers *Managers) ExportManager {
+	exportMgr := &exportManager{
+		cfg:             cfg,
+		nodePersistence: nodePersistence,
+		fileStorage:     fileStorage,
+		managers:        managers,
+		exportPath:      utils.JoinPaths(cfg.GetFileStorageTempBasePath(), "exports"),
+		exports:         map[models.DataExportID]*models.DataExport{},
+		done:            make(chan struct{}),
+		logger:          utils.CreateLogger(cfg.GetLoggingConfig()),
+	}
+	exportMgr.tokenSecret = loadTokenSecret(cfg, exportMgr.logger)
+
+	err := os.RemoveAll(exportMgr.exportPath)
+	if err == nil {
+		err = os.MkdirAll(exportMgr.exportPath, 0770)
+	}
+	if err != nil {
+		exportMgr.logger.WithError(err).WithField("path", exportMgr.exportPath).Error("Failed to prepare data export folder")
+	}
+	go exportMgr.cleanupExpiredDataExportsRoutine()
+
+	managers.Export = exportMgr
+	return exportMgr
+}
+
+const (
+	dataExportCleanupInterval = time.Hour
+	dataExportManifestName    = "manifest.json"
+	dataExportFilesFolder     = "files"
+)
+
+type exportManager struct {
+	cfg             config.Config
+	nodePersistence persistence.NodePersistenceController
+	fileStorage     storage.FileStorageController
+	managers        *Managers
+	tokenSecret     []byte
+	exportPath      string
+	lock            sync.Mutex
+	exports         map[models.DataExportID]*models.DataExport
+	done            chan struct{}
+	logger          utils.Logger
+}
+
+// dataExportPayload is signed into the download link, so the link works without authentication until the export expires
+type dataExportPayload struct {
+	ExportID   models.DataExportID `json:"e"`
+	UserID     models.UserID       `json:"u"`
+	ValidUntil int64               `json:"x"`
+}
+
+func (mgr *exportManager) Close() {
+	close(mgr.done)
+}
+
+func (mgr *exportManager) cleanupExpiredDataExportsRoutine() {
+	ticker := time.NewTicker(dataExportCleanupInterval)
+	for {
+		select {
+		case <-mgr.done:
+			return
+		case <-ticker.C:
+			mgr.cleanupExpiredDataExports()
+		}
+	}
+}
+
+func (mgr *exportManager) cleanupExpiredDataExports() {
+	mgr.lock.Lock()
+	defer mgr.lock.Unlock()
+
+	now := utils.GetCurrentTime()
+	for exportID, export := range mgr.exports {
+		if now.Before(export.ExpiresAt) || export.Status == models.DataExportStatusPending {
+			continue
+		}
+		delete(mgr.exports, exportID)
+		err := os.Remove(mgr.getExportFilePath(exportID))
+		if err != nil && !os.IsNotExist(err) {
+			mgr.logger.WithError(err).WithField("exportID", exportID).Error("Failed to delete expired data export")
+		}
+	}
+}
+
+// RequestDataExport starts building the export of all files and account data of the user in the background.
+// The pending export is returned instead if one is already being built.
+func (mgr *exportManager) RequestDataExport(authCtx *authorization.Context) (export *models.DataExport, fcerr *fcerror.Error) {
+	defer func() {
+		event := &models.AuditEvent{Action: models.AuditActionDataExport, TargetType: models.AuditTargetTypeUser}
+		if authCtx.User != nil {
+			event.TargetID = string(authCtx.User.ID)
+		}
+		mgr.managers.Audit.Record(authCtx, withOutcome(event, fcerr))
+	}()
+
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceNoAccessToken(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Data can only be exported for users"))
+		return
+	}
+
+	mgr.lock.Lock()
+	defer mgr.lock.Unlock()
+
+	for _, existingExport := range mgr.exports {
+		if existingExport.UserID == authCtx.User.ID && existingExport.Status == models.DataExportStatusPending {
+			copiedExport := *existingExport
+			return &copiedExport, nil
+		}
+	}
+
+	now := utils.GetCurrentTime()
+	export = &models.DataExport{
+		ID:        models.DataExportID(uuid.NewString()),
+		UserID:    authCtx.User.ID,
+		Status:    models.DataExportStatusPending,
+		Created:   now,
+		ExpiresAt: now.Add(mgr.cfg.GetDataExportExpiration()),
+	}
+	mgr.exports[export.ID] = export
+	mgr.logger.WithFields(logrus.Fields{"userID": export.UserID, "exportID": export.ID}).Info("Started data export")
+
+	go mgr.buildDataExport(authorization.NewUser(authCtx.User), export.ID)
+
+	copiedExport := *export
+	return &copiedExport, nil
+}
+
+// GetOwnDataExports returns all exports of the user which did not expire yet
+func (mgr *exportManager) GetOwnDataExports(authCtx *authorization.Context) (exports []*models.DataExport, fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceNoAccessToken(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	exports = []*models.DataExport{}
+	if authCtx.User == nil {
+		return
+	}
+
+	mgr.lock.Lock()
+	defer mgr.lock.Unlock()
+
+	now := utils.GetCurrentTime()
+	for _, export := range mgr.exports {
+		if export.UserID == authCtx.User.ID && now.Before(export.ExpiresAt) {
+			copiedExport := *export
+			exports = append(exports, &copiedExport)
+		}
+	}
+	return
+}
+
+// OpenDataExport returns the ZIP of the ready export the signed token of the download link belongs to
+func (mgr *exportManager) OpenDataExport(token string) (export *models.DataExport, reader io.ReadCloser, size int64, fcerr *fcerror.Error) {
+	payload := &dataExportPayload{}
+	err := utils.VerifySignedPayload(token, mgr.tokenSecret, payload)
+	if err != nil || payload.ExportID == "" {
+		mgr.logger.WithError(err).Warn("Received invalid data export token")
+		fcerr = fcerror.NewError(fcerror.ErrDataExportNotFound, err)
+		return
+	}
+	if utils.GetCurrentTime().Unix() > payload.ValidUntil {
+		fcerr = fcerror.NewError(fcerror.ErrDataExportExpired, nil)
+		return
+	}
+
+	mgr.lock.Lock()
+	storedExport, ok := mgr.exports[payload.ExportID]
+	if ok {
+		copiedExport := *storedExport
+		export = &copiedExport
+	}
+	mgr.lock.Unlock()
+	if !ok || export.UserID != payload.UserID {
+		fcerr = fcerror.NewError(fcerror.ErrDataExportNotFound, nil)
+		return
+	}
+	if export.Status != models.DataExportStatusReady {
+		fcerr = fcerror.NewError(fcerror.ErrDataExportNotReady, nil)
+		return
+	}
+
+	file, err := os.Open(mgr.getExportFilePath(export.ID))
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrDataExportNotFound, err)
+		mgr.logger.WithError(err).WithField("exportID", export.ID).Error("Failed to open data export")
+		return
+	}
+	return export, file, export.Size, nil
+}
+
+func (mgr *exportManager) getExportFilePath(exportID models.DataExportID) string {
+	return utils.JoinPaths(mgr.exportPath, string(exportID)+".zip")
+}
+
+// buildDataExport writes the ZIP of the export and marks the export as ready or failed afterwards
+func (mgr *exportManager) buildDataExport(authCtx *authorization.Context, exportID models.DataExportID) {
+	logger := mgr.logger.WithFields(logrus.Fields{"userID": authCtx.User.ID, "exportID": exportID})
+	filePath := mgr.getExportFilePath(exportID)
+
+	size, fcerr := mgr.writeDataExport(authCtx, filePath)
+	if fcerr != nil {
+		logger.WithError(fcerr).Error("Failed to build data export")
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			logger.WithError(err).Error("Failed to delete failed data export")
+		}
+		mgr.finishDataExport(exportID, models.DataExportStatusFailed, 0, nil)
+		return
+	}
+
+	export := mgr.getDataExport(exportID)
+	if export == nil {
+		// Cleaned up in the meantime
+		_ = os.Remove(filePath)
+		return
+	}
+	token, err := utils.SignPayload(&dataExportPayload{ExportID: exportID, UserID: export.UserID, ValidUntil: export.ExpiresAt.Unix()}, mgr.tokenSecret)
+	if err != nil {
+		logger.WithError(err).Error("Failed to sign data export token")
+		mgr.finishDataExport(exportID, models.DataExportStatusFailed, 0, nil)
+		return
+	}
+	downloadURL := fmt.Sprintf("%s/api/export/%s", strings.TrimSuffix(mgr.cfg.GetPublicURL(), "/"), token)
+	mgr.finishDataExport(exportID, models.DataExportStatusReady, size, &downloadURL)
+	logger.WithField("size", size).Info("Finished data export")
+}
+
+func (mgr *exportManager) getDataExport(exportID models.DataExportID) *models.DataExport {
+	mgr.lock.Lock()
+	defer mgr.lock.Unlock()
+
+	export, ok := mgr.exports[exportID]
+	if !ok {
+		return nil
+	}
+	copiedExport := *export
+	return &copiedExport
+}
+
+func (mgr *exportManager) finishDataExport(exportID models.DataExportID, status models.DataExportStatus, size int64, downloadURL *string) {
+	mgr.lock.Lock()
+	defer mgr.lock.Unlock()
+
+	export, ok := mgr.exports[exportID]
+	if !ok {
+		return
+	}
+	export.Status = status
+	export.Size = size
+	export.DownloadURL = downloadURL
+}
+
+// writeDataExport writes all owned files of the user in their folder structure and the manifest into a new ZIP
+func (mgr *exportManager) writeDataExport(authCtx *authorization.Context, filePath string) (size int64, fcerr *fcerror.Error) {
+	manifest, fcerr := mgr.getDataExportManifest(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrDataExportFailed, err)
+		return
+	}
+	defer file.Close()
+
+	zipWriter := zip.NewWriter(file)
+	for _, node := range manifest.Files {
+		fcerr = mgr.writeDataExportNode(zipWriter, node)
+		if fcerr != nil {
+			return
+		}
+	}
+
+	manifestWriter, err := zipWriter.Create(dataExportManifestName)
+	if err == nil {
+		encoder := json.NewEncoder(manifestWriter)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(manifest)
+	}
+	if err == nil {
+		err = zipWriter.Close()
+	}
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrDataExportFailed, err)
+		return
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrDataExportFailed, err)
+		return
+	}
+	return stat.Size(), nil
+}
+
+func (mgr *exportManager) writeDataExportNode(zipWriter *zip.Writer, node *models.Node) (fcerr *fcerror.Error) {
+	name := dataExportFilesFolder + node.FullPath
+	if node.Type == models.NodeTypeFolder {
+		_, err := zipWriter.CreateHeader(&zip.FileHeader{Name: name + "/", Modified: node.Updated})
+		if err != nil {
+			fcerr = fcerror.NewError(fcerror.ErrDataExportFailed, err)
+		}
+		return
+	}
+
+	reader, _, fcerr := mgr.fileStorage.DownloadFile(node)
+	if fcerr != nil {
+		return
+	}
+	defer reader.Close()
+
+	writer, err := zipWriter.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: node.Updated})
+	if err == nil {
+		_, err = io.Copy(writer, reader)
+	}
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrDataExportFailed, err)
+	}
+	return
+}
+
+func (mgr *exportManager) getDataExportManifest(authCtx *authorization.Context) (manifest *models.DataExportManifest, fcerr *fcerror.Error) {
+	manifest = &models.DataExportManifest{ExportedAt: utils.GetCurrentTime()}
+
+	manifest.User, fcerr = mgr.managers.User.GetUserByID(authCtx, authCtx.User.ID)
+	if fcerr != nil {
+		return
+	}
+	manifest.Shares, fcerr = mgr.managers.Share.GetOwnShares(authCtx)
+	if fcerr != nil {
+		return
+	}
+	manifest.Sessions, fcerr = mgr.managers.Auth.GetOwnSessions(authCtx, "")
+	if fcerr != nil {
+		return
+	}
+
+	trans, fcerr := mgr.nodePersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer trans.Close()
+
+	manifest.Files, fcerr = trans.GetOwnedNodes(authCtx.User.ID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to get owned nodes for data export")
+	}
+	return
+}
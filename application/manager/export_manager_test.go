@@ -0,0 +1,132 @@
+package manager_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/application/manager"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/mock"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitForDataExport polls the exports of the user until the export is not pending anymore
+func waitForDataExport(t *testing.T, exportMgr manager.ExportManager, authCtx *authorization.Context, exportID models.DataExportID) *models.DataExport {
+	for attempt := 0; attempt < 100; attempt++ {
+		exports, fcerr := exportMgr.GetOwnDataExports(authCtx)
+		require.Nil(t, fcerr, "Failed to get data exports")
+		for _, export := range exports {
+			if export.ID == exportID && export.Status != models.DataExportStatusPending {
+				return export
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.FailNow(t, "Data export did not finish")
+	return nil
+}
+
+func TestDataExport(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "freecloud-export-test")
+	require.Nil(t, err, "Failed to create temp dir")
+	defer os.RemoveAll(tmpDir)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	user := &models.User{ID: testUserID, FirstName: "New", LastName: "User", Email: testEmail}
+	authCtx := authorization.NewUser(user)
+	fileContent := "content of the report"
+	files := []*models.Node{
+		{ID: "folder", Type: models.NodeTypeFolder, Name: "Documents", FullPath: "/Documents"},
+		{ID: "file", Type: models.NodeTypeFile, Name: "report.txt", FullPath: "/Documents/report.txt"},
+	}
+	shares := []*models.Share{{NodeID: "folder", SharedWithID: "other-user"}}
+	sessions := []*models.Session{{ID: "session", UserID: testUserID}}
+
+	cfgMock := mock.NewMockConfig(mockCtrl)
+	cfgMock.EXPECT().GetLoggingConfig().Return(&utils.LoggingConfig{}).AnyTimes()
+	cfgMock.EXPECT().GetFileStorageTempBasePath().Return(tmpDir).AnyTimes()
+	cfgMock.EXPECT().GetTokenSigningSecret().Return("secret").AnyTimes()
+	cfgMock.EXPECT().GetDataExportExpiration().Return(time.Hour).AnyTimes()
+	cfgMock.EXPECT().GetPublicURL().Return("https://cloud.example.com/").AnyTimes()
+
+	nodePersistenceMock := mock.NewMockNodePersistenceController(mockCtrl)
+	nodeTransMock := mock.NewMockNodePersistenceReadWriteTransaction(mockCtrl)
+	nodePersistenceMock.EXPECT().StartReadTransaction().Return(nodeTransMock, nil).Times(1)
+	nodeTransMock.EXPECT().GetOwnedNodes(testUserID).Return(files, nil).Times(1)
+	nodeTransMock.EXPECT().Close().Times(1)
+
+	fileStorageMock := mock.NewMockFileStorageController(mockCtrl)
+	fileStorageMock.EXPECT().DownloadFile(files[1]).Return(ioutil.NopCloser(strings.NewReader(fileContent)), int64(len(fileContent)), nil).Times(1)
+
+	userMgrMock := mock.NewMockUserManager(mockCtrl)
+	userMgrMock.EXPECT().GetUserByID(gomock.Any(), testUserID).Return(user, nil).Times(1)
+	shareMgrMock := mock.NewMockShareManager(mockCtrl)
+	shareMgrMock.EXPECT().GetOwnShares(gomock.Any()).Return(shares, nil).Times(1)
+	authMgrMock := mock.NewMockAuthManager(mockCtrl)
+	authMgrMock.EXPECT().GetOwnSessions(gomock.Any(), models.Token("")).Return(sessions, nil).Times(1)
+	auditMgrMock := mock.NewMockAuditManager(mockCtrl)
+	auditMgrMock.EXPECT().Record(authCtx, gomock.Any()).Times(1)
+
+	managers := &manager.Managers{User: userMgrMock, Share: shareMgrMock, Auth: authMgrMock, Audit: auditMgrMock}
+	exportMgr := manager.NewExportManager(cfgMock, nodePersistenceMock, fileStorageMock, managers)
+	defer exportMgr.Close()
+
+	export, fcerr := exportMgr.RequestDataExport(authCtx)
+	require.Nil(t, fcerr, "Failed to request data export")
+	assert.Equal(t, models.DataExportStatusPending, export.Status, "New export is not pending")
+
+	export = waitForDataExport(t, exportMgr, authCtx, export.ID)
+	require.Equal(t, models.DataExportStatusReady, export.Status, "Export did not succeed")
+	require.NotNil(t, export.DownloadURL, "Ready export has no download link")
+	require.True(t, strings.HasPrefix(*export.DownloadURL, "https://cloud.example.com/api/export/"), "Wrong download link")
+
+	_, _, _, fcerr = exportMgr.OpenDataExport("invalid")
+	require.NotNil(t, fcerr, "Invalid token accepted")
+	assert.Equal(t, fcerror.ErrDataExportNotFound, fcerr.ID, "Wrong error for invalid token")
+
+	token := strings.TrimPrefix(*export.DownloadURL, "https://cloud.example.com/api/export/")
+	openedExport, reader, size, fcerr := exportMgr.OpenDataExport(token)
+	require.Nil(t, fcerr, "Failed to open data export")
+	defer reader.Close()
+	assert.Equal(t, export.ID, openedExport.ID, "Wrong export opened")
+
+	zipContent, err := ioutil.ReadAll(reader)
+	require.Nil(t, err, "Failed to read data export")
+	assert.Equal(t, size, int64(len(zipContent)), "Wrong size of data export")
+	zipReader, err := zip.NewReader(bytes.NewReader(zipContent), size)
+	require.Nil(t, err, "Data export is no valid ZIP")
+
+	entries := map[string]string{}
+	for _, zipFile := range zipReader.File {
+		fileReader, err := zipFile.Open()
+		require.Nil(t, err, "Failed to open ZIP entry")
+		content, err := ioutil.ReadAll(fileReader)
+		require.Nil(t, err, "Failed to read ZIP entry")
+		fileReader.Close()
+		entries[zipFile.Name] = string(content)
+	}
+	assert.Len(t, entries, 3, "Wrong number of ZIP entries")
+	assert.Contains(t, entries, "files/Documents/", "Folder is missing")
+	assert.Equal(t, fileContent, entries["files/Documents/report.txt"], "Wrong file content")
+
+	manifest := &models.DataExportManifest{}
+	require.Nil(t, json.Unmarshal([]byte(entries["manifest.json"]), manifest), "Failed to parse manifest")
+	assert.Equal(t, testEmail, manifest.User.Email, "Wrong user in manifest")
+	assert.Len(t, manifest.Shares, 1, "Wrong shares in manifest")
+	assert.Len(t, manifest.Sessions, 1, "Wrong sessions in manifest")
+	assert.Len(t, manifest.Files, 2, "Wrong files in manifest")
+}
@@ -0,0 +1,185 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/application/persistence"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+type FileDropManager interface {
+	CreateFileDrop(authCtx *authorization.Context, fileDrop *models.FileDrop) *fcerror.Error
+	GetOwnFileDrops(authCtx *authorization.Context) ([]*models.FileDrop, *fcerror.Error)
+	DeleteFileDrop(authCtx *authorization.Context, fileDropID models.FileDropID) *fcerror.Error
+	GetFileDropContext(fileDropID models.FileDropID) (*authorization.Context, *fcerror.Error)
+	Close()
+}
+
+func NewFileDropManager(cfg config.Config, fileDropPersistence persistence.FileDropPersistenceController, managers *Managers) FileDropManager {
+	fileDropMgr := &fileDropManager{
+		cfg:                 cfg,
+		fileDropPersistence: fileDropPersistence,
+		managers:            managers,
+		logger:              utils.CreateLogger(cfg.GetLoggingConfig()),
+	}
+
+	managers.FileDrop = fileDropMgr
+	return fileDropMgr
+}
+
+type fileDropManager struct {
+	cfg                 config.Config
+	fileDropPersistence persistence.FileDropPersistenceController
+	managers            *Managers
+	logger              utils.Logger
+}
+
+var _ FileDropManager = &fileDropManager{}
+
+func (mgr *fileDropManager) Close() {
+}
+
+func (mgr *fileDropManager) CreateFileDrop(authCtx *authorization.Context, fileDrop *models.FileDrop) (fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("File drops can only be created by users"))
+		return
+	}
+
+	if strings.Contains(fileDrop.NamePrefix, "/") {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, fmt.Errorf("Invalid name prefix for file drop: '%s'", fileDrop.NamePrefix))
+		return
+	}
+	if fileDrop.MaxSize < 0 {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Size limit of file drop must not be negative"))
+		return
+	}
+	if fileDrop.ExpiresAt != nil && fileDrop.ExpiresAt.Before(utils.GetCurrentTime()) {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("File drop expiration date lies in the past"))
+		return
+	}
+
+	trans, fcerr := mgr.fileDropPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	// Only owners may create file drops for their folders
+	fileDrop.OwnerID = authCtx.User.ID
+	fcerr = trans.SaveFileDrop(fileDrop)
+	if fcerr != nil && fcerr.ID != fcerror.ErrNodeNotFound {
+		mgr.logger.WithError(fcerr).WithField("fileDrop", fileDrop).Error("Failed to save file drop")
+	}
+	return
+}
+
+func (mgr *fileDropManager) GetOwnFileDrops(authCtx *authorization.Context) (fileDrops []*models.FileDrop, fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		return []*models.FileDrop{}, nil
+	}
+
+	trans, fcerr := mgr.fileDropPersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer trans.Close()
+
+	fileDrops, fcerr = trans.GetFileDropsOfUser(authCtx.User.ID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", authCtx.User.ID).Error("Failed to get file drops of user")
+	}
+	return
+}
+
+func (mgr *fileDropManager) DeleteFileDrop(authCtx *authorization.Context, fileDropID models.FileDropID) (fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		fcerr = fcerror.NewError(fcerror.ErrFileDropNotFound, nil)
+		return
+	}
+
+	trans, fcerr := mgr.fileDropPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = trans.DeleteFileDrop(authCtx.User.ID, fileDropID)
+	if fcerr != nil && fcerr.ID != fcerror.ErrFileDropNotFound {
+		mgr.logger.WithError(fcerr).WithFields(logrus.Fields{"userID": authCtx.User.ID, "fileDropID": fileDropID}).Error("Failed to delete file drop")
+	}
+	return
+}
+
+// GetFileDropContext resolves a file drop link to a restricted context which only allows uploading new files to the target folder
+func (mgr *fileDropManager) GetFileDropContext(fileDropID models.FileDropID) (authCtx *authorization.Context, fcerr *fcerror.Error) {
+	trans, fcerr := mgr.fileDropPersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	fileDrop, fcerr := trans.GetFileDropByID(fileDropID)
+	trans.Close()
+	if fcerr != nil {
+		if fcerr.ID != fcerror.ErrFileDropNotFound {
+			mgr.logger.WithError(fcerr).WithField("fileDropID", fileDropID).Error("Failed to get file drop")
+		}
+		return
+	}
+
+	if fileDrop.ExpiresAt != nil && fileDrop.ExpiresAt.Before(utils.GetCurrentTime()) {
+		fcerr = fcerror.NewError(fcerror.ErrFileDropExpired, nil)
+		return
+	}
+
+	owner, fcerr := mgr.managers.User.GetUserByID(authorization.NewSystem(), fileDrop.OwnerID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("fileDrop", fileDrop).Error("Failed to get owner of file drop")
+		return
+	}
+	// Links of disabled users stop working like their sessions, without revealing the state of the account
+	if owner.Disabled {
+		fcerr = fcerror.NewError(fcerror.ErrFileDropNotFound, nil)
+		return
+	}
+
+	authCtx = authorization.NewFileDrop(owner, fileDrop)
+	return
+}
@@ -0,0 +1,66 @@
+package manager_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/freecloudio/server/application/manager"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/mock"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFileDropContext(t *testing.T) {
+	expired := utils.GetTimeIn(-time.Hour)
+
+	tests := []struct {
+		name        string
+		expiresAt   *time.Time
+		disabled    bool
+		expectedErr fcerror.ErrorID
+	}{
+		{name: "Active file drop"},
+		{name: "Expired file drop", expiresAt: &expired, expectedErr: fcerror.ErrFileDropExpired},
+		{name: "Owner disabled", disabled: true, expectedErr: fcerror.ErrFileDropNotFound},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			owner := &models.User{ID: testUserID, Email: testEmail, Disabled: test.disabled}
+			fileDrop := &models.FileDrop{ID: "drop", NodeID: "folder", OwnerID: owner.ID, ExpiresAt: test.expiresAt}
+
+			cfgMock := mock.NewMockConfig(mockCtrl)
+			cfgMock.EXPECT().GetLoggingConfig().Return(&utils.LoggingConfig{}).AnyTimes()
+			fileDropPersistenceMock := mock.NewMockFileDropPersistenceController(mockCtrl)
+			fileDropTransMock := mock.NewMockFileDropPersistenceReadWriteTransaction(mockCtrl)
+			fileDropPersistenceMock.EXPECT().StartReadTransaction().Return(fileDropTransMock, nil).Times(1)
+			fileDropTransMock.EXPECT().GetFileDropByID(fileDrop.ID).Return(fileDrop, nil).Times(1)
+			fileDropTransMock.EXPECT().Close().Return(nil).Times(1)
+			userMgrMock := mock.NewMockUserManager(mockCtrl)
+			if test.expiresAt == nil {
+				userMgrMock.EXPECT().GetUserByID(gomock.Any(), owner.ID).Return(owner, nil).Times(1)
+			}
+
+			fileDropMgr := manager.NewFileDropManager(cfgMock, fileDropPersistenceMock, &manager.Managers{User: userMgrMock})
+			authCtx, fcerr := fileDropMgr.GetFileDropContext(fileDrop.ID)
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "File drop context created")
+				assert.Equal(t, test.expectedErr, fcerr.ID, "Wrong error for unusable file drop")
+				assert.Nil(t, authCtx, "Context returned for unusable file drop")
+				return
+			}
+			require.Nil(t, fcerr, "Failed to get file drop context")
+			require.NotNil(t, authCtx.User, "File drop context without owner")
+			assert.Equal(t, owner.ID, authCtx.User.ID, "File drop context of wrong user")
+		})
+	}
+}
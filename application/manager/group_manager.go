@@ -0,0 +1,267 @@
+package manager
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/application/persistence"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+type GroupManager interface {
+	CreateGroup(authCtx *authorization.Context, group *models.Group) *fcerror.Error
+	GetGroupByID(authCtx *authorization.Context, groupID models.GroupID) (*models.Group, *fcerror.Error)
+	GetOwnGroups(authCtx *authorization.Context) ([]*models.Group, *fcerror.Error)
+	GetGroupMembers(authCtx *authorization.Context, groupID models.GroupID) ([]*models.GroupMember, *fcerror.Error)
+	AddGroupMember(authCtx *authorization.Context, member *models.GroupMember) *fcerror.Error
+	RemoveGroupMember(authCtx *authorization.Context, groupID models.GroupID, userID models.UserID) *fcerror.Error
+	Close()
+}
+
+func NewGroupManager(cfg config.Config, groupPersistence persistence.GroupPersistenceController, managers *Managers) GroupManager {
+	groupMgr := &groupManager{
+		cfg:              cfg,
+		groupPersistence: groupPersistence,
+		managers:         managers,
+		logger:           utils.CreateLogger(cfg.GetLoggingConfig()),
+	}
+
+	managers.Group = groupMgr
+	return groupMgr
+}
+
+type groupManager struct {
+	cfg              config.Config
+	groupPersistence persistence.GroupPersistenceController
+	managers         *Managers
+	logger           utils.Logger
+}
+
+var _ GroupManager = &groupManager{}
+
+func (mgr *groupManager) Close() {
+}
+
+// enforceGroupMember checks that the user of the context is a member of the group - and optionally a group admin.
+// The system and users allowed to manage all groups are always allowed.
+func (mgr *groupManager) enforceGroupMember(authCtx *authorization.Context, trans persistence.GroupPersistenceReadTransaction, groupID models.GroupID, needsGroupAdmin bool) (fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authorization.HasPermission(authCtx, authorization.PermissionManageGroups) {
+		return
+	}
+
+	member, fcerr := trans.GetGroupMember(groupID, authCtx.User.ID)
+	if fcerr != nil {
+		if fcerr.ID == fcerror.ErrGroupMemberNotFound {
+			fcerr = fcerror.NewError(fcerror.ErrGroupNotFound, nil)
+		}
+		return
+	}
+	if needsGroupAdmin && !member.IsAdmin {
+		fcerr = fcerror.NewError(fcerror.ErrForbidden, nil)
+	}
+	return
+}
+
+func (mgr *groupManager) CreateGroup(authCtx *authorization.Context, group *models.Group) (fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Groups can only be created by users"))
+		return
+	}
+
+	group.Name = strings.TrimSpace(group.Name)
+	v := &validator{}
+	v.validateGroupName("name", group.Name)
+	fcerr = v.err()
+	if fcerr != nil {
+		return
+	}
+
+	trans, fcerr := mgr.groupPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = trans.SaveGroup(group)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("group", group).Error("Failed to save group")
+		return
+	}
+
+	fcerr = trans.SaveGroupMember(&models.GroupMember{GroupID: group.ID, UserID: authCtx.User.ID, IsAdmin: true})
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("group", group).Error("Failed to add creator as group admin")
+		return
+	}
+	return
+}
+
+func (mgr *groupManager) GetGroupByID(authCtx *authorization.Context, groupID models.GroupID) (group *models.Group, fcerr *fcerror.Error) {
+	trans, fcerr := mgr.groupPersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer trans.Close()
+
+	fcerr = mgr.enforceGroupMember(authCtx, trans, groupID, false)
+	if fcerr != nil {
+		return
+	}
+
+	group, fcerr = trans.GetGroupByID(groupID)
+	if fcerr != nil && fcerr.ID != fcerror.ErrGroupNotFound {
+		mgr.logger.WithError(fcerr).WithField("groupID", groupID).Error("Failed to get group")
+	}
+	return
+}
+
+func (mgr *groupManager) GetOwnGroups(authCtx *authorization.Context) (groups []*models.Group, fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		return []*models.Group{}, nil
+	}
+
+	trans, fcerr := mgr.groupPersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer trans.Close()
+
+	groups, fcerr = trans.GetGroupsOfUser(authCtx.User.ID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", authCtx.User.ID).Error("Failed to get groups of user")
+	}
+	return
+}
+
+func (mgr *groupManager) GetGroupMembers(authCtx *authorization.Context, groupID models.GroupID) (members []*models.GroupMember, fcerr *fcerror.Error) {
+	trans, fcerr := mgr.groupPersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer trans.Close()
+
+	fcerr = mgr.enforceGroupMember(authCtx, trans, groupID, false)
+	if fcerr != nil {
+		return
+	}
+
+	members, fcerr = trans.GetGroupMembers(groupID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("groupID", groupID).Error("Failed to get group members")
+	}
+	return
+}
+
+func (mgr *groupManager) AddGroupMember(authCtx *authorization.Context, member *models.GroupMember) (fcerr *fcerror.Error) {
+	trans, fcerr := mgr.groupPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = mgr.enforceGroupMember(authCtx, trans, member.GroupID, true)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	if !member.IsAdmin {
+		fcerr = mgr.enforceRemainingGroupAdmin(trans, member.GroupID, member.UserID)
+		if fcerr != nil {
+			return
+		}
+	}
+
+	fcerr = trans.SaveGroupMember(member)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("member", member).Error("Failed to save group member")
+	}
+	return
+}
+
+func (mgr *groupManager) RemoveGroupMember(authCtx *authorization.Context, groupID models.GroupID, userID models.UserID) (fcerr *fcerror.Error) {
+	trans, fcerr := mgr.groupPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	// Members are always allowed to leave a group on their own
+	leavesGroup := authCtx.User != nil && authCtx.User.ID == userID
+	fcerr = mgr.enforceGroupMember(authCtx, trans, groupID, !leavesGroup)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = mgr.enforceRemainingGroupAdmin(trans, groupID, userID)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = trans.DeleteGroupMember(groupID, userID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithFields(logrus.Fields{"groupID": groupID, "userID": userID}).Error("Failed to delete group member")
+	}
+	return
+}
+
+// enforceRemainingGroupAdmin rejects removing or demoting the user if it is the last admin of the group,
+// as nobody but users allowed to manage all groups could manage the group afterwards
+func (mgr *groupManager) enforceRemainingGroupAdmin(trans persistence.GroupPersistenceReadTransaction, groupID models.GroupID, userID models.UserID) (fcerr *fcerror.Error) {
+	members, fcerr := trans.GetGroupMembers(groupID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("groupID", groupID).Error("Failed to get group members")
+		return
+	}
+
+	adminCount := 0
+	isAdmin := false
+	for _, member := range members {
+		if member.IsAdmin {
+			adminCount++
+			isAdmin = isAdmin || member.UserID == userID
+		}
+	}
+	if isAdmin && adminCount == 1 {
+		fcerr = fcerror.NewError(fcerror.ErrLastGroupAdmin, nil)
+	}
+	return
+}
@@ -0,0 +1,131 @@
+package manager_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/application/manager"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/mock"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createGroupMocks(mockCtrl *gomock.Controller) (*mock.MockGroupPersistenceController, *mock.MockGroupPersistenceReadWriteTransaction, manager.GroupManager) {
+	groupPersistence := mock.NewMockGroupPersistenceController(mockCtrl)
+	groupTrans := mock.NewMockGroupPersistenceReadWriteTransaction(mockCtrl)
+	cfg := mock.NewMockConfig(mockCtrl)
+	cfg.EXPECT().GetLoggingConfig().Return(&utils.LoggingConfig{}).AnyTimes()
+
+	groupMgr := manager.NewGroupManager(cfg, groupPersistence, &manager.Managers{})
+	return groupPersistence, groupTrans, groupMgr
+}
+
+func TestCreateGroupValidation(t *testing.T) {
+	tests := []struct {
+		name         string
+		groupName    string
+		expectedName string
+		expectedErr  fcerror.ErrorID
+	}{
+		{name: "Name trimmed", groupName: "  Team  ", expectedName: "Team"},
+		{name: "Empty name", groupName: "   ", expectedErr: fcerror.ErrValidationFailed},
+		{name: "Name too long", groupName: strings.Repeat("a", 101), expectedErr: fcerror.ErrValidationFailed},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			groupPersistence, groupTrans, groupMgr := createGroupMocks(mockCtrl)
+			user := &models.User{ID: testUserID}
+			group := &models.Group{Name: test.groupName}
+
+			if test.expectedErr == 0 {
+				groupPersistence.EXPECT().StartReadWriteTransaction().Return(groupTrans, nil).Times(1)
+				groupTrans.EXPECT().SaveGroup(group).DoAndReturn(func(group *models.Group) *fcerror.Error {
+					group.ID = "group"
+					return nil
+				}).Times(1)
+				groupTrans.EXPECT().SaveGroupMember(&models.GroupMember{GroupID: "group", UserID: user.ID, IsAdmin: true}).Return(nil).Times(1)
+				groupTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWith(nil)).Times(1)
+			}
+
+			fcerr := groupMgr.CreateGroup(authorization.NewUser(user), group)
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Invalid group name accepted")
+				assert.Equal(t, test.expectedErr, fcerr.ID, "Wrong error for invalid group name")
+				require.Len(t, fcerr.Fields, 1, "Wrong number of invalid fields")
+				assert.Equal(t, "name", fcerr.Fields[0].Field, "Wrong invalid field")
+				return
+			}
+			require.Nil(t, fcerr, "Failed to create group")
+			assert.Equal(t, test.expectedName, group.Name, "Group name not trimmed")
+		})
+	}
+}
+
+func TestRemoveLastGroupAdmin(t *testing.T) {
+	groupID := models.GroupID("group")
+	admin := &models.GroupMember{GroupID: groupID, UserID: "admin", IsAdmin: true}
+	otherAdmin := &models.GroupMember{GroupID: groupID, UserID: "other-admin", IsAdmin: true}
+	member := &models.GroupMember{GroupID: groupID, UserID: "member"}
+
+	tests := []struct {
+		name        string
+		members     []*models.GroupMember
+		target      *models.GroupMember
+		demote      bool
+		expectedErr fcerror.ErrorID
+	}{
+		{name: "Last admin leaves", members: []*models.GroupMember{admin, member}, target: admin, expectedErr: fcerror.ErrLastGroupAdmin},
+		{name: "Last admin demoted", members: []*models.GroupMember{admin, member}, target: admin, demote: true, expectedErr: fcerror.ErrLastGroupAdmin},
+		{name: "Admin leaves with other admin", members: []*models.GroupMember{admin, otherAdmin}, target: admin},
+		{name: "Admin demoted with other admin", members: []*models.GroupMember{admin, otherAdmin}, target: admin, demote: true},
+		{name: "Member removed", members: []*models.GroupMember{admin, member}, target: member},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			groupPersistence, groupTrans, groupMgr := createGroupMocks(mockCtrl)
+			authCtx := authorization.NewUser(&models.User{ID: admin.UserID})
+			demoted := &models.GroupMember{GroupID: groupID, UserID: test.target.UserID}
+
+			groupPersistence.EXPECT().StartReadWriteTransaction().Return(groupTrans, nil).Times(1)
+			groupTrans.EXPECT().GetGroupMember(groupID, admin.UserID).Return(admin, nil).Times(1)
+			groupTrans.EXPECT().GetGroupMembers(groupID).Return(test.members, nil).Times(1)
+			groupTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWith(nil)).Times(1)
+			if test.expectedErr == 0 {
+				if test.demote {
+					groupTrans.EXPECT().SaveGroupMember(demoted).Return(nil).Times(1)
+				} else {
+					groupTrans.EXPECT().DeleteGroupMember(groupID, test.target.UserID).Return(nil).Times(1)
+				}
+			}
+
+			var fcerr *fcerror.Error
+			if test.demote {
+				fcerr = groupMgr.AddGroupMember(authCtx, demoted)
+			} else {
+				fcerr = groupMgr.RemoveGroupMember(authCtx, groupID, test.target.UserID)
+			}
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Last admin removed from group")
+				assert.EqualValues(t, test.expectedErr, fcerr.ID, "Wrong error for removing the last admin")
+				return
+			}
+			assert.Nil(t, fcerr, "Failed to remove group member")
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package manager
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/utils"
+)
+
+// loginThrottle tracks failed logins per account and per IP in memory.
+// Every failure doubles the time until the next attempt is allowed and too many failures lock the key out completely.
+type loginThrottle struct {
+	cfg     *config.LoginThrottleConfig
+	mutex   sync.Mutex
+	entries map[string]*loginFailures
+}
+
+type loginFailures struct {
+	count       int
+	lastFailure time.Time
+	lockedUntil time.Time
+	// Attempts which were allowed but did not finish yet
+	pending int
+}
+
+func newLoginThrottle(cfg *config.LoginThrottleConfig) *loginThrottle {
+	return &loginThrottle{
+		cfg:     cfg,
+		entries: map[string]*loginFailures{},
+	}
+}
+
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// backoff returns the time to wait after the given number of consecutive failures
+func (th *loginThrottle) backoff(count int) time.Duration {
+	if th.cfg.BackoffBase <= 0 || count <= 0 {
+		return 0
+	}
+	backoff := th.cfg.BackoffBase
+	for it := 1; it < count && backoff < th.cfg.MaxBackoff; it++ {
+		backoff *= 2
+	}
+	if th.cfg.MaxBackoff > 0 && backoff > th.cfg.MaxBackoff {
+		backoff = th.cfg.MaxBackoff
+	}
+	return backoff
+}
+
+// isExpired returns whether the failures are old enough to be forgotten; keys with attempts in progress are kept
+func (th *loginThrottle) isExpired(failures *loginFailures, now time.Time) bool {
+	return failures.pending == 0 && now.After(failures.lockedUntil) && now.After(failures.lastFailure.Add(th.cfg.LockoutDuration))
+}
+
+// throttleKey is an account or IP with the number of failures locking it out
+type throttleKey struct {
+	key              string
+	lockoutThreshold int
+}
+
+func (th *loginThrottle) getLoginThrottleKeys(email, ip string) (keys []throttleKey) {
+	if email != "" {
+		keys = append(keys, throttleKey{accountThrottleKey(email), th.cfg.AccountLockoutThreshold})
+	}
+	if ip != "" {
+		keys = append(keys, throttleKey{ipThrottleKey(ip), th.cfg.IPLockoutThreshold})
+	}
+	return
+}
+
+// checkLogin returns how long to wait until the next login for the email from the IP is allowed; both are optional
+func (th *loginThrottle) checkLogin(email, ip string) time.Duration {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+
+	return th.wait(th.getLoginThrottleKeys(email, ip), utils.GetCurrentTime())
+}
+
+// beginLogin reserves an attempt for the email from the IP if it is allowed and returns how long to wait otherwise.
+// The attempt counts as failed for other attempts until it is finished, so parallel attempts cannot skip the throttling.
+// Every reserved attempt has to be finished with finishLogin.
+func (th *loginThrottle) beginLogin(email, ip string) (wait time.Duration) {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+
+	keys := th.getLoginThrottleKeys(email, ip)
+	wait = th.wait(keys, utils.GetCurrentTime())
+	if wait > 0 {
+		return
+	}
+
+	for _, key := range keys {
+		failures, ok := th.entries[key.key]
+		if !ok {
+			failures = &loginFailures{}
+			th.entries[key.key] = failures
+		}
+		failures.pending++
+	}
+	return
+}
+
+// finishLogin releases an attempt reserved with beginLogin and records it if it failed
+func (th *loginThrottle) finishLogin(email, ip string, failed bool) {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+
+	for _, key := range th.getLoginThrottleKeys(email, ip) {
+		if failures, ok := th.entries[key.key]; ok && failures.pending > 0 {
+			failures.pending--
+		}
+		if failed {
+			th.fail(key)
+		}
+	}
+}
+
+// failLogin records a failed login for the email and the IP; both are optional
+func (th *loginThrottle) failLogin(email, ip string) {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+
+	for _, key := range th.getLoginThrottleKeys(email, ip) {
+		th.fail(key)
+	}
+}
+
+// resetAccount forgets all failed logins for the email, the failures of IPs are kept
+func (th *loginThrottle) resetAccount(email string) {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+
+	key := accountThrottleKey(email)
+	failures, ok := th.entries[key]
+	if !ok {
+		return
+	}
+	if failures.pending > 0 {
+		th.entries[key] = &loginFailures{pending: failures.pending}
+		return
+	}
+	delete(th.entries, key)
+}
+
+// wait returns how long to wait until the next attempt for all of the keys is allowed, the mutex has to be held.
+// Attempts in progress are treated as if they just failed.
+func (th *loginThrottle) wait(keys []throttleKey, now time.Time) (wait time.Duration) {
+	for _, key := range keys {
+		failures, ok := th.entries[key.key]
+		if !ok {
+			continue
+		}
+
+		count := failures.count + failures.pending
+		lastFailure := failures.lastFailure
+		lockedUntil := failures.lockedUntil
+		if failures.pending > 0 {
+			lastFailure = now
+			if key.lockoutThreshold > 0 && count >= key.lockoutThreshold && now.Add(th.cfg.LockoutDuration).After(lockedUntil) {
+				lockedUntil = now.Add(th.cfg.LockoutDuration)
+			}
+		}
+
+		allowedAt := lastFailure.Add(th.backoff(count))
+		if lockedUntil.After(allowedAt) {
+			allowedAt = lockedUntil
+		}
+		if keyWait := allowedAt.Sub(now); keyWait > wait {
+			wait = keyWait
+		}
+	}
+	return
+}
+
+// fail records a failed attempt for the key and locks it once the threshold is reached, the mutex has to be held.
+// A threshold of 0 never locks.
+func (th *loginThrottle) fail(key throttleKey) {
+	now := utils.GetCurrentTime()
+	failures, ok := th.entries[key.key]
+	if !ok || th.isExpired(failures, now) || (!failures.lockedUntil.IsZero() && now.After(failures.lockedUntil)) {
+		pending := 0
+		if ok {
+			pending = failures.pending
+		}
+		failures = &loginFailures{pending: pending}
+		th.entries[key.key] = failures
+	}
+
+	failures.count++
+	failures.lastFailure = now
+	if key.lockoutThreshold > 0 && failures.count >= key.lockoutThreshold {
+		failures.lockedUntil = now.Add(th.cfg.LockoutDuration)
+	}
+}
+
+func (th *loginThrottle) cleanup() {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+
+	now := utils.GetCurrentTime()
+	for key, failures := range th.entries {
+		if th.isExpired(failures, now) {
+			delete(th.entries, key)
+		}
+	}
+}
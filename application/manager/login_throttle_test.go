@@ -0,0 +1,168 @@
+package manager
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/freecloudio/server/application/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginThrottleBackoff(t *testing.T) {
+	th := newLoginThrottle(&config.LoginThrottleConfig{BackoffBase: time.Second, MaxBackoff: 5 * time.Second})
+
+	tests := []struct {
+		count    int
+		expected time.Duration
+	}{
+		{count: 0, expected: 0},
+		{count: 1, expected: time.Second},
+		{count: 2, expected: 2 * time.Second},
+		{count: 3, expected: 4 * time.Second},
+		{count: 4, expected: 5 * time.Second},
+		{count: 50, expected: 5 * time.Second},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, th.backoff(test.count), "Wrong backoff after %d failures", test.count)
+	}
+
+	disabled := newLoginThrottle(&config.LoginThrottleConfig{})
+	assert.Equal(t, time.Duration(0), disabled.backoff(10), "Backoff without base")
+}
+
+func TestLoginThrottleBackoffWait(t *testing.T) {
+	th := newLoginThrottle(&config.LoginThrottleConfig{BackoffBase: time.Minute, MaxBackoff: time.Hour, LockoutDuration: time.Hour})
+
+	assert.Equal(t, time.Duration(0), th.checkLogin("alice@example.com", "10.0.0.1"), "Login throttled without failures")
+
+	th.failLogin("alice@example.com", "10.0.0.1")
+	wait := th.checkLogin("alice@example.com", "10.0.0.1")
+	assert.True(t, wait > 0 && wait <= time.Minute, "Wrong wait after one failure: %v", wait)
+
+	th.failLogin("alice@example.com", "10.0.0.1")
+	wait = th.checkLogin("alice@example.com", "10.0.0.1")
+	assert.True(t, wait > time.Minute && wait <= 2*time.Minute, "Wait did not double after the second failure: %v", wait)
+}
+
+func TestLoginThrottleLockout(t *testing.T) {
+	th := newLoginThrottle(&config.LoginThrottleConfig{AccountLockoutThreshold: 3, LockoutDuration: time.Hour})
+
+	for it := 0; it < 2; it++ {
+		th.failLogin("alice@example.com", "")
+		assert.Equal(t, time.Duration(0), th.checkLogin("alice@example.com", ""), "Account locked before the threshold")
+	}
+
+	th.failLogin("alice@example.com", "")
+	wait := th.checkLogin("alice@example.com", "")
+	assert.True(t, wait > 59*time.Minute && wait <= time.Hour, "Account not locked at the threshold: %v", wait)
+}
+
+func TestLoginThrottleResetAccount(t *testing.T) {
+	th := newLoginThrottle(&config.LoginThrottleConfig{AccountLockoutThreshold: 1, IPLockoutThreshold: 1, LockoutDuration: time.Hour})
+
+	th.failLogin("alice@example.com", "10.0.0.1")
+	assert.True(t, th.checkLogin("alice@example.com", "") > 0, "Account not locked")
+
+	th.resetAccount("alice@example.com")
+	assert.Equal(t, time.Duration(0), th.checkLogin("alice@example.com", ""), "Account still locked after reset")
+	assert.True(t, th.checkLogin("", "10.0.0.1") > 0, "Failures of the IP must be kept on reset")
+}
+
+func TestLoginThrottleKeyIsolation(t *testing.T) {
+	th := newLoginThrottle(&config.LoginThrottleConfig{AccountLockoutThreshold: 2, IPLockoutThreshold: 2, LockoutDuration: time.Hour})
+
+	th.failLogin("Alice@Example.com ", "10.0.0.1")
+	th.failLogin("alice@example.com", "10.0.0.1")
+
+	assert.True(t, th.checkLogin("alice@example.com", "10.0.0.2") > 0, "Account not locked for other IPs")
+	assert.True(t, th.checkLogin("bob@example.com", "10.0.0.1") > 0, "IP not locked for other accounts")
+	assert.Equal(t, time.Duration(0), th.checkLogin("bob@example.com", "10.0.0.2"), "Unrelated account and IP locked")
+
+	// Failures from different IPs add up for the account but not for the IPs
+	th.failLogin("bob@example.com", "10.0.0.3")
+	th.failLogin("bob@example.com", "10.0.0.4")
+	assert.True(t, th.checkLogin("bob@example.com", "") > 0, "Account not locked by failures from several IPs")
+	assert.Equal(t, time.Duration(0), th.checkLogin("", "10.0.0.3"), "IP locked by a single failure")
+}
+
+func TestLoginThrottleExpiration(t *testing.T) {
+	th := newLoginThrottle(&config.LoginThrottleConfig{AccountLockoutThreshold: 1, LockoutDuration: time.Hour})
+
+	th.failLogin("alice@example.com", "")
+	assert.True(t, th.checkLogin("alice@example.com", "") > 0, "Account not locked")
+
+	// Move the lockout into the past instead of waiting for it
+	failures := th.entries[accountThrottleKey("alice@example.com")]
+	failures.lastFailure = failures.lastFailure.Add(-2 * time.Hour)
+	failures.lockedUntil = failures.lockedUntil.Add(-2 * time.Hour)
+	assert.Equal(t, time.Duration(0), th.checkLogin("alice@example.com", ""), "Account still locked after the lockout")
+
+	th.cleanup()
+	assert.Empty(t, th.entries, "Expired failures not cleaned up")
+}
+
+// beginConcurrentLogins starts the attempts at once and returns how many of them were allowed
+func beginConcurrentLogins(th *loginThrottle, attempts int, email, ip string) (allowed int) {
+	start := make(chan struct{})
+	results := make(chan bool, attempts)
+	wg := sync.WaitGroup{}
+	for it := 0; it < attempts; it++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			results <- th.beginLogin(email, ip) == 0
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(results)
+
+	for ok := range results {
+		if ok {
+			allowed++
+		}
+	}
+	return
+}
+
+func TestLoginThrottleConcurrentAttempts(t *testing.T) {
+	tests := []struct {
+		name            string
+		cfg             *config.LoginThrottleConfig
+		expectedAllowed int
+	}{
+		{name: "Lockout", cfg: &config.LoginThrottleConfig{AccountLockoutThreshold: 3, IPLockoutThreshold: 10, LockoutDuration: time.Hour}, expectedAllowed: 3},
+		{name: "Backoff", cfg: &config.LoginThrottleConfig{BackoffBase: time.Minute, MaxBackoff: time.Hour, LockoutDuration: time.Hour}, expectedAllowed: 1},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			th := newLoginThrottle(test.cfg)
+
+			allowed := beginConcurrentLogins(th, 20, "alice@example.com", "10.0.0.1")
+			assert.Equal(t, test.expectedAllowed, allowed, "Parallel attempts skipped the throttling")
+
+			for it := 0; it < allowed; it++ {
+				th.finishLogin("alice@example.com", "10.0.0.1", true)
+			}
+			assert.True(t, th.checkLogin("alice@example.com", "") > 0, "Account not throttled after the failed attempts")
+			assert.Equal(t, 0, th.entries[accountThrottleKey("alice@example.com")].pending, "Finished attempts still pending")
+		})
+	}
+}
+
+func TestLoginThrottleFinishSuccessfulAttempt(t *testing.T) {
+	th := newLoginThrottle(&config.LoginThrottleConfig{AccountLockoutThreshold: 1, LockoutDuration: time.Hour})
+
+	assert.Equal(t, time.Duration(0), th.beginLogin("alice@example.com", ""), "First attempt throttled")
+	assert.True(t, th.checkLogin("alice@example.com", "") > 0, "Attempt in progress not counted")
+
+	th.finishLogin("alice@example.com", "", false)
+	assert.Equal(t, time.Duration(0), th.checkLogin("alice@example.com", ""), "Successful attempt counted as failure")
+	th.cleanup()
+	assert.Empty(t, th.entries, "Finished attempts not cleaned up")
+}
@@ -1,8 +1,12 @@
 package manager
 
 type Managers struct {
-	Auth  AuthManager
-	User  UserManager
-	Node  NodeManager
-	Share ShareManager
+	Auth     AuthManager
+	User     UserManager
+	Node     NodeManager
+	Share    ShareManager
+	Group    GroupManager
+	FileDrop FileDropManager
+	Audit    AuditManager
+	Export   ExportManager
 }
@@ -3,6 +3,7 @@ package manager
 import (
 	"errors"
 	"io"
+	"os"
 
 	"github.com/freecloudio/server/application/authorization"
 	"github.com/freecloudio/server/application/config"
@@ -17,6 +18,8 @@ import (
 
 type NodeManager interface {
 	CreateUserRootFolder(authCtx *authorization.Context, userID models.UserID) *fcerror.Error
+	DeleteUserRootFolder(authCtx *authorization.Context, userID models.UserID) *fcerror.Error
+	TransferUserRootFolder(authCtx *authorization.Context, fromUserID, toUserID models.UserID, folderName string) *fcerror.Error
 	GetNodeByPath(authCtx *authorization.Context, path string) (*models.Node, *fcerror.Error)
 	GetNodeByID(authCtx *authorization.Context, nodeID models.NodeID) (*models.Node, *fcerror.Error)
 	ListByID(authCtx *authorization.Context, nodeID models.NodeID) ([]*models.Node, *fcerror.Error)
@@ -61,7 +64,17 @@ func (mgr *nodeManager) CreateUserRootFolder(authCtx *authorization.Context, use
 		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
 		return
 	}
-	defer func() { fcerr = trans.Finish(fcerr) }()
+	storageCreated := false
+	defer func() {
+		fcerr = trans.Finish(fcerr)
+		// The commit can still fail after the folder was created in the file storage
+		if fcerr != nil && storageCreated {
+			deleteErr := mgr.fileStorage.DeleteUserRootFolder(userID)
+			if deleteErr != nil {
+				mgr.logger.WithField("userID", userID).WithError(deleteErr).Error("Failed to delete file storage user root folder after failed commit")
+			}
+		}
+	}()
 
 	_, fcerr = trans.CreateUserRootFolder(userID)
 	if fcerr != nil {
@@ -74,14 +87,89 @@ func (mgr *nodeManager) CreateUserRootFolder(authCtx *authorization.Context, use
 		mgr.logger.WithField("userID", userID).WithError(fcerr).Error("Failed to create file storage user root folder")
 		return
 	}
+	storageCreated = true
+
+	return
+}
+
+// DeleteUserRootFolder deletes all files of the user, nothing is deleted if they were transferred before
+func (mgr *nodeManager) DeleteUserRootFolder(authCtx *authorization.Context, userID models.UserID) (fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceSystem(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	trans, fcerr := mgr.nodePersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = trans.DeleteUserRootFolder(userID)
+	if fcerr != nil {
+		mgr.logger.WithField("userID", userID).WithError(fcerr).Error("Failed to delete persistence user root folder")
+		return
+	}
+
+	fcerr = mgr.fileStorage.DeleteUserRootFolder(userID)
+	if fcerr != nil {
+		mgr.logger.WithField("userID", userID).WithError(fcerr).Error("Failed to delete file storage user root folder")
+		return
+	}
+
+	return
+}
+
+// TransferUserRootFolder moves all files of a user into a new folder in the root folder of another user
+func (mgr *nodeManager) TransferUserRootFolder(authCtx *authorization.Context, fromUserID, toUserID models.UserID, folderName string) (fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceSystem(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	trans, fcerr := mgr.nodePersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	logger := mgr.logger.WithField("fromUserID", fromUserID).WithField("toUserID", toUserID)
+	transferredName, fcerr := trans.TransferUserRootFolder(fromUserID, toUserID, folderName)
+	if fcerr != nil {
+		logger.WithError(fcerr).Error("Failed to transfer persistence user root folder")
+		return
+	}
+
+	fcerr = mgr.fileStorage.TransferUserRootFolder(fromUserID, toUserID, transferredName)
+	if fcerr != nil {
+		logger.WithError(fcerr).Error("Failed to transfer file storage user root folder")
+		return
+	}
 
 	return
 }
 
 func (mgr *nodeManager) CreateNode(authCtx *authorization.Context, node *models.Node) (created bool, fcerr *fcerror.Error) {
-	// TODO: Sanitize Name
+	v := &validator{}
+	v.validateNodeName("name", node.Name)
+	fcerr = v.err()
+	if fcerr != nil {
+		return
+	}
 
-	fcerr = authorization.EnforceUser(authCtx)
+	isFileDrop := authCtx.Type == authorization.ContextTypeFileDrop
+	if isFileDrop {
+		fcerr = authorization.EnforceFileDropCreate(authCtx, node)
+	} else {
+		fcerr = authorization.EnforceUser(authCtx)
+	}
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceWriteScope(authCtx)
 	if fcerr != nil {
 		return
 	}
@@ -98,12 +186,22 @@ func (mgr *nodeManager) CreateNode(authCtx *authorization.Context, node *models.
 	}
 	defer func() { fcerr = trans.Finish(fcerr) }()
 
+	fcerr = enforceNodeScope(authCtx, trans, *node.ParentNodeID)
+	if fcerr != nil {
+		return
+	}
+
 	created, fcerr = trans.CreateNodeByID(authCtx.User.ID, node)
 	if fcerr != nil {
 		mgr.logger.WithError(fcerr).Error("Failed to create node")
 		return
 	}
 	if !created {
+		// File drops must never give access to existing files
+		if isFileDrop {
+			fcerr = fcerror.NewError(fcerror.ErrNodeNameAlreadyUsed, nil)
+			return
+		}
 		mgr.logger.WithField("node", node).Info("File or folder already exists in persistence, don't create in storage")
 		return
 	}
@@ -111,13 +209,62 @@ func (mgr *nodeManager) CreateNode(authCtx *authorization.Context, node *models.
 	fcerr = mgr.fileStorage.CreateEmptyFileOrFolder(node)
 	if fcerr != nil {
 		mgr.logger.WithError(fcerr).WithField("node", node).Error("Failed to create empty file or folder")
+		return
+	}
+
+	if isFileDrop {
+		authCtx.AddFileDropNode(node.ID)
 	}
 
 	return
 }
 
 func (mgr *nodeManager) UploadFileByID(authCtx *authorization.Context, nodeID models.NodeID, uploadFilePath string) (fcerr *fcerror.Error) {
-	node, fcerr := mgr.GetNodeByID(authCtx, nodeID)
+	defer func() {
+		event := &models.AuditEvent{Action: models.AuditActionUpload, TargetType: models.AuditTargetTypeNode, TargetID: string(nodeID)}
+		if authCtx.Type == authorization.ContextTypeFileDrop {
+			event.Details = "file_drop=" + string(authCtx.FileDrop.ID)
+		}
+		mgr.managers.Audit.Record(authCtx, withOutcome(event, fcerr))
+	}()
+
+	if authCtx.Type == authorization.ContextTypeFileDrop {
+		fcerr = authorization.EnforceFileDropUpload(authCtx, nodeID)
+	} else {
+		fcerr = authorization.EnforceUser(authCtx)
+	}
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceWriteScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	trans, fcerr := mgr.nodePersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+
+	fcerr = enforceNodeScope(authCtx, trans, nodeID)
+	if fcerr != nil {
+		trans.Close()
+		return
+	}
+
+	// Uploading needs write access to the node, either as owner or through a read & write share
+	node, fcerr := trans.GetNodeByID(authCtx.User.ID, nodeID, models.ShareModeReadWrite)
+	trans.Close()
+	if fcerr != nil {
+		if fcerr.ID != fcerror.ErrNodeNotFound {
+			mgr.logger.WithError(fcerr).WithFields(logrus.Fields{"userID": authCtx.User.ID, "nodeID": nodeID}).Error("Failed to get node for upload")
+		}
+		return
+	}
+
+	fcerr = mgr.enforceQuota(node, uploadFilePath)
 	if fcerr != nil {
 		return
 	}
@@ -130,14 +277,56 @@ func (mgr *nodeManager) UploadFileByID(authCtx *authorization.Context, nodeID mo
 	return
 }
 
-func (mgr *nodeManager) GetNodeByPath(authCtx *authorization.Context, path string) (node *models.Node, fcerr *fcerror.Error) {
-	//TODO: Sanitize
+// enforceQuota returns ErrQuotaExceeded if the upload replacing the file would exceed the storage quota of its owner.
+// Uploads into shared folders count against the owner of the folder.
+func (mgr *nodeManager) enforceQuota(node *models.Node, uploadFilePath string) (fcerr *fcerror.Error) {
+	owner, fcerr := mgr.managers.User.GetUserByID(authorization.NewSystem(), node.OwnerID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("node", node).Error("Failed to get owner of node for quota")
+		return
+	}
+	if owner.Quota <= 0 {
+		return
+	}
+
+	uploadStat, err := os.Stat(uploadFilePath)
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrOpenUploadFile, err)
+		mgr.logger.WithError(err).WithField("node", node).Error("Failed to get size of upload")
+		return
+	}
+	usedBytes, fcerr := mgr.fileStorage.GetUsedBytes(owner.ID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", owner.ID).Error("Failed to get used storage of user")
+		return
+	}
+	reader, replacedSize, fcerr := mgr.fileStorage.DownloadFile(node)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("node", node).Error("Failed to get size of replaced file")
+		return
+	}
+	reader.Close()
 
+	if usedBytes-replacedSize+uploadStat.Size() > owner.Quota {
+		fcerr = fcerror.NewError(fcerror.ErrQuotaExceeded, nil)
+		mgr.logger.WithFields(logrus.Fields{"userID": owner.ID, "usedBytes": usedBytes, "uploadSize": uploadStat.Size()}).Info("Upload exceeds quota")
+	}
+	return
+}
+
+func (mgr *nodeManager) GetNodeByPath(authCtx *authorization.Context, path string) (node *models.Node, fcerr *fcerror.Error) {
 	fcerr = authorization.EnforceUser(authCtx)
 	if fcerr != nil {
 		return
 	}
 
+	v := &validator{}
+	v.validateNodePath("full_path", path)
+	fcerr = v.err()
+	if fcerr != nil {
+		return
+	}
+
 	trans, fcerr := mgr.nodePersistence.StartReadTransaction()
 	if fcerr != nil {
 		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
@@ -146,11 +335,18 @@ func (mgr *nodeManager) GetNodeByPath(authCtx *authorization.Context, path strin
 	defer trans.Close()
 
 	node, fcerr = trans.GetNodeByPath(authCtx.User.ID, path, models.ShareModeRead)
-	if fcerr != nil && fcerr.ID != fcerror.ErrNodeNotFound {
-		mgr.logger.WithError(fcerr).WithFields(logrus.Fields{"userID": authCtx.User.ID, "path": path}).Error("Failed to get node for path")
+	if fcerr != nil {
+		if fcerr.ID != fcerror.ErrNodeNotFound {
+			mgr.logger.WithError(fcerr).WithFields(logrus.Fields{"userID": authCtx.User.ID, "path": path}).Error("Failed to get node for path")
+		}
 		return
 	}
 
+	fcerr = enforceNodeScope(authCtx, trans, node.ID)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+
 	return
 }
 
@@ -167,6 +363,11 @@ func (mgr *nodeManager) GetNodeByID(authCtx *authorization.Context, nodeID model
 	}
 	defer trans.Close()
 
+	fcerr = enforceNodeScope(authCtx, trans, nodeID)
+	if fcerr != nil {
+		return
+	}
+
 	node, fcerr = trans.GetNodeByID(authCtx.User.ID, nodeID, models.ShareModeRead)
 	if fcerr != nil && fcerr.ID != fcerror.ErrNodeNotFound {
 		mgr.logger.WithError(fcerr).WithFields(logrus.Fields{"userID": authCtx.User.ID, "nodeID": nodeID}).Error("Failed to get node for nodeID")
@@ -189,6 +390,11 @@ func (mgr *nodeManager) ListByID(authCtx *authorization.Context, nodeID models.N
 	}
 	defer trans.Close()
 
+	fcerr = enforceNodeScope(authCtx, trans, nodeID)
+	if fcerr != nil {
+		return
+	}
+
 	node, fcerr = trans.ListByID(authCtx.User.ID, nodeID, models.ShareModeRead)
 	if fcerr != nil && fcerr.ID != fcerror.ErrNodeNotFound {
 		mgr.logger.WithError(fcerr).WithFields(logrus.Fields{"userID": authCtx.User.ID, "nodeID": nodeID}).Error("Failed to get content for nodeID")
@@ -199,6 +405,10 @@ func (mgr *nodeManager) ListByID(authCtx *authorization.Context, nodeID models.N
 }
 
 func (mgr *nodeManager) DownloadFile(authCtx *authorization.Context, nodeID models.NodeID) (node *models.Node, reader io.ReadCloser, size int64, fcerr *fcerror.Error) {
+	defer func() {
+		mgr.managers.Audit.Record(authCtx, withOutcome(&models.AuditEvent{Action: models.AuditActionDownload, TargetType: models.AuditTargetTypeNode, TargetID: string(nodeID)}, fcerr))
+	}()
+
 	fcerr = authorization.EnforceUser(authCtx)
 	if fcerr != nil {
 		return
@@ -223,3 +433,19 @@ func (mgr *nodeManager) DownloadFile(authCtx *authorization.Context, nodeID mode
 	}
 	return
 }
+
+// enforceNodeScope checks that the node lies within the folder an access token of the context is limited to
+func enforceNodeScope(authCtx *authorization.Context, trans persistence.NodePersistenceReadTransaction, nodeID models.NodeID) (fcerr *fcerror.Error) {
+	if authCtx.Scope == nil || authCtx.Scope.FolderID == "" {
+		return
+	}
+
+	contained, fcerr := trans.IsNodeInFolder(authCtx.Scope.FolderID, nodeID)
+	if fcerr != nil {
+		return
+	}
+	if !contained {
+		fcerr = fcerror.NewError(fcerror.ErrForbidden, nil)
+	}
+	return
+}
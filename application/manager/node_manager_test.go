@@ -0,0 +1,235 @@
+package manager_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/application/manager"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/mock"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nodeMocks struct {
+	nodePersistence *mock.MockNodePersistenceController
+	nodeTrans       *mock.MockNodePersistenceReadWriteTransaction
+	fileStorage     *mock.MockFileStorageController
+	userMgr         *mock.MockUserManager
+	nodeMgr         manager.NodeManager
+}
+
+func createNodeMocks(mockCtrl *gomock.Controller) *nodeMocks {
+	mocks := &nodeMocks{
+		nodePersistence: mock.NewMockNodePersistenceController(mockCtrl),
+		nodeTrans:       mock.NewMockNodePersistenceReadWriteTransaction(mockCtrl),
+		fileStorage:     mock.NewMockFileStorageController(mockCtrl),
+		userMgr:         mock.NewMockUserManager(mockCtrl),
+	}
+	cfg := mock.NewMockConfig(mockCtrl)
+	cfg.EXPECT().GetLoggingConfig().Return(&utils.LoggingConfig{}).AnyTimes()
+	auditMgr := mock.NewMockAuditManager(mockCtrl)
+	auditMgr.EXPECT().Record(gomock.Any(), gomock.Any()).AnyTimes()
+
+	mocks.nodeMgr = manager.NewNodeManager(cfg, mocks.nodePersistence, mocks.fileStorage, &manager.Managers{User: mocks.userMgr, Audit: auditMgr})
+	return mocks
+}
+
+// writeUploadFile stores the content as a temporary upload and returns its path
+func writeUploadFile(t *testing.T, content string) string {
+	uploadFile, err := ioutil.TempFile("", "freecloud-upload-test")
+	require.Nil(t, err, "Failed to create upload file")
+	_, err = uploadFile.WriteString(content)
+	require.Nil(t, err, "Failed to write upload file")
+	require.Nil(t, uploadFile.Close(), "Failed to close upload file")
+	return uploadFile.Name()
+}
+
+func TestUploadFileQuota(t *testing.T) {
+	tests := []struct {
+		name         string
+		quota        int64
+		usedBytes    int64
+		replacedSize int64
+		expectedErr  fcerror.ErrorID
+	}{
+		{name: "Unlimited quota", usedBytes: 1000},
+		{name: "Within quota", quota: 100, usedBytes: 90},
+		{name: "Replaced file frees its size", quota: 100, usedBytes: 98, replacedSize: 8},
+		{name: "Quota exceeded", quota: 100, usedBytes: 91, expectedErr: fcerror.ErrQuotaExceeded},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			uploadPath := writeUploadFile(t, "ten bytes!")
+			defer os.Remove(uploadPath)
+
+			mocks := createNodeMocks(mockCtrl)
+			user := &models.User{ID: testUserID, Quota: test.quota}
+			node := &models.Node{ID: "file", Type: models.NodeTypeFile, OwnerID: user.ID, PerspectiveUserID: user.ID}
+
+			mocks.nodePersistence.EXPECT().StartReadTransaction().Return(mocks.nodeTrans, nil).Times(1)
+			mocks.nodeTrans.EXPECT().GetNodeByID(user.ID, node.ID, models.ShareModeReadWrite).Return(node, nil).Times(1)
+			mocks.nodeTrans.EXPECT().Close().Return(nil).Times(1)
+			mocks.userMgr.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil).Times(1)
+			if test.quota > 0 {
+				mocks.fileStorage.EXPECT().GetUsedBytes(user.ID).Return(test.usedBytes, nil).Times(1)
+				replaced := ioutil.NopCloser(strings.NewReader(strings.Repeat("x", int(test.replacedSize))))
+				mocks.fileStorage.EXPECT().DownloadFile(node).Return(replaced, test.replacedSize, nil).Times(1)
+			}
+			if test.expectedErr == 0 {
+				mocks.fileStorage.EXPECT().CopyFileFromUpload(node, uploadPath).Return(nil).Times(1)
+			}
+
+			fcerr := mocks.nodeMgr.UploadFileByID(authorization.NewUser(user), node.ID, uploadPath)
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Upload exceeding the quota accepted")
+				assert.Equal(t, test.expectedErr, fcerr.ID, "Wrong error for exceeded quota")
+				return
+			}
+			assert.Nil(t, fcerr, "Upload within the quota rejected")
+		})
+	}
+}
+
+func TestNodeAccessTokenScope(t *testing.T) {
+	user := &models.User{ID: testUserID}
+	folderScope := &models.TokenScope{FolderID: "folder"}
+	readOnlyScope := &models.TokenScope{ReadOnly: true}
+	nodeID := models.NodeID("node")
+	node := &models.Node{ID: nodeID, Type: models.NodeTypeFile, OwnerID: user.ID, PerspectiveUserID: user.ID}
+
+	expectReadScope := func(contained bool) func(mocks *nodeMocks) {
+		return func(mocks *nodeMocks) {
+			mocks.nodePersistence.EXPECT().StartReadTransaction().Return(mocks.nodeTrans, nil).Times(1)
+			mocks.nodeTrans.EXPECT().IsNodeInFolder(folderScope.FolderID, nodeID).Return(contained, nil).Times(1)
+			mocks.nodeTrans.EXPECT().Close().Return(nil).Times(1)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		scope       *models.TokenScope
+		expect      func(mocks *nodeMocks)
+		call        func(nodeMgr manager.NodeManager, authCtx *authorization.Context) *fcerror.Error
+		expectedErr fcerror.ErrorID
+	}{
+		{
+			name:  "GetNodeByPath outside of folder",
+			scope: folderScope,
+			expect: func(mocks *nodeMocks) {
+				expectReadScope(false)(mocks)
+				mocks.nodeTrans.EXPECT().GetNodeByPath(user.ID, "/other/file.txt", models.ShareModeRead).Return(node, nil).Times(1)
+			},
+			call: func(nodeMgr manager.NodeManager, authCtx *authorization.Context) *fcerror.Error {
+				_, fcerr := nodeMgr.GetNodeByPath(authCtx, "/other/file.txt")
+				return fcerr
+			},
+			expectedErr: fcerror.ErrForbidden,
+		},
+		{
+			name:   "ListByID outside of folder",
+			scope:  folderScope,
+			expect: expectReadScope(false),
+			call: func(nodeMgr manager.NodeManager, authCtx *authorization.Context) *fcerror.Error {
+				_, fcerr := nodeMgr.ListByID(authCtx, nodeID)
+				return fcerr
+			},
+			expectedErr: fcerror.ErrForbidden,
+		},
+		{
+			name:  "ListByID inside of folder",
+			scope: folderScope,
+			expect: func(mocks *nodeMocks) {
+				expectReadScope(true)(mocks)
+				mocks.nodeTrans.EXPECT().ListByID(user.ID, nodeID, models.ShareModeRead).Return([]*models.Node{}, nil).Times(1)
+			},
+			call: func(nodeMgr manager.NodeManager, authCtx *authorization.Context) *fcerror.Error {
+				_, fcerr := nodeMgr.ListByID(authCtx, nodeID)
+				return fcerr
+			},
+		},
+		{
+			name:  "CreateNode outside of folder",
+			scope: folderScope,
+			expect: func(mocks *nodeMocks) {
+				mocks.nodePersistence.EXPECT().StartReadWriteTransaction().Return(mocks.nodeTrans, nil).Times(1)
+				mocks.nodeTrans.EXPECT().IsNodeInFolder(folderScope.FolderID, nodeID).Return(false, nil).Times(1)
+				mocks.nodeTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWith(nil)).Times(1)
+			},
+			call: func(nodeMgr manager.NodeManager, authCtx *authorization.Context) *fcerror.Error {
+				_, fcerr := nodeMgr.CreateNode(authCtx, &models.Node{Name: "file.txt", Type: models.NodeTypeFile, ParentNodeID: &nodeID})
+				return fcerr
+			},
+			expectedErr: fcerror.ErrForbidden,
+		},
+		{
+			name:   "UploadFileByID outside of folder",
+			scope:  folderScope,
+			expect: expectReadScope(false),
+			call: func(nodeMgr manager.NodeManager, authCtx *authorization.Context) *fcerror.Error {
+				return nodeMgr.UploadFileByID(authCtx, nodeID, "upload")
+			},
+			expectedErr: fcerror.ErrForbidden,
+		},
+		{
+			name:   "DownloadFile outside of folder",
+			scope:  folderScope,
+			expect: expectReadScope(false),
+			call: func(nodeMgr manager.NodeManager, authCtx *authorization.Context) *fcerror.Error {
+				_, _, _, fcerr := nodeMgr.DownloadFile(authCtx, nodeID)
+				return fcerr
+			},
+			expectedErr: fcerror.ErrForbidden,
+		},
+		{
+			name:  "CreateNode with read-only token",
+			scope: readOnlyScope,
+			call: func(nodeMgr manager.NodeManager, authCtx *authorization.Context) *fcerror.Error {
+				_, fcerr := nodeMgr.CreateNode(authCtx, &models.Node{Name: "file.txt", Type: models.NodeTypeFile, ParentNodeID: &nodeID})
+				return fcerr
+			},
+			expectedErr: fcerror.ErrForbidden,
+		},
+		{
+			name:  "UploadFileByID with read-only token",
+			scope: readOnlyScope,
+			call: func(nodeMgr manager.NodeManager, authCtx *authorization.Context) *fcerror.Error {
+				return nodeMgr.UploadFileByID(authCtx, nodeID, "upload")
+			},
+			expectedErr: fcerror.ErrForbidden,
+		},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createNodeMocks(mockCtrl)
+			if test.expect != nil {
+				test.expect(mocks)
+			}
+
+			fcerr := test.call(mocks.nodeMgr, authorization.NewAccessToken(user, test.scope))
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Access outside of the token scope accepted")
+				assert.Equal(t, test.expectedErr, fcerr.ID, "Wrong error for access outside of the token scope")
+				return
+			}
+			assert.Nil(t, fcerr, "Access within the token scope rejected")
+		})
+	}
+}
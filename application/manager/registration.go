@@ -0,0 +1,197 @@
+package manager
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/google/uuid"
+)
+
+const inviteCodeLength = 32
+
+// checkRegistration returns the invite to use for the registration if one is needed or given.
+// The first user can always register, so a fresh installation can be set up regardless of the mode.
+func (mgr *userManager) checkRegistration(email, inviteCode string) (invite *models.Invite, fcerr *fcerror.Error) {
+	count, fcerr := mgr.CountUsers(authorization.NewSystem())
+	if fcerr != nil {
+		return
+	}
+	if count == 0 {
+		return
+	}
+
+	registrationCfg := mgr.cfg.GetRegistrationConfig()
+	if registrationCfg.Mode == config.RegistrationModeDisabled {
+		fcerr = fcerror.NewError(fcerror.ErrRegistrationDisabled, nil)
+		return
+	}
+	if inviteCode != "" {
+		return mgr.getValidInvite(inviteCode)
+	}
+
+	switch registrationCfg.Mode {
+	case config.RegistrationModeOpen:
+		return
+	case config.RegistrationModeAllowedDomains:
+		if !isEmailDomainAllowed(email, registrationCfg.AllowedDomains) {
+			fcerr = fcerror.NewError(fcerror.ErrEmailDomainNotAllowed, nil)
+		}
+		return
+	case config.RegistrationModeInviteOnly:
+		fcerr = fcerror.NewError(fcerror.ErrRegistrationDisabled, nil)
+		return
+	default:
+		mgr.logger.WithField("mode", registrationCfg.Mode).Error("Unknown registration mode - registration is disabled")
+		fcerr = fcerror.NewError(fcerror.ErrRegistrationDisabled, nil)
+		return
+	}
+}
+
+func isEmailDomainAllowed(email string, allowedDomains []string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, allowedDomain := range allowedDomains {
+		if domain == strings.ToLower(strings.TrimPrefix(allowedDomain, "@")) {
+			return true
+		}
+	}
+	return false
+}
+
+// getValidInvite returns the unused and not expired invite of the code, it is only consumed with the creation of the user
+func (mgr *userManager) getValidInvite(inviteCode string) (invite *models.Invite, fcerr *fcerror.Error) {
+	trans, fcerr := mgr.userPersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer trans.Close()
+
+	invite, fcerr = trans.GetInviteByCodeHash(utils.HashToken(inviteCode))
+	if fcerr != nil {
+		if fcerr.ID != fcerror.ErrInviteInvalid {
+			mgr.logger.WithError(fcerr).Error("Failed to get invite")
+		}
+		return
+	}
+
+	if utils.GetCurrentTime().After(invite.ExpiresAt) {
+		return nil, fcerror.NewError(fcerror.ErrInviteInvalid, nil)
+	}
+	return
+}
+
+// CreateInvite issues a single-use invite code, the code is only returned here
+func (mgr *userManager) CreateInvite(authCtx *authorization.Context, invite *models.Invite) (code string, fcerr *fcerror.Error) {
+	fcerr = authorization.Enforce(authCtx, authorization.PermissionCreateUsers, nil)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Invites can only be created by users"))
+		return
+	}
+	if invite.IsAdmin {
+		fcerr = authorization.Enforce(authCtx, authorization.PermissionManageRoles, nil)
+		if fcerr != nil {
+			return
+		}
+	}
+
+	if invite.Quota < 0 {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Quota of an invite cannot be negative"))
+		return
+	}
+	now := utils.GetCurrentTime()
+	if invite.ExpiresAt.IsZero() {
+		invite.ExpiresAt = now.Add(mgr.cfg.GetRegistrationConfig().InviteExpiration)
+	} else if invite.ExpiresAt.Before(now) {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Invite expiration date lies in the past"))
+		return
+	}
+
+	code, err := utils.GenerateSecureRandomString(inviteCodeLength)
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrUnknown, err)
+		mgr.logger.WithError(fcerr).Error("Failed to generate invite code")
+		return
+	}
+
+	invite.ID = models.InviteID(uuid.NewString())
+	invite.CodeHash = utils.HashToken(code)
+	invite.CreatedBy = authCtx.User.ID
+	invite.Created = now
+
+	trans, fcerr := mgr.userPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return "", fcerr
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = trans.SaveInvite(invite)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to save invite")
+		return "", fcerr
+	}
+	return
+}
+
+func (mgr *userManager) GetInvites(authCtx *authorization.Context) (invites []*models.Invite, fcerr *fcerror.Error) {
+	fcerr = authorization.Enforce(authCtx, authorization.PermissionCreateUsers, nil)
+	if fcerr != nil {
+		return
+	}
+
+	trans, fcerr := mgr.userPersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer trans.Close()
+
+	invites, fcerr = trans.GetInvites()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to get invites")
+	}
+	return
+}
+
+func (mgr *userManager) RevokeInvite(authCtx *authorization.Context, inviteID models.InviteID) (fcerr *fcerror.Error) {
+	fcerr = authorization.Enforce(authCtx, authorization.PermissionCreateUsers, nil)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	trans, fcerr := mgr.userPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = trans.DeleteInvite(inviteID)
+	if fcerr != nil && fcerr.ID != fcerror.ErrInviteNotFound {
+		mgr.logger.WithError(fcerr).WithField("inviteID", inviteID).Error("Failed to delete invite")
+	}
+	return
+}
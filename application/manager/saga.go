@@ -0,0 +1,38 @@
+package manager
+
+import (
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/utils"
+)
+
+// sagaStep is one step of an operation spanning several transactions or storages.
+// The compensation undoes the action and is only run if a later step fails, it may be nil.
+type sagaStep struct {
+	name       string
+	action     func() *fcerror.Error
+	compensate func() *fcerror.Error
+}
+
+// runSaga runs the steps in order. If a step fails, the compensations of all completed steps are run in reverse order
+// and the error of the failed step is returned. Failing compensations are logged as they cannot be undone themselves.
+func runSaga(logger utils.Logger, steps ...*sagaStep) (fcerr *fcerror.Error) {
+	for completed, step := range steps {
+		fcerr = step.action()
+		if fcerr == nil {
+			continue
+		}
+
+		logger.WithError(fcerr).WithField("step", step.name).Error("Saga step failed - compensate completed steps")
+		for it := completed - 1; it >= 0; it-- {
+			if steps[it].compensate == nil {
+				continue
+			}
+			compensateErr := steps[it].compensate()
+			if compensateErr != nil {
+				logger.WithError(compensateErr).WithField("step", steps[it].name).Error("Failed to compensate saga step - manual cleanup needed")
+			}
+		}
+		return
+	}
+	return
+}
@@ -1,16 +1,26 @@
 package manager
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/freecloudio/server/application/authorization"
 	"github.com/freecloudio/server/application/config"
 	"github.com/freecloudio/server/application/persistence"
 	"github.com/freecloudio/server/domain/models"
 	"github.com/freecloudio/server/domain/models/fcerror"
 	"github.com/freecloudio/server/utils"
+
+	"github.com/sirupsen/logrus"
 )
 
 type ShareManager interface {
 	CreateShare(authCtx *authorization.Context, share *models.Share) (bool, *fcerror.Error)
+	UpdateShareMount(authCtx *authorization.Context, nodeID models.NodeID, update *models.ShareMountUpdate) (*models.Node, *fcerror.Error)
+	RevokeShare(authCtx *authorization.Context, share *models.Share) *fcerror.Error
+	GetOwnShares(authCtx *authorization.Context) ([]*models.Share, *fcerror.Error)
 	Close()
 }
 
@@ -25,8 +35,10 @@ func NewShareManager(
 		sharePersistence: sharePersistence,
 		nodePersistence:  nodePersistence,
 		managers:         managers,
+		done:             make(chan struct{}),
 		logger:           utils.CreateLogger(cfg.GetLoggingConfig()),
 	}
+	go shareMgr.cleanupExpiredSharesRoutine()
 
 	managers.Share = shareMgr
 	return shareMgr
@@ -37,18 +49,90 @@ type shareManager struct {
 	sharePersistence persistence.SharePersistenceController
 	nodePersistence  persistence.NodePersistenceController
 	managers         *Managers
+	done             chan struct{}
 	logger           utils.Logger
 }
 
 func (mgr *shareManager) Close() {
+	mgr.done <- struct{}{}
+}
+
+func (mgr *shareManager) cleanupExpiredSharesRoutine() {
+	interval := mgr.cfg.GetShareCleanupInterval()
+	mgr.logger.WithField("interval", interval).Debug("Starting share cleanup")
+
+	mgr.cleanupExpiredShares()
+	ticker := time.NewTicker(interval)
+	for {
+		select {
+		case <-mgr.done:
+			return
+		case <-ticker.C:
+			mgr.cleanupExpiredShares()
+		}
+	}
+}
+
+func (mgr *shareManager) cleanupExpiredShares() {
+	mgr.logger.Debug("Cleaning expired shares")
+
+	trans, fcerr := mgr.sharePersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { _ = trans.Finish(fcerr) }()
+
+	fcerr = trans.DeleteExpiredShares()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to delete expired shares")
+		return
+	}
 }
 
 func (mgr *shareManager) CreateShare(authCtx *authorization.Context, share *models.Share) (created bool, fcerr *fcerror.Error) {
+	defer func() { mgr.auditShare(authCtx, models.AuditActionShareCreate, share, fcerr) }()
+
 	fcerr = authorization.EnforceUser(authCtx)
 	if fcerr != nil {
 		return
 	}
 
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	switch share.TargetType {
+	case models.ShareTargetTypeGroup:
+		// Only members are allowed to share with a group
+		_, fcerr = mgr.managers.Group.GetGroupByID(authCtx, share.SharedWithGroupID)
+		if fcerr != nil {
+			return
+		}
+	case models.ShareTargetTypeUser, "":
+		share.TargetType = models.ShareTargetTypeUser
+		if share.SharedWithID == authCtx.User.ID {
+			fcerr = fcerror.NewError(fcerror.ErrShareWithOwner, nil)
+			return
+		}
+	default:
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, fmt.Errorf("Unknown share target type '%s'", share.TargetType))
+		return
+	}
+
+	if share.Name != "" {
+		fcerr = validateMountName(share.Name)
+		if fcerr != nil {
+			return
+		}
+	}
+
+	if share.ExpiresAt != nil && share.ExpiresAt.Before(utils.GetCurrentTime()) {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Share expiration date lies in the past"))
+		return
+	}
+
 	nodeTrans, fcerr := mgr.nodePersistence.StartReadTransaction()
 	if fcerr != nil {
 		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
@@ -67,6 +151,17 @@ func (mgr *shareManager) CreateShare(authCtx *authorization.Context, share *mode
 		fcerr = nil
 	}
 
+	if shareNode.ParentNodeID == nil {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Root folder can not be shared"))
+		return
+	}
+
+	// Mount the share with the name of the node if no explicit name is given - the persistence renames it on conflicts
+	mountName := share.Name
+	if mountName == "" {
+		mountName = shareNode.Name
+	}
+
 	shareTrans, fcerr := mgr.sharePersistence.StartReadWriteTransaction()
 	defer func() { fcerr = shareTrans.Finish(fcerr) }()
 	if fcerr != nil {
@@ -74,21 +169,145 @@ func (mgr *shareManager) CreateShare(authCtx *authorization.Context, share *mode
 		return
 	}
 
-	// TODO: Check that nodeName is not already used in root folder and name is not empty (root folder)
+	if share.TargetType == models.ShareTargetTypeGroup {
+		created, fcerr = shareTrans.CreateGroupShare(authCtx.User.ID, share, mountName)
+	} else {
+		created, fcerr = shareTrans.CreateShare(authCtx.User.ID, share, mountName)
+	}
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("share", share).Error("Failed to create share")
+		return
+	}
+	return
+}
+
+func (mgr *shareManager) UpdateShareMount(authCtx *authorization.Context, nodeID models.NodeID, update *models.ShareMountUpdate) (node *models.Node, fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	if update.Name != nil {
+		fcerr = validateMountName(*update.Name)
+		if fcerr != nil {
+			return
+		}
+	}
+
+	shareTrans, fcerr := mgr.sharePersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+
+	fcerr = shareTrans.UpdateShareMount(authCtx.User.ID, nodeID, update)
+	if fcerr != nil && fcerr.ID != fcerror.ErrNodeNotFound && fcerr.ID != fcerror.ErrNodeNameAlreadyUsed && fcerr.ID != fcerror.ErrShareMountCycle {
+		mgr.logger.WithError(fcerr).WithFields(logrus.Fields{"nodeID": nodeID, "update": update}).Error("Failed to update share mount")
+	}
+	fcerr = shareTrans.Finish(fcerr)
+	if fcerr != nil {
+		return
+	}
+
+	return mgr.managers.Node.GetNodeByID(authCtx, nodeID)
+}
+
+// RevokeShare removes the share of the node with the user or group, only the owner of the node can revoke its shares
+func (mgr *shareManager) RevokeShare(authCtx *authorization.Context, share *models.Share) (fcerr *fcerror.Error) {
+	defer func() { mgr.auditShare(authCtx, models.AuditActionShareRevoke, share, fcerr) }()
+
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	switch share.TargetType {
+	case models.ShareTargetTypeGroup:
+	case models.ShareTargetTypeUser, "":
+		share.TargetType = models.ShareTargetTypeUser
+	default:
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, fmt.Errorf("Unknown share target type '%s'", share.TargetType))
+		return
+	}
+
+	trans, fcerr := mgr.sharePersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = trans.DeleteShare(authCtx.User.ID, share)
+	if fcerr != nil && fcerr.ID != fcerror.ErrShareNotFound {
+		mgr.logger.WithError(fcerr).WithField("share", share).Error("Failed to delete share")
+	}
+	return
+}
+
+func (mgr *shareManager) auditShare(authCtx *authorization.Context, action models.AuditAction, share *models.Share, fcerr *fcerror.Error) {
+	sharedWithID := string(share.SharedWithID)
+	if share.TargetType == models.ShareTargetTypeGroup {
+		sharedWithID = string(share.SharedWithGroupID)
+	}
+	details := fmt.Sprintf("shared_with=%s:%s", share.TargetType, sharedWithID)
+	if share.Mode != models.ShareModeNone {
+		details += fmt.Sprintf(",mode=%s", share.Mode)
+	}
+
+	mgr.managers.Audit.Record(authCtx, withOutcome(&models.AuditEvent{
+		Action:     action,
+		TargetType: models.AuditTargetTypeNode,
+		TargetID:   string(share.NodeID),
+		Details:    details,
+	}, fcerr))
+}
 
-	containsShares, fcerr := shareTrans.NodeContainsNestedShares(share.NodeID)
+// GetOwnShares returns all active shares the user created for own files and folders
+func (mgr *shareManager) GetOwnShares(authCtx *authorization.Context) (shares []*models.Share, fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
 	if fcerr != nil {
-		mgr.logger.WithError(fcerr).WithField("share", share).Error("Failed to get whether node contains nested shares")
 		return
-	} else if containsShares {
-		fcerr = fcerror.NewError(fcerror.ErrShareContainsOtherShares, nil)
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
 		return
 	}
+	if authCtx.User == nil {
+		return []*models.Share{}, nil
+	}
 
-	created, fcerr = shareTrans.CreateShare(authCtx.User.ID, share, shareNode.Name)
+	trans, fcerr := mgr.sharePersistence.StartReadTransaction()
 	if fcerr != nil {
-		mgr.logger.WithError(fcerr).WithField("share", share).Error("Failed to get whether node contains nested shares")
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
 		return
 	}
+	defer trans.Close()
+
+	shares, fcerr = trans.GetSharesOfUser(authCtx.User.ID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", authCtx.User.ID).Error("Failed to get shares of user")
+	}
 	return
 }
+
+// validateMountName checks the name of a mount point like a node name, as both are listed in the same folder
+func validateMountName(name string) *fcerror.Error {
+	v := &validator{}
+	if strings.TrimSpace(name) == "" {
+		v.addError("name", fcerror.FieldErrorRequired, "Name must not be empty")
+	} else {
+		v.validateNodeName("name", name)
+	}
+	return v.err()
+}
@@ -0,0 +1,148 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/mock"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type shareMocks struct {
+	sharePersistence *mock.MockSharePersistenceController
+	shareTrans       *mock.MockSharePersistenceReadWriteTransaction
+	nodePersistence  *mock.MockNodePersistenceController
+	nodeTrans        *mock.MockNodePersistenceReadWriteTransaction
+	groupPersistence *mock.MockGroupPersistenceController
+	groupTrans       *mock.MockGroupPersistenceReadWriteTransaction
+	shareMgr         *shareManager
+}
+
+// createShareMocks builds the share manager without its cleanup routine and with a real group manager
+func createShareMocks(mockCtrl *gomock.Controller) *shareMocks {
+	mocks := &shareMocks{
+		sharePersistence: mock.NewMockSharePersistenceController(mockCtrl),
+		shareTrans:       mock.NewMockSharePersistenceReadWriteTransaction(mockCtrl),
+		nodePersistence:  mock.NewMockNodePersistenceController(mockCtrl),
+		nodeTrans:        mock.NewMockNodePersistenceReadWriteTransaction(mockCtrl),
+		groupPersistence: mock.NewMockGroupPersistenceController(mockCtrl),
+		groupTrans:       mock.NewMockGroupPersistenceReadWriteTransaction(mockCtrl),
+	}
+	cfg := mock.NewMockConfig(mockCtrl)
+	cfg.EXPECT().GetLoggingConfig().Return(&utils.LoggingConfig{}).AnyTimes()
+	auditMgr := mock.NewMockAuditManager(mockCtrl)
+	auditMgr.EXPECT().Record(gomock.Any(), gomock.Any()).AnyTimes()
+
+	managers := &Managers{Audit: auditMgr}
+	NewGroupManager(cfg, mocks.groupPersistence, managers)
+	mocks.shareMgr = &shareManager{
+		cfg:              cfg,
+		sharePersistence: mocks.sharePersistence,
+		nodePersistence:  mocks.nodePersistence,
+		managers:         managers,
+		done:             make(chan struct{}),
+		logger:           utils.CreateLogger(&utils.LoggingConfig{}),
+	}
+	return mocks
+}
+
+func finishWithArg(fcerr *fcerror.Error) *fcerror.Error {
+	return fcerr
+}
+
+func TestCreateGroupShare(t *testing.T) {
+	user := &models.User{ID: "user"}
+	parentID := models.NodeID("root")
+	node := &models.Node{ID: "folder", Name: "Documents", ParentNodeID: &parentID}
+
+	tests := []struct {
+		name      string
+		memberErr *fcerror.Error
+		expectErr fcerror.ErrorID
+	}{
+		{name: "Member shares with group"},
+		{name: "Non-member rejected", memberErr: fcerror.NewError(fcerror.ErrGroupMemberNotFound, nil), expectErr: fcerror.ErrGroupNotFound},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createShareMocks(mockCtrl)
+			share := &models.Share{NodeID: node.ID, TargetType: models.ShareTargetTypeGroup, SharedWithGroupID: "group", Mode: models.ShareModeRead}
+
+			mocks.groupPersistence.EXPECT().StartReadTransaction().Return(mocks.groupTrans, nil).Times(1)
+			mocks.groupTrans.EXPECT().Close().Return(nil).Times(1)
+			if test.memberErr != nil {
+				mocks.groupTrans.EXPECT().GetGroupMember(share.SharedWithGroupID, user.ID).Return(nil, test.memberErr).Times(1)
+			} else {
+				mocks.groupTrans.EXPECT().GetGroupMember(share.SharedWithGroupID, user.ID).Return(&models.GroupMember{GroupID: share.SharedWithGroupID, UserID: user.ID}, nil).Times(1)
+				mocks.groupTrans.EXPECT().GetGroupByID(share.SharedWithGroupID).Return(&models.Group{ID: share.SharedWithGroupID}, nil).Times(1)
+
+				mocks.nodePersistence.EXPECT().StartReadTransaction().Return(mocks.nodeTrans, nil).Times(1)
+				mocks.nodeTrans.EXPECT().GetNodeByID(user.ID, node.ID, models.ShareModeNone).Return(node, nil).Times(1)
+				mocks.nodeTrans.EXPECT().Close().Return(nil).Times(1)
+				mocks.sharePersistence.EXPECT().StartReadWriteTransaction().Return(mocks.shareTrans, nil).Times(1)
+				mocks.shareTrans.EXPECT().CreateGroupShare(user.ID, share, node.Name).Return(true, nil).Times(1)
+				mocks.shareTrans.EXPECT().Finish(nil).Return(nil).Times(1)
+			}
+
+			created, fcerr := mocks.shareMgr.CreateShare(authorization.NewUser(user), share)
+			if test.expectErr != 0 {
+				assert.False(t, created, "Share created by non-member")
+				if assert.NotNil(t, fcerr, "Non-member allowed to share with group") {
+					assert.Equal(t, test.expectErr, fcerr.ID, "Wrong error for non-member")
+				}
+				return
+			}
+			assert.Nil(t, fcerr, "Failed to share with group")
+			assert.True(t, created, "Group share not created")
+		})
+	}
+}
+
+func TestRevokeGroupShare(t *testing.T) {
+	user := &models.User{ID: "user"}
+
+	tests := []struct {
+		name      string
+		share     *models.Share
+		deleteErr *fcerror.Error
+		expectErr fcerror.ErrorID
+	}{
+		{name: "Group share revoked", share: &models.Share{NodeID: "folder", TargetType: models.ShareTargetTypeGroup, SharedWithGroupID: "group"}},
+		{name: "Unknown group share", share: &models.Share{NodeID: "folder", TargetType: models.ShareTargetTypeGroup, SharedWithGroupID: "other"}, deleteErr: fcerror.NewError(fcerror.ErrShareNotFound, nil), expectErr: fcerror.ErrShareNotFound},
+		{name: "Unknown target type", share: &models.Share{NodeID: "folder", TargetType: "team", SharedWithGroupID: "group"}, expectErr: fcerror.ErrBadRequest},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createShareMocks(mockCtrl)
+			if test.expectErr != fcerror.ErrBadRequest {
+				mocks.sharePersistence.EXPECT().StartReadWriteTransaction().Return(mocks.shareTrans, nil).Times(1)
+				mocks.shareTrans.EXPECT().DeleteShare(user.ID, test.share).Return(test.deleteErr).Times(1)
+				mocks.shareTrans.EXPECT().Finish(test.deleteErr).DoAndReturn(finishWithArg).Times(1)
+			}
+
+			fcerr := mocks.shareMgr.RevokeShare(authorization.NewUser(user), test.share)
+			if test.expectErr != 0 {
+				if assert.NotNil(t, fcerr, "Missing error for revoking share") {
+					assert.Equal(t, test.expectErr, fcerr.ID, "Wrong error for revoking share")
+				}
+				return
+			}
+			assert.Nil(t, fcerr, "Failed to revoke group share")
+		})
+	}
+}
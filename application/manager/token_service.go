@@ -0,0 +1,48 @@
+package manager
+
+import (
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/utils"
+)
+
+const tokenSecretLength = 64
+
+// tokenService creates the secret tokens handed out to clients from a cryptographically secure source.
+// Tokens are only stored as their hash, so reading the database does not reveal usable tokens.
+type tokenService struct {
+	length int
+}
+
+func newTokenService(length int) *tokenService {
+	return &tokenService{length: length}
+}
+
+// newToken returns a new random token and the hash it is stored with
+func (svc *tokenService) newToken() (token models.Token, tokenHash string, err error) {
+	randomToken, err := utils.GenerateSecureRandomString(svc.length)
+	if err != nil {
+		return
+	}
+	token = models.Token(randomToken)
+	return token, svc.hash(token), nil
+}
+
+func (svc *tokenService) hash(token models.Token) string {
+	return utils.HashToken(string(token))
+}
+
+// loadTokenSecret returns the configured secret for signing tokens in links or a random one only valid until the next restart
+func loadTokenSecret(cfg config.Config, logger utils.Logger) []byte {
+	if secret := cfg.GetTokenSigningSecret(); secret != "" {
+		return []byte(secret)
+	}
+
+	logger.Warn("No token signing secret configured - signed links become invalid on restart")
+	secret, err := utils.GenerateSecureRandomString(tokenSecretLength)
+	if err != nil {
+		logger.WithError(err).Error("Failed to generate secure token signing secret - fall back to insecure one")
+		secret = utils.GenerateRandomString(tokenSecretLength)
+	}
+	return []byte(secret)
+}
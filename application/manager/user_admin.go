@@ -0,0 +1,304 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+)
+
+const usersPageSize = 50
+
+// GetUsers returns a page of users, the next page starts after the last user of the previous one
+func (mgr *userManager) GetUsers(authCtx *authorization.Context, filter *models.UserFilter, sort *models.UserSort, after *models.UserID) (users []*models.User, fcerr *fcerror.Error) {
+	fcerr = authorization.Enforce(authCtx, authorization.PermissionReadUsers, nil)
+	if fcerr != nil {
+		return
+	}
+	if sort != nil && !sort.Field.IsValid() {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, fmt.Errorf("Users cannot be sorted by '%s'", sort.Field))
+		return
+	}
+
+	trans, fcerr := mgr.userPersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer trans.Close()
+
+	users, fcerr = trans.GetUsers(filter, sort, after, usersPageSize)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to get users")
+		return
+	}
+	for _, user := range users {
+		user.Password = ""
+	}
+	return
+}
+
+// DisableUser blocks the login of the user and ends all sessions, access tokens are rejected until the user is enabled again
+func (mgr *userManager) DisableUser(authCtx *authorization.Context, userID models.UserID) (user *models.User, fcerr *fcerror.Error) {
+	defer func() {
+		mgr.managers.Audit.Record(authCtx, withOutcome(&models.AuditEvent{
+			Action:     models.AuditActionUserDisable,
+			TargetType: models.AuditTargetTypeUser,
+			TargetID:   string(userID),
+		}, fcerr))
+	}()
+
+	user, fcerr = mgr.setUserDisabled(authCtx, userID, true)
+	if fcerr != nil {
+		return
+	}
+
+	revokeErr := mgr.managers.Auth.RevokeAllSessionsOfUser(authorization.NewSystem(), userID)
+	if revokeErr != nil {
+		// Sessions of disabled users are rejected anyway
+		mgr.logger.WithError(revokeErr).WithField("userID", userID).Error("Failed to delete sessions of disabled user - ignore for now")
+	}
+	return
+}
+
+func (mgr *userManager) EnableUser(authCtx *authorization.Context, userID models.UserID) (user *models.User, fcerr *fcerror.Error) {
+	defer func() {
+		mgr.managers.Audit.Record(authCtx, withOutcome(&models.AuditEvent{
+			Action:     models.AuditActionUserEnable,
+			TargetType: models.AuditTargetTypeUser,
+			TargetID:   string(userID),
+		}, fcerr))
+	}()
+
+	return mgr.setUserDisabled(authCtx, userID, false)
+}
+
+func (mgr *userManager) setUserDisabled(authCtx *authorization.Context, userID models.UserID, disabled bool) (user *models.User, fcerr *fcerror.Error) {
+	user, fcerr = mgr.getManagedUser(authCtx, authorization.PermissionDisableUsers, userID)
+	if fcerr != nil {
+		return
+	}
+	if user.Disabled != disabled {
+		user.Disabled = disabled
+		fcerr = mgr.saveUserUpdate(user, false)
+		if fcerr != nil {
+			return nil, fcerr
+		}
+	}
+	user.Password = ""
+	return
+}
+
+// DeleteUser deletes the user with all sessions, shares and files.
+// The files are moved into a folder of another user first if a user to transfer them to is given.
+func (mgr *userManager) DeleteUser(authCtx *authorization.Context, userID models.UserID, transferToUserID *models.UserID) (fcerr *fcerror.Error) {
+	details := ""
+	defer func() {
+		mgr.managers.Audit.Record(authCtx, withOutcome(&models.AuditEvent{
+			Action:     models.AuditActionUserDelete,
+			TargetType: models.AuditTargetTypeUser,
+			TargetID:   string(userID),
+			Details:    details,
+		}, fcerr))
+	}()
+
+	user, fcerr := mgr.getManagedUser(authCtx, authorization.PermissionDeleteUsers, userID)
+	if fcerr != nil {
+		return
+	}
+
+	if transferToUserID != nil {
+		details = "transfer_to=" + string(*transferToUserID)
+	}
+	return mgr.deleteUser(user, transferToUserID)
+}
+
+// DeleteMyAccount deletes the account of the user with all sessions, shares and files after confirming the password.
+// The last admin cannot delete their account, as nobody could manage the instance afterwards.
+func (mgr *userManager) DeleteMyAccount(authCtx *authorization.Context, password string) (fcerr *fcerror.Error) {
+	defer func() {
+		event := &models.AuditEvent{Action: models.AuditActionUserDelete, TargetType: models.AuditTargetTypeUser, Details: "self"}
+		if authCtx.User != nil {
+			event.TargetID = string(authCtx.User.ID)
+		}
+		mgr.managers.Audit.Record(authCtx, withOutcome(event, fcerr))
+	}()
+
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceNoAccessToken(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Only users can delete their account"))
+		return
+	}
+
+	user, fcerr := mgr.GetUserByID(authorization.NewSystem(), authCtx.User.ID)
+	if fcerr != nil {
+		return
+	}
+	_, err := mgr.passwordHashers.Validate(password, user.Password)
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrPasswordConfirmationFailed, err)
+		return
+	}
+
+	if user.IsAdmin {
+		fcerr = mgr.ensureOtherAdminExists(user.ID)
+		if fcerr != nil {
+			return
+		}
+	}
+
+	return mgr.deleteUser(user, nil)
+}
+
+func (mgr *userManager) ensureOtherAdminExists(userID models.UserID) (fcerr *fcerror.Error) {
+	trans, fcerr := mgr.userPersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer trans.Close()
+
+	adminRole := models.RoleAdmin
+	admins, fcerr := trans.GetUsers(&models.UserFilter{Role: &adminRole}, nil, nil, 2)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to get admins")
+		return
+	}
+	for _, admin := range admins {
+		if admin.ID != userID {
+			return nil
+		}
+	}
+	return fcerror.NewError(fcerror.ErrBadRequest, errors.New("The last admin cannot delete their account"))
+}
+
+// deleteUser deletes the user after moving the files to another user if one is given.
+// The user is disabled first, so nothing changes while the files are removed. It is only enabled again on failure
+// as long as the files are still there, otherwise it stays disabled without files and the deletion can be retried.
+func (mgr *userManager) deleteUser(user *models.User, transferToUserID *models.UserID) (fcerr *fcerror.Error) {
+	userID := user.ID
+	if transferToUserID != nil {
+		if *transferToUserID == userID {
+			fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Files cannot be transferred to the deleted user"))
+			return
+		}
+		_, fcerr = mgr.GetUserByID(authorization.NewSystem(), *transferToUserID)
+		if fcerr != nil {
+			return
+		}
+	}
+
+	wasDisabled := user.Disabled
+	filesRemoved := false
+	return runSaga(mgr.logger.WithField("userID", userID),
+		&sagaStep{
+			name:   "disable user",
+			action: func() *fcerror.Error { return mgr.updateUserDisabled(user, true) },
+			compensate: func() *fcerror.Error {
+				if filesRemoved {
+					return nil
+				}
+				return mgr.updateUserDisabled(user, wasDisabled)
+			},
+		},
+		&sagaStep{
+			name:   "remove files",
+			action: func() *fcerror.Error { return mgr.removeUserFiles(user, transferToUserID, &filesRemoved) },
+		},
+		&sagaStep{
+			name:   "delete user",
+			action: func() *fcerror.Error { return mgr.deleteUserNode(userID) },
+		},
+	)
+}
+
+func (mgr *userManager) updateUserDisabled(user *models.User, disabled bool) *fcerror.Error {
+	if user.Disabled == disabled {
+		return nil
+	}
+	user.Disabled = disabled
+	return mgr.saveUserUpdate(user, false)
+}
+
+// removeUserFiles transfers the files of the user if a user to transfer them to is given and deletes everything left
+func (mgr *userManager) removeUserFiles(user *models.User, transferToUserID *models.UserID, filesRemoved *bool) (fcerr *fcerror.Error) {
+	if transferToUserID != nil {
+		fcerr = mgr.managers.Node.TransferUserRootFolder(authorization.NewSystem(), user.ID, *transferToUserID, "Files of "+user.Email)
+		if fcerr != nil {
+			return
+		}
+		*filesRemoved = true
+	}
+
+	fcerr = mgr.managers.Node.DeleteUserRootFolder(authorization.NewSystem(), user.ID)
+	if fcerr != nil {
+		return
+	}
+	*filesRemoved = true
+
+	// A leftover avatar is not worth keeping the user for
+	if avatarErr := mgr.fileStorage.DeleteAvatars(user.ID); avatarErr != nil {
+		mgr.logger.WithError(avatarErr).WithField("userID", user.ID).Error("Failed to delete avatar of deleted user")
+	}
+	return
+}
+
+func (mgr *userManager) deleteUserNode(userID models.UserID) (fcerr *fcerror.Error) {
+	trans, fcerr := mgr.userPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = trans.DeleteUser(userID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", userID).Error("Failed to delete user")
+	}
+	return
+}
+
+// getManagedUser returns the user another user with the permission wants to manage.
+// Users cannot manage themselves this way and admins can only be managed by users allowed to manage roles.
+func (mgr *userManager) getManagedUser(authCtx *authorization.Context, permission authorization.Permission, userID models.UserID) (user *models.User, fcerr *fcerror.Error) {
+	fcerr = authorization.Enforce(authCtx, permission, nil)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User != nil && authCtx.User.ID == userID {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Users cannot disable or delete themselves"))
+		return
+	}
+
+	user, fcerr = mgr.GetUserByID(authorization.NewSystem(), userID)
+	if fcerr != nil {
+		return
+	}
+	if user.IsAdmin {
+		fcerr = authorization.Enforce(authCtx, authorization.PermissionManageRoles, nil)
+		if fcerr != nil {
+			return nil, fcerr
+		}
+	}
+	return
+}
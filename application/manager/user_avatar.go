@@ -0,0 +1,221 @@
+package manager
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	// Formats accepted for uploaded avatars
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+
+	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+)
+
+const (
+	// Larger images are rejected before decoding, as decoding them would need too much memory
+	maxAvatarDimension = 4096
+	defaultAvatarSize  = 128
+)
+
+// SetAvatar stores the uploaded image as avatar of the user, cropped to a square and resized to all avatar sizes
+func (mgr *userManager) SetAvatar(authCtx *authorization.Context, userID models.UserID, content io.Reader) (user *models.User, fcerr *fcerror.Error) {
+	defer func() {
+		mgr.managers.Audit.Record(authCtx, withOutcome(&models.AuditEvent{
+			Action:     models.AuditActionUserUpdate,
+			TargetType: models.AuditTargetTypeUser,
+			TargetID:   string(userID),
+			Details:    "avatar",
+		}, fcerr))
+	}()
+
+	user, fcerr = mgr.getAvatarUser(authCtx, userID)
+	if fcerr != nil {
+		return
+	}
+
+	maxSize := mgr.cfg.GetAvatarMaxSize()
+	data, err := ioutil.ReadAll(io.LimitReader(content, maxSize+1))
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrOpenUploadFile, err)
+		return
+	}
+	if int64(len(data)) > maxSize {
+		fcerr = fcerror.NewError(fcerror.ErrAvatarTooLarge, nil)
+		return
+	}
+	img, fcerr := decodeAvatar(data)
+	if fcerr != nil {
+		return
+	}
+
+	// Every size is scaled down from the next larger one, so the uploaded image is only scanned once
+	for it := len(models.AvatarSizes) - 1; it >= 0; it-- {
+		size := models.AvatarSizes[it]
+		img = resizeAvatar(img, size)
+
+		buf := &bytes.Buffer{}
+		err = png.Encode(buf, img)
+		if err != nil {
+			fcerr = fcerror.NewError(fcerror.ErrInternalServerError, err)
+			mgr.logger.WithError(err).WithField("userID", userID).Error("Failed to encode avatar")
+			return
+		}
+		fcerr = mgr.fileStorage.SaveAvatar(userID, size, buf)
+		if fcerr != nil {
+			mgr.logger.WithError(fcerr).WithField("userID", userID).Error("Failed to save avatar")
+			return
+		}
+	}
+
+	if !user.HasAvatar {
+		user.HasAvatar = true
+		fcerr = mgr.saveUserUpdate(user, false)
+		if fcerr != nil {
+			return
+		}
+	}
+	user.Password = ""
+	return
+}
+
+// DeleteAvatar removes the avatar of the user in all sizes
+func (mgr *userManager) DeleteAvatar(authCtx *authorization.Context, userID models.UserID) (user *models.User, fcerr *fcerror.Error) {
+	defer func() {
+		mgr.managers.Audit.Record(authCtx, withOutcome(&models.AuditEvent{
+			Action:     models.AuditActionUserUpdate,
+			TargetType: models.AuditTargetTypeUser,
+			TargetID:   string(userID),
+			Details:    "avatar",
+		}, fcerr))
+	}()
+
+	user, fcerr = mgr.getAvatarUser(authCtx, userID)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = mgr.fileStorage.DeleteAvatars(userID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", userID).Error("Failed to delete avatar")
+		return
+	}
+
+	if user.HasAvatar {
+		user.HasAvatar = false
+		fcerr = mgr.saveUserUpdate(user, false)
+		if fcerr != nil {
+			return
+		}
+	}
+	user.Password = ""
+	return
+}
+
+// GetAvatar returns the PNG of the avatar in the smallest stored size not smaller than the requested one.
+// Avatars of all users are visible to every authenticated user, e.g. to choose whom to share with.
+func (mgr *userManager) GetAvatar(authCtx *authorization.Context, userID models.UserID, size int) (reader io.ReadCloser, length int64, fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	return mgr.fileStorage.OpenAvatar(userID, getAvatarSize(size))
+}
+
+func (mgr *userManager) getAvatarUser(authCtx *authorization.Context, userID models.UserID) (user *models.User, fcerr *fcerror.Error) {
+	fcerr = authorization.Enforce(authCtx, authorization.PermissionUpdateUsers, authorization.UserResource(userID))
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	return mgr.GetUserByID(authorization.NewSystem(), userID)
+}
+
+// getAvatarSize returns the smallest stored avatar size fitting the requested one, the default size if none is requested
+func getAvatarSize(requested int) int {
+	if requested <= 0 {
+		return defaultAvatarSize
+	}
+	for _, size := range models.AvatarSizes {
+		if size >= requested {
+			return size
+		}
+	}
+	return models.AvatarSizes[len(models.AvatarSizes)-1]
+}
+
+func decodeAvatar(data []byte) (img image.Image, fcerr *fcerror.Error) {
+	imgCfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fcerror.NewError(fcerror.ErrAvatarInvalid, err)
+	}
+	if imgCfg.Width <= 0 || imgCfg.Height <= 0 || imgCfg.Width > maxAvatarDimension || imgCfg.Height > maxAvatarDimension {
+		return nil, fcerror.NewError(fcerror.ErrAvatarInvalid, errors.New("Avatar has invalid dimensions"))
+	}
+
+	img, _, err = image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fcerror.NewError(fcerror.ErrAvatarInvalid, err)
+	}
+	return img, nil
+}
+
+// resizeAvatar crops the centered square of the image and scales it to the size.
+// Every target pixel is the average of the source pixels it covers, smaller images are scaled up by repeating pixels.
+func resizeAvatar(src image.Image, size int) *image.RGBA {
+	bounds := src.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	offsetX := bounds.Min.X + (bounds.Dx()-side)/2
+	offsetY := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for dstY := 0; dstY < size; dstY++ {
+		srcY0, srcY1 := getAvatarSourceRange(dstY, size, side)
+		for dstX := 0; dstX < size; dstX++ {
+			srcX0, srcX1 := getAvatarSourceRange(dstX, size, side)
+
+			var r, g, b, a, count uint64
+			for srcY := srcY0; srcY < srcY1; srcY++ {
+				for srcX := srcX0; srcX < srcX1; srcX++ {
+					pr, pg, pb, pa := src.At(offsetX+srcX, offsetY+srcY).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					b += uint64(pb)
+					a += uint64(pa)
+					count++
+				}
+			}
+			dst.SetRGBA(dstX, dstY, color.RGBA{
+				R: uint8(r / count >> 8),
+				G: uint8(g / count >> 8),
+				B: uint8(b / count >> 8),
+				A: uint8(a / count >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+// getAvatarSourceRange returns the source pixels covered by the target pixel, at least one
+func getAvatarSourceRange(dst, size, side int) (start, end int) {
+	start = dst * side / size
+	end = (dst + 1) * side / size
+	if end <= start {
+		end = start + 1
+	}
+	return
+}
@@ -0,0 +1,77 @@
+package manager
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/freecloudio/server/domain/models/fcerror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResizeAvatar(t *testing.T) {
+	// Wide image with a red left and a blue right half around a green center square
+	src := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			switch {
+			case x < 10:
+				src.SetRGBA(x, y, color.RGBA{R: 255, A: 255})
+			case x >= 30:
+				src.SetRGBA(x, y, color.RGBA{B: 255, A: 255})
+			default:
+				src.SetRGBA(x, y, color.RGBA{G: 255, A: 255})
+			}
+		}
+	}
+
+	downscaled := resizeAvatar(src, 10)
+	assert.Equal(t, image.Rect(0, 0, 10, 10), downscaled.Bounds(), "Wrong size of downscaled avatar")
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			require.Equal(t, color.RGBA{G: 255, A: 255}, downscaled.RGBAAt(x, y), "Avatar is not cropped to the centered square")
+		}
+	}
+
+	upscaled := resizeAvatar(src, 64)
+	assert.Equal(t, image.Rect(0, 0, 64, 64), upscaled.Bounds(), "Wrong size of upscaled avatar")
+	assert.Equal(t, color.RGBA{G: 255, A: 255}, upscaled.RGBAAt(63, 63), "Wrong color of upscaled avatar")
+
+	// Averaging two pixels of different colors
+	stripes := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	stripes.SetRGBA(0, 0, color.RGBA{R: 255, A: 255})
+	stripes.SetRGBA(0, 1, color.RGBA{R: 255, A: 255})
+	stripes.SetRGBA(1, 0, color.RGBA{A: 255})
+	stripes.SetRGBA(1, 1, color.RGBA{A: 255})
+	assert.Equal(t, color.RGBA{R: 127, A: 255}, resizeAvatar(stripes, 1).RGBAAt(0, 0), "Pixels are not averaged")
+}
+
+func TestDecodeAvatar(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.Nil(t, png.Encode(buf, image.NewRGBA(image.Rect(0, 0, 3, 2))), "Failed to encode image")
+	img, fcerr := decodeAvatar(buf.Bytes())
+	require.Nil(t, fcerr, "Failed to decode valid avatar")
+	assert.Equal(t, image.Rect(0, 0, 3, 2), img.Bounds(), "Wrong bounds of decoded avatar")
+
+	_, fcerr = decodeAvatar([]byte("no image"))
+	require.NotNil(t, fcerr, "Invalid avatar decoded")
+	assert.Equal(t, fcerror.ErrAvatarInvalid, fcerr.ID, "Wrong error for invalid avatar")
+
+	buf.Reset()
+	require.Nil(t, png.Encode(buf, image.NewGray(image.Rect(0, 0, maxAvatarDimension+1, 1))), "Failed to encode image")
+	_, fcerr = decodeAvatar(buf.Bytes())
+	require.NotNil(t, fcerr, "Too large avatar decoded")
+	assert.Equal(t, fcerror.ErrAvatarInvalid, fcerr.ID, "Wrong error for too large avatar")
+}
+
+func TestGetAvatarSize(t *testing.T) {
+	assert.Equal(t, defaultAvatarSize, getAvatarSize(0), "Wrong default size")
+	assert.Equal(t, 32, getAvatarSize(10), "Wrong size for small request")
+	assert.Equal(t, 64, getAvatarSize(64), "Wrong size for exact request")
+	assert.Equal(t, 128, getAvatarSize(100), "Wrong size for request between sizes")
+	assert.Equal(t, 256, getAvatarSize(1000), "Wrong size for large request")
+}
@@ -1,29 +1,50 @@
 package manager
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
 	"github.com/freecloudio/server/application/authorization"
 	"github.com/freecloudio/server/application/config"
 	"github.com/freecloudio/server/application/persistence"
+	"github.com/freecloudio/server/application/storage"
 	"github.com/freecloudio/server/domain/models"
 	"github.com/freecloudio/server/domain/models/fcerror"
 	"github.com/freecloudio/server/utils"
-
-	"github.com/sirupsen/logrus"
 )
 
 type UserManager interface {
-	CreateUser(user *models.User) (*models.Session, *fcerror.Error)
+	CreateUser(user *models.User, inviteCode string) (*models.Session, *fcerror.Error)
+	CreateUserAsAdmin(authCtx *authorization.Context, user *models.User) (*models.User, *fcerror.Error)
+	ProvisionExternalUser(externalUser *models.ExternalUser) (*models.User, *fcerror.Error)
+	SyncExternalUser(externalUser *models.ExternalUser) (*models.User, *fcerror.Error)
 	GetUserByID(authCtx *authorization.Context, userID models.UserID) (*models.User, *fcerror.Error)
 	GetUserByEmail(authCtx *authorization.Context, email string) (*models.User, *fcerror.Error)
 	UpdateUser(authCtx *authorization.Context, userID models.UserID, updateUser *models.UserUpdate) (*models.User, *fcerror.Error)
+	SetUserRoles(authCtx *authorization.Context, userID models.UserID, roles []models.Role) (*models.User, *fcerror.Error)
 	CountUsers(authCtx *authorization.Context) (int64, *fcerror.Error)
+	GetUsers(authCtx *authorization.Context, filter *models.UserFilter, sort *models.UserSort, after *models.UserID) ([]*models.User, *fcerror.Error)
+	DisableUser(authCtx *authorization.Context, userID models.UserID) (*models.User, *fcerror.Error)
+	EnableUser(authCtx *authorization.Context, userID models.UserID) (*models.User, *fcerror.Error)
+	DeleteUser(authCtx *authorization.Context, userID models.UserID, transferToUserID *models.UserID) *fcerror.Error
+	DeleteMyAccount(authCtx *authorization.Context, password string) *fcerror.Error
+	SetAvatar(authCtx *authorization.Context, userID models.UserID, content io.Reader) (*models.User, *fcerror.Error)
+	DeleteAvatar(authCtx *authorization.Context, userID models.UserID) (*models.User, *fcerror.Error)
+	GetAvatar(authCtx *authorization.Context, userID models.UserID, size int) (io.ReadCloser, int64, *fcerror.Error)
+	CreateInvite(authCtx *authorization.Context, invite *models.Invite) (string, *fcerror.Error)
+	GetInvites(authCtx *authorization.Context) ([]*models.Invite, *fcerror.Error)
+	RevokeInvite(authCtx *authorization.Context, inviteID models.InviteID) *fcerror.Error
 	Close()
 }
 
-func NewUserManager(cfg config.Config, userPersistence persistence.UserPersistenceController, managers *Managers) UserManager {
+func NewUserManager(cfg config.Config, userPersistence persistence.UserPersistenceController, fileStorage storage.FileStorageController, passwordHashers *utils.PasswordHashers, managers *Managers) UserManager {
 	userMgr := &userManager{
 		cfg:             cfg,
 		userPersistence: userPersistence,
+		fileStorage:     fileStorage,
+		passwordHashers: passwordHashers,
 		managers:        managers,
 		logger:          utils.CreateLogger(cfg.GetLoggingConfig()),
 	}
@@ -32,9 +53,14 @@ func NewUserManager(cfg config.Config, userPersistence persistence.UserPersisten
 	return userMgr
 }
 
+// Length of the random password of users provisioned by an external identity source
+const externalUserPasswordLength = 64
+
 type userManager struct {
 	cfg             config.Config
 	userPersistence persistence.UserPersistenceController
+	fileStorage     storage.FileStorageController
+	passwordHashers *utils.PasswordHashers
 	managers        *Managers
 	logger          utils.Logger
 }
@@ -44,71 +70,268 @@ var _ UserManager = &userManager{}
 func (mgr *userManager) Close() {
 }
 
-func (mgr *userManager) CreateUser(user *models.User) (session *models.Session, fcerr *fcerror.Error) {
-	// TODO: Input Validation
+// CreateUser registers a new user as allowed by the registration mode, the invite code may be empty
+func (mgr *userManager) CreateUser(user *models.User, inviteCode string) (session *models.Session, fcerr *fcerror.Error) {
+	fcerr = mgr.normalizeUserInput(user)
+	if fcerr != nil {
+		return
+	}
+
+	invite, fcerr := mgr.checkRegistration(user.Email, inviteCode)
+	if fcerr != nil {
+		return
+	}
+
+	user.EmailVerified = false
+	user.IsAdmin = false
+	user.AssignedRoles = nil
+	user.Quota = 0
+	if invite != nil {
+		user.IsAdmin = invite.IsAdmin
+		user.Quota = invite.Quota
+	}
+	fcerr = mgr.createUser(user, invite)
+	if fcerr != nil {
+		return
+	}
+	mgr.sendEmailVerification(user)
+
+	// TODO: Remove once REST API is gone
+	return mgr.managers.Auth.CreateNewSession(user.ID, false, nil)
+}
+
+// CreateUserAsAdmin creates a user regardless of the registration mode
+func (mgr *userManager) CreateUserAsAdmin(authCtx *authorization.Context, user *models.User) (createdUser *models.User, fcerr *fcerror.Error) {
+	fcerr = authorization.Enforce(authCtx, authorization.PermissionCreateUsers, nil)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if user.IsAdmin {
+		fcerr = authorization.Enforce(authCtx, authorization.PermissionManageRoles, nil)
+		if fcerr != nil {
+			return
+		}
+	}
+	if user.Quota < 0 {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Quota of a user cannot be negative"))
+		return
+	}
+	fcerr = mgr.normalizeUserInput(user)
+	if fcerr != nil {
+		return
+	}
+
+	user.EmailVerified = false
+	user.AssignedRoles = nil
+	fcerr = mgr.createUser(user, nil)
+	if fcerr != nil {
+		return
+	}
+	mgr.sendEmailVerification(user)
+	mgr.logger.WithField("userID", user.ID).Info("Created user as admin")
+
+	user.Password = ""
+	return user, nil
+}
+
+// ProvisionExternalUser returns the user with the email of a user authenticated by an external identity source.
+// If no such user exists yet, it is created just in time with an unusable random password and linked to the source.
+func (mgr *userManager) ProvisionExternalUser(externalUser *models.ExternalUser) (user *models.User, fcerr *fcerror.Error) {
+	externalUser.Email = normalizeEmail(externalUser.Email)
+	user, fcerr = mgr.SyncExternalUser(externalUser)
+	if fcerr == nil || fcerr.ID != fcerror.ErrUserNotFound {
+		return
+	}
+
+	password, err := utils.GenerateSecureRandomString(externalUserPasswordLength)
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrInternalServerError, err)
+		mgr.logger.WithError(err).Error("Failed to generate password for external user")
+		return
+	}
+	user = &models.User{
+		Email:     externalUser.Email,
+		FirstName: externalUser.FirstName,
+		LastName:  externalUser.LastName,
+		Password:  password,
+		// The external identity source is trusted to own the email
+		EmailVerified: true,
+		External:      true,
+	}
+	fcerr = mgr.createUser(user, nil)
+	if fcerr != nil {
+		return
+	}
+	mgr.logger.WithField("userID", user.ID).Info("Provisioned user from external identity source")
+
+	if externalUser.IsAdmin != nil && *externalUser.IsAdmin != user.IsAdmin {
+		user, fcerr = mgr.UpdateUser(authorization.NewSystem(), user.ID, &models.UserUpdate{IsAdmin: externalUser.IsAdmin})
+		if fcerr != nil {
+			return
+		}
+	}
+
+	user.Password = ""
+	return
+}
+
+// SyncExternalUser updates the names, admin flag and email verification of an existing user linked to an external identity source.
+// Local users are never matched, as the identity source could otherwise take them over; they have to link their account first.
+func (mgr *userManager) SyncExternalUser(externalUser *models.ExternalUser) (user *models.User, fcerr *fcerror.Error) {
+	trans, fcerr := mgr.userPersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	user, fcerr = trans.GetUserByEmail(normalizeEmail(externalUser.Email))
+	trans.Close()
+	if fcerr != nil {
+		if fcerr.ID != fcerror.ErrUserNotFound {
+			mgr.logger.WithError(fcerr).Error("Failed to get user for external sync")
+		}
+		return
+	}
+	if !user.External {
+		mgr.logger.WithField("userID", user.ID).Info("External user matches a local user which is not linked")
+		return nil, fcerror.NewError(fcerror.ErrExternalUserNotLinked, nil)
+	}
+
+	userUpdate := &models.UserUpdate{}
+	changed := false
+	if externalUser.FirstName != "" && externalUser.FirstName != user.FirstName {
+		userUpdate.FirstName = &externalUser.FirstName
+		changed = true
+	}
+	if externalUser.LastName != "" && externalUser.LastName != user.LastName {
+		userUpdate.LastName = &externalUser.LastName
+		changed = true
+	}
+	if externalUser.IsAdmin != nil && *externalUser.IsAdmin != user.IsAdmin {
+		userUpdate.IsAdmin = externalUser.IsAdmin
+		changed = true
+	}
+	if !user.EmailVerified {
+		verified := true
+		userUpdate.EmailVerified = &verified
+		changed = true
+	}
+
+	if changed {
+		user, fcerr = mgr.UpdateUser(authorization.NewSystem(), user.ID, userUpdate)
+		if fcerr != nil {
+			return
+		}
+	}
 
+	user.Password = ""
+	return
+}
+
+// createUser provisions the user with its root folder. Either all of it is created or nothing is left behind,
+// the first user of an installation is made an admin within the same transaction as the user is saved.
+func (mgr *userManager) createUser(user *models.User, invite *models.Invite) (fcerr *fcerror.Error) {
+	var err error
+	user.Password, err = mgr.passwordHashers.Hash(user.Password)
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrPasswordHashingFailed, err)
+		mgr.logger.WithError(fcerr).Error("Failed to hash new user password")
+		return
+	}
+
+	return runSaga(mgr.logger.WithField("email", user.Email),
+		&sagaStep{
+			name:       "save user",
+			action:     func() *fcerror.Error { return mgr.saveNewUser(user, invite) },
+			compensate: func() *fcerror.Error { return mgr.removeNewUser(user, invite) },
+		},
+		&sagaStep{
+			name: "create root folder",
+			action: func() *fcerror.Error {
+				return mgr.managers.Node.CreateUserRootFolder(authorization.NewSystem(), user.ID)
+			},
+			compensate: func() *fcerror.Error {
+				return mgr.managers.Node.DeleteUserRootFolder(authorization.NewSystem(), user.ID)
+			},
+		},
+	)
+}
+
+// saveNewUser saves the user and consumes the invite in one transaction
+func (mgr *userManager) saveNewUser(user *models.User, invite *models.Invite) (fcerr *fcerror.Error) {
 	trans, fcerr := mgr.userPersistence.StartReadWriteTransaction()
 	if fcerr != nil {
 		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
 		return
 	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
 
 	existingUser, fcerr := trans.GetUserByEmail(user.Email)
 	if fcerr != nil && fcerr.ID != fcerror.ErrUserNotFound {
 		mgr.logger.WithError(fcerr).Error("Could not verify if user with this email already exists")
-		trans.Rollback()
 		return
 	} else if fcerr == nil && existingUser != nil {
 		fcerr = fcerror.NewError(fcerror.ErrEmailAlreadyRegistered, nil)
-		trans.Rollback()
 		return
 	}
 
-	var err error
-	user.Password, err = utils.HashScrypt(user.Password)
-	if err != nil {
-		fcerr = fcerror.NewError(fcerror.ErrPasswordHashingFailed, err)
-		mgr.logger.WithError(fcerr).Error("Failed to hash new user password")
+	if invite != nil {
+		fcerr = trans.DeleteInvite(invite.ID)
+		if fcerr != nil {
+			// Used by a concurrent registration in the meantime
+			if fcerr.ID == fcerror.ErrInviteNotFound {
+				fcerr = fcerror.NewError(fcerror.ErrInviteInvalid, nil)
+			} else {
+				mgr.logger.WithError(fcerr).Error("Failed to consume invite")
+			}
+			return
+		}
+	}
+
+	count, fcerr := trans.CountUsers()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to count users to determine whether created user should be an admin")
 		return
 	}
-	user.IsAdmin = false
+	if count == 0 {
+		user.IsAdmin = true
+	}
 
 	fcerr = trans.SaveUser(user)
 	if fcerr != nil {
 		mgr.logger.WithError(fcerr).Error("Failed to save user")
-		trans.Rollback()
 		return
 	}
+	return
+}
 
-	fcerr = trans.Commit()
+// removeNewUser deletes the user saved by saveNewUser again and restores the consumed invite
+func (mgr *userManager) removeNewUser(user *models.User, invite *models.Invite) (fcerr *fcerror.Error) {
+	trans, fcerr := mgr.userPersistence.StartReadWriteTransaction()
 	if fcerr != nil {
-		mgr.logger.WithError(fcerr).Error("Failed to commit transaction")
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
 		return
 	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
 
-	fcerr = mgr.managers.Node.CreateUserRootFolder(authorization.NewSystem(), user.ID)
+	fcerr = trans.DeleteUser(user.ID)
 	if fcerr != nil {
-		// TODO: Delete user
+		mgr.logger.WithError(fcerr).WithField("userID", user.ID).Error("Failed to delete user")
 		return
 	}
 
-	count, fcerr := mgr.CountUsers(authorization.NewSystem())
-	if fcerr == nil && count == 1 {
-		isAdmin := true
-		_, fcerr = mgr.UpdateUser(authorization.NewSystem(), user.ID, &models.UserUpdate{IsAdmin: &isAdmin})
-		if fcerr == nil {
-			user.IsAdmin = true
-		} else {
-			mgr.logger.WithError(fcerr).Error("Failed to set first user an admin - ignore for now")
-			fcerr = nil
+	if invite != nil {
+		fcerr = trans.SaveInvite(invite)
+		if fcerr != nil {
+			mgr.logger.WithError(fcerr).WithField("inviteID", invite.ID).Error("Failed to restore invite")
+			return
 		}
-	} else if fcerr != nil {
-		mgr.logger.WithError(fcerr).Error("Failed to count users to determine whether created user should be an admin - ignore for now")
-		fcerr = nil
 	}
-
-	// TODO: Remove once REST API is gone
-	return mgr.managers.Auth.CreateNewSession(user.ID)
+	return
 }
 
 func (mgr *userManager) GetUserByID(authCtx *authorization.Context, userID models.UserID) (user *models.User, fcerr *fcerror.Error) {
@@ -144,6 +367,7 @@ func (mgr *userManager) GetUserByEmail(authCtx *authorization.Context, email str
 	}
 	defer trans.Close()
 
+	email = normalizeEmail(email)
 	user, fcerr = trans.GetUserByEmail(email)
 	if fcerr != nil {
 		mgr.logger.WithError(fcerr).WithField("email", email).Warn("User with given email not found for login")
@@ -151,7 +375,7 @@ func (mgr *userManager) GetUserByEmail(authCtx *authorization.Context, email str
 		return
 	}
 
-	fcerr = authorization.EnforceSelf(authCtx, user.ID)
+	fcerr = authorization.Enforce(authCtx, authorization.PermissionReadUsers, authorization.UserResource(user.ID))
 	if fcerr != nil {
 		return
 	}
@@ -164,9 +388,21 @@ func (mgr *userManager) GetUserByEmail(authCtx *authorization.Context, email str
 }
 
 func (mgr *userManager) UpdateUser(authCtx *authorization.Context, userID models.UserID, updateUser *models.UserUpdate) (user *models.User, fcerr *fcerror.Error) {
-	// TODO: Input Validation
+	changedFields := []string{}
+	adminChanged := false
+	defer func() { mgr.auditUserUpdate(authCtx, user, userID, changedFields, adminChanged, fcerr) }()
 
-	fcerr = authorization.EnforceSelf(authCtx, userID)
+	fcerr = authorization.Enforce(authCtx, authorization.PermissionUpdateUsers, authorization.UserResource(userID))
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = mgr.normalizeUserUpdate(updateUser, authCtx.Type != authorization.ContextTypeSystem)
 	if fcerr != nil {
 		return
 	}
@@ -175,29 +411,163 @@ func (mgr *userManager) UpdateUser(authCtx *authorization.Context, userID models
 	if fcerr != nil {
 		return
 	}
+	// Taking over users with roles through their email or password would circumvent the role management
+	if len(user.Roles()) > 0 && (authCtx.User == nil || authCtx.User.ID != userID) {
+		fcerr = authorization.Enforce(authCtx, authorization.PermissionManageRoles, nil)
+		if fcerr != nil {
+			return
+		}
+	}
 
 	if updateUser.FirstName != nil {
 		user.FirstName = *updateUser.FirstName
+		changedFields = append(changedFields, "first_name")
 	}
 	if updateUser.LastName != nil {
 		user.LastName = *updateUser.LastName
+		changedFields = append(changedFields, "last_name")
+	}
+	if updateUser.DisplayName != nil {
+		user.DisplayName = *updateUser.DisplayName
+		changedFields = append(changedFields, "display_name")
 	}
-	if updateUser.Email != nil {
+	if updateUser.Locale != nil {
+		user.Locale = *updateUser.Locale
+		changedFields = append(changedFields, "locale")
+	}
+	if updateUser.Timezone != nil {
+		user.Timezone = *updateUser.Timezone
+		changedFields = append(changedFields, "timezone")
+	}
+	emailChanged := false
+	if updateUser.Email != nil && *updateUser.Email != user.Email {
 		user.Email = *updateUser.Email
+		user.EmailVerified = false
+		emailChanged = true
+		changedFields = append(changedFields, "email")
 	}
 	if updateUser.Password != nil {
-		hashedPassword, err := utils.HashScrypt(*updateUser.Password)
+		hashedPassword, err := mgr.passwordHashers.Hash(*updateUser.Password)
 		if err != nil {
 			fcerr = fcerror.NewError(fcerror.ErrPasswordHashingFailed, err)
 			mgr.logger.WithError(err).WithField("userID", userID).Error("Failed to hash password for UpdateUser")
 			return
 		}
 		user.Password = hashedPassword
+		changedFields = append(changedFields, "password")
 	}
-	if updateUser.IsAdmin != nil && authorization.EnforceAdmin(authCtx) == nil {
+	if updateUser.IsAdmin != nil && authorization.HasPermission(authCtx, authorization.PermissionManageRoles) {
+		adminChanged = user.IsAdmin != *updateUser.IsAdmin
 		user.IsAdmin = *updateUser.IsAdmin
 	}
+	// Linking hands the admin flag over to the external identity source
+	if updateUser.External != nil && authorization.HasPermission(authCtx, authorization.PermissionManageRoles) {
+		user.External = *updateUser.External
+		changedFields = append(changedFields, "external")
+	}
+	// Only verified by the system after a mailed token was used or by a trusted identity source
+	if updateUser.EmailVerified != nil && authCtx.Type == authorization.ContextTypeSystem {
+		user.EmailVerified = *updateUser.EmailVerified
+		changedFields = append(changedFields, "email_verified")
+	}
 
+	fcerr = mgr.saveUserUpdate(user, emailChanged)
+	if fcerr != nil {
+		return
+	}
+
+	if emailChanged {
+		mgr.sendEmailVerification(user)
+	}
+	return
+}
+
+// auditUserUpdate records the update and a change of the admin flag separately, as the latter changes the roles of the user
+func (mgr *userManager) auditUserUpdate(authCtx *authorization.Context, user *models.User, userID models.UserID, changedFields []string, adminChanged bool, fcerr *fcerror.Error) {
+	if len(changedFields) > 0 || fcerr != nil {
+		mgr.managers.Audit.Record(authCtx, withOutcome(&models.AuditEvent{
+			Action:     models.AuditActionUserUpdate,
+			TargetType: models.AuditTargetTypeUser,
+			TargetID:   string(userID),
+			Details:    strings.Join(changedFields, ","),
+		}, fcerr))
+	}
+	if adminChanged {
+		mgr.managers.Audit.Record(authCtx, withOutcome(&models.AuditEvent{
+			Action:     models.AuditActionRoleChange,
+			TargetType: models.AuditTargetTypeUser,
+			TargetID:   string(userID),
+			Details:    fmt.Sprintf("is_admin=%t", user.IsAdmin),
+		}, fcerr))
+	}
+}
+
+// SetUserRoles replaces all roles of the user, the admin role is stored as the admin flag
+func (mgr *userManager) SetUserRoles(authCtx *authorization.Context, userID models.UserID, roles []models.Role) (user *models.User, fcerr *fcerror.Error) {
+	defer func() {
+		roleNames := make([]string, len(roles))
+		for it, role := range roles {
+			roleNames[it] = string(role)
+		}
+		mgr.managers.Audit.Record(authCtx, withOutcome(&models.AuditEvent{
+			Action:     models.AuditActionRoleChange,
+			TargetType: models.AuditTargetTypeUser,
+			TargetID:   string(userID),
+			Details:    "roles=" + strings.Join(roleNames, ","),
+		}, fcerr))
+	}()
+
+	fcerr = authorization.Enforce(authCtx, authorization.PermissionManageRoles, nil)
+	if fcerr != nil {
+		return
+	}
+
+	isAdmin := false
+	assignedRoles := []models.Role{}
+	for _, role := range roles {
+		if !role.IsValid() {
+			fcerr = fcerror.NewError(fcerror.ErrBadRequest, fmt.Errorf("Unknown role '%s'", role))
+			return
+		}
+		if role == models.RoleAdmin {
+			isAdmin = true
+		} else if !containsRole(assignedRoles, role) {
+			assignedRoles = append(assignedRoles, role)
+		}
+	}
+	// Admins could otherwise lock everyone out of the role management
+	if !isAdmin && authCtx.User != nil && authCtx.User.ID == userID {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Admins cannot remove their own admin role"))
+		return
+	}
+
+	user, fcerr = mgr.GetUserByID(authCtx, userID)
+	if fcerr != nil {
+		return
+	}
+	user.IsAdmin = isAdmin
+	user.AssignedRoles = assignedRoles
+
+	fcerr = mgr.saveUserUpdate(user, false)
+	if fcerr != nil {
+		return
+	}
+
+	mgr.logger.WithField("userID", userID).WithField("roles", user.Roles()).Info("Changed roles of user")
+	return
+}
+
+func containsRole(roles []models.Role, role models.Role) bool {
+	for _, existingRole := range roles {
+		if existingRole == role {
+			return true
+		}
+	}
+	return false
+}
+
+// saveUserUpdate persists the changed user, a changed email is checked not to be registered already in the same transaction
+func (mgr *userManager) saveUserUpdate(user *models.User, emailChanged bool) (fcerr *fcerror.Error) {
 	trans, fcerr := mgr.userPersistence.StartReadWriteTransaction()
 	if fcerr != nil {
 		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
@@ -205,17 +575,35 @@ func (mgr *userManager) UpdateUser(authCtx *authorization.Context, userID models
 	}
 	defer func() { fcerr = trans.Finish(fcerr) }()
 
+	if emailChanged {
+		existingUser, lookupErr := trans.GetUserByEmail(user.Email)
+		if lookupErr == nil && existingUser.ID != user.ID {
+			fcerr = fcerror.NewError(fcerror.ErrEmailAlreadyRegistered, nil)
+			return
+		} else if lookupErr != nil && lookupErr.ID != fcerror.ErrUserNotFound {
+			fcerr = lookupErr
+			mgr.logger.WithError(fcerr).Error("Could not verify if user with this email already exists")
+			return
+		}
+	}
+
 	fcerr = trans.UpdateUser(user)
 	if fcerr != nil {
-		mgr.logger.WithError(fcerr).WithFields(logrus.Fields{"userID": userID, "updateUser": updateUser}).Error("Failed to update user")
-		return
+		mgr.logger.WithError(fcerr).WithField("userID", user.ID).Error("Failed to update user")
 	}
-
 	return
 }
 
+// sendEmailVerification mails a verification link to a new or changed email, failures do not fail the calling use case
+func (mgr *userManager) sendEmailVerification(user *models.User) {
+	fcerr := mgr.managers.Auth.RequestEmailVerification(user.Email)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", user.ID).Error("Failed to send email verification - ignore for now")
+	}
+}
+
 func (mgr *userManager) CountUsers(authCtx *authorization.Context) (count int64, fcerr *fcerror.Error) {
-	fcerr = authorization.EnforceAdmin(authCtx)
+	fcerr = authorization.Enforce(authCtx, authorization.PermissionReadUsers, nil)
 	if fcerr != nil {
 		return
 	}
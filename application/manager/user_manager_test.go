@@ -0,0 +1,788 @@
+package manager_test
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/application/manager"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/mock"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//go:generate mockgen -destination ../../mock/persistence.go -package mock github.com/freecloudio/server/application/persistence UserPersistenceController,UserPersistenceReadWriteTransaction,NodePersistenceController,NodePersistenceReadWriteTransaction,AuthPersistenceController,AuthPersistenceReadWriteTransaction,SharePersistenceController,SharePersistenceReadWriteTransaction,GroupPersistenceController,GroupPersistenceReadWriteTransaction,FileDropPersistenceController,FileDropPersistenceReadWriteTransaction
+//go:generate mockgen -destination ../../mock/storage.go -package mock github.com/freecloudio/server/application/storage FileStorageController
+
+const (
+	testEmail  = "new@example.com"
+	testUserID = models.UserID("new-user")
+)
+
+// provisioningMocks holds the mocks of all steps of the user provisioning
+type provisioningMocks struct {
+	cfg             *mock.MockConfig
+	userPersistence *mock.MockUserPersistenceController
+	saveTrans       *mock.MockUserPersistenceReadWriteTransaction
+	removeTrans     *mock.MockUserPersistenceReadWriteTransaction
+	nodePersistence *mock.MockNodePersistenceController
+	nodeTrans       *mock.MockNodePersistenceReadWriteTransaction
+	fileStorage     *mock.MockFileStorageController
+	authMgr         *mock.MockAuthManager
+	auditMgr        *mock.MockAuditManager
+	hashers         *utils.PasswordHashers
+	userMgr         manager.UserManager
+}
+
+// provisioningFailures are the errors injected into the steps of the user provisioning, nil means the step succeeds
+type provisioningFailures struct {
+	lookupErr        *fcerror.Error
+	consumeInviteErr *fcerror.Error
+	countErr         *fcerror.Error
+	saveErr          *fcerror.Error
+	commitErr        *fcerror.Error
+	nodeErr          *fcerror.Error
+	storageErr       *fcerror.Error
+	nodeCommitErr    *fcerror.Error
+	removeErr        *fcerror.Error
+}
+
+func createProvisioningMocks(t *testing.T, mockCtrl *gomock.Controller) *provisioningMocks {
+	hashers, err := utils.NewPasswordHashers(&utils.PasswordHashingConfig{
+		Algorithm:       utils.Argon2idAlgorithm,
+		Argon2idTime:    1,
+		Argon2idMemory:  64,
+		Argon2idThreads: 1,
+		ScryptN:         2,
+		ScryptR:         1,
+		ScryptP:         1,
+	})
+	require.Nil(t, err, "Failed to create password hashers")
+
+	mocks := &provisioningMocks{
+		cfg:             mock.NewMockConfig(mockCtrl),
+		userPersistence: mock.NewMockUserPersistenceController(mockCtrl),
+		saveTrans:       mock.NewMockUserPersistenceReadWriteTransaction(mockCtrl),
+		removeTrans:     mock.NewMockUserPersistenceReadWriteTransaction(mockCtrl),
+		nodePersistence: mock.NewMockNodePersistenceController(mockCtrl),
+		nodeTrans:       mock.NewMockNodePersistenceReadWriteTransaction(mockCtrl),
+		fileStorage:     mock.NewMockFileStorageController(mockCtrl),
+		authMgr:         mock.NewMockAuthManager(mockCtrl),
+		auditMgr:        mock.NewMockAuditManager(mockCtrl),
+		hashers:         hashers,
+	}
+	mocks.cfg.EXPECT().GetLoggingConfig().Return(&utils.LoggingConfig{}).AnyTimes()
+	mocks.cfg.EXPECT().GetPasswordPolicyConfig().Return(&config.PasswordPolicyConfig{MinLength: 8}).AnyTimes()
+	mocks.authMgr.EXPECT().RequestEmailVerification(gomock.Any()).Return(nil).AnyTimes()
+	mocks.auditMgr.EXPECT().Record(gomock.Any(), gomock.Any()).AnyTimes()
+
+	managers := &manager.Managers{Auth: mocks.authMgr, Audit: mocks.auditMgr}
+	mocks.userMgr = manager.NewUserManager(mocks.cfg, mocks.userPersistence, mocks.fileStorage, hashers, managers)
+	manager.NewNodeManager(mocks.cfg, mocks.nodePersistence, mocks.fileStorage, managers)
+	return mocks
+}
+
+func newTestUser() *models.User {
+	return &models.User{FirstName: "New", LastName: "User", Email: testEmail, Password: "password"}
+}
+
+// finishWith returns a Finish implementation that passes preceding errors through and fails the commit with commitErr
+func finishWith(commitErr *fcerror.Error) func(fcerr *fcerror.Error) *fcerror.Error {
+	return func(fcerr *fcerror.Error) *fcerror.Error {
+		if fcerr != nil {
+			return fcerr
+		}
+		return commitErr
+	}
+}
+
+// expectProvisioning sets up the expected calls of all steps until the first injected failure and their compensations
+func expectProvisioning(mocks *provisioningMocks, userCount int64, invite *models.Invite, failures *provisioningFailures) {
+	userSaved := expectSaveUser(mocks, userCount, invite, failures)
+	rootFolderCreated := false
+	if userSaved {
+		rootFolderCreated = expectCreateRootFolder(mocks, failures)
+	}
+
+	if userSaved && !rootFolderCreated {
+		mocks.userPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.removeTrans, nil)
+		mocks.removeTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWith(nil))
+		mocks.removeTrans.EXPECT().DeleteUser(testUserID).Return(failures.removeErr)
+		if invite != nil && failures.removeErr == nil {
+			mocks.removeTrans.EXPECT().SaveInvite(invite).Return(nil)
+		}
+	}
+}
+
+func expectSaveUser(mocks *provisioningMocks, userCount int64, invite *models.Invite, failures *provisioningFailures) (saved bool) {
+	mocks.userPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.saveTrans, nil)
+	mocks.saveTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWith(failures.commitErr))
+
+	if failures.lookupErr != nil {
+		mocks.saveTrans.EXPECT().GetUserByEmail(testEmail).Return(nil, failures.lookupErr)
+		return
+	}
+	mocks.saveTrans.EXPECT().GetUserByEmail(testEmail).Return(nil, fcerror.NewError(fcerror.ErrUserNotFound, nil))
+
+	if invite != nil {
+		mocks.saveTrans.EXPECT().DeleteInvite(invite.ID).Return(failures.consumeInviteErr)
+		if failures.consumeInviteErr != nil {
+			return
+		}
+	}
+
+	mocks.saveTrans.EXPECT().CountUsers().Return(userCount, failures.countErr)
+	if failures.countErr != nil {
+		return
+	}
+
+	mocks.saveTrans.EXPECT().SaveUser(gomock.Any()).DoAndReturn(func(user *models.User) *fcerror.Error {
+		user.ID = testUserID
+		return failures.saveErr
+	})
+	return failures.saveErr == nil && failures.commitErr == nil
+}
+
+func expectCreateRootFolder(mocks *provisioningMocks, failures *provisioningFailures) (created bool) {
+	mocks.nodePersistence.EXPECT().StartReadWriteTransaction().Return(mocks.nodeTrans, nil)
+	mocks.nodeTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWith(failures.nodeCommitErr))
+
+	mocks.nodeTrans.EXPECT().CreateUserRootFolder(testUserID).Return(failures.nodeErr == nil, failures.nodeErr)
+	if failures.nodeErr != nil {
+		return
+	}
+
+	mocks.fileStorage.EXPECT().CreateUserRootFolder(testUserID).Return(failures.storageErr)
+	if failures.storageErr != nil {
+		return
+	}
+
+	if failures.nodeCommitErr != nil {
+		mocks.fileStorage.EXPECT().DeleteUserRootFolder(testUserID).Return(nil)
+		return
+	}
+	return true
+}
+
+func TestCreateUserProvisioning(t *testing.T) {
+	dbErr := fcerror.NewError(fcerror.ErrDBWriteFailed, nil)
+	storageErr := fcerror.NewError(fcerror.ErrFileFolderCreationFailed, nil)
+
+	tests := []struct {
+		name          string
+		userCount     int64
+		failures      provisioningFailures
+		expectedErr   *fcerror.Error
+		expectedAdmin bool
+	}{
+		{name: "Created", userCount: 1},
+		{name: "First user becomes admin", userCount: 0, expectedAdmin: true},
+		{name: "Email lookup fails", userCount: 1, failures: provisioningFailures{lookupErr: dbErr}, expectedErr: dbErr},
+		{name: "Counting users fails", userCount: 0, failures: provisioningFailures{countErr: dbErr}, expectedErr: dbErr},
+		{name: "Saving user fails", userCount: 1, failures: provisioningFailures{saveErr: dbErr}, expectedErr: dbErr},
+		{name: "Committing user fails", userCount: 1, failures: provisioningFailures{commitErr: dbErr}, expectedErr: dbErr},
+		{name: "Persistence root folder fails", userCount: 1, failures: provisioningFailures{nodeErr: dbErr}, expectedErr: dbErr},
+		{name: "Storage root folder fails", userCount: 1, failures: provisioningFailures{storageErr: storageErr}, expectedErr: storageErr},
+		{name: "Committing root folder fails", userCount: 1, failures: provisioningFailures{nodeCommitErr: dbErr}, expectedErr: dbErr},
+		{name: "Compensation fails", userCount: 1, failures: provisioningFailures{storageErr: storageErr, removeErr: dbErr}, expectedErr: storageErr},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createProvisioningMocks(t, mockCtrl)
+			expectProvisioning(mocks, test.userCount, nil, &test.failures)
+
+			user, fcerr := mocks.userMgr.CreateUserAsAdmin(authorization.NewSystem(), newTestUser())
+			if test.expectedErr != nil {
+				require.NotNil(t, fcerr, "Provisioning did not fail")
+				assert.Equal(t, test.expectedErr.ID, fcerr.ID, "Provisioning failed with wrong error")
+				return
+			}
+			require.Nil(t, fcerr, "Provisioning failed")
+			assert.Equal(t, testUserID, user.ID, "Wrong user returned")
+			assert.Equal(t, test.expectedAdmin, user.IsAdmin, "Wrong admin flag of created user")
+			assert.Empty(t, user.Password, "Password returned")
+		})
+	}
+}
+
+func TestCreateUserProvisioningWithInvite(t *testing.T) {
+	dbErr := fcerror.NewError(fcerror.ErrDBWriteFailed, nil)
+	storageErr := fcerror.NewError(fcerror.ErrFileFolderCreationFailed, nil)
+	session := &models.Session{UserID: testUserID}
+
+	tests := []struct {
+		name        string
+		failures    provisioningFailures
+		expectedErr *fcerror.Error
+	}{
+		{name: "Created"},
+		{name: "Invite used concurrently", failures: provisioningFailures{consumeInviteErr: fcerror.NewError(fcerror.ErrInviteNotFound, nil)}, expectedErr: fcerror.NewError(fcerror.ErrInviteInvalid, nil)},
+		{name: "Root folder fails restores invite", failures: provisioningFailures{storageErr: storageErr}, expectedErr: storageErr},
+		{name: "Deleting user fails keeps invite consumed", failures: provisioningFailures{nodeErr: dbErr, removeErr: dbErr}, expectedErr: dbErr},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createProvisioningMocks(t, mockCtrl)
+			invite := &models.Invite{ID: "invite", CreatedBy: "admin", ExpiresAt: utils.GetCurrentTime().Add(time.Hour), Quota: 10}
+			mocks.cfg.EXPECT().GetRegistrationConfig().Return(&config.RegistrationConfig{Mode: config.RegistrationModeInviteOnly}).AnyTimes()
+
+			readTrans := mock.NewMockUserPersistenceReadWriteTransaction(mockCtrl)
+			mocks.userPersistence.EXPECT().StartReadTransaction().Return(readTrans, nil).Times(2)
+			readTrans.EXPECT().Close().Return(nil).Times(2)
+			readTrans.EXPECT().CountUsers().Return(int64(1), nil)
+			readTrans.EXPECT().GetInviteByCodeHash(utils.HashToken("code")).Return(invite, nil)
+
+			expectProvisioning(mocks, 1, invite, &test.failures)
+			if test.expectedErr == nil {
+				mocks.authMgr.EXPECT().CreateNewSession(testUserID, false, nil).Return(session, nil)
+			}
+
+			createdSession, fcerr := mocks.userMgr.CreateUser(newTestUser(), "code")
+			if test.expectedErr != nil {
+				require.NotNil(t, fcerr, "Provisioning did not fail")
+				assert.Equal(t, test.expectedErr.ID, fcerr.ID, "Provisioning failed with wrong error")
+				return
+			}
+			require.Nil(t, fcerr, "Provisioning failed")
+			assert.Equal(t, session, createdSession, "Wrong session returned")
+		})
+	}
+}
+
+func TestCreateUserRegistrationModes(t *testing.T) {
+	session := &models.Session{UserID: testUserID}
+	expiredInvite := &models.Invite{ID: "invite", ExpiresAt: utils.GetCurrentTime().Add(-time.Hour)}
+
+	tests := []struct {
+		name           string
+		mode           config.RegistrationMode
+		allowedDomains []string
+		userCount      int64
+		inviteCode     string
+		invite         *models.Invite
+		expectedErr    fcerror.ErrorID
+	}{
+		{name: "Open", mode: config.RegistrationModeOpen, userCount: 1},
+		{name: "Allowed domain", mode: config.RegistrationModeAllowedDomains, allowedDomains: []string{"@EXAMPLE.com"}, userCount: 1},
+		{name: "Domain not allowed", mode: config.RegistrationModeAllowedDomains, allowedDomains: []string{"example.org"}, userCount: 1, expectedErr: fcerror.ErrEmailDomainNotAllowed},
+		{name: "Invite only without invite", mode: config.RegistrationModeInviteOnly, userCount: 1, expectedErr: fcerror.ErrRegistrationDisabled},
+		{name: "Invite only with expired invite", mode: config.RegistrationModeInviteOnly, userCount: 1, inviteCode: "code", invite: expiredInvite, expectedErr: fcerror.ErrInviteInvalid},
+		{name: "Disabled", mode: config.RegistrationModeDisabled, userCount: 1, expectedErr: fcerror.ErrRegistrationDisabled},
+		{name: "Disabled ignores invites", mode: config.RegistrationModeDisabled, userCount: 1, inviteCode: "code", expectedErr: fcerror.ErrRegistrationDisabled},
+		{name: "Unknown mode", mode: config.RegistrationMode("unknown"), userCount: 1, expectedErr: fcerror.ErrRegistrationDisabled},
+		{name: "First user despite disabled", mode: config.RegistrationModeDisabled, userCount: 0},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createProvisioningMocks(t, mockCtrl)
+			mocks.cfg.EXPECT().GetRegistrationConfig().Return(&config.RegistrationConfig{Mode: test.mode, AllowedDomains: test.allowedDomains}).AnyTimes()
+
+			readTrans := mock.NewMockUserPersistenceReadWriteTransaction(mockCtrl)
+			mocks.userPersistence.EXPECT().StartReadTransaction().Return(readTrans, nil)
+			readTrans.EXPECT().Close().Return(nil)
+			readTrans.EXPECT().CountUsers().Return(test.userCount, nil)
+			if test.invite != nil {
+				mocks.userPersistence.EXPECT().StartReadTransaction().Return(readTrans, nil)
+				readTrans.EXPECT().Close().Return(nil)
+				readTrans.EXPECT().GetInviteByCodeHash(utils.HashToken(test.inviteCode)).Return(test.invite, nil)
+			}
+			if test.expectedErr == 0 {
+				expectProvisioning(mocks, test.userCount, nil, &provisioningFailures{})
+				mocks.authMgr.EXPECT().CreateNewSession(testUserID, false, nil).Return(session, nil)
+			}
+
+			createdSession, fcerr := mocks.userMgr.CreateUser(newTestUser(), test.inviteCode)
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Registration did not fail")
+				assert.Equal(t, test.expectedErr, fcerr.ID, "Registration failed with wrong error")
+				return
+			}
+			require.Nil(t, fcerr, "Registration failed")
+			assert.Equal(t, session, createdSession, "Wrong session returned")
+		})
+	}
+}
+
+func TestCreateInvite(t *testing.T) {
+	admin := &models.User{ID: "admin", IsAdmin: true}
+	userManager := &models.User{ID: "user-manager", AssignedRoles: []models.Role{models.RoleUserManager}}
+
+	tests := []struct {
+		name        string
+		creator     *models.User
+		invite      *models.Invite
+		expectedErr fcerror.ErrorID
+	}{
+		{name: "Created by admin", creator: admin, invite: &models.Invite{IsAdmin: true, Quota: 10}},
+		{name: "Created by user manager", creator: userManager, invite: &models.Invite{ExpiresAt: utils.GetCurrentTime().Add(time.Hour)}},
+		{name: "Admin invite by user manager", creator: userManager, invite: &models.Invite{IsAdmin: true}, expectedErr: fcerror.ErrForbidden},
+		{name: "Without permission", creator: &models.User{ID: "user"}, invite: &models.Invite{}, expectedErr: fcerror.ErrForbidden},
+		{name: "Expired", creator: admin, invite: &models.Invite{ExpiresAt: utils.GetCurrentTime().Add(-time.Hour)}, expectedErr: fcerror.ErrBadRequest},
+		{name: "Negative quota", creator: admin, invite: &models.Invite{Quota: -1}, expectedErr: fcerror.ErrBadRequest},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createProvisioningMocks(t, mockCtrl)
+			mocks.cfg.EXPECT().GetRegistrationConfig().Return(&config.RegistrationConfig{Mode: config.RegistrationModeInviteOnly, InviteExpiration: 24 * time.Hour}).AnyTimes()
+			var savedInvite *models.Invite
+			if test.expectedErr == 0 {
+				mocks.userPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.saveTrans, nil)
+				mocks.saveTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWith(nil))
+				mocks.saveTrans.EXPECT().SaveInvite(gomock.Any()).DoAndReturn(func(invite *models.Invite) *fcerror.Error {
+					savedInvite = invite
+					return nil
+				})
+			}
+
+			code, fcerr := mocks.userMgr.CreateInvite(authorization.NewUser(test.creator), test.invite)
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Invite creation did not fail")
+				assert.Equal(t, test.expectedErr, fcerr.ID, "Invite creation failed with wrong error")
+				return
+			}
+			require.Nil(t, fcerr, "Invite creation failed")
+			require.NotNil(t, savedInvite, "Invite not saved")
+			assert.NotEmpty(t, code, "Missing invite code")
+			assert.Equal(t, utils.HashToken(code), savedInvite.CodeHash, "Invite not stored with the hash of the code")
+			assert.Equal(t, test.creator.ID, savedInvite.CreatedBy, "Wrong creator of invite")
+			assert.True(t, savedInvite.ExpiresAt.After(utils.GetCurrentTime()), "Invite already expired")
+		})
+	}
+}
+
+func TestCreateUserAsAdminPermissions(t *testing.T) {
+	userManager := &models.User{ID: "user-manager", AssignedRoles: []models.Role{models.RoleUserManager}}
+
+	tests := []struct {
+		name        string
+		creator     *models.User
+		isAdmin     bool
+		expectedErr fcerror.ErrorID
+	}{
+		{name: "Created by user manager", creator: userManager},
+		{name: "Admin created by user manager", creator: userManager, isAdmin: true, expectedErr: fcerror.ErrForbidden},
+		{name: "Without permission", creator: &models.User{ID: "user"}, expectedErr: fcerror.ErrForbidden},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createProvisioningMocks(t, mockCtrl)
+			if test.expectedErr == 0 {
+				expectProvisioning(mocks, 1, nil, &provisioningFailures{})
+			}
+
+			newUser := newTestUser()
+			newUser.IsAdmin = test.isAdmin
+			// Roles can only be assigned separately by users allowed to manage them
+			newUser.AssignedRoles = []models.Role{models.RoleAuditor}
+			user, fcerr := mocks.userMgr.CreateUserAsAdmin(authorization.NewUser(test.creator), newUser)
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Creation did not fail")
+				assert.Equal(t, test.expectedErr, fcerr.ID, "Creation failed with wrong error")
+				return
+			}
+			require.Nil(t, fcerr, "Creation failed")
+			assert.Equal(t, testUserID, user.ID, "Wrong user returned")
+			assert.Empty(t, user.AssignedRoles, "Roles assigned on creation")
+			assert.False(t, user.EmailVerified, "Email of created user verified")
+		})
+	}
+}
+
+func TestUpdateUserValidation(t *testing.T) {
+	strPtr := func(str string) *string { return &str }
+	otherUser := &models.User{ID: "other", Email: "other@example.com"}
+
+	tests := []struct {
+		name           string
+		update         *models.UserUpdate
+		existingUser   *models.User
+		expectedEmail  string
+		expectedErr    fcerror.ErrorID
+		expectedFields []string
+	}{
+		{name: "Email normalized", update: &models.UserUpdate{Email: strPtr("  Changed@Example.COM ")}, expectedEmail: "changed@example.com"},
+		{name: "Email unchanged in other case", update: &models.UserUpdate{Email: strPtr("NEW@example.com")}, expectedEmail: testEmail},
+		{name: "Email already registered", update: &models.UserUpdate{Email: strPtr("Other@example.com")}, existingUser: otherUser, expectedErr: fcerror.ErrEmailAlreadyRegistered},
+		{name: "Invalid fields", update: &models.UserUpdate{FirstName: strPtr("  "), Email: strPtr("Other <other@example.com>"), Password: strPtr("short")}, expectedErr: fcerror.ErrValidationFailed, expectedFields: []string{"first_name", "email", "password"}},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createProvisioningMocks(t, mockCtrl)
+			user := newTestUser()
+			user.ID = testUserID
+			authCtx := authorization.NewUser(&models.User{ID: testUserID})
+
+			if test.expectedErr != fcerror.ErrValidationFailed {
+				readTrans := mock.NewMockUserPersistenceReadWriteTransaction(mockCtrl)
+				mocks.userPersistence.EXPECT().StartReadTransaction().Return(readTrans, nil)
+				readTrans.EXPECT().GetUserByID(testUserID).Return(user, nil)
+				readTrans.EXPECT().Close().Return(nil)
+
+				mocks.userPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.saveTrans, nil)
+				mocks.saveTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWith(nil))
+				if test.expectedEmail != testEmail {
+					lookupErr := fcerror.NewError(fcerror.ErrUserNotFound, nil)
+					if test.existingUser != nil {
+						lookupErr = nil
+					}
+					mocks.saveTrans.EXPECT().GetUserByEmail(normalizedEmail(test.update)).Return(test.existingUser, lookupErr)
+				}
+				if test.expectedErr == 0 {
+					mocks.saveTrans.EXPECT().UpdateUser(gomock.Any()).Return(nil)
+				}
+			}
+
+			updatedUser, fcerr := mocks.userMgr.UpdateUser(authCtx, testUserID, test.update)
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Update did not fail")
+				assert.Equal(t, test.expectedErr, fcerr.ID, "Update failed with wrong error")
+				fields := []string{}
+				for _, field := range fcerr.Fields {
+					fields = append(fields, field.Field)
+				}
+				assert.ElementsMatch(t, test.expectedFields, fields, "Wrong invalid fields")
+				return
+			}
+			require.Nil(t, fcerr, "Update failed")
+			assert.Equal(t, test.expectedEmail, updatedUser.Email, "Wrong email of updated user")
+		})
+	}
+}
+
+func TestUpdateUserWithRoles(t *testing.T) {
+	strPtr := func(str string) *string { return &str }
+	userManager := &models.User{ID: "manager", AssignedRoles: []models.Role{models.RoleUserManager}}
+
+	tests := []struct {
+		name        string
+		authCtx     *authorization.Context
+		targetRoles []models.Role
+		targetAdmin bool
+		expectedErr fcerror.ErrorID
+	}{
+		{name: "User manager updates user without roles", authCtx: authorization.NewUser(userManager)},
+		{name: "User manager updates auditor", authCtx: authorization.NewUser(userManager), targetRoles: []models.Role{models.RoleAuditor}, expectedErr: fcerror.ErrForbidden},
+		{name: "User manager updates admin", authCtx: authorization.NewUser(userManager), targetAdmin: true, expectedErr: fcerror.ErrForbidden},
+		{name: "Admin updates auditor", authCtx: authorization.NewUser(&models.User{ID: "admin", IsAdmin: true}), targetRoles: []models.Role{models.RoleAuditor}},
+		{name: "System updates auditor", authCtx: authorization.NewSystem(), targetRoles: []models.Role{models.RoleAuditor}},
+		{name: "Auditor updates itself", authCtx: authorization.NewUser(&models.User{ID: testUserID, AssignedRoles: []models.Role{models.RoleAuditor}}), targetRoles: []models.Role{models.RoleAuditor}},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createProvisioningMocks(t, mockCtrl)
+			user := newTestUser()
+			user.ID = testUserID
+			user.AssignedRoles = test.targetRoles
+			user.IsAdmin = test.targetAdmin
+
+			readTrans := mock.NewMockUserPersistenceReadWriteTransaction(mockCtrl)
+			mocks.userPersistence.EXPECT().StartReadTransaction().Return(readTrans, nil)
+			readTrans.EXPECT().GetUserByID(testUserID).Return(user, nil)
+			readTrans.EXPECT().Close().Return(nil)
+			if test.expectedErr == 0 {
+				mocks.userPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.saveTrans, nil)
+				mocks.saveTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWith(nil))
+				mocks.saveTrans.EXPECT().UpdateUser(gomock.Any()).Return(nil)
+			}
+
+			updatedUser, fcerr := mocks.userMgr.UpdateUser(test.authCtx, testUserID, &models.UserUpdate{FirstName: strPtr("Changed")})
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Update did not fail")
+				assert.Equal(t, test.expectedErr, fcerr.ID, "Update failed with wrong error")
+				return
+			}
+			require.Nil(t, fcerr, "Update failed")
+			assert.Equal(t, "Changed", updatedUser.FirstName, "Wrong first name of updated user")
+		})
+	}
+}
+
+func normalizedEmail(update *models.UserUpdate) string {
+	return strings.ToLower(strings.TrimSpace(*update.Email))
+}
+
+func TestDeleteMyAccount(t *testing.T) {
+	otherAdmin := &models.User{ID: "other-admin", IsAdmin: true}
+	dbErr := fcerror.NewError(fcerror.ErrDBWriteFailed, nil)
+
+	tests := []struct {
+		name        string
+		password    string
+		isAdmin     bool
+		otherAdmins []*models.User
+		nodeErr     *fcerror.Error
+		deleteErr   *fcerror.Error
+		expectedErr fcerror.ErrorID
+	}{
+		{name: "Wrong password", password: "wrong", expectedErr: fcerror.ErrPasswordConfirmationFailed},
+		{name: "Last admin", password: "password", isAdmin: true, expectedErr: fcerror.ErrBadRequest},
+		{name: "Admin with other admin", password: "password", isAdmin: true, otherAdmins: []*models.User{otherAdmin}},
+		{name: "User", password: "password"},
+		{name: "Deleting files fails enables user again", password: "password", nodeErr: dbErr, expectedErr: fcerror.ErrDBWriteFailed},
+		{name: "Deleting user fails keeps user disabled", password: "password", deleteErr: dbErr, expectedErr: fcerror.ErrDBWriteFailed},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createProvisioningMocks(t, mockCtrl)
+			user := newTestUser()
+			user.ID = testUserID
+			user.IsAdmin = test.isAdmin
+			hashedPassword, err := mocks.hashers.Hash(user.Password)
+			require.Nil(t, err, "Failed to hash password")
+			user.Password = hashedPassword
+			authCtx := authorization.NewUser(&models.User{ID: testUserID, IsAdmin: test.isAdmin})
+
+			readTrans := mock.NewMockUserPersistenceReadWriteTransaction(mockCtrl)
+			mocks.userPersistence.EXPECT().StartReadTransaction().Return(readTrans, nil).AnyTimes()
+			readTrans.EXPECT().Close().Return(nil).AnyTimes()
+			readTrans.EXPECT().GetUserByID(testUserID).Return(user, nil)
+			if test.isAdmin && test.password == "password" {
+				adminRole := models.RoleAdmin
+				admins := append([]*models.User{user}, test.otherAdmins...)
+				readTrans.EXPECT().GetUsers(&models.UserFilter{Role: &adminRole}, nil, nil, 2).Return(admins, nil)
+			}
+
+			deletionStarted := test.expectedErr == 0 || test.nodeErr != nil || test.deleteErr != nil
+			var disabledStates []bool
+			if deletionStarted {
+				// The user is disabled while its files are removed and only enabled again if they are still there
+				updateTrans := mock.NewMockUserPersistenceReadWriteTransaction(mockCtrl)
+				updateTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWith(nil)).AnyTimes()
+				updateTrans.EXPECT().UpdateUser(user).DoAndReturn(func(updated *models.User) *fcerror.Error {
+					disabledStates = append(disabledStates, updated.Disabled)
+					return nil
+				}).AnyTimes()
+				startCalls := []*gomock.Call{mocks.userPersistence.EXPECT().StartReadWriteTransaction().Return(updateTrans, nil)}
+
+				mocks.nodePersistence.EXPECT().StartReadWriteTransaction().Return(mocks.nodeTrans, nil)
+				mocks.nodeTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWith(nil))
+				mocks.nodeTrans.EXPECT().DeleteUserRootFolder(testUserID).Return(test.nodeErr)
+				if test.nodeErr != nil {
+					startCalls = append(startCalls, mocks.userPersistence.EXPECT().StartReadWriteTransaction().Return(updateTrans, nil))
+				} else {
+					mocks.fileStorage.EXPECT().DeleteUserRootFolder(testUserID).Return(nil)
+					mocks.fileStorage.EXPECT().DeleteAvatars(testUserID).Return(nil)
+					startCalls = append(startCalls, mocks.userPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.removeTrans, nil))
+					mocks.removeTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWith(nil))
+					mocks.removeTrans.EXPECT().DeleteUser(testUserID).Return(test.deleteErr)
+				}
+				gomock.InOrder(startCalls...)
+			}
+
+			fcerr := mocks.userMgr.DeleteMyAccount(authCtx, test.password)
+			switch {
+			case test.nodeErr != nil:
+				assert.Equal(t, []bool{true, false}, disabledStates, "User not enabled again while the files are kept")
+			case deletionStarted:
+				assert.Equal(t, []bool{true}, disabledStates, "User not kept disabled once the files are removed")
+			}
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Deletion did not fail")
+				assert.Equal(t, test.expectedErr, fcerr.ID, "Deletion failed with wrong error")
+				return
+			}
+			assert.Nil(t, fcerr, "Deletion failed")
+		})
+	}
+}
+
+func TestSetAvatar(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.Nil(t, png.Encode(buf, image.NewRGBA(image.Rect(0, 0, 300, 200))), "Failed to encode image")
+	validAvatar := buf.Bytes()
+
+	tests := []struct {
+		name        string
+		content     []byte
+		hasAvatar   bool
+		expectedErr fcerror.ErrorID
+	}{
+		{name: "Too large", content: bytes.Repeat([]byte("a"), len(validAvatar)+1), expectedErr: fcerror.ErrAvatarTooLarge},
+		{name: "No image", content: []byte("no image"), expectedErr: fcerror.ErrAvatarInvalid},
+		{name: "First avatar", content: validAvatar},
+		{name: "Replaced avatar", content: validAvatar, hasAvatar: true},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createProvisioningMocks(t, mockCtrl)
+			mocks.cfg.EXPECT().GetAvatarMaxSize().Return(int64(len(validAvatar))).AnyTimes()
+			user := newTestUser()
+			user.ID = testUserID
+			user.HasAvatar = test.hasAvatar
+			authCtx := authorization.NewUser(&models.User{ID: testUserID})
+
+			readTrans := mock.NewMockUserPersistenceReadWriteTransaction(mockCtrl)
+			mocks.userPersistence.EXPECT().StartReadTransaction().Return(readTrans, nil)
+			readTrans.EXPECT().GetUserByID(testUserID).Return(user, nil)
+			readTrans.EXPECT().Close().Return(nil)
+
+			savedSizes := []int{}
+			if test.expectedErr == 0 {
+				mocks.fileStorage.EXPECT().SaveAvatar(testUserID, gomock.Any(), gomock.Any()).DoAndReturn(func(_ models.UserID, size int, content io.Reader) *fcerror.Error {
+					data, err := ioutil.ReadAll(content)
+					require.Nil(t, err, "Failed to read saved avatar")
+					img, err := png.Decode(bytes.NewReader(data))
+					require.Nil(t, err, "Saved avatar is no PNG")
+					assert.Equal(t, image.Rect(0, 0, size, size), img.Bounds(), "Saved avatar has wrong size")
+					savedSizes = append(savedSizes, size)
+					return nil
+				}).Times(len(models.AvatarSizes))
+			}
+			if test.expectedErr == 0 && !test.hasAvatar {
+				mocks.userPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.saveTrans, nil)
+				mocks.saveTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWith(nil))
+				mocks.saveTrans.EXPECT().UpdateUser(gomock.Any()).DoAndReturn(func(updatedUser *models.User) *fcerror.Error {
+					assert.True(t, updatedUser.HasAvatar, "Avatar is not set for user")
+					return nil
+				})
+			}
+
+			updatedUser, fcerr := mocks.userMgr.SetAvatar(authCtx, testUserID, bytes.NewReader(test.content))
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Setting avatar did not fail")
+				assert.Equal(t, test.expectedErr, fcerr.ID, "Setting avatar failed with wrong error")
+				return
+			}
+			require.Nil(t, fcerr, "Failed to set avatar")
+			assert.True(t, updatedUser.HasAvatar, "Returned user has no avatar")
+			assert.Empty(t, updatedUser.Password, "Password of returned user is not cleared")
+			assert.ElementsMatch(t, models.AvatarSizes, savedSizes, "Avatar is not saved in all sizes")
+		})
+	}
+}
+
+func TestSyncExternalUser(t *testing.T) {
+	isAdmin := false
+	externalUser := &models.ExternalUser{Email: "Admin@Example.com", FirstName: "External", LastName: "Name", IsAdmin: &isAdmin}
+
+	tests := []struct {
+		name          string
+		existingUser  *models.User
+		expectedErr   fcerror.ErrorID
+		expectUpdate  bool
+		expectedFirst string
+		expectedAdmin bool
+	}{
+		{name: "Linked user synced", existingUser: &models.User{ID: testUserID, FirstName: "Old", Email: "admin@example.com", EmailVerified: true, IsAdmin: true, External: true}, expectUpdate: true, expectedFirst: "External", expectedAdmin: false},
+		{name: "Local user with verified email rejected", existingUser: &models.User{ID: testUserID, FirstName: "Old", Email: "admin@example.com", EmailVerified: true, IsAdmin: true}, expectedErr: fcerror.ErrExternalUserNotLinked},
+		{name: "Local user with unverified email rejected", existingUser: &models.User{ID: testUserID, FirstName: "Old", Email: "admin@example.com", IsAdmin: true}, expectedErr: fcerror.ErrExternalUserNotLinked},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := createProvisioningMocks(t, mockCtrl)
+			lookupTrans := mock.NewMockUserPersistenceReadWriteTransaction(mockCtrl)
+			mocks.userPersistence.EXPECT().StartReadTransaction().Return(lookupTrans, nil)
+			lookupTrans.EXPECT().GetUserByEmail("admin@example.com").Return(test.existingUser, nil)
+			lookupTrans.EXPECT().Close().Return(nil)
+
+			var savedUser *models.User
+			if test.expectUpdate {
+				readTrans := mock.NewMockUserPersistenceReadWriteTransaction(mockCtrl)
+				mocks.userPersistence.EXPECT().StartReadTransaction().Return(readTrans, nil)
+				readTrans.EXPECT().GetUserByID(testUserID).Return(test.existingUser, nil)
+				readTrans.EXPECT().Close().Return(nil)
+
+				mocks.userPersistence.EXPECT().StartReadWriteTransaction().Return(mocks.saveTrans, nil)
+				mocks.saveTrans.EXPECT().Finish(gomock.Any()).DoAndReturn(finishWith(nil))
+				mocks.saveTrans.EXPECT().UpdateUser(gomock.Any()).DoAndReturn(func(user *models.User) *fcerror.Error {
+					savedUser = user
+					return nil
+				})
+			}
+
+			user, fcerr := mocks.userMgr.SyncExternalUser(externalUser)
+			if test.expectedErr != 0 {
+				require.NotNil(t, fcerr, "Unlinked user synced")
+				assert.Equal(t, test.expectedErr, fcerr.ID, "Sync failed with wrong error")
+				return
+			}
+			require.Nil(t, fcerr, "Sync failed")
+			assert.Equal(t, test.expectedFirst, user.FirstName, "Wrong first name after sync")
+			assert.Equal(t, test.expectedAdmin, user.IsAdmin, "Wrong admin flag after sync")
+			if test.expectUpdate {
+				require.NotNil(t, savedUser, "Synced user not saved")
+				assert.Equal(t, test.expectedAdmin, savedUser.IsAdmin, "Wrong admin flag saved")
+			}
+		})
+	}
+}
+
+func TestProvisionExternalUserLinksNewUser(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mocks := createProvisioningMocks(t, mockCtrl)
+	lookupTrans := mock.NewMockUserPersistenceReadWriteTransaction(mockCtrl)
+	mocks.userPersistence.EXPECT().StartReadTransaction().Return(lookupTrans, nil)
+	lookupTrans.EXPECT().GetUserByEmail(testEmail).Return(nil, fcerror.NewError(fcerror.ErrUserNotFound, nil))
+	lookupTrans.EXPECT().Close().Return(nil)
+	expectProvisioning(mocks, 1, nil, &provisioningFailures{})
+
+	user, fcerr := mocks.userMgr.ProvisionExternalUser(&models.ExternalUser{Email: testEmail, FirstName: "New", LastName: "User"})
+	require.Nil(t, fcerr, "Failed to provision external user")
+	assert.True(t, user.External, "Provisioned user not linked to the identity source")
+	assert.True(t, user.EmailVerified, "Email of provisioned user not verified")
+	assert.False(t, user.IsAdmin, "Provisioned user made admin")
+}
@@ -0,0 +1,235 @@
+package manager
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/utils"
+)
+
+const (
+	maxUserNameLength  = 100
+	maxGroupNameLength = 100
+	maxEmailLength     = 254
+	// Longer passwords are not more secure, but make hashing more expensive
+	maxPasswordLength = 1024
+	maxNodeNameLength = 255
+	maxLocaleLength   = 35
+)
+
+var localeRegexp = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{1,8})*$`)
+
+// validator collects the errors of all invalid fields of an input, so they can be reported at once
+type validator struct {
+	fields []*fcerror.FieldError
+}
+
+func (v *validator) addError(field string, code fcerror.FieldErrorCode, message string) {
+	v.fields = append(v.fields, &fcerror.FieldError{Field: field, Code: code, Message: message})
+}
+
+// err returns the validation error of all collected field errors or nil if the input is valid
+func (v *validator) err() *fcerror.Error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return fcerror.NewValidationError(v.fields)
+}
+
+func (v *validator) validateUserName(field, name string) {
+	if name == "" {
+		v.addError(field, fcerror.FieldErrorRequired, "Name must not be empty")
+	} else if utf8.RuneCountInString(name) > maxUserNameLength {
+		v.addError(field, fcerror.FieldErrorTooLong, fmt.Sprintf("Name must not be longer than %d characters", maxUserNameLength))
+	}
+}
+
+func (v *validator) validateGroupName(field, name string) {
+	if name == "" {
+		v.addError(field, fcerror.FieldErrorRequired, "Name must not be empty")
+	} else if utf8.RuneCountInString(name) > maxGroupNameLength {
+		v.addError(field, fcerror.FieldErrorTooLong, fmt.Sprintf("Name must not be longer than %d characters", maxGroupNameLength))
+	}
+}
+
+// validateEmail checks an email normalized by normalizeEmail
+func (v *validator) validateEmail(field, email string) {
+	if email == "" {
+		v.addError(field, fcerror.FieldErrorRequired, "Email must not be empty")
+		return
+	}
+	if len(email) > maxEmailLength {
+		v.addError(field, fcerror.FieldErrorTooLong, fmt.Sprintf("Email must not be longer than %d characters", maxEmailLength))
+		return
+	}
+	// Only plain addresses without display names or comments are accepted
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		v.addError(field, fcerror.FieldErrorInvalidFormat, "Email is not a valid address")
+	}
+}
+
+func (v *validator) validatePassword(field, password string, policy *config.PasswordPolicyConfig) {
+	length := utf8.RuneCountInString(password)
+	if length == 0 {
+		v.addError(field, fcerror.FieldErrorRequired, "Password must not be empty")
+		return
+	}
+	if length < policy.MinLength {
+		v.addError(field, fcerror.FieldErrorTooShort, fmt.Sprintf("Password must be at least %d characters long", policy.MinLength))
+		return
+	}
+	if length > maxPasswordLength {
+		v.addError(field, fcerror.FieldErrorTooLong, fmt.Sprintf("Password must not be longer than %d characters", maxPasswordLength))
+		return
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, char := range password {
+		switch {
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsDigit(char):
+			hasDigit = true
+		case !unicode.IsLetter(char):
+			hasSymbol = true
+		}
+	}
+
+	missing := []string{}
+	if policy.RequireLowercase && !hasLower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if policy.RequireUppercase && !hasUpper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		missing = append(missing, "a digit")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		missing = append(missing, "a symbol")
+	}
+	if len(missing) > 0 {
+		v.addError(field, fcerror.FieldErrorTooWeak, "Password must contain "+strings.Join(missing, ", "))
+	}
+}
+
+func (v *validator) validateNodeName(field, name string) {
+	if name == "" {
+		v.addError(field, fcerror.FieldErrorRequired, "Name must not be empty")
+		return
+	}
+	if len(name) > maxNodeNameLength {
+		v.addError(field, fcerror.FieldErrorTooLong, fmt.Sprintf("Name must not be longer than %d bytes", maxNodeNameLength))
+		return
+	}
+	if name == "." || name == ".." || !utf8.ValidString(name) || strings.IndexFunc(name, isInvalidNodeNameChar) >= 0 {
+		v.addError(field, fcerror.FieldErrorInvalidFormat, "Name must not be '.' or '..' and must not contain slashes or control characters")
+	}
+}
+
+// validateNodePath checks every segment of the path as a node name
+func (v *validator) validateNodePath(field, path string) {
+	for _, segment := range utils.GetPathSegments(path) {
+		fieldCount := len(v.fields)
+		v.validateNodeName(field, segment)
+		if len(v.fields) > fieldCount {
+			return
+		}
+	}
+}
+
+// validateLocale checks the syntax of a BCP 47 language tag, an empty locale unsets it
+func (v *validator) validateLocale(field, locale string) {
+	if len(locale) > maxLocaleLength {
+		v.addError(field, fcerror.FieldErrorTooLong, fmt.Sprintf("Locale must not be longer than %d characters", maxLocaleLength))
+	} else if locale != "" && !localeRegexp.MatchString(locale) {
+		v.addError(field, fcerror.FieldErrorInvalidFormat, "Locale is not a valid language tag like en-US")
+	}
+}
+
+// validateTimezone checks that the IANA time zone is known, an empty time zone unsets it
+func (v *validator) validateTimezone(field, timezone string) {
+	if timezone == "" {
+		return
+	}
+	// Local is the time zone of the server and means nothing to clients
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+		v.addError(field, fcerror.FieldErrorInvalidFormat, "Time zone is not a known IANA time zone like Europe/Berlin")
+	}
+}
+
+func isInvalidNodeNameChar(char rune) bool {
+	return char == '/' || unicode.IsControl(char)
+}
+
+// normalizeEmail makes the email comparable, as addresses are handled case-insensitively by almost all mail servers
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// normalizeUserInput normalizes the names and email of a new user and validates them with the password
+func (mgr *userManager) normalizeUserInput(user *models.User) *fcerror.Error {
+	user.FirstName = strings.TrimSpace(user.FirstName)
+	user.LastName = strings.TrimSpace(user.LastName)
+	user.Email = normalizeEmail(user.Email)
+
+	v := &validator{}
+	v.validateUserName("first_name", user.FirstName)
+	v.validateUserName("last_name", user.LastName)
+	v.validateEmail("email", user.Email)
+	v.validatePassword("password", user.Password, mgr.cfg.GetPasswordPolicyConfig())
+	return v.err()
+}
+
+// normalizeUserUpdate normalizes and validates the changed fields of a user update.
+// The password policy is not enforced for the system, which only sets passwords validated before or rehashes existing ones.
+func (mgr *userManager) normalizeUserUpdate(updateUser *models.UserUpdate, enforcePasswordPolicy bool) *fcerror.Error {
+	v := &validator{}
+	if updateUser.FirstName != nil {
+		firstName := strings.TrimSpace(*updateUser.FirstName)
+		updateUser.FirstName = &firstName
+		v.validateUserName("first_name", firstName)
+	}
+	if updateUser.LastName != nil {
+		lastName := strings.TrimSpace(*updateUser.LastName)
+		updateUser.LastName = &lastName
+		v.validateUserName("last_name", lastName)
+	}
+	if updateUser.DisplayName != nil {
+		displayName := strings.TrimSpace(*updateUser.DisplayName)
+		updateUser.DisplayName = &displayName
+		if utf8.RuneCountInString(displayName) > maxUserNameLength {
+			v.addError("display_name", fcerror.FieldErrorTooLong, fmt.Sprintf("Display name must not be longer than %d characters", maxUserNameLength))
+		}
+	}
+	if updateUser.Locale != nil {
+		locale := strings.TrimSpace(*updateUser.Locale)
+		updateUser.Locale = &locale
+		v.validateLocale("locale", locale)
+	}
+	if updateUser.Timezone != nil {
+		timezone := strings.TrimSpace(*updateUser.Timezone)
+		updateUser.Timezone = &timezone
+		v.validateTimezone("timezone", timezone)
+	}
+	if updateUser.Email != nil {
+		email := normalizeEmail(*updateUser.Email)
+		updateUser.Email = &email
+		v.validateEmail("email", email)
+	}
+	if updateUser.Password != nil && enforcePasswordPolicy {
+		v.validatePassword("password", *updateUser.Password, mgr.cfg.GetPasswordPolicyConfig())
+	}
+	return v.err()
+}
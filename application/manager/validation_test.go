@@ -0,0 +1,190 @@
+package manager
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/domain/models/fcerror"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getFieldErrorCode(v *validator) fcerror.FieldErrorCode {
+	if len(v.fields) == 0 {
+		return ""
+	}
+	return v.fields[0].Code
+}
+
+func TestValidatePassword(t *testing.T) {
+	strictPolicy := &config.PasswordPolicyConfig{MinLength: 8, RequireLowercase: true, RequireUppercase: true, RequireDigit: true, RequireSymbol: true}
+
+	tests := []struct {
+		name         string
+		password     string
+		policy       *config.PasswordPolicyConfig
+		expectedCode fcerror.FieldErrorCode
+	}{
+		{name: "Empty", password: "", policy: &config.PasswordPolicyConfig{}, expectedCode: fcerror.FieldErrorRequired},
+		{name: "Too short", password: "abc", policy: &config.PasswordPolicyConfig{MinLength: 8}, expectedCode: fcerror.FieldErrorTooShort},
+		{name: "Multibyte characters counted once", password: "äöüäöüäö", policy: &config.PasswordPolicyConfig{MinLength: 8}},
+		{name: "Too long", password: strings.Repeat("a", maxPasswordLength+1), policy: &config.PasswordPolicyConfig{MinLength: 8}, expectedCode: fcerror.FieldErrorTooLong},
+		{name: "Character classes not required", password: "password", policy: &config.PasswordPolicyConfig{MinLength: 8}},
+		{name: "Missing character classes", password: "password", policy: strictPolicy, expectedCode: fcerror.FieldErrorTooWeak},
+		{name: "Missing symbol", password: "Passw0rdd", policy: strictPolicy, expectedCode: fcerror.FieldErrorTooWeak},
+		{name: "All character classes", password: "Passw0rd!", policy: strictPolicy},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			v := &validator{}
+			v.validatePassword("password", test.password, test.policy)
+			assert.Equal(t, test.expectedCode, getFieldErrorCode(v), "Wrong field error code")
+		})
+	}
+}
+
+func TestValidateEmail(t *testing.T) {
+	tests := []struct {
+		email        string
+		expectedCode fcerror.FieldErrorCode
+	}{
+		{email: "user@example.com"},
+		{email: "first.last+tag@sub.example.com"},
+		{email: "", expectedCode: fcerror.FieldErrorRequired},
+		{email: "user", expectedCode: fcerror.FieldErrorInvalidFormat},
+		{email: "user@", expectedCode: fcerror.FieldErrorInvalidFormat},
+		{email: "User <user@example.com>", expectedCode: fcerror.FieldErrorInvalidFormat},
+		{email: strings.Repeat("a", maxEmailLength) + "@example.com", expectedCode: fcerror.FieldErrorTooLong},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.email, func(t *testing.T) {
+			v := &validator{}
+			v.validateEmail("email", test.email)
+			assert.Equal(t, test.expectedCode, getFieldErrorCode(v), "Wrong field error code")
+		})
+	}
+}
+
+func TestValidateNodeName(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedCode fcerror.FieldErrorCode
+	}{
+		{name: "report.pdf"},
+		{name: ".hidden"},
+		{name: "", expectedCode: fcerror.FieldErrorRequired},
+		{name: ".", expectedCode: fcerror.FieldErrorInvalidFormat},
+		{name: "..", expectedCode: fcerror.FieldErrorInvalidFormat},
+		{name: "a/b", expectedCode: fcerror.FieldErrorInvalidFormat},
+		{name: "line\nbreak", expectedCode: fcerror.FieldErrorInvalidFormat},
+		{name: "invalid\xff", expectedCode: fcerror.FieldErrorInvalidFormat},
+		{name: strings.Repeat("a", maxNodeNameLength+1), expectedCode: fcerror.FieldErrorTooLong},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			v := &validator{}
+			v.validateNodeName("name", test.name)
+			assert.Equal(t, test.expectedCode, getFieldErrorCode(v), "Wrong field error code")
+		})
+	}
+}
+
+func TestValidateNodePath(t *testing.T) {
+	v := &validator{}
+	v.validateNodePath("full_path", "/folder/sub folder/file.txt")
+	assert.Nil(t, v.err(), "Valid path rejected")
+
+	v = &validator{}
+	v.validateNodePath("full_path", "/folder/../..")
+	assert.Len(t, v.fields, 1, "Expected a single error for the path")
+	fcerr := v.err()
+	if assert.NotNil(t, fcerr, "Invalid path accepted") {
+		assert.Equal(t, fcerror.ErrValidationFailed, fcerr.ID, "Wrong error for invalid path")
+		assert.Equal(t, "full_path", fcerr.Fields[0].Field, "Wrong invalid field")
+	}
+}
+
+func TestValidateMountName(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedCode fcerror.FieldErrorCode
+	}{
+		{name: "Shared documents"},
+		{name: "   ", expectedCode: fcerror.FieldErrorRequired},
+		{name: "a/b", expectedCode: fcerror.FieldErrorInvalidFormat},
+		{name: "tab\tname", expectedCode: fcerror.FieldErrorInvalidFormat},
+		{name: "..", expectedCode: fcerror.FieldErrorInvalidFormat},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			fcerr := validateMountName(test.name)
+			if test.expectedCode == "" {
+				assert.Nil(t, fcerr, "Valid mount name rejected")
+				return
+			}
+			if assert.NotNil(t, fcerr, "Invalid mount name accepted") {
+				assert.Equal(t, fcerror.ErrValidationFailed, fcerr.ID, "Wrong error for invalid mount name")
+				assert.Equal(t, test.expectedCode, fcerr.Fields[0].Code, "Wrong field error code")
+			}
+		})
+	}
+}
+
+func TestValidateLocale(t *testing.T) {
+	tests := []struct {
+		locale       string
+		expectedCode fcerror.FieldErrorCode
+	}{
+		{locale: ""},
+		{locale: "de"},
+		{locale: "en-US"},
+		{locale: "zh-Hant-TW"},
+		{locale: "e", expectedCode: fcerror.FieldErrorInvalidFormat},
+		{locale: "en_US", expectedCode: fcerror.FieldErrorInvalidFormat},
+		{locale: "en-", expectedCode: fcerror.FieldErrorInvalidFormat},
+		{locale: "en-" + strings.Repeat("a", maxLocaleLength), expectedCode: fcerror.FieldErrorTooLong},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.locale, func(t *testing.T) {
+			v := &validator{}
+			v.validateLocale("locale", test.locale)
+			assert.Equal(t, test.expectedCode, getFieldErrorCode(v), "Wrong field error code")
+		})
+	}
+}
+
+func TestValidateTimezone(t *testing.T) {
+	tests := []struct {
+		timezone     string
+		expectedCode fcerror.FieldErrorCode
+	}{
+		{timezone: ""},
+		{timezone: "UTC"},
+		{timezone: "Local", expectedCode: fcerror.FieldErrorInvalidFormat},
+		{timezone: "Nowhere/Unknown", expectedCode: fcerror.FieldErrorInvalidFormat},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.timezone, func(t *testing.T) {
+			v := &validator{}
+			v.validateTimezone("timezone", test.timezone)
+			assert.Equal(t, test.expectedCode, getFieldErrorCode(v), "Wrong field error code")
+		})
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	assert.Equal(t, "user@example.com", normalizeEmail("  User@Example.COM\n"), "Email not normalized")
+}
@@ -0,0 +1,438 @@
+package manager
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/application/persistence"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	webAuthnCredentialType   = "public-key"
+	webAuthnResidentKey      = "preferred"
+	webAuthnUserVerification = "preferred"
+	webAuthnAttestation      = "none"
+)
+
+func (mgr *authManager) webAuthnRelyingParty() *utils.WebAuthnRelyingParty {
+	webAuthnCfg := mgr.cfg.GetWebAuthnConfig()
+	return &utils.WebAuthnRelyingParty{ID: webAuthnCfg.RPID, Origin: webAuthnCfg.Origin}
+}
+
+// BeginWebAuthnRegistration starts the registration of a new security key or passkey for the user
+func (mgr *authManager) BeginWebAuthnRegistration(authCtx *authorization.Context) (options *models.WebAuthnCreationOptions, fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Security keys can only be registered by users"))
+		return
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	// Already registered authenticators refuse to create a second credential for the same user
+	credentials, fcerr := trans.GetWebAuthnCredentialsOfUser(authCtx.User.ID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", authCtx.User.ID).Error("Failed to get security keys of user")
+		return
+	}
+
+	challenge, fcerr := mgr.createWebAuthnChallenge(trans, authCtx.User.ID, models.WebAuthnChallengePurposeRegistration)
+	if fcerr != nil {
+		return
+	}
+
+	webAuthnCfg := mgr.cfg.GetWebAuthnConfig()
+	options = &models.WebAuthnCreationOptions{
+		Challenge: challenge.Challenge,
+		RP:        &models.WebAuthnRelyingPartyEntity{ID: webAuthnCfg.RPID, Name: webAuthnCfg.RPName},
+		User: &models.WebAuthnUserEntity{
+			ID:          utils.EncodeBase64URL([]byte(authCtx.User.ID)),
+			Name:        authCtx.User.Email,
+			DisplayName: strings.TrimSpace(authCtx.User.FirstName + " " + authCtx.User.LastName),
+		},
+		PubKeyCredParams: []*models.WebAuthnCredentialParameter{
+			{Type: webAuthnCredentialType, Alg: utils.WebAuthnAlgES256},
+			{Type: webAuthnCredentialType, Alg: utils.WebAuthnAlgRS256},
+		},
+		ExcludeCredentials:     toWebAuthnCredentialDescriptors(credentials),
+		AuthenticatorSelection: &models.WebAuthnAuthenticatorSelection{ResidentKey: webAuthnResidentKey, UserVerification: webAuthnUserVerification},
+		Attestation:            webAuthnAttestation,
+		Timeout:                loginChallengeExpiration.Milliseconds(),
+	}
+	return
+}
+
+// FinishWebAuthnRegistration verifies the response of the authenticator and stores the created credential
+func (mgr *authManager) FinishWebAuthnRegistration(authCtx *authorization.Context, name string, attestation *models.WebAuthnAttestation) (credential *models.WebAuthnCredential, fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Security keys can only be registered by users"))
+		return
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Security key needs a name"))
+		return
+	}
+
+	challenge, valid, fcerr := mgr.takeWebAuthnChallenge(attestation.ClientDataJSON, models.WebAuthnChallengePurposeRegistration)
+	if fcerr != nil {
+		return
+	}
+	if !valid || challenge.UserID != authCtx.User.ID {
+		fcerr = fcerror.NewError(fcerror.ErrWebAuthnChallengeInvalid, nil)
+		return
+	}
+
+	registration, err := utils.VerifyWebAuthnRegistration(mgr.webAuthnRelyingParty(), challenge.Challenge, attestation.ClientDataJSON, attestation.AttestationObject, false)
+	if err != nil {
+		mgr.logger.WithError(err).WithField("userID", authCtx.User.ID).Info("Failed to verify security key registration")
+		fcerr = fcerror.NewError(fcerror.ErrWebAuthnVerificationFailed, err)
+		return
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	credentialID := models.WebAuthnCredentialID(utils.EncodeBase64URL(registration.CredentialID))
+	_, fcerr = trans.GetWebAuthnCredential(credentialID)
+	if fcerr == nil {
+		fcerr = fcerror.NewError(fcerror.ErrWebAuthnCredentialAlreadyRegistered, nil)
+		return
+	} else if fcerr.ID != fcerror.ErrWebAuthnCredentialNotFound {
+		mgr.logger.WithError(fcerr).Error("Failed to check for existing security key")
+		return
+	}
+
+	credential = &models.WebAuthnCredential{
+		ID:        credentialID,
+		UserID:    authCtx.User.ID,
+		Name:      name,
+		PublicKey: utils.EncodeBase64URL(registration.PublicKey),
+		SignCount: int64(registration.SignCount),
+		Created:   utils.GetCurrentTime(),
+	}
+	fcerr = trans.SaveWebAuthnCredential(credential)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", authCtx.User.ID).Error("Failed to save security key")
+		return nil, fcerr
+	}
+	return
+}
+
+func (mgr *authManager) GetOwnWebAuthnCredentials(authCtx *authorization.Context) (credentials []*models.WebAuthnCredential, fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		return []*models.WebAuthnCredential{}, nil
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer trans.Close()
+
+	credentials, fcerr = trans.GetWebAuthnCredentialsOfUser(authCtx.User.ID)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("userID", authCtx.User.ID).Error("Failed to get security keys of user")
+	}
+	return
+}
+
+func (mgr *authManager) DeleteWebAuthnCredential(authCtx *authorization.Context, credentialID models.WebAuthnCredentialID) (fcerr *fcerror.Error) {
+	fcerr = authorization.EnforceUser(authCtx)
+	if fcerr != nil {
+		return
+	}
+
+	fcerr = authorization.EnforceFullScope(authCtx)
+	if fcerr != nil {
+		return
+	}
+	if authCtx.User == nil {
+		fcerr = fcerror.NewError(fcerror.ErrWebAuthnCredentialNotFound, nil)
+		return
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	fcerr = trans.DeleteWebAuthnCredential(authCtx.User.ID, credentialID)
+	if fcerr != nil && fcerr.ID != fcerror.ErrWebAuthnCredentialNotFound {
+		mgr.logger.WithError(fcerr).WithField("credentialID", credentialID).Error("Failed to delete security key")
+	}
+	return
+}
+
+// BeginWebAuthnLogin starts a login with a security key instead of a password.
+// Without email any discoverable credential is allowed; unknown emails get the same options as accounts without security keys.
+func (mgr *authManager) BeginWebAuthnLogin(email string) (options *models.WebAuthnRequestOptions, fcerr *fcerror.Error) {
+	var userID models.UserID
+	if email != "" {
+		user, userErr := mgr.managers.User.GetUserByEmail(authorization.NewSystem(), email)
+		if userErr == nil {
+			userID = user.ID
+		} else if userErr.ID != fcerror.ErrUserNotFound {
+			return nil, userErr
+		}
+	}
+
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	credentials := []*models.WebAuthnCredential{}
+	if userID != "" {
+		credentials, fcerr = trans.GetWebAuthnCredentialsOfUser(userID)
+		if fcerr != nil {
+			mgr.logger.WithError(fcerr).WithField("userID", userID).Error("Failed to get security keys of user")
+			return
+		}
+	}
+
+	challenge, fcerr := mgr.createWebAuthnChallenge(trans, userID, models.WebAuthnChallengePurposeLogin)
+	if fcerr != nil {
+		return
+	}
+
+	return mgr.webAuthnRequestOptions(challenge, credentials, "required"), nil
+}
+
+// WebAuthnLogin creates a session for the owner of the security key which answered a login challenge.
+// The key has to verify the user itself, so it replaces both the password and the second factor.
+func (mgr *authManager) WebAuthnLogin(assertion *models.WebAuthnAssertion, client *models.SessionClient) (session *models.Session, fcerr *fcerror.Error) {
+	var user *models.User
+	defer func() { mgr.auditLogin("", user, client, fcerr) }()
+
+	clientIP := getClientIP(client)
+	fcerr = mgr.beginLoginAttempt("", clientIP)
+	if fcerr != nil {
+		return
+	}
+
+	credential, valid, fcerr := mgr.checkWebAuthnLogin(assertion)
+	mgr.loginThrottle.finishLogin("", clientIP, fcerr == nil && !valid)
+	if fcerr != nil {
+		return
+	}
+	if !valid {
+		fcerr = fcerror.NewError(fcerror.ErrWebAuthnVerificationFailed, nil)
+		return
+	}
+
+	user, fcerr = mgr.managers.User.GetUserByID(authorization.NewSystem(), credential.UserID)
+	if fcerr != nil {
+		return
+	}
+
+	if mgr.cfg.GetEmailVerificationRequired() && !user.EmailVerified {
+		fcerr = fcerror.NewError(fcerror.ErrEmailNotVerified, nil)
+		return
+	}
+	if user.Disabled {
+		fcerr = fcerror.NewError(fcerror.ErrUserDisabled, nil)
+		return
+	}
+
+	session, fcerr = mgr.CreateNewSession(user.ID, false, client)
+	if fcerr != nil {
+		return
+	}
+	mgr.loginThrottle.resetAccount(user.Email)
+	return
+}
+
+func (mgr *authManager) checkWebAuthnLogin(assertion *models.WebAuthnAssertion) (credential *models.WebAuthnCredential, valid bool, fcerr *fcerror.Error) {
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	return mgr.verifyWebAuthnAssertion(trans, "", models.WebAuthnChallengePurposeLogin, assertion, true)
+}
+
+// CompleteLoginChallengeWebAuthn completes a login with password with a registered security key as second factor
+func (mgr *authManager) CompleteLoginChallengeWebAuthn(challengeToken models.Token, assertion *models.WebAuthnAssertion, client *models.SessionClient) (session *models.Session, fcerr *fcerror.Error) {
+	return mgr.completeLoginChallenge(challengeToken, client, func(trans persistence.AuthPersistenceReadWriteTransaction, userID models.UserID) (valid bool, fcerr *fcerror.Error) {
+		_, valid, fcerr = mgr.verifyWebAuthnAssertion(trans, userID, models.WebAuthnChallengePurposeSecondFactor, assertion, false)
+		return
+	})
+}
+
+// verifyWebAuthnAssertion consumes the answered challenge and checks the signature and sign count of the credential.
+// Invalid assertions are only reported as not valid, so the consumed challenge is committed.
+func (mgr *authManager) verifyWebAuthnAssertion(trans persistence.AuthPersistenceReadWriteTransaction, userID models.UserID, purpose models.WebAuthnChallengePurpose, assertion *models.WebAuthnAssertion, requireUserVerification bool) (credential *models.WebAuthnCredential, valid bool, fcerr *fcerror.Error) {
+	challenge, valid, fcerr := mgr.consumeWebAuthnChallenge(trans, assertion.ClientDataJSON, purpose)
+	if fcerr != nil || !valid {
+		return
+	}
+	valid = false
+	if userID != "" && challenge.UserID != userID {
+		return
+	}
+
+	credential, fcerr = trans.GetWebAuthnCredential(assertion.CredentialID)
+	if fcerr != nil {
+		if fcerr.ID == fcerror.ErrWebAuthnCredentialNotFound {
+			return nil, false, nil
+		}
+		mgr.logger.WithError(fcerr).WithField("credentialID", assertion.CredentialID).Error("Failed to get security key")
+		return
+	}
+	if challenge.UserID != "" && credential.UserID != challenge.UserID {
+		return nil, false, nil
+	}
+	if len(assertion.UserHandle) > 0 && models.UserID(assertion.UserHandle) != credential.UserID {
+		return nil, false, nil
+	}
+
+	publicKey, err := utils.DecodeBase64URL(credential.PublicKey)
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrModelConversionFailed, err)
+		mgr.logger.WithError(fcerr).WithField("credentialID", credential.ID).Error("Failed to decode public key of security key")
+		return
+	}
+	signCount, err := utils.VerifyWebAuthnAssertion(mgr.webAuthnRelyingParty(), challenge.Challenge, publicKey, uint32(credential.SignCount), assertion.ClientDataJSON, assertion.AuthenticatorData, assertion.Signature, requireUserVerification)
+	if err != nil {
+		mgr.logger.WithError(err).WithFields(logrus.Fields{"userID": credential.UserID, "credentialID": credential.ID}).Warn("Failed to verify security key assertion")
+		return nil, false, nil
+	}
+
+	now := utils.GetCurrentTime()
+	credential.SignCount = int64(signCount)
+	credential.LastUsed = &now
+	fcerr = trans.UpdateWebAuthnCredentialUsage(credential)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).WithField("credentialID", credential.ID).Error("Failed to update usage of security key")
+		return
+	}
+	return credential, true, nil
+}
+
+// takeWebAuthnChallenge consumes the challenge in its own transaction, so it is gone even if the following verification fails
+func (mgr *authManager) takeWebAuthnChallenge(clientDataJSON []byte, purpose models.WebAuthnChallengePurpose) (challenge *models.WebAuthnChallenge, valid bool, fcerr *fcerror.Error) {
+	trans, fcerr := mgr.authPersistence.StartReadWriteTransaction()
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to create transaction")
+		return
+	}
+	defer func() { fcerr = trans.Finish(fcerr) }()
+
+	return mgr.consumeWebAuthnChallenge(trans, clientDataJSON, purpose)
+}
+
+// consumeWebAuthnChallenge deletes the challenge the client data answers, it is only valid for the given purpose until it expires
+func (mgr *authManager) consumeWebAuthnChallenge(trans persistence.AuthPersistenceReadWriteTransaction, clientDataJSON []byte, purpose models.WebAuthnChallengePurpose) (challenge *models.WebAuthnChallenge, valid bool, fcerr *fcerror.Error) {
+	clientData, err := utils.ParseWebAuthnClientData(clientDataJSON)
+	if err != nil {
+		mgr.logger.WithError(err).Info("Failed to parse security key client data")
+		return
+	}
+
+	challenge, fcerr = trans.GetWebAuthnChallenge(clientData.Challenge)
+	if fcerr != nil {
+		if fcerr.ID == fcerror.ErrWebAuthnChallengeInvalid {
+			return nil, false, nil
+		}
+		mgr.logger.WithError(fcerr).Error("Failed to get security key challenge")
+		return
+	}
+
+	fcerr = trans.DeleteWebAuthnChallenge(challenge.Challenge)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to delete security key challenge")
+		return
+	}
+
+	valid = challenge.Purpose == purpose && !utils.GetCurrentTime().After(challenge.ValidUntil)
+	return
+}
+
+func (mgr *authManager) createWebAuthnChallenge(trans persistence.AuthPersistenceReadWriteTransaction, userID models.UserID, purpose models.WebAuthnChallengePurpose) (challenge *models.WebAuthnChallenge, fcerr *fcerror.Error) {
+	value, err := utils.GenerateWebAuthnChallenge()
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrUnknown, err)
+		mgr.logger.WithError(fcerr).Error("Failed to generate security key challenge")
+		return
+	}
+
+	challenge = &models.WebAuthnChallenge{
+		Challenge:  value,
+		UserID:     userID,
+		Purpose:    purpose,
+		ValidUntil: utils.GetTimeIn(loginChallengeExpiration),
+	}
+	fcerr = trans.SaveWebAuthnChallenge(challenge)
+	if fcerr != nil {
+		mgr.logger.WithError(fcerr).Error("Failed to save security key challenge")
+	}
+	return
+}
+
+func (mgr *authManager) webAuthnRequestOptions(challenge *models.WebAuthnChallenge, credentials []*models.WebAuthnCredential, userVerification string) *models.WebAuthnRequestOptions {
+	return &models.WebAuthnRequestOptions{
+		Challenge:        challenge.Challenge,
+		RPID:             mgr.cfg.GetWebAuthnConfig().RPID,
+		AllowCredentials: toWebAuthnCredentialDescriptors(credentials),
+		UserVerification: userVerification,
+		Timeout:          loginChallengeExpiration.Milliseconds(),
+	}
+}
+
+func toWebAuthnCredentialDescriptors(credentials []*models.WebAuthnCredential) []*models.WebAuthnCredentialDescriptor {
+	descriptors := make([]*models.WebAuthnCredentialDescriptor, len(credentials))
+	for it, credential := range credentials {
+		descriptors[it] = &models.WebAuthnCredentialDescriptor{Type: webAuthnCredentialType, ID: string(credential.ID)}
+	}
+	return descriptors
+}
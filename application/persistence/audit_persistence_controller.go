@@ -0,0 +1,24 @@
+package persistence
+
+import (
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+)
+
+type AuditPersistenceController interface {
+	StartReadTransaction() (AuditPersistenceReadTransaction, *fcerror.Error)
+	StartReadWriteTransaction() (AuditPersistenceReadWriteTransaction, *fcerror.Error)
+}
+
+type AuditPersistenceReadTransaction interface {
+	ReadTransaction
+	// GetAuditEvents returns the newest events matching the filter that are older than the event 'after', if given
+	GetAuditEvents(filter *models.AuditFilter, after *models.AuditEventID, limit int) ([]*models.AuditEvent, *fcerror.Error)
+}
+
+// AuditPersistenceReadWriteTransaction is append-only, stored events can not be changed or deleted
+type AuditPersistenceReadWriteTransaction interface {
+	ReadWriteTransaction
+	AuditPersistenceReadTransaction
+	SaveAuditEvent(event *models.AuditEvent) *fcerror.Error
+}
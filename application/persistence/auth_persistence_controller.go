@@ -12,13 +12,48 @@ type AuthPersistenceController interface {
 
 type AuthPersistenceReadTransaction interface {
 	ReadTransaction
-	GetSessionByToken(token models.Token) (*models.Session, *fcerror.Error)
+	GetSessionByToken(tokenHash string) (*models.Session, *fcerror.Error)
+	GetPlainSessionTokens() ([]models.Token, *fcerror.Error)
+	GetSessionsOfUser(userID models.UserID) ([]*models.Session, *fcerror.Error)
+	GetLoginChallenge(token models.Token) (*models.LoginChallenge, *fcerror.Error)
+	GetTOTP(userID models.UserID) (*models.TOTP, *fcerror.Error)
+	GetAccessTokenByHash(tokenHash string) (*models.AccessToken, *fcerror.Error)
+	GetAccessTokensOfUser(userID models.UserID) ([]*models.AccessToken, *fcerror.Error)
+	GetEmailToken(tokenHash string) (*models.EmailToken, *fcerror.Error)
+	GetWebAuthnCredential(credentialID models.WebAuthnCredentialID) (*models.WebAuthnCredential, *fcerror.Error)
+	GetWebAuthnCredentialsOfUser(userID models.UserID) ([]*models.WebAuthnCredential, *fcerror.Error)
+	GetWebAuthnChallenge(challenge string) (*models.WebAuthnChallenge, *fcerror.Error)
 }
 
 type AuthPersistenceReadWriteTransaction interface {
 	ReadWriteTransaction
 	AuthPersistenceReadTransaction
 	SaveSession(session *models.Session) *fcerror.Error
-	DeleteSessionByToken(token models.Token) *fcerror.Error
+	UpdateSessionUsage(session *models.Session) *fcerror.Error
+	DeleteSessionByToken(tokenHash string) *fcerror.Error
+	HashSessionToken(token models.Token, tokenHash string) *fcerror.Error
+	DeleteSessionByID(userID models.UserID, sessionID models.SessionID) *fcerror.Error
+	DeleteSessionsOfUser(userID models.UserID, keepTokenHash string) *fcerror.Error
 	DeleteExpiredSessions() *fcerror.Error
+	SaveLoginChallenge(challenge *models.LoginChallenge) *fcerror.Error
+	UpdateLoginChallengeAttempts(challenge *models.LoginChallenge) *fcerror.Error
+	DeleteLoginChallenge(token models.Token) *fcerror.Error
+	DeleteExpiredLoginChallenges() *fcerror.Error
+	SaveTOTP(totp *models.TOTP) *fcerror.Error
+	DeleteTOTP(userID models.UserID) *fcerror.Error
+	SaveRecoveryCodes(userID models.UserID, codeHashes []string) *fcerror.Error
+	UseRecoveryCode(userID models.UserID, codeHash string) (bool, *fcerror.Error)
+	SaveAccessToken(accessToken *models.AccessToken) *fcerror.Error
+	UpdateAccessTokenLastUsed(accessToken *models.AccessToken) *fcerror.Error
+	DeleteAccessToken(userID models.UserID, accessTokenID models.AccessTokenID) *fcerror.Error
+	SaveEmailToken(emailToken *models.EmailToken) *fcerror.Error
+	DeleteEmailToken(tokenHash string) *fcerror.Error
+	DeleteEmailTokensOfUser(userID models.UserID, purpose models.EmailTokenPurpose) *fcerror.Error
+	DeleteExpiredEmailTokens() *fcerror.Error
+	SaveWebAuthnCredential(credential *models.WebAuthnCredential) *fcerror.Error
+	UpdateWebAuthnCredentialUsage(credential *models.WebAuthnCredential) *fcerror.Error
+	DeleteWebAuthnCredential(userID models.UserID, credentialID models.WebAuthnCredentialID) *fcerror.Error
+	SaveWebAuthnChallenge(challenge *models.WebAuthnChallenge) *fcerror.Error
+	DeleteWebAuthnChallenge(challenge string) *fcerror.Error
+	DeleteExpiredWebAuthnChallenges() *fcerror.Error
 }
@@ -0,0 +1,24 @@
+package persistence
+
+import (
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+)
+
+type FileDropPersistenceController interface {
+	StartReadTransaction() (FileDropPersistenceReadTransaction, *fcerror.Error)
+	StartReadWriteTransaction() (FileDropPersistenceReadWriteTransaction, *fcerror.Error)
+}
+
+type FileDropPersistenceReadTransaction interface {
+	ReadTransaction
+	GetFileDropByID(fileDropID models.FileDropID) (*models.FileDrop, *fcerror.Error)
+	GetFileDropsOfUser(userID models.UserID) ([]*models.FileDrop, *fcerror.Error)
+}
+
+type FileDropPersistenceReadWriteTransaction interface {
+	ReadWriteTransaction
+	FileDropPersistenceReadTransaction
+	SaveFileDrop(fileDrop *models.FileDrop) *fcerror.Error
+	DeleteFileDrop(userID models.UserID, fileDropID models.FileDropID) *fcerror.Error
+}
@@ -0,0 +1,27 @@
+package persistence
+
+import (
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+)
+
+type GroupPersistenceController interface {
+	StartReadTransaction() (GroupPersistenceReadTransaction, *fcerror.Error)
+	StartReadWriteTransaction() (GroupPersistenceReadWriteTransaction, *fcerror.Error)
+}
+
+type GroupPersistenceReadTransaction interface {
+	ReadTransaction
+	GetGroupByID(groupID models.GroupID) (*models.Group, *fcerror.Error)
+	GetGroupsOfUser(userID models.UserID) ([]*models.Group, *fcerror.Error)
+	GetGroupMember(groupID models.GroupID, userID models.UserID) (*models.GroupMember, *fcerror.Error)
+	GetGroupMembers(groupID models.GroupID) ([]*models.GroupMember, *fcerror.Error)
+}
+
+type GroupPersistenceReadWriteTransaction interface {
+	ReadWriteTransaction
+	GroupPersistenceReadTransaction
+	SaveGroup(group *models.Group) *fcerror.Error
+	SaveGroupMember(member *models.GroupMember) *fcerror.Error
+	DeleteGroupMember(groupID models.GroupID, userID models.UserID) *fcerror.Error
+}
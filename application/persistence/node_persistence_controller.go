@@ -15,11 +15,15 @@ type NodePersistenceReadTransaction interface {
 	GetNodeByPath(userID models.UserID, path string, includedShareMode models.ShareMode) (*models.Node, *fcerror.Error)
 	GetNodeByID(userID models.UserID, nodeID models.NodeID, includedShareMode models.ShareMode) (*models.Node, *fcerror.Error)
 	ListByID(userID models.UserID, nodeID models.NodeID, includedShareMode models.ShareMode) ([]*models.Node, *fcerror.Error)
+	IsNodeInFolder(folderID, nodeID models.NodeID) (bool, *fcerror.Error)
+	GetOwnedNodes(userID models.UserID) ([]*models.Node, *fcerror.Error)
 }
 
 type NodePersistenceReadWriteTransaction interface {
 	ReadWriteTransaction
 	NodePersistenceReadTransaction
 	CreateUserRootFolder(userID models.UserID) (bool, *fcerror.Error)
+	DeleteUserRootFolder(userID models.UserID) *fcerror.Error
+	TransferUserRootFolder(fromUserID, toUserID models.UserID, folderName string) (string, *fcerror.Error)
 	CreateNodeByID(userID models.UserID, node *models.Node) (bool, *fcerror.Error)
 }
@@ -12,11 +12,15 @@ type SharePersistenceController interface {
 
 type SharePersistenceReadTransaction interface {
 	ReadTransaction
-	NodeContainsNestedShares(nodeID models.NodeID) (bool, *fcerror.Error)
+	GetSharesOfUser(userID models.UserID) ([]*models.Share, *fcerror.Error)
 }
 
 type SharePersistenceReadWriteTransaction interface {
 	ReadWriteTransaction
 	SharePersistenceReadTransaction
 	CreateShare(userID models.UserID, share *models.Share, insertName string) (bool, *fcerror.Error)
+	CreateGroupShare(userID models.UserID, share *models.Share, insertName string) (bool, *fcerror.Error)
+	UpdateShareMount(userID models.UserID, nodeID models.NodeID, update *models.ShareMountUpdate) *fcerror.Error
+	DeleteShare(userID models.UserID, share *models.Share) *fcerror.Error
+	DeleteExpiredShares() *fcerror.Error
 }
@@ -15,6 +15,9 @@ type UserPersistenceReadTransaction interface {
 	CountUsers() (int64, *fcerror.Error)
 	GetUserByID(userID models.UserID) (*models.User, *fcerror.Error)
 	GetUserByEmail(email string) (*models.User, *fcerror.Error)
+	GetUsers(filter *models.UserFilter, sort *models.UserSort, after *models.UserID, limit int) ([]*models.User, *fcerror.Error)
+	GetInvites() ([]*models.Invite, *fcerror.Error)
+	GetInviteByCodeHash(codeHash string) (*models.Invite, *fcerror.Error)
 }
 
 type UserPersistenceReadWriteTransaction interface {
@@ -22,4 +25,7 @@ type UserPersistenceReadWriteTransaction interface {
 	UserPersistenceReadTransaction
 	SaveUser(*models.User) *fcerror.Error
 	UpdateUser(*models.User) *fcerror.Error
+	DeleteUser(userID models.UserID) *fcerror.Error
+	SaveInvite(invite *models.Invite) *fcerror.Error
+	DeleteInvite(inviteID models.InviteID) *fcerror.Error
 }
@@ -9,7 +9,14 @@ import (
 
 type FileStorageController interface {
 	CreateUserRootFolder(userID models.UserID) *fcerror.Error
+	DeleteUserRootFolder(userID models.UserID) *fcerror.Error
+	TransferUserRootFolder(fromUserID, toUserID models.UserID, folderName string) *fcerror.Error
 	CreateEmptyFileOrFolder(node *models.Node) *fcerror.Error
 	CopyFileFromUpload(node *models.Node, uploadPath string) *fcerror.Error
 	DownloadFile(node *models.Node) (io.ReadCloser, int64, *fcerror.Error)
+	// GetUsedBytes returns the size of all files of the user
+	GetUsedBytes(userID models.UserID) (int64, *fcerror.Error)
+	SaveAvatar(userID models.UserID, size int, content io.Reader) *fcerror.Error
+	OpenAvatar(userID models.UserID, size int) (io.ReadCloser, int64, *fcerror.Error)
+	DeleteAvatars(userID models.UserID) *fcerror.Error
 }
@@ -8,10 +8,16 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/freecloudio/server/application/authentication"
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/application/mail"
 	"github.com/freecloudio/server/application/manager"
 	"github.com/freecloudio/server/plugin/gin"
+	"github.com/freecloudio/server/plugin/ldapplg"
 	"github.com/freecloudio/server/plugin/localfs"
+	"github.com/freecloudio/server/plugin/logmail"
 	"github.com/freecloudio/server/plugin/neo"
+	"github.com/freecloudio/server/plugin/smtpplg"
 	"github.com/freecloudio/server/plugin/viperplg"
 	"github.com/freecloudio/server/utils"
 )
@@ -42,17 +48,63 @@ func main() {
 	if fcerr != nil {
 		logger.WithError(fcerr).Fatal("Failed to initialize neo share persistence plugin - abort")
 	}
+	groupPersistence, fcerr := neo.CreateGroupPersistence(cfg)
+	if fcerr != nil {
+		logger.WithError(fcerr).Fatal("Failed to initialize neo group persistence plugin - abort")
+	}
+	fileDropPersistence, fcerr := neo.CreateFileDropPersistence(cfg)
+	if fcerr != nil {
+		logger.WithError(fcerr).Fatal("Failed to initialize neo file drop persistence plugin - abort")
+	}
+	auditPersistence, fcerr := neo.CreateAuditPersistence(cfg)
+	if fcerr != nil {
+		logger.WithError(fcerr).Fatal("Failed to initialize neo audit persistence plugin - abort")
+	}
 
 	localFSFileStorage, fcerr := localfs.CreateLocalFSStorage(cfg)
 	if fcerr != nil {
 		logger.WithError(fcerr).Fatal("Failed to initialize localfs file storage plugin - abort")
 	}
 
+	authenticators := []authentication.Authenticator{}
+	if cfg.GetLDAPConfig().Enabled {
+		ldapAuthenticator, fcerr := ldapplg.CreateLDAPAuthenticator(cfg)
+		if fcerr != nil {
+			logger.WithError(fcerr).Fatal("Failed to initialize ldap authenticator plugin - abort")
+		}
+		authenticators = append(authenticators, ldapAuthenticator)
+	}
+
+	var mailer mail.Mailer
+	switch cfg.GetMailConfig().Plugin {
+	case config.SMTPMailKey:
+		mailer, fcerr = smtpplg.CreateSMTPMailer(cfg)
+		if fcerr != nil {
+			logger.WithError(fcerr).Fatal("Failed to initialize smtp mail plugin - abort")
+		}
+	case config.LogMailKey:
+		mailer, fcerr = logmail.CreateLogMailer(cfg)
+		if fcerr != nil {
+			logger.WithError(fcerr).Fatal("Failed to initialize log mail plugin - abort")
+		}
+	default:
+		logger.WithField("plugin", cfg.GetMailConfig().Plugin).Fatal("Unknown mail plugin - abort")
+	}
+
+	passwordHashers, err := utils.NewPasswordHashers(cfg.GetPasswordHashingConfig())
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid password hashing config - abort")
+	}
+
 	managers := &manager.Managers{}
-	authMgr := manager.NewAuthManager(cfg, authPersistence, managers)
-	userMgr := manager.NewUserManager(cfg, userPersistence, managers)
+	auditMgr := manager.NewAuditManager(cfg, auditPersistence, managers)
+	authMgr := manager.NewAuthManager(cfg, authPersistence, authenticators, mailer, passwordHashers, managers)
+	userMgr := manager.NewUserManager(cfg, userPersistence, localFSFileStorage, passwordHashers, managers)
 	nodeMgr := manager.NewNodeManager(cfg, nodePersistence, localFSFileStorage, managers)
 	shareMgr := manager.NewShareManager(cfg, sharePersistence, nodePersistence, managers)
+	groupMgr := manager.NewGroupManager(cfg, groupPersistence, managers)
+	fileDropMgr := manager.NewFileDropManager(cfg, fileDropPersistence, managers)
+	exportMgr := manager.NewExportManager(cfg, nodePersistence, localFSFileStorage, managers)
 
 	router := gin.NewRouter(managers, cfg, ":8080")
 
@@ -78,6 +130,10 @@ func main() {
 	userMgr.Close()
 	authMgr.Close()
 	shareMgr.Close()
+	groupMgr.Close()
+	fileDropMgr.Close()
+	exportMgr.Close()
+	auditMgr.Close()
 
 	fcerr = nodePersistence.Close()
 	if fcerr != nil {
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+)
+
+// AccessTokenPrefix marks personal access tokens to distinguish them from session tokens
+const AccessTokenPrefix = "fcpat_"
+
+type AccessTokenID string
+
+// AccessToken is a long-lived personal token for scripts and CI jobs.
+// Only the hash of the token is stored, the token itself is shown once after creation.
+type AccessToken struct {
+	ID        AccessTokenID `json:"id" fc_neo:",unique"`
+	TokenHash string        `json:"-" fc_neo:"token_hash,index"`
+	UserID    UserID        `json:"user_id" fc_neo:"-"`
+	Name      string        `json:"name"`
+	Created   time.Time     `json:"created"`
+	LastUsed  *time.Time    `json:"last_used" fc_neo:",optional"`
+	ExpiresAt *time.Time    `json:"expires_at" fc_neo:",optional"`
+
+	ReadOnly bool   `json:"read_only"`
+	FolderID NodeID `json:"folder_id" fc_neo:",optional"`
+}
+
+// Scope returns the restrictions of contexts authenticated by the token
+func (token *AccessToken) Scope() *TokenScope {
+	return &TokenScope{ReadOnly: token.ReadOnly, FolderID: token.FolderID}
+}
+
+// TokenScope restricts what a context authenticated by an access token is allowed to do
+type TokenScope struct {
+	// ReadOnly forbids all changes
+	ReadOnly bool
+	// FolderID limits node access to the folder and its content, empty for no limitation.
+	// All other features except reading users and groups are forbidden for folder scoped tokens.
+	FolderID NodeID
+}
+
+// IsRestricted returns whether the scope limits the access in any way
+func (scope *TokenScope) IsRestricted() bool {
+	return scope.ReadOnly || scope.FolderID != ""
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+)
+
+type AuditEventID string
+
+type AuditAction string
+
+const (
+	AuditActionLogin       AuditAction = "LOGIN"
+	AuditActionLogout      AuditAction = "LOGOUT"
+	AuditActionUserUpdate  AuditAction = "USER_UPDATE"
+	AuditActionRoleChange  AuditAction = "ROLE_CHANGE"
+	AuditActionUserDisable AuditAction = "USER_DISABLE"
+	AuditActionUserEnable  AuditAction = "USER_ENABLE"
+	AuditActionUserDelete  AuditAction = "USER_DELETE"
+	AuditActionShareCreate AuditAction = "SHARE_CREATE"
+	AuditActionShareRevoke AuditAction = "SHARE_REVOKE"
+	AuditActionDownload    AuditAction = "DOWNLOAD"
+	AuditActionUpload      AuditAction = "UPLOAD"
+	AuditActionDataExport  AuditAction = "DATA_EXPORT"
+)
+
+type AuditOutcome string
+
+const (
+	AuditOutcomeSuccess AuditOutcome = "SUCCESS"
+	AuditOutcomeFailure AuditOutcome = "FAILURE"
+)
+
+type AuditTargetType string
+
+const (
+	AuditTargetTypeUser AuditTargetType = "USER"
+	AuditTargetTypeNode AuditTargetType = "NODE"
+)
+
+// AuditEvent records a security relevant action; events are only ever appended and never changed
+type AuditEvent struct {
+	ID      AuditEventID `json:"id" fc_neo:",unique"`
+	Time    time.Time    `json:"time" fc_neo:",index"`
+	Action  AuditAction  `json:"action" fc_neo:",index"`
+	Outcome AuditOutcome `json:"outcome"`
+
+	// The actor is empty for anonymous and system actions, failed logins only know the email used
+	ActorID    UserID `json:"actor_id,omitempty" fc_neo:",optional"`
+	ActorEmail string `json:"actor_email,omitempty" fc_neo:",optional"`
+	ClientIP   string `json:"client_ip,omitempty" fc_neo:",optional"`
+
+	TargetType AuditTargetType `json:"target_type,omitempty" fc_neo:",optional"`
+	TargetID   string          `json:"target_id,omitempty" fc_neo:",optional"`
+	Details    string          `json:"details,omitempty" fc_neo:",optional"`
+}
+
+// AuditFilter limits the audit log to events matching all set fields
+type AuditFilter struct {
+	Action   *AuditAction  `json:"action"`
+	Outcome  *AuditOutcome `json:"outcome"`
+	ActorID  *UserID       `json:"actor_id"`
+	TargetID *string       `json:"target_id"`
+	From     *time.Time    `json:"from"`
+	Until    *time.Time    `json:"until"`
+}
@@ -4,8 +4,71 @@ import "time"
 
 type Token string
 
+type SessionID string
+
+// Session authenticates a client with a token only handed out on creation, just the hash of the token is stored.
+// Its validity is extended on usage up to a maximum lifetime, both longer if the user chose to be remembered.
 type Session struct {
-	Token      Token     `json:"token" fc_neo:",index"`
+	ID         SessionID `json:"id" fc_neo:",unique,optional"`
+	Token      Token     `json:"token" fc_neo:"-"`
+	TokenHash  string    `json:"-" fc_neo:"token_hash,unique"`
+	UserID     UserID    `json:"user_id" fc_neo:"-"`
+	ValidUntil time.Time `json:"valid_until"`
+	Created    time.Time `json:"created" fc_neo:",optional"`
+	LastUsed   time.Time `json:"last_used" fc_neo:",optional"`
+	UserAgent  string    `json:"user_agent" fc_neo:",optional"`
+	ClientIP   string    `json:"client_ip" fc_neo:",optional"`
+	RememberMe bool      `json:"remember_me" fc_neo:",optional"`
+	Current    bool      `json:"current" fc_neo:"-"`
+}
+
+// SessionClient describes the device a session is created or used from
+type SessionClient struct {
+	UserAgent string
+	ClientIP  string
+}
+
+// LoginChallenge is issued by a login with password if the user has a second factor enabled.
+// It has to be completed with that factor before a session is created.
+type LoginChallenge struct {
+	Token      Token     `json:"token" fc_neo:",unique"`
 	UserID     UserID    `json:"user_id" fc_neo:"-"`
 	ValidUntil time.Time `json:"valid_until"`
+	Attempts   int64     `json:"attempts"`
+	// Passed on to the session created once the challenge is completed
+	RememberMe bool `json:"remember_me" fc_neo:",optional"`
+	// Only set on creation if the user can answer the challenge with a security key
+	WebAuthnOptions *WebAuthnRequestOptions `json:"webauthn_options" fc_neo:"-"`
+}
+
+// LoginResult contains either the created session or a challenge for the second factor
+type LoginResult struct {
+	Session   *Session        `json:"session"`
+	Challenge *LoginChallenge `json:"challenge"`
+}
+
+// TOTP is the configuration of time-based one-time passwords of a user
+type TOTP struct {
+	UserID       UserID    `json:"user_id" fc_neo:"-"`
+	Created      time.Time `json:"created"`
+	Secret       string    `json:"secret"`
+	Confirmed    bool      `json:"confirmed"`
+	LastUsedStep int64     `json:"last_used_step"`
+}
+
+type EmailTokenPurpose string
+
+const (
+	EmailTokenPurposePasswordReset EmailTokenPurpose = "password_reset"
+	EmailTokenPurposeVerification  EmailTokenPurpose = "verification"
+)
+
+// EmailToken is a signed single-use token mailed to a user to reset the password or verify the email.
+// Only the hash of the token is stored.
+type EmailToken struct {
+	TokenHash  string            `json:"-" fc_neo:"token_hash,unique"`
+	UserID     UserID            `json:"user_id" fc_neo:"-"`
+	Purpose    EmailTokenPurpose `json:"purpose"`
+	Email      string            `json:"email"`
+	ValidUntil time.Time         `json:"valid_until"`
 }
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+type DataExportID string
+
+type DataExportStatus string
+
+const (
+	DataExportStatusPending DataExportStatus = "PENDING"
+	DataExportStatusReady   DataExportStatus = "READY"
+	DataExportStatusFailed  DataExportStatus = "FAILED"
+)
+
+// DataExport is a ZIP of all files and account data of a user, built in the background
+type DataExport struct {
+	ID        DataExportID     `json:"id"`
+	UserID    UserID           `json:"user_id"`
+	Status    DataExportStatus `json:"status"`
+	Created   time.Time        `json:"created"`
+	ExpiresAt time.Time        `json:"expires_at"`
+	Size      int64            `json:"size"`
+
+	// Signed link the ZIP can be downloaded with without authentication, only set once the export is ready
+	DownloadURL *string `json:"download_url,omitempty"`
+}
+
+// DataExportManifest describes the account in the manifest of the export, next to the exported files
+type DataExportManifest struct {
+	ExportedAt time.Time  `json:"exported_at"`
+	User       *User      `json:"user"`
+	Shares     []*Share   `json:"shares"`
+	Sessions   []*Session `json:"sessions"`
+	Files      []*Node    `json:"files"`
+}
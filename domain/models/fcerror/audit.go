@@ -0,0 +1,9 @@
+package fcerror
+
+const (
+	ErrAuditExportFailed ErrorID = iota + 1000
+)
+
+func init() {
+	errorDescriptions[ErrAuditExportFailed] = "Failed to export audit log"
+}
@@ -7,6 +7,25 @@ const (
 	ErrPasswordHashingFailed
 	ErrTokenNotFound
 	ErrSessionExpired
+	ErrSessionNotFound
+	ErrLoginChallengeNotFound
+	ErrLoginChallengeExpired
+	ErrTOTPNotFound
+	ErrTOTPAlreadyEnabled
+	ErrSecondFactorInvalid
+	ErrAccessTokenNotFound
+	ErrAccessTokenExpired
+	ErrExternalLoginFailed
+	ErrAuthenticatorFailed
+	ErrTooManyAttempts
+	ErrEmailTokenInvalid
+	ErrEmailTokenExpired
+	ErrWebAuthnCredentialNotFound
+	ErrWebAuthnCredentialAlreadyRegistered
+	ErrWebAuthnChallengeInvalid
+	ErrWebAuthnVerificationFailed
+	ErrCSRFTokenInvalid
+	ErrPasswordConfirmationFailed
 )
 
 func init() {
@@ -16,4 +35,23 @@ func init() {
 	errorDescriptions[ErrPasswordHashingFailed] = "Failed to hash password or stored hash is invalid"
 	errorDescriptions[ErrTokenNotFound] = "Token could not be found"
 	errorDescriptions[ErrSessionExpired] = "Session is expired"
+	errorDescriptions[ErrSessionNotFound] = "Session could not be found"
+	errorDescriptions[ErrLoginChallengeNotFound] = "Login challenge could not be found"
+	errorDescriptions[ErrLoginChallengeExpired] = "Login challenge is expired"
+	errorDescriptions[ErrTOTPNotFound] = "Two-factor authentication is not set up"
+	errorDescriptions[ErrTOTPAlreadyEnabled] = "Two-factor authentication is already enabled"
+	errorDescriptions[ErrSecondFactorInvalid] = "Code for the second factor is not valid"
+	errorDescriptions[ErrAccessTokenNotFound] = "Access token could not be found"
+	errorDescriptions[ErrAccessTokenExpired] = "Access token is expired"
+	errorDescriptions[ErrExternalLoginFailed] = "Login with the external identity provider failed"
+	errorDescriptions[ErrAuthenticatorFailed] = "External authenticator failed"
+	errorDescriptions[ErrTooManyAttempts] = "Too many failed attempts, try again later"
+	errorDescriptions[ErrEmailTokenInvalid] = "Token from the email is not valid or was already used"
+	errorDescriptions[ErrEmailTokenExpired] = "Token from the email is expired"
+	errorDescriptions[ErrWebAuthnCredentialNotFound] = "Security key could not be found"
+	errorDescriptions[ErrWebAuthnCredentialAlreadyRegistered] = "Security key is already registered"
+	errorDescriptions[ErrWebAuthnChallengeInvalid] = "Security key challenge is not valid or expired"
+	errorDescriptions[ErrWebAuthnVerificationFailed] = "Security key response could not be verified"
+	errorDescriptions[ErrCSRFTokenInvalid] = "CSRF token is missing or does not match the session"
+	errorDescriptions[ErrPasswordConfirmationFailed] = "Password confirmation failed"
 }
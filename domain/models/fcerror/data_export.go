@@ -0,0 +1,15 @@
+package fcerror
+
+const (
+	ErrDataExportNotFound ErrorID = iota + 1200
+	ErrDataExportExpired
+	ErrDataExportNotReady
+	ErrDataExportFailed
+)
+
+func init() {
+	errorDescriptions[ErrDataExportNotFound] = "Data export not found"
+	errorDescriptions[ErrDataExportExpired] = "Data export is expired"
+	errorDescriptions[ErrDataExportNotReady] = "Data export is not ready yet"
+	errorDescriptions[ErrDataExportFailed] = "Failed to create data export"
+}
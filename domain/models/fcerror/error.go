@@ -15,6 +15,9 @@ type Error struct {
 	File        string  `json:"file,omitempty"`
 	Line        int     `json:"line,omitempty"`
 	Function    string  `json:"function,omitempty"`
+
+	// Invalid input fields of an ErrValidationFailed error
+	Fields []*FieldError `json:"fields,omitempty"`
 }
 
 func (err Error) Error() string {
@@ -0,0 +1,13 @@
+package fcerror
+
+const (
+	ErrFileDropNotFound ErrorID = iota + 800
+	ErrFileDropExpired
+	ErrFileDropSizeExceeded
+)
+
+func init() {
+	errorDescriptions[ErrFileDropNotFound] = "File drop not found"
+	errorDescriptions[ErrFileDropExpired] = "File drop is expired"
+	errorDescriptions[ErrFileDropSizeExceeded] = "File exceeds the size limit of the file drop"
+}
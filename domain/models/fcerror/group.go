@@ -0,0 +1,13 @@
+package fcerror
+
+const (
+	ErrGroupNotFound ErrorID = iota + 700
+	ErrGroupMemberNotFound
+	ErrLastGroupAdmin
+)
+
+func init() {
+	errorDescriptions[ErrGroupNotFound] = "Group not found"
+	errorDescriptions[ErrGroupMemberNotFound] = "User is not a member of this group"
+	errorDescriptions[ErrLastGroupAdmin] = "The last admin of a group cannot be removed"
+}
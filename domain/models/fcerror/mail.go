@@ -0,0 +1,11 @@
+package fcerror
+
+const (
+	ErrMailSendFailed ErrorID = iota + 900
+	ErrMailInvalid
+)
+
+func init() {
+	errorDescriptions[ErrMailSendFailed] = "Failed to send mail"
+	errorDescriptions[ErrMailInvalid] = "Mail is not valid"
+}
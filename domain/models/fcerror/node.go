@@ -2,8 +2,10 @@ package fcerror
 
 const (
 	ErrNodeNotFound ErrorID = iota + 400
+	ErrNodeNameAlreadyUsed
 )
 
 func init() {
 	errorDescriptions[ErrNodeNotFound] = "File or folder not found"
+	errorDescriptions[ErrNodeNameAlreadyUsed] = "A file or folder with this name already exists"
 }
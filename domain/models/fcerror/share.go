@@ -2,8 +2,14 @@ package fcerror
 
 const (
 	ErrShareContainsOtherShares ErrorID = iota + 600
+	ErrShareWithOwner
+	ErrShareNotFound
+	ErrShareMountCycle
 )
 
 func init() {
 	errorDescriptions[ErrShareContainsOtherShares] = "Node that should be shared, contains other shared files"
+	errorDescriptions[ErrShareWithOwner] = "Node can not be shared with its owner"
+	errorDescriptions[ErrShareNotFound] = "Share not found"
+	errorDescriptions[ErrShareMountCycle] = "Share can not be mounted inside of itself"
 }
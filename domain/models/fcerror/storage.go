@@ -6,6 +6,9 @@ const (
 	ErrOpenUploadFile
 	ErrOpenUserFile
 	ErrCopyFileFailed
+	ErrFileFolderDeletionFailed
+	ErrFileFolderMoveFailed
+	ErrQuotaExceeded
 )
 
 func init() {
@@ -14,4 +17,7 @@ func init() {
 	errorDescriptions[ErrOpenUploadFile] = "Failed to open uploaded file"
 	errorDescriptions[ErrOpenUserFile] = "Failed to open users file"
 	errorDescriptions[ErrCopyFileFailed] = "Failed to copy file"
+	errorDescriptions[ErrFileFolderDeletionFailed] = "Failed to delete folder or file"
+	errorDescriptions[ErrFileFolderMoveFailed] = "Failed to move folder or file"
+	errorDescriptions[ErrQuotaExceeded] = "Upload exceeds the storage quota of the owner"
 }
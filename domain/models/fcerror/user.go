@@ -3,9 +3,29 @@ package fcerror
 const (
 	ErrUserNotFound ErrorID = iota + 100
 	ErrEmailAlreadyRegistered
+	ErrEmailNotVerified
+	ErrRegistrationDisabled
+	ErrEmailDomainNotAllowed
+	ErrInviteInvalid
+	ErrInviteNotFound
+	ErrUserDisabled
+	ErrAvatarNotFound
+	ErrAvatarInvalid
+	ErrAvatarTooLarge
+	ErrExternalUserNotLinked
 )
 
 func init() {
 	errorDescriptions[ErrUserNotFound] = "User not found"
 	errorDescriptions[ErrEmailAlreadyRegistered] = "User with this email address is already registered"
+	errorDescriptions[ErrEmailNotVerified] = "Email address of the user is not verified"
+	errorDescriptions[ErrRegistrationDisabled] = "Registration is disabled or needs an invite"
+	errorDescriptions[ErrEmailDomainNotAllowed] = "Registration with this email domain is not allowed"
+	errorDescriptions[ErrInviteInvalid] = "Invite code is not valid, expired or was already used"
+	errorDescriptions[ErrInviteNotFound] = "Invite could not be found"
+	errorDescriptions[ErrUserDisabled] = "User is disabled"
+	errorDescriptions[ErrAvatarNotFound] = "User has no avatar"
+	errorDescriptions[ErrAvatarInvalid] = "Avatar is not a supported image or too large in dimensions"
+	errorDescriptions[ErrAvatarTooLarge] = "Avatar image exceeds the maximum size"
+	errorDescriptions[ErrExternalUserNotLinked] = "User with this email address is not linked to the external identity source"
 }
@@ -0,0 +1,48 @@
+package fcerror
+
+import (
+	"strings"
+)
+
+const (
+	ErrValidationFailed ErrorID = iota + 1100
+)
+
+func init() {
+	errorDescriptions[ErrValidationFailed] = "Input is not valid"
+}
+
+// FieldErrorCode tells clients why a field is invalid, the message of the field error is only meant for humans
+type FieldErrorCode string
+
+const (
+	FieldErrorRequired      FieldErrorCode = "REQUIRED"
+	FieldErrorTooShort      FieldErrorCode = "TOO_SHORT"
+	FieldErrorTooLong       FieldErrorCode = "TOO_LONG"
+	FieldErrorInvalidFormat FieldErrorCode = "INVALID_FORMAT"
+	FieldErrorTooWeak       FieldErrorCode = "TOO_WEAK"
+)
+
+// FieldError describes why the value of a single input field is invalid
+type FieldError struct {
+	Field   string         `json:"field"`
+	Code    FieldErrorCode `json:"code"`
+	Message string         `json:"message"`
+}
+
+type fieldErrors []*FieldError
+
+func (errs fieldErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, fieldErr := range errs {
+		messages = append(messages, fieldErr.Field+": "+fieldErr.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// NewValidationError creates an ErrValidationFailed error listing all invalid fields of the input
+func NewValidationError(fields []*FieldError) *Error {
+	fcerr := newError(ErrValidationFailed, fieldErrors(fields), false)
+	fcerr.Fields = fields
+	return fcerr
+}
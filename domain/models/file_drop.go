@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+type FileDropID string
+
+// FileDrop is an upload-only link to a folder; visitors may add files but never list or download its content
+type FileDrop struct {
+	ID      FileDropID `json:"id" fc_neo:",unique"`
+	Created time.Time  `json:"created"`
+
+	NodeID     NodeID     `json:"node_id" fc_neo:"-"`
+	OwnerID    UserID     `json:"owner_id" fc_neo:"-"`
+	NamePrefix string     `json:"name_prefix"`
+	MaxSize    int64      `json:"max_size"`
+	ExpiresAt  *time.Time `json:"expires_at" fc_neo:",optional"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+type GroupID string
+
+type Group struct {
+	ID      GroupID   `json:"id" fc_neo:",unique"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+
+	Name string `json:"name"`
+}
+
+type GroupMember struct {
+	GroupID GroupID `json:"group_id" fc_neo:"-"`
+	UserID  UserID  `json:"user_id" fc_neo:"-"`
+	IsAdmin bool    `json:"is_admin"`
+}
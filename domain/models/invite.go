@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type InviteID string
+
+// Invite allows to register a single account regardless of the registration mode.
+// Only the hash of the code is stored, the code itself is shown once after creation.
+type Invite struct {
+	ID        InviteID  `json:"id" fc_neo:",unique"`
+	CodeHash  string    `json:"-" fc_neo:"code_hash,unique"`
+	CreatedBy UserID    `json:"created_by" fc_neo:"-"`
+	Created   time.Time `json:"created"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Preset for the registered user
+	Quota   int64 `json:"quota" fc_neo:",optional"`
+	IsAdmin bool  `json:"is_admin" fc_neo:",optional"`
+}
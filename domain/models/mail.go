@@ -0,0 +1,8 @@
+package models
+
+// Mail is a plain text mail sent to a single recipient
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
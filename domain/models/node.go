@@ -29,5 +29,8 @@ type Node struct {
 	Path         string   `json:"path" fc_neo:"-"`
 	FullPath     string   `json:"full_path" fc_neo:"-"`
 
+	// Permission is the effective access of the perspective user, the most permissive path to the node wins
+	Permission ShareMode `json:"permission" fc_neo:"-"`
+
 	PerspectiveUserID UserID `json:"-"`
 }
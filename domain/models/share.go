@@ -1,5 +1,9 @@
 package models
 
+import (
+	"time"
+)
+
 type ShareMode string
 
 const (
@@ -8,8 +12,25 @@ const (
 	ShareModeReadWrite ShareMode = "READ_WRITE"
 )
 
+type ShareTargetType string
+
+const (
+	ShareTargetTypeUser  ShareTargetType = "USER"
+	ShareTargetTypeGroup ShareTargetType = "GROUP"
+)
+
 type Share struct {
-	NodeID       NodeID    `json:"node_id" fc_neo:"-"`
-	SharedWithID UserID    `json:"shared_with_id" fc_neo:"-"`
-	Mode         ShareMode `json:"share_mode"`
+	NodeID            NodeID          `json:"node_id" fc_neo:"-"`
+	Name              string          `json:"name" fc_neo:"-"`
+	TargetType        ShareTargetType `json:"target_type" fc_neo:"-"`
+	SharedWithID      UserID          `json:"shared_with_id" fc_neo:"-"`
+	SharedWithGroupID GroupID         `json:"shared_with_group_id" fc_neo:"-"`
+	Mode              ShareMode       `json:"share_mode"`
+	ExpiresAt         *time.Time      `json:"expires_at" fc_neo:",optional"`
+}
+
+// ShareMountUpdate changes where a share is mounted for the recipient, it does not affect the owner or other recipients
+type ShareMountUpdate struct {
+	Name         *string `json:"name"`
+	ParentNodeID *NodeID `json:"parent_node_id"`
 }
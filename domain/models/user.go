@@ -6,6 +6,26 @@ import (
 
 type UserID string
 
+type Role string
+
+const (
+	// RoleAdmin grants all permissions, it is stored as the IsAdmin flag of users for compatibility
+	RoleAdmin        Role = "ADMIN"
+	RoleUserManager  Role = "USER_MANAGER"
+	RoleAuditor      Role = "AUDITOR"
+	RoleStorageAdmin Role = "STORAGE_ADMIN"
+)
+
+// IsValid reports whether the role is known
+func (role Role) IsValid() bool {
+	switch role {
+	case RoleAdmin, RoleUserManager, RoleAuditor, RoleStorageAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
 type User struct {
 	ID      UserID    `json:"id" fc_neo:",unique"`
 	Created time.Time `json:"created"`
@@ -16,7 +36,83 @@ type User struct {
 	Email     string `json:"email" fc_neo:",unique"`
 	Password  string `json:"password,omitempty"`
 
-	IsAdmin bool `json:"is_admin"`
+	EmailVerified bool   `json:"email_verified" fc_neo:",optional"`
+	IsAdmin       bool   `json:"is_admin"`
+	AssignedRoles []Role `json:"roles" fc_neo:"roles,optional"`
+	// External users are linked to an external identity source, which decides about their names and admin flag
+	External bool `json:"external" fc_neo:",optional"`
+
+	// Storage quota in bytes, 0 means unlimited
+	Quota int64 `json:"quota" fc_neo:",optional"`
+
+	// Disabled users cannot log in and have no valid sessions
+	Disabled bool `json:"disabled" fc_neo:",optional"`
+
+	// Shown instead of the first and last name if set
+	DisplayName string `json:"display_name" fc_neo:",optional"`
+	// BCP 47 language tag like en-US, empty means the locale of the client is used
+	Locale string `json:"locale" fc_neo:",optional"`
+	// IANA time zone like Europe/Berlin, empty means the time zone of the client is used
+	Timezone  string `json:"timezone" fc_neo:",optional"`
+	HasAvatar bool   `json:"has_avatar" fc_neo:",optional"`
+}
+
+// AvatarSizes are the edge lengths in pixels of the square versions an uploaded avatar is stored in, in ascending order
+var AvatarSizes = []int{32, 64, 128, 256}
+
+// Roles returns all roles of the user including the admin role
+func (user *User) Roles() []Role {
+	roles := make([]Role, 0, len(user.AssignedRoles)+1)
+	if user.IsAdmin {
+		roles = append(roles, RoleAdmin)
+	}
+	for _, role := range user.AssignedRoles {
+		if role != RoleAdmin {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+func (user *User) HasRole(role Role) bool {
+	for _, userRole := range user.Roles() {
+		if userRole == role {
+			return true
+		}
+	}
+	return false
+}
+
+// UserFilter limits a user list to users matching all set fields
+type UserFilter struct {
+	// Matched case-insensitively against the name, display name and email
+	Search   *string `json:"search"`
+	Role     *Role   `json:"role"`
+	Disabled *bool   `json:"disabled"`
+}
+
+type UserSortField string
+
+const (
+	UserSortFieldEmail     UserSortField = "EMAIL"
+	UserSortFieldFirstName UserSortField = "FIRST_NAME"
+	UserSortFieldLastName  UserSortField = "LAST_NAME"
+	UserSortFieldCreated   UserSortField = "CREATED"
+)
+
+// IsValid reports whether users can be sorted by the field
+func (field UserSortField) IsValid() bool {
+	switch field {
+	case UserSortFieldEmail, UserSortFieldFirstName, UserSortFieldLastName, UserSortFieldCreated:
+		return true
+	default:
+		return false
+	}
+}
+
+type UserSort struct {
+	Field      UserSortField `json:"field"`
+	Descending bool          `json:"descending"`
 }
 
 type UserUpdate struct {
@@ -25,5 +121,21 @@ type UserUpdate struct {
 	Email     *string `json:"email"`
 	Password  *string `json:"password"`
 
-	IsAdmin *bool `json:"is_admin"`
+	DisplayName *string `json:"display_name"`
+	Locale      *string `json:"locale"`
+	Timezone    *string `json:"timezone"`
+
+	EmailVerified *bool `json:"email_verified"`
+	IsAdmin       *bool `json:"is_admin"`
+	External      *bool `json:"external"`
+}
+
+// ExternalUser is a user as known to an external identity source like an identity provider or a directory
+type ExternalUser struct {
+	Email     string
+	FirstName string
+	LastName  string
+
+	// IsAdmin is only set if the identity source decides about admin rights
+	IsAdmin *bool
 }
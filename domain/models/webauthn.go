@@ -0,0 +1,100 @@
+package models
+
+import "time"
+
+// WebAuthnCredentialID is the base64url encoded raw ID the authenticator assigned to the credential
+type WebAuthnCredentialID string
+
+// WebAuthnCredential is a security key or passkey registered by a user.
+// The public key is stored as base64url encoded COSE key.
+type WebAuthnCredential struct {
+	ID        WebAuthnCredentialID `json:"id" fc_neo:",unique"`
+	UserID    UserID               `json:"user_id" fc_neo:"-"`
+	Name      string               `json:"name"`
+	PublicKey string               `json:"-" fc_neo:"public_key"`
+	SignCount int64                `json:"sign_count"`
+	Created   time.Time            `json:"created"`
+	LastUsed  *time.Time           `json:"last_used" fc_neo:",optional"`
+}
+
+type WebAuthnChallengePurpose string
+
+const (
+	WebAuthnChallengePurposeRegistration WebAuthnChallengePurpose = "registration"
+	WebAuthnChallengePurposeLogin        WebAuthnChallengePurpose = "login"
+	WebAuthnChallengePurposeSecondFactor WebAuthnChallengePurpose = "second_factor"
+)
+
+// WebAuthnChallenge is handed to the browser at the start of a ceremony and can only be answered once.
+// The user is empty for logins with discoverable credentials where the user is only known from the response.
+type WebAuthnChallenge struct {
+	Challenge  string                   `json:"challenge" fc_neo:",unique"`
+	UserID     UserID                   `json:"user_id" fc_neo:",optional"`
+	Purpose    WebAuthnChallengePurpose `json:"purpose"`
+	ValidUntil time.Time                `json:"valid_until"`
+}
+
+// WebAuthnRelyingPartyEntity names the server in the options of a registration
+type WebAuthnRelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// WebAuthnUserEntity names the user in the options of a registration, the ID is returned as user handle by discoverable credentials
+type WebAuthnUserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type WebAuthnCredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type WebAuthnCredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type WebAuthnAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// WebAuthnCreationOptions are passed by the client to navigator.credentials.create after decoding the base64url fields
+type WebAuthnCreationOptions struct {
+	Challenge              string                          `json:"challenge"`
+	RP                     *WebAuthnRelyingPartyEntity     `json:"rp"`
+	User                   *WebAuthnUserEntity             `json:"user"`
+	PubKeyCredParams       []*WebAuthnCredentialParameter  `json:"pubKeyCredParams"`
+	ExcludeCredentials     []*WebAuthnCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection *WebAuthnAuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                          `json:"attestation"`
+	Timeout                int64                           `json:"timeout"`
+}
+
+// WebAuthnRequestOptions are passed by the client to navigator.credentials.get after decoding the base64url fields
+type WebAuthnRequestOptions struct {
+	Challenge        string                          `json:"challenge"`
+	RPID             string                          `json:"rpId"`
+	AllowCredentials []*WebAuthnCredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                          `json:"userVerification"`
+	Timeout          int64                           `json:"timeout"`
+}
+
+// WebAuthnAttestation is the response of the authenticator to a registration
+type WebAuthnAttestation struct {
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+// WebAuthnAssertion is the response of the authenticator to a login or second factor challenge
+type WebAuthnAssertion struct {
+	CredentialID      WebAuthnCredentialID
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	// Only set by discoverable credentials
+	UserHandle []byte
+}
@@ -5,6 +5,7 @@ go 1.14
 require (
 	github.com/99designs/gqlgen v0.13.0
 	github.com/gin-gonic/gin v1.7.7
+	github.com/go-ldap/ldap/v3 v3.4.1
 	github.com/golang/mock v1.5.0
 	github.com/google/uuid v1.2.0
 	github.com/neo4j/neo4j-go-driver v1.8.3
@@ -0,0 +1,429 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/freecloudio/server/application/config (interfaces: Config)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	reflect "reflect"
+	time "time"
+
+	config "github.com/freecloudio/server/application/config"
+	utils "github.com/freecloudio/server/utils"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockConfig is a mock of Config interface.
+type MockConfig struct {
+	ctrl     *gomock.Controller
+	recorder *MockConfigMockRecorder
+}
+
+// MockConfigMockRecorder is the mock recorder for MockConfig.
+type MockConfigMockRecorder struct {
+	mock *MockConfig
+}
+
+// NewMockConfig creates a new mock instance.
+func NewMockConfig(ctrl *gomock.Controller) *MockConfig {
+	mock := &MockConfig{ctrl: ctrl}
+	mock.recorder = &MockConfigMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockConfig) EXPECT() *MockConfigMockRecorder {
+	return m.recorder
+}
+
+// GetAvatarMaxSize mocks base method.
+func (m *MockConfig) GetAvatarMaxSize() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAvatarMaxSize")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// GetAvatarMaxSize indicates an expected call of GetAvatarMaxSize.
+func (mr *MockConfigMockRecorder) GetAvatarMaxSize() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAvatarMaxSize", reflect.TypeOf((*MockConfig)(nil).GetAvatarMaxSize))
+}
+
+// GetDBConnectionString mocks base method.
+func (m *MockConfig) GetDBConnectionString() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDBConnectionString")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetDBConnectionString indicates an expected call of GetDBConnectionString.
+func (mr *MockConfigMockRecorder) GetDBConnectionString() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDBConnectionString", reflect.TypeOf((*MockConfig)(nil).GetDBConnectionString))
+}
+
+// GetDBPassword mocks base method.
+func (m *MockConfig) GetDBPassword() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDBPassword")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetDBPassword indicates an expected call of GetDBPassword.
+func (mr *MockConfigMockRecorder) GetDBPassword() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDBPassword", reflect.TypeOf((*MockConfig)(nil).GetDBPassword))
+}
+
+// GetDBUsername mocks base method.
+func (m *MockConfig) GetDBUsername() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDBUsername")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetDBUsername indicates an expected call of GetDBUsername.
+func (mr *MockConfigMockRecorder) GetDBUsername() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDBUsername", reflect.TypeOf((*MockConfig)(nil).GetDBUsername))
+}
+
+// GetDataExportExpiration mocks base method.
+func (m *MockConfig) GetDataExportExpiration() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDataExportExpiration")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// GetDataExportExpiration indicates an expected call of GetDataExportExpiration.
+func (mr *MockConfigMockRecorder) GetDataExportExpiration() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataExportExpiration", reflect.TypeOf((*MockConfig)(nil).GetDataExportExpiration))
+}
+
+// GetEmailVerificationExpiration mocks base method.
+func (m *MockConfig) GetEmailVerificationExpiration() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmailVerificationExpiration")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// GetEmailVerificationExpiration indicates an expected call of GetEmailVerificationExpiration.
+func (mr *MockConfigMockRecorder) GetEmailVerificationExpiration() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmailVerificationExpiration", reflect.TypeOf((*MockConfig)(nil).GetEmailVerificationExpiration))
+}
+
+// GetEmailVerificationRequired mocks base method.
+func (m *MockConfig) GetEmailVerificationRequired() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmailVerificationRequired")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// GetEmailVerificationRequired indicates an expected call of GetEmailVerificationRequired.
+func (mr *MockConfigMockRecorder) GetEmailVerificationRequired() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmailVerificationRequired", reflect.TypeOf((*MockConfig)(nil).GetEmailVerificationRequired))
+}
+
+// GetExternalUserSyncInterval mocks base method.
+func (m *MockConfig) GetExternalUserSyncInterval() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExternalUserSyncInterval")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// GetExternalUserSyncInterval indicates an expected call of GetExternalUserSyncInterval.
+func (mr *MockConfigMockRecorder) GetExternalUserSyncInterval() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExternalUserSyncInterval", reflect.TypeOf((*MockConfig)(nil).GetExternalUserSyncInterval))
+}
+
+// GetFileStorageLocalFSBasePath mocks base method.
+func (m *MockConfig) GetFileStorageLocalFSBasePath() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileStorageLocalFSBasePath")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetFileStorageLocalFSBasePath indicates an expected call of GetFileStorageLocalFSBasePath.
+func (mr *MockConfigMockRecorder) GetFileStorageLocalFSBasePath() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileStorageLocalFSBasePath", reflect.TypeOf((*MockConfig)(nil).GetFileStorageLocalFSBasePath))
+}
+
+// GetFileStorageTempBasePath mocks base method.
+func (m *MockConfig) GetFileStorageTempBasePath() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileStorageTempBasePath")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetFileStorageTempBasePath indicates an expected call of GetFileStorageTempBasePath.
+func (mr *MockConfigMockRecorder) GetFileStorageTempBasePath() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileStorageTempBasePath", reflect.TypeOf((*MockConfig)(nil).GetFileStorageTempBasePath))
+}
+
+// GetLDAPConfig mocks base method.
+func (m *MockConfig) GetLDAPConfig() *config.LDAPConfig {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLDAPConfig")
+	ret0, _ := ret[0].(*config.LDAPConfig)
+	return ret0
+}
+
+// GetLDAPConfig indicates an expected call of GetLDAPConfig.
+func (mr *MockConfigMockRecorder) GetLDAPConfig() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLDAPConfig", reflect.TypeOf((*MockConfig)(nil).GetLDAPConfig))
+}
+
+// GetLoggingConfig mocks base method.
+func (m *MockConfig) GetLoggingConfig() *utils.LoggingConfig {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoggingConfig")
+	ret0, _ := ret[0].(*utils.LoggingConfig)
+	return ret0
+}
+
+// GetLoggingConfig indicates an expected call of GetLoggingConfig.
+func (mr *MockConfigMockRecorder) GetLoggingConfig() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoggingConfig", reflect.TypeOf((*MockConfig)(nil).GetLoggingConfig))
+}
+
+// GetLoginThrottleConfig mocks base method.
+func (m *MockConfig) GetLoginThrottleConfig() *config.LoginThrottleConfig {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginThrottleConfig")
+	ret0, _ := ret[0].(*config.LoginThrottleConfig)
+	return ret0
+}
+
+// GetLoginThrottleConfig indicates an expected call of GetLoginThrottleConfig.
+func (mr *MockConfigMockRecorder) GetLoginThrottleConfig() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginThrottleConfig", reflect.TypeOf((*MockConfig)(nil).GetLoginThrottleConfig))
+}
+
+// GetMailConfig mocks base method.
+func (m *MockConfig) GetMailConfig() *config.MailConfig {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMailConfig")
+	ret0, _ := ret[0].(*config.MailConfig)
+	return ret0
+}
+
+// GetMailConfig indicates an expected call of GetMailConfig.
+func (mr *MockConfigMockRecorder) GetMailConfig() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMailConfig", reflect.TypeOf((*MockConfig)(nil).GetMailConfig))
+}
+
+// GetOIDCConfig mocks base method.
+func (m *MockConfig) GetOIDCConfig() *config.OIDCConfig {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOIDCConfig")
+	ret0, _ := ret[0].(*config.OIDCConfig)
+	return ret0
+}
+
+// GetOIDCConfig indicates an expected call of GetOIDCConfig.
+func (mr *MockConfigMockRecorder) GetOIDCConfig() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOIDCConfig", reflect.TypeOf((*MockConfig)(nil).GetOIDCConfig))
+}
+
+// GetPasswordHashingConfig mocks base method.
+func (m *MockConfig) GetPasswordHashingConfig() *utils.PasswordHashingConfig {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPasswordHashingConfig")
+	ret0, _ := ret[0].(*utils.PasswordHashingConfig)
+	return ret0
+}
+
+// GetPasswordHashingConfig indicates an expected call of GetPasswordHashingConfig.
+func (mr *MockConfigMockRecorder) GetPasswordHashingConfig() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPasswordHashingConfig", reflect.TypeOf((*MockConfig)(nil).GetPasswordHashingConfig))
+}
+
+// GetPasswordPolicyConfig mocks base method.
+func (m *MockConfig) GetPasswordPolicyConfig() *config.PasswordPolicyConfig {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPasswordPolicyConfig")
+	ret0, _ := ret[0].(*config.PasswordPolicyConfig)
+	return ret0
+}
+
+// GetPasswordPolicyConfig indicates an expected call of GetPasswordPolicyConfig.
+func (mr *MockConfigMockRecorder) GetPasswordPolicyConfig() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPasswordPolicyConfig", reflect.TypeOf((*MockConfig)(nil).GetPasswordPolicyConfig))
+}
+
+// GetPasswordResetExpiration mocks base method.
+func (m *MockConfig) GetPasswordResetExpiration() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPasswordResetExpiration")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// GetPasswordResetExpiration indicates an expected call of GetPasswordResetExpiration.
+func (mr *MockConfigMockRecorder) GetPasswordResetExpiration() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPasswordResetExpiration", reflect.TypeOf((*MockConfig)(nil).GetPasswordResetExpiration))
+}
+
+// GetPublicURL mocks base method.
+func (m *MockConfig) GetPublicURL() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPublicURL")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetPublicURL indicates an expected call of GetPublicURL.
+func (mr *MockConfigMockRecorder) GetPublicURL() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPublicURL", reflect.TypeOf((*MockConfig)(nil).GetPublicURL))
+}
+
+// GetRegistrationConfig mocks base method.
+func (m *MockConfig) GetRegistrationConfig() *config.RegistrationConfig {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRegistrationConfig")
+	ret0, _ := ret[0].(*config.RegistrationConfig)
+	return ret0
+}
+
+// GetRegistrationConfig indicates an expected call of GetRegistrationConfig.
+func (mr *MockConfigMockRecorder) GetRegistrationConfig() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRegistrationConfig", reflect.TypeOf((*MockConfig)(nil).GetRegistrationConfig))
+}
+
+// GetSessionCleanupInterval mocks base method.
+func (m *MockConfig) GetSessionCleanupInterval() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionCleanupInterval")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// GetSessionCleanupInterval indicates an expected call of GetSessionCleanupInterval.
+func (mr *MockConfigMockRecorder) GetSessionCleanupInterval() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionCleanupInterval", reflect.TypeOf((*MockConfig)(nil).GetSessionCleanupInterval))
+}
+
+// GetSessionCookieConfig mocks base method.
+func (m *MockConfig) GetSessionCookieConfig() *config.SessionCookieConfig {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionCookieConfig")
+	ret0, _ := ret[0].(*config.SessionCookieConfig)
+	return ret0
+}
+
+// GetSessionCookieConfig indicates an expected call of GetSessionCookieConfig.
+func (mr *MockConfigMockRecorder) GetSessionCookieConfig() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionCookieConfig", reflect.TypeOf((*MockConfig)(nil).GetSessionCookieConfig))
+}
+
+// GetSessionExpirationDuration mocks base method.
+func (m *MockConfig) GetSessionExpirationDuration() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionExpirationDuration")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// GetSessionExpirationDuration indicates an expected call of GetSessionExpirationDuration.
+func (mr *MockConfigMockRecorder) GetSessionExpirationDuration() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionExpirationDuration", reflect.TypeOf((*MockConfig)(nil).GetSessionExpirationDuration))
+}
+
+// GetSessionLifetimeConfig mocks base method.
+func (m *MockConfig) GetSessionLifetimeConfig() *config.SessionLifetimeConfig {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionLifetimeConfig")
+	ret0, _ := ret[0].(*config.SessionLifetimeConfig)
+	return ret0
+}
+
+// GetSessionLifetimeConfig indicates an expected call of GetSessionLifetimeConfig.
+func (mr *MockConfigMockRecorder) GetSessionLifetimeConfig() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionLifetimeConfig", reflect.TypeOf((*MockConfig)(nil).GetSessionLifetimeConfig))
+}
+
+// GetSessionTokenLength mocks base method.
+func (m *MockConfig) GetSessionTokenLength() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionTokenLength")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GetSessionTokenLength indicates an expected call of GetSessionTokenLength.
+func (mr *MockConfigMockRecorder) GetSessionTokenLength() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionTokenLength", reflect.TypeOf((*MockConfig)(nil).GetSessionTokenLength))
+}
+
+// GetShareCleanupInterval mocks base method.
+func (m *MockConfig) GetShareCleanupInterval() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShareCleanupInterval")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// GetShareCleanupInterval indicates an expected call of GetShareCleanupInterval.
+func (mr *MockConfigMockRecorder) GetShareCleanupInterval() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShareCleanupInterval", reflect.TypeOf((*MockConfig)(nil).GetShareCleanupInterval))
+}
+
+// GetTokenSigningSecret mocks base method.
+func (m *MockConfig) GetTokenSigningSecret() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenSigningSecret")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetTokenSigningSecret indicates an expected call of GetTokenSigningSecret.
+func (mr *MockConfigMockRecorder) GetTokenSigningSecret() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenSigningSecret", reflect.TypeOf((*MockConfig)(nil).GetTokenSigningSecret))
+}
+
+// GetWebAuthnConfig mocks base method.
+func (m *MockConfig) GetWebAuthnConfig() *config.WebAuthnConfig {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebAuthnConfig")
+	ret0, _ := ret[0].(*config.WebAuthnConfig)
+	return ret0
+}
+
+// GetWebAuthnConfig indicates an expected call of GetWebAuthnConfig.
+func (mr *MockConfigMockRecorder) GetWebAuthnConfig() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebAuthnConfig", reflect.TypeOf((*MockConfig)(nil).GetWebAuthnConfig))
+}
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/freecloudio/server/application/manager (interfaces: AuthManager,UserManager,NodeManager)
+// Source: github.com/freecloudio/server/application/manager (interfaces: AuthManager,UserManager,NodeManager,FileDropManager,AuditManager,ExportManager,ShareManager)
 
 // Package mock is a generated GoMock package.
 package mock
@@ -37,6 +37,36 @@ func (m *MockAuthManager) EXPECT() *MockAuthManagerMockRecorder {
 	return m.recorder
 }
 
+// BeginWebAuthnLogin mocks base method.
+func (m *MockAuthManager) BeginWebAuthnLogin(arg0 string) (*models.WebAuthnRequestOptions, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginWebAuthnLogin", arg0)
+	ret0, _ := ret[0].(*models.WebAuthnRequestOptions)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// BeginWebAuthnLogin indicates an expected call of BeginWebAuthnLogin.
+func (mr *MockAuthManagerMockRecorder) BeginWebAuthnLogin(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginWebAuthnLogin", reflect.TypeOf((*MockAuthManager)(nil).BeginWebAuthnLogin), arg0)
+}
+
+// BeginWebAuthnRegistration mocks base method.
+func (m *MockAuthManager) BeginWebAuthnRegistration(arg0 *authorization.Context) (*models.WebAuthnCreationOptions, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginWebAuthnRegistration", arg0)
+	ret0, _ := ret[0].(*models.WebAuthnCreationOptions)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// BeginWebAuthnRegistration indicates an expected call of BeginWebAuthnRegistration.
+func (mr *MockAuthManagerMockRecorder) BeginWebAuthnRegistration(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginWebAuthnRegistration", reflect.TypeOf((*MockAuthManager)(nil).BeginWebAuthnRegistration), arg0)
+}
+
 // Close mocks base method.
 func (m *MockAuthManager) Close() {
 	m.ctrl.T.Helper()
@@ -49,34 +79,227 @@ func (mr *MockAuthManagerMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockAuthManager)(nil).Close))
 }
 
+// CompleteLoginChallenge mocks base method.
+func (m *MockAuthManager) CompleteLoginChallenge(arg0 models.Token, arg1 string, arg2 *models.SessionClient) (*models.Session, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteLoginChallenge", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Session)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// CompleteLoginChallenge indicates an expected call of CompleteLoginChallenge.
+func (mr *MockAuthManagerMockRecorder) CompleteLoginChallenge(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteLoginChallenge", reflect.TypeOf((*MockAuthManager)(nil).CompleteLoginChallenge), arg0, arg1, arg2)
+}
+
+// CompleteLoginChallengeWebAuthn mocks base method.
+func (m *MockAuthManager) CompleteLoginChallengeWebAuthn(arg0 models.Token, arg1 *models.WebAuthnAssertion, arg2 *models.SessionClient) (*models.Session, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteLoginChallengeWebAuthn", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Session)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// CompleteLoginChallengeWebAuthn indicates an expected call of CompleteLoginChallengeWebAuthn.
+func (mr *MockAuthManagerMockRecorder) CompleteLoginChallengeWebAuthn(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteLoginChallengeWebAuthn", reflect.TypeOf((*MockAuthManager)(nil).CompleteLoginChallengeWebAuthn), arg0, arg1, arg2)
+}
+
+// ConfirmTOTP mocks base method.
+func (m *MockAuthManager) ConfirmTOTP(arg0 *authorization.Context, arg1 string) ([]string, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTP", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockAuthManagerMockRecorder) ConfirmTOTP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockAuthManager)(nil).ConfirmTOTP), arg0, arg1)
+}
+
+// CreateAccessToken mocks base method.
+func (m *MockAuthManager) CreateAccessToken(arg0 *authorization.Context, arg1 *models.AccessToken) (models.Token, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccessToken", arg0, arg1)
+	ret0, _ := ret[0].(models.Token)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// CreateAccessToken indicates an expected call of CreateAccessToken.
+func (mr *MockAuthManagerMockRecorder) CreateAccessToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccessToken", reflect.TypeOf((*MockAuthManager)(nil).CreateAccessToken), arg0, arg1)
+}
+
 // CreateNewSession mocks base method.
-func (m *MockAuthManager) CreateNewSession(arg0 models.UserID) (*models.Session, *fcerror.Error) {
+func (m *MockAuthManager) CreateNewSession(arg0 models.UserID, arg1 bool, arg2 *models.SessionClient) (*models.Session, *fcerror.Error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateNewSession", arg0)
+	ret := m.ctrl.Call(m, "CreateNewSession", arg0, arg1, arg2)
 	ret0, _ := ret[0].(*models.Session)
 	ret1, _ := ret[1].(*fcerror.Error)
 	return ret0, ret1
 }
 
 // CreateNewSession indicates an expected call of CreateNewSession.
-func (mr *MockAuthManagerMockRecorder) CreateNewSession(arg0 interface{}) *gomock.Call {
+func (mr *MockAuthManagerMockRecorder) CreateNewSession(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewSession", reflect.TypeOf((*MockAuthManager)(nil).CreateNewSession), arg0, arg1, arg2)
+}
+
+// DeleteWebAuthnCredential mocks base method.
+func (m *MockAuthManager) DeleteWebAuthnCredential(arg0 *authorization.Context, arg1 models.WebAuthnCredentialID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebAuthnCredential", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteWebAuthnCredential indicates an expected call of DeleteWebAuthnCredential.
+func (mr *MockAuthManagerMockRecorder) DeleteWebAuthnCredential(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebAuthnCredential", reflect.TypeOf((*MockAuthManager)(nil).DeleteWebAuthnCredential), arg0, arg1)
+}
+
+// DisableTOTP mocks base method.
+func (m *MockAuthManager) DisableTOTP(arg0 *authorization.Context, arg1 string) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTOTP", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DisableTOTP indicates an expected call of DisableTOTP.
+func (mr *MockAuthManagerMockRecorder) DisableTOTP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTP", reflect.TypeOf((*MockAuthManager)(nil).DisableTOTP), arg0, arg1)
+}
+
+// EnrollTOTP mocks base method.
+func (m *MockAuthManager) EnrollTOTP(arg0 *authorization.Context) (string, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTOTP", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// EnrollTOTP indicates an expected call of EnrollTOTP.
+func (mr *MockAuthManagerMockRecorder) EnrollTOTP(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTOTP", reflect.TypeOf((*MockAuthManager)(nil).EnrollTOTP), arg0)
+}
+
+// FinishWebAuthnRegistration mocks base method.
+func (m *MockAuthManager) FinishWebAuthnRegistration(arg0 *authorization.Context, arg1 string, arg2 *models.WebAuthnAttestation) (*models.WebAuthnCredential, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishWebAuthnRegistration", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.WebAuthnCredential)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// FinishWebAuthnRegistration indicates an expected call of FinishWebAuthnRegistration.
+func (mr *MockAuthManagerMockRecorder) FinishWebAuthnRegistration(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewSession", reflect.TypeOf((*MockAuthManager)(nil).CreateNewSession), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishWebAuthnRegistration", reflect.TypeOf((*MockAuthManager)(nil).FinishWebAuthnRegistration), arg0, arg1, arg2)
+}
+
+// GetOwnAccessTokens mocks base method.
+func (m *MockAuthManager) GetOwnAccessTokens(arg0 *authorization.Context) ([]*models.AccessToken, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOwnAccessTokens", arg0)
+	ret0, _ := ret[0].([]*models.AccessToken)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetOwnAccessTokens indicates an expected call of GetOwnAccessTokens.
+func (mr *MockAuthManagerMockRecorder) GetOwnAccessTokens(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnAccessTokens", reflect.TypeOf((*MockAuthManager)(nil).GetOwnAccessTokens), arg0)
+}
+
+// GetOwnSessions mocks base method.
+func (m *MockAuthManager) GetOwnSessions(arg0 *authorization.Context, arg1 models.Token) ([]*models.Session, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOwnSessions", arg0, arg1)
+	ret0, _ := ret[0].([]*models.Session)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetOwnSessions indicates an expected call of GetOwnSessions.
+func (mr *MockAuthManagerMockRecorder) GetOwnSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnSessions", reflect.TypeOf((*MockAuthManager)(nil).GetOwnSessions), arg0, arg1)
+}
+
+// GetOwnWebAuthnCredentials mocks base method.
+func (m *MockAuthManager) GetOwnWebAuthnCredentials(arg0 *authorization.Context) ([]*models.WebAuthnCredential, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOwnWebAuthnCredentials", arg0)
+	ret0, _ := ret[0].([]*models.WebAuthnCredential)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetOwnWebAuthnCredentials indicates an expected call of GetOwnWebAuthnCredentials.
+func (mr *MockAuthManagerMockRecorder) GetOwnWebAuthnCredentials(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnWebAuthnCredentials", reflect.TypeOf((*MockAuthManager)(nil).GetOwnWebAuthnCredentials), arg0)
+}
+
+// LinkExternalAccount mocks base method.
+func (m *MockAuthManager) LinkExternalAccount(arg0 *authorization.Context, arg1, arg2 string) (*models.User, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkExternalAccount", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// LinkExternalAccount indicates an expected call of LinkExternalAccount.
+func (mr *MockAuthManagerMockRecorder) LinkExternalAccount(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkExternalAccount", reflect.TypeOf((*MockAuthManager)(nil).LinkExternalAccount), arg0, arg1, arg2)
 }
 
 // Login mocks base method.
-func (m *MockAuthManager) Login(arg0, arg1 string) (*models.Session, *fcerror.Error) {
+func (m *MockAuthManager) Login(arg0, arg1 string, arg2 bool, arg3 *models.SessionClient) (*models.LoginResult, *fcerror.Error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Login", arg0, arg1)
-	ret0, _ := ret[0].(*models.Session)
+	ret := m.ctrl.Call(m, "Login", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*models.LoginResult)
 	ret1, _ := ret[1].(*fcerror.Error)
 	return ret0, ret1
 }
 
 // Login indicates an expected call of Login.
-func (mr *MockAuthManagerMockRecorder) Login(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockAuthManagerMockRecorder) Login(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthManager)(nil).Login), arg0, arg1, arg2, arg3)
+}
+
+// LoginExternal mocks base method.
+func (m *MockAuthManager) LoginExternal(arg0 *models.ExternalUser, arg1 *models.SessionClient) (*models.Session, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginExternal", arg0, arg1)
+	ret0, _ := ret[0].(*models.Session)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// LoginExternal indicates an expected call of LoginExternal.
+func (mr *MockAuthManagerMockRecorder) LoginExternal(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthManager)(nil).Login), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginExternal", reflect.TypeOf((*MockAuthManager)(nil).LoginExternal), arg0, arg1)
 }
 
 // Logout mocks base method.
@@ -93,19 +316,176 @@ func (mr *MockAuthManagerMockRecorder) Logout(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockAuthManager)(nil).Logout), arg0)
 }
 
+// RequestEmailVerification mocks base method.
+func (m *MockAuthManager) RequestEmailVerification(arg0 string) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestEmailVerification", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// RequestEmailVerification indicates an expected call of RequestEmailVerification.
+func (mr *MockAuthManagerMockRecorder) RequestEmailVerification(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestEmailVerification", reflect.TypeOf((*MockAuthManager)(nil).RequestEmailVerification), arg0)
+}
+
+// RequestPasswordReset mocks base method.
+func (m *MockAuthManager) RequestPasswordReset(arg0 string) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPasswordReset", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// RequestPasswordReset indicates an expected call of RequestPasswordReset.
+func (mr *MockAuthManagerMockRecorder) RequestPasswordReset(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPasswordReset", reflect.TypeOf((*MockAuthManager)(nil).RequestPasswordReset), arg0)
+}
+
+// ResetPassword mocks base method.
+func (m *MockAuthManager) ResetPassword(arg0, arg1 string) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockAuthManagerMockRecorder) ResetPassword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockAuthManager)(nil).ResetPassword), arg0, arg1)
+}
+
+// RevokeAccessToken mocks base method.
+func (m *MockAuthManager) RevokeAccessToken(arg0 *authorization.Context, arg1 models.AccessTokenID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAccessToken", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// RevokeAccessToken indicates an expected call of RevokeAccessToken.
+func (mr *MockAuthManagerMockRecorder) RevokeAccessToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAccessToken", reflect.TypeOf((*MockAuthManager)(nil).RevokeAccessToken), arg0, arg1)
+}
+
+// RevokeAllOtherSessions mocks base method.
+func (m *MockAuthManager) RevokeAllOtherSessions(arg0 *authorization.Context, arg1 models.Token) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAllOtherSessions", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// RevokeAllOtherSessions indicates an expected call of RevokeAllOtherSessions.
+func (mr *MockAuthManagerMockRecorder) RevokeAllOtherSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllOtherSessions", reflect.TypeOf((*MockAuthManager)(nil).RevokeAllOtherSessions), arg0, arg1)
+}
+
+// RevokeAllSessionsOfUser mocks base method.
+func (m *MockAuthManager) RevokeAllSessionsOfUser(arg0 *authorization.Context, arg1 models.UserID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAllSessionsOfUser", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// RevokeAllSessionsOfUser indicates an expected call of RevokeAllSessionsOfUser.
+func (mr *MockAuthManagerMockRecorder) RevokeAllSessionsOfUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllSessionsOfUser", reflect.TypeOf((*MockAuthManager)(nil).RevokeAllSessionsOfUser), arg0, arg1)
+}
+
+// RevokeSession mocks base method.
+func (m *MockAuthManager) RevokeSession(arg0 *authorization.Context, arg1 models.SessionID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockAuthManagerMockRecorder) RevokeSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockAuthManager)(nil).RevokeSession), arg0, arg1)
+}
+
+// UnlockLogin mocks base method.
+func (m *MockAuthManager) UnlockLogin(arg0 *authorization.Context, arg1 models.UserID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockLogin", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// UnlockLogin indicates an expected call of UnlockLogin.
+func (mr *MockAuthManagerMockRecorder) UnlockLogin(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockLogin", reflect.TypeOf((*MockAuthManager)(nil).UnlockLogin), arg0, arg1)
+}
+
+// VerifyAccessToken mocks base method.
+func (m *MockAuthManager) VerifyAccessToken(arg0 models.Token) (*models.User, *models.TokenScope, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyAccessToken", arg0)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(*models.TokenScope)
+	ret2, _ := ret[2].(*fcerror.Error)
+	return ret0, ret1, ret2
+}
+
+// VerifyAccessToken indicates an expected call of VerifyAccessToken.
+func (mr *MockAuthManagerMockRecorder) VerifyAccessToken(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyAccessToken", reflect.TypeOf((*MockAuthManager)(nil).VerifyAccessToken), arg0)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockAuthManager) VerifyEmail(arg0 string) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockAuthManagerMockRecorder) VerifyEmail(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockAuthManager)(nil).VerifyEmail), arg0)
+}
+
 // VerifyToken mocks base method.
-func (m *MockAuthManager) VerifyToken(arg0 models.Token) (*models.User, *fcerror.Error) {
+func (m *MockAuthManager) VerifyToken(arg0 models.Token, arg1 *models.SessionClient) (*models.User, *fcerror.Error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "VerifyToken", arg0)
+	ret := m.ctrl.Call(m, "VerifyToken", arg0, arg1)
 	ret0, _ := ret[0].(*models.User)
 	ret1, _ := ret[1].(*fcerror.Error)
 	return ret0, ret1
 }
 
 // VerifyToken indicates an expected call of VerifyToken.
-func (mr *MockAuthManagerMockRecorder) VerifyToken(arg0 interface{}) *gomock.Call {
+func (mr *MockAuthManagerMockRecorder) VerifyToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyToken", reflect.TypeOf((*MockAuthManager)(nil).VerifyToken), arg0, arg1)
+}
+
+// WebAuthnLogin mocks base method.
+func (m *MockAuthManager) WebAuthnLogin(arg0 *models.WebAuthnAssertion, arg1 *models.SessionClient) (*models.Session, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WebAuthnLogin", arg0, arg1)
+	ret0, _ := ret[0].(*models.Session)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// WebAuthnLogin indicates an expected call of WebAuthnLogin.
+func (mr *MockAuthManagerMockRecorder) WebAuthnLogin(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyToken", reflect.TypeOf((*MockAuthManager)(nil).VerifyToken), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WebAuthnLogin", reflect.TypeOf((*MockAuthManager)(nil).WebAuthnLogin), arg0, arg1)
 }
 
 // MockUserManager is a mock of UserManager interface.
@@ -131,76 +511,299 @@ func (m *MockUserManager) EXPECT() *MockUserManagerMockRecorder {
 	return m.recorder
 }
 
-// Close mocks base method.
-func (m *MockUserManager) Close() {
+// Close mocks base method.
+func (m *MockUserManager) Close() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close")
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockUserManagerMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockUserManager)(nil).Close))
+}
+
+// CountUsers mocks base method.
+func (m *MockUserManager) CountUsers(arg0 *authorization.Context) (int64, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUsers", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// CountUsers indicates an expected call of CountUsers.
+func (mr *MockUserManagerMockRecorder) CountUsers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUsers", reflect.TypeOf((*MockUserManager)(nil).CountUsers), arg0)
+}
+
+// CreateInvite mocks base method.
+func (m *MockUserManager) CreateInvite(arg0 *authorization.Context, arg1 *models.Invite) (string, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInvite", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// CreateInvite indicates an expected call of CreateInvite.
+func (mr *MockUserManagerMockRecorder) CreateInvite(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvite", reflect.TypeOf((*MockUserManager)(nil).CreateInvite), arg0, arg1)
+}
+
+// CreateUser mocks base method.
+func (m *MockUserManager) CreateUser(arg0 *models.User, arg1 string) (*models.Session, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
+	ret0, _ := ret[0].(*models.Session)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockUserManagerMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserManager)(nil).CreateUser), arg0, arg1)
+}
+
+// CreateUserAsAdmin mocks base method.
+func (m *MockUserManager) CreateUserAsAdmin(arg0 *authorization.Context, arg1 *models.User) (*models.User, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserAsAdmin", arg0, arg1)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// CreateUserAsAdmin indicates an expected call of CreateUserAsAdmin.
+func (mr *MockUserManagerMockRecorder) CreateUserAsAdmin(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserAsAdmin", reflect.TypeOf((*MockUserManager)(nil).CreateUserAsAdmin), arg0, arg1)
+}
+
+// DeleteAvatar mocks base method.
+func (m *MockUserManager) DeleteAvatar(arg0 *authorization.Context, arg1 models.UserID) (*models.User, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAvatar", arg0, arg1)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// DeleteAvatar indicates an expected call of DeleteAvatar.
+func (mr *MockUserManagerMockRecorder) DeleteAvatar(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAvatar", reflect.TypeOf((*MockUserManager)(nil).DeleteAvatar), arg0, arg1)
+}
+
+// DeleteMyAccount mocks base method.
+func (m *MockUserManager) DeleteMyAccount(arg0 *authorization.Context, arg1 string) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMyAccount", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteMyAccount indicates an expected call of DeleteMyAccount.
+func (mr *MockUserManagerMockRecorder) DeleteMyAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMyAccount", reflect.TypeOf((*MockUserManager)(nil).DeleteMyAccount), arg0, arg1)
+}
+
+// DeleteUser mocks base method.
+func (m *MockUserManager) DeleteUser(arg0 *authorization.Context, arg1 models.UserID, arg2 *models.UserID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockUserManagerMockRecorder) DeleteUser(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserManager)(nil).DeleteUser), arg0, arg1, arg2)
+}
+
+// DisableUser mocks base method.
+func (m *MockUserManager) DisableUser(arg0 *authorization.Context, arg1 models.UserID) (*models.User, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableUser", arg0, arg1)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// DisableUser indicates an expected call of DisableUser.
+func (mr *MockUserManagerMockRecorder) DisableUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableUser", reflect.TypeOf((*MockUserManager)(nil).DisableUser), arg0, arg1)
+}
+
+// EnableUser mocks base method.
+func (m *MockUserManager) EnableUser(arg0 *authorization.Context, arg1 models.UserID) (*models.User, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableUser", arg0, arg1)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// EnableUser indicates an expected call of EnableUser.
+func (mr *MockUserManagerMockRecorder) EnableUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableUser", reflect.TypeOf((*MockUserManager)(nil).EnableUser), arg0, arg1)
+}
+
+// GetAvatar mocks base method.
+func (m *MockUserManager) GetAvatar(arg0 *authorization.Context, arg1 models.UserID, arg2 int) (io.ReadCloser, int64, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAvatar", arg0, arg1, arg2)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(*fcerror.Error)
+	return ret0, ret1, ret2
+}
+
+// GetAvatar indicates an expected call of GetAvatar.
+func (mr *MockUserManagerMockRecorder) GetAvatar(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAvatar", reflect.TypeOf((*MockUserManager)(nil).GetAvatar), arg0, arg1, arg2)
+}
+
+// GetInvites mocks base method.
+func (m *MockUserManager) GetInvites(arg0 *authorization.Context) ([]*models.Invite, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvites", arg0)
+	ret0, _ := ret[0].([]*models.Invite)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetInvites indicates an expected call of GetInvites.
+func (mr *MockUserManagerMockRecorder) GetInvites(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvites", reflect.TypeOf((*MockUserManager)(nil).GetInvites), arg0)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockUserManager) GetUserByEmail(arg0 *authorization.Context, arg1 string) (*models.User, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", arg0, arg1)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockUserManagerMockRecorder) GetUserByEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockUserManager)(nil).GetUserByEmail), arg0, arg1)
+}
+
+// GetUserByID mocks base method.
+func (m *MockUserManager) GetUserByID(arg0 *authorization.Context, arg1 models.UserID) (*models.User, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", arg0, arg1)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockUserManagerMockRecorder) GetUserByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockUserManager)(nil).GetUserByID), arg0, arg1)
+}
+
+// GetUsers mocks base method.
+func (m *MockUserManager) GetUsers(arg0 *authorization.Context, arg1 *models.UserFilter, arg2 *models.UserSort, arg3 *models.UserID) ([]*models.User, *fcerror.Error) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Close")
+	ret := m.ctrl.Call(m, "GetUsers", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*models.User)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
 }
 
-// Close indicates an expected call of Close.
-func (mr *MockUserManagerMockRecorder) Close() *gomock.Call {
+// GetUsers indicates an expected call of GetUsers.
+func (mr *MockUserManagerMockRecorder) GetUsers(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockUserManager)(nil).Close))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserManager)(nil).GetUsers), arg0, arg1, arg2, arg3)
 }
 
-// CountUsers mocks base method.
-func (m *MockUserManager) CountUsers(arg0 *authorization.Context) (int64, *fcerror.Error) {
+// ProvisionExternalUser mocks base method.
+func (m *MockUserManager) ProvisionExternalUser(arg0 *models.ExternalUser) (*models.User, *fcerror.Error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CountUsers", arg0)
-	ret0, _ := ret[0].(int64)
+	ret := m.ctrl.Call(m, "ProvisionExternalUser", arg0)
+	ret0, _ := ret[0].(*models.User)
 	ret1, _ := ret[1].(*fcerror.Error)
 	return ret0, ret1
 }
 
-// CountUsers indicates an expected call of CountUsers.
-func (mr *MockUserManagerMockRecorder) CountUsers(arg0 interface{}) *gomock.Call {
+// ProvisionExternalUser indicates an expected call of ProvisionExternalUser.
+func (mr *MockUserManagerMockRecorder) ProvisionExternalUser(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUsers", reflect.TypeOf((*MockUserManager)(nil).CountUsers), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProvisionExternalUser", reflect.TypeOf((*MockUserManager)(nil).ProvisionExternalUser), arg0)
 }
 
-// CreateUser mocks base method.
-func (m *MockUserManager) CreateUser(arg0 *models.User) (*models.Session, *fcerror.Error) {
+// RevokeInvite mocks base method.
+func (m *MockUserManager) RevokeInvite(arg0 *authorization.Context, arg1 models.InviteID) *fcerror.Error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", arg0)
-	ret0, _ := ret[0].(*models.Session)
+	ret := m.ctrl.Call(m, "RevokeInvite", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// RevokeInvite indicates an expected call of RevokeInvite.
+func (mr *MockUserManagerMockRecorder) RevokeInvite(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeInvite", reflect.TypeOf((*MockUserManager)(nil).RevokeInvite), arg0, arg1)
+}
+
+// SetAvatar mocks base method.
+func (m *MockUserManager) SetAvatar(arg0 *authorization.Context, arg1 models.UserID, arg2 io.Reader) (*models.User, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAvatar", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.User)
 	ret1, _ := ret[1].(*fcerror.Error)
 	return ret0, ret1
 }
 
-// CreateUser indicates an expected call of CreateUser.
-func (mr *MockUserManagerMockRecorder) CreateUser(arg0 interface{}) *gomock.Call {
+// SetAvatar indicates an expected call of SetAvatar.
+func (mr *MockUserManagerMockRecorder) SetAvatar(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserManager)(nil).CreateUser), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAvatar", reflect.TypeOf((*MockUserManager)(nil).SetAvatar), arg0, arg1, arg2)
 }
 
-// GetUserByEmail mocks base method.
-func (m *MockUserManager) GetUserByEmail(arg0 *authorization.Context, arg1 string) (*models.User, *fcerror.Error) {
+// SetUserRoles mocks base method.
+func (m *MockUserManager) SetUserRoles(arg0 *authorization.Context, arg1 models.UserID, arg2 []models.Role) (*models.User, *fcerror.Error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByEmail", arg0, arg1)
+	ret := m.ctrl.Call(m, "SetUserRoles", arg0, arg1, arg2)
 	ret0, _ := ret[0].(*models.User)
 	ret1, _ := ret[1].(*fcerror.Error)
 	return ret0, ret1
 }
 
-// GetUserByEmail indicates an expected call of GetUserByEmail.
-func (mr *MockUserManagerMockRecorder) GetUserByEmail(arg0, arg1 interface{}) *gomock.Call {
+// SetUserRoles indicates an expected call of SetUserRoles.
+func (mr *MockUserManagerMockRecorder) SetUserRoles(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockUserManager)(nil).GetUserByEmail), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRoles", reflect.TypeOf((*MockUserManager)(nil).SetUserRoles), arg0, arg1, arg2)
 }
 
-// GetUserByID mocks base method.
-func (m *MockUserManager) GetUserByID(arg0 *authorization.Context, arg1 models.UserID) (*models.User, *fcerror.Error) {
+// SyncExternalUser mocks base method.
+func (m *MockUserManager) SyncExternalUser(arg0 *models.ExternalUser) (*models.User, *fcerror.Error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByID", arg0, arg1)
+	ret := m.ctrl.Call(m, "SyncExternalUser", arg0)
 	ret0, _ := ret[0].(*models.User)
 	ret1, _ := ret[1].(*fcerror.Error)
 	return ret0, ret1
 }
 
-// GetUserByID indicates an expected call of GetUserByID.
-func (mr *MockUserManagerMockRecorder) GetUserByID(arg0, arg1 interface{}) *gomock.Call {
+// SyncExternalUser indicates an expected call of SyncExternalUser.
+func (mr *MockUserManagerMockRecorder) SyncExternalUser(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockUserManager)(nil).GetUserByID), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncExternalUser", reflect.TypeOf((*MockUserManager)(nil).SyncExternalUser), arg0)
 }
 
 // UpdateUser mocks base method.
@@ -282,6 +885,20 @@ func (mr *MockNodeManagerMockRecorder) CreateUserRootFolder(arg0, arg1 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserRootFolder", reflect.TypeOf((*MockNodeManager)(nil).CreateUserRootFolder), arg0, arg1)
 }
 
+// DeleteUserRootFolder mocks base method.
+func (m *MockNodeManager) DeleteUserRootFolder(arg0 *authorization.Context, arg1 models.UserID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserRootFolder", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteUserRootFolder indicates an expected call of DeleteUserRootFolder.
+func (mr *MockNodeManagerMockRecorder) DeleteUserRootFolder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserRootFolder", reflect.TypeOf((*MockNodeManager)(nil).DeleteUserRootFolder), arg0, arg1)
+}
+
 // DownloadFile mocks base method.
 func (m *MockNodeManager) DownloadFile(arg0 *authorization.Context, arg1 models.NodeID) (*models.Node, io.ReadCloser, int64, *fcerror.Error) {
 	m.ctrl.T.Helper()
@@ -344,6 +961,20 @@ func (mr *MockNodeManagerMockRecorder) ListByID(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByID", reflect.TypeOf((*MockNodeManager)(nil).ListByID), arg0, arg1)
 }
 
+// TransferUserRootFolder mocks base method.
+func (m *MockNodeManager) TransferUserRootFolder(arg0 *authorization.Context, arg1, arg2 models.UserID, arg3 string) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferUserRootFolder", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// TransferUserRootFolder indicates an expected call of TransferUserRootFolder.
+func (mr *MockNodeManagerMockRecorder) TransferUserRootFolder(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferUserRootFolder", reflect.TypeOf((*MockNodeManager)(nil).TransferUserRootFolder), arg0, arg1, arg2, arg3)
+}
+
 // UploadFileByID mocks base method.
 func (m *MockNodeManager) UploadFileByID(arg0 *authorization.Context, arg1 models.NodeID, arg2 string) *fcerror.Error {
 	m.ctrl.T.Helper()
@@ -357,3 +988,348 @@ func (mr *MockNodeManagerMockRecorder) UploadFileByID(arg0, arg1, arg2 interface
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadFileByID", reflect.TypeOf((*MockNodeManager)(nil).UploadFileByID), arg0, arg1, arg2)
 }
+
+// MockFileDropManager is a mock of FileDropManager interface.
+type MockFileDropManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileDropManagerMockRecorder
+}
+
+// MockFileDropManagerMockRecorder is the mock recorder for MockFileDropManager.
+type MockFileDropManagerMockRecorder struct {
+	mock *MockFileDropManager
+}
+
+// NewMockFileDropManager creates a new mock instance.
+func NewMockFileDropManager(ctrl *gomock.Controller) *MockFileDropManager {
+	mock := &MockFileDropManager{ctrl: ctrl}
+	mock.recorder = &MockFileDropManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileDropManager) EXPECT() *MockFileDropManagerMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockFileDropManager) Close() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close")
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockFileDropManagerMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockFileDropManager)(nil).Close))
+}
+
+// CreateFileDrop mocks base method.
+func (m *MockFileDropManager) CreateFileDrop(arg0 *authorization.Context, arg1 *models.FileDrop) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFileDrop", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// CreateFileDrop indicates an expected call of CreateFileDrop.
+func (mr *MockFileDropManagerMockRecorder) CreateFileDrop(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFileDrop", reflect.TypeOf((*MockFileDropManager)(nil).CreateFileDrop), arg0, arg1)
+}
+
+// DeleteFileDrop mocks base method.
+func (m *MockFileDropManager) DeleteFileDrop(arg0 *authorization.Context, arg1 models.FileDropID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFileDrop", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteFileDrop indicates an expected call of DeleteFileDrop.
+func (mr *MockFileDropManagerMockRecorder) DeleteFileDrop(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFileDrop", reflect.TypeOf((*MockFileDropManager)(nil).DeleteFileDrop), arg0, arg1)
+}
+
+// GetFileDropContext mocks base method.
+func (m *MockFileDropManager) GetFileDropContext(arg0 models.FileDropID) (*authorization.Context, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileDropContext", arg0)
+	ret0, _ := ret[0].(*authorization.Context)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetFileDropContext indicates an expected call of GetFileDropContext.
+func (mr *MockFileDropManagerMockRecorder) GetFileDropContext(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileDropContext", reflect.TypeOf((*MockFileDropManager)(nil).GetFileDropContext), arg0)
+}
+
+// GetOwnFileDrops mocks base method.
+func (m *MockFileDropManager) GetOwnFileDrops(arg0 *authorization.Context) ([]*models.FileDrop, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOwnFileDrops", arg0)
+	ret0, _ := ret[0].([]*models.FileDrop)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetOwnFileDrops indicates an expected call of GetOwnFileDrops.
+func (mr *MockFileDropManagerMockRecorder) GetOwnFileDrops(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnFileDrops", reflect.TypeOf((*MockFileDropManager)(nil).GetOwnFileDrops), arg0)
+}
+
+// MockAuditManager is a mock of AuditManager interface.
+type MockAuditManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditManagerMockRecorder
+}
+
+// MockAuditManagerMockRecorder is the mock recorder for MockAuditManager.
+type MockAuditManagerMockRecorder struct {
+	mock *MockAuditManager
+}
+
+// NewMockAuditManager creates a new mock instance.
+func NewMockAuditManager(ctrl *gomock.Controller) *MockAuditManager {
+	mock := &MockAuditManager{ctrl: ctrl}
+	mock.recorder = &MockAuditManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditManager) EXPECT() *MockAuditManagerMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockAuditManager) Close() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close")
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockAuditManagerMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockAuditManager)(nil).Close))
+}
+
+// ExportAuditLog mocks base method.
+func (m *MockAuditManager) ExportAuditLog(arg0 *authorization.Context, arg1 *models.AuditFilter, arg2 io.Writer) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportAuditLog", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// ExportAuditLog indicates an expected call of ExportAuditLog.
+func (mr *MockAuditManagerMockRecorder) ExportAuditLog(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportAuditLog", reflect.TypeOf((*MockAuditManager)(nil).ExportAuditLog), arg0, arg1, arg2)
+}
+
+// GetAuditLog mocks base method.
+func (m *MockAuditManager) GetAuditLog(arg0 *authorization.Context, arg1 *models.AuditFilter, arg2 *models.AuditEventID) ([]*models.AuditEvent, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditLog", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*models.AuditEvent)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetAuditLog indicates an expected call of GetAuditLog.
+func (mr *MockAuditManagerMockRecorder) GetAuditLog(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditLog", reflect.TypeOf((*MockAuditManager)(nil).GetAuditLog), arg0, arg1, arg2)
+}
+
+// Record mocks base method.
+func (m *MockAuditManager) Record(arg0 *authorization.Context, arg1 *models.AuditEvent) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Record", arg0, arg1)
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAuditManagerMockRecorder) Record(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAuditManager)(nil).Record), arg0, arg1)
+}
+
+// MockExportManager is a mock of ExportManager interface.
+type MockExportManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockExportManagerMockRecorder
+}
+
+// MockExportManagerMockRecorder is the mock recorder for MockExportManager.
+type MockExportManagerMockRecorder struct {
+	mock *MockExportManager
+}
+
+// NewMockExportManager creates a new mock instance.
+func NewMockExportManager(ctrl *gomock.Controller) *MockExportManager {
+	mock := &MockExportManager{ctrl: ctrl}
+	mock.recorder = &MockExportManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExportManager) EXPECT() *MockExportManagerMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockExportManager) Close() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close")
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockExportManagerMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockExportManager)(nil).Close))
+}
+
+// GetOwnDataExports mocks base method.
+func (m *MockExportManager) GetOwnDataExports(arg0 *authorization.Context) ([]*models.DataExport, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOwnDataExports", arg0)
+	ret0, _ := ret[0].([]*models.DataExport)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetOwnDataExports indicates an expected call of GetOwnDataExports.
+func (mr *MockExportManagerMockRecorder) GetOwnDataExports(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnDataExports", reflect.TypeOf((*MockExportManager)(nil).GetOwnDataExports), arg0)
+}
+
+// OpenDataExport mocks base method.
+func (m *MockExportManager) OpenDataExport(arg0 string) (*models.DataExport, io.ReadCloser, int64, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenDataExport", arg0)
+	ret0, _ := ret[0].(*models.DataExport)
+	ret1, _ := ret[1].(io.ReadCloser)
+	ret2, _ := ret[2].(int64)
+	ret3, _ := ret[3].(*fcerror.Error)
+	return ret0, ret1, ret2, ret3
+}
+
+// OpenDataExport indicates an expected call of OpenDataExport.
+func (mr *MockExportManagerMockRecorder) OpenDataExport(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenDataExport", reflect.TypeOf((*MockExportManager)(nil).OpenDataExport), arg0)
+}
+
+// RequestDataExport mocks base method.
+func (m *MockExportManager) RequestDataExport(arg0 *authorization.Context) (*models.DataExport, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestDataExport", arg0)
+	ret0, _ := ret[0].(*models.DataExport)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// RequestDataExport indicates an expected call of RequestDataExport.
+func (mr *MockExportManagerMockRecorder) RequestDataExport(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestDataExport", reflect.TypeOf((*MockExportManager)(nil).RequestDataExport), arg0)
+}
+
+// MockShareManager is a mock of ShareManager interface.
+type MockShareManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockShareManagerMockRecorder
+}
+
+// MockShareManagerMockRecorder is the mock recorder for MockShareManager.
+type MockShareManagerMockRecorder struct {
+	mock *MockShareManager
+}
+
+// NewMockShareManager creates a new mock instance.
+func NewMockShareManager(ctrl *gomock.Controller) *MockShareManager {
+	mock := &MockShareManager{ctrl: ctrl}
+	mock.recorder = &MockShareManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockShareManager) EXPECT() *MockShareManagerMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockShareManager) Close() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close")
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockShareManagerMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockShareManager)(nil).Close))
+}
+
+// CreateShare mocks base method.
+func (m *MockShareManager) CreateShare(arg0 *authorization.Context, arg1 *models.Share) (bool, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateShare", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// CreateShare indicates an expected call of CreateShare.
+func (mr *MockShareManagerMockRecorder) CreateShare(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShare", reflect.TypeOf((*MockShareManager)(nil).CreateShare), arg0, arg1)
+}
+
+// GetOwnShares mocks base method.
+func (m *MockShareManager) GetOwnShares(arg0 *authorization.Context) ([]*models.Share, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOwnShares", arg0)
+	ret0, _ := ret[0].([]*models.Share)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetOwnShares indicates an expected call of GetOwnShares.
+func (mr *MockShareManagerMockRecorder) GetOwnShares(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnShares", reflect.TypeOf((*MockShareManager)(nil).GetOwnShares), arg0)
+}
+
+// RevokeShare mocks base method.
+func (m *MockShareManager) RevokeShare(arg0 *authorization.Context, arg1 *models.Share) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeShare", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// RevokeShare indicates an expected call of RevokeShare.
+func (mr *MockShareManagerMockRecorder) RevokeShare(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeShare", reflect.TypeOf((*MockShareManager)(nil).RevokeShare), arg0, arg1)
+}
+
+// UpdateShareMount mocks base method.
+func (m *MockShareManager) UpdateShareMount(arg0 *authorization.Context, arg1 models.NodeID, arg2 *models.ShareMountUpdate) (*models.Node, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateShareMount", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Node)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// UpdateShareMount indicates an expected call of UpdateShareMount.
+func (mr *MockShareManagerMockRecorder) UpdateShareMount(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateShareMount", reflect.TypeOf((*MockShareManager)(nil).UpdateShareMount), arg0, arg1, arg2)
+}
@@ -0,0 +1,1893 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/freecloudio/server/application/persistence (interfaces: UserPersistenceController,UserPersistenceReadWriteTransaction,NodePersistenceController,NodePersistenceReadWriteTransaction,AuthPersistenceController,AuthPersistenceReadWriteTransaction,SharePersistenceController,SharePersistenceReadWriteTransaction,GroupPersistenceController,GroupPersistenceReadWriteTransaction,FileDropPersistenceController,FileDropPersistenceReadWriteTransaction)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	reflect "reflect"
+
+	persistence "github.com/freecloudio/server/application/persistence"
+	models "github.com/freecloudio/server/domain/models"
+	fcerror "github.com/freecloudio/server/domain/models/fcerror"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockUserPersistenceController is a mock of UserPersistenceController interface.
+type MockUserPersistenceController struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserPersistenceControllerMockRecorder
+}
+
+// MockUserPersistenceControllerMockRecorder is the mock recorder for MockUserPersistenceController.
+type MockUserPersistenceControllerMockRecorder struct {
+	mock *MockUserPersistenceController
+}
+
+// NewMockUserPersistenceController creates a new mock instance.
+func NewMockUserPersistenceController(ctrl *gomock.Controller) *MockUserPersistenceController {
+	mock := &MockUserPersistenceController{ctrl: ctrl}
+	mock.recorder = &MockUserPersistenceControllerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserPersistenceController) EXPECT() *MockUserPersistenceControllerMockRecorder {
+	return m.recorder
+}
+
+// StartReadTransaction mocks base method.
+func (m *MockUserPersistenceController) StartReadTransaction() (persistence.UserPersistenceReadTransaction, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartReadTransaction")
+	ret0, _ := ret[0].(persistence.UserPersistenceReadTransaction)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// StartReadTransaction indicates an expected call of StartReadTransaction.
+func (mr *MockUserPersistenceControllerMockRecorder) StartReadTransaction() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartReadTransaction", reflect.TypeOf((*MockUserPersistenceController)(nil).StartReadTransaction))
+}
+
+// StartReadWriteTransaction mocks base method.
+func (m *MockUserPersistenceController) StartReadWriteTransaction() (persistence.UserPersistenceReadWriteTransaction, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartReadWriteTransaction")
+	ret0, _ := ret[0].(persistence.UserPersistenceReadWriteTransaction)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// StartReadWriteTransaction indicates an expected call of StartReadWriteTransaction.
+func (mr *MockUserPersistenceControllerMockRecorder) StartReadWriteTransaction() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartReadWriteTransaction", reflect.TypeOf((*MockUserPersistenceController)(nil).StartReadWriteTransaction))
+}
+
+// MockUserPersistenceReadWriteTransaction is a mock of UserPersistenceReadWriteTransaction interface.
+type MockUserPersistenceReadWriteTransaction struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserPersistenceReadWriteTransactionMockRecorder
+}
+
+// MockUserPersistenceReadWriteTransactionMockRecorder is the mock recorder for MockUserPersistenceReadWriteTransaction.
+type MockUserPersistenceReadWriteTransactionMockRecorder struct {
+	mock *MockUserPersistenceReadWriteTransaction
+}
+
+// NewMockUserPersistenceReadWriteTransaction creates a new mock instance.
+func NewMockUserPersistenceReadWriteTransaction(ctrl *gomock.Controller) *MockUserPersistenceReadWriteTransaction {
+	mock := &MockUserPersistenceReadWriteTransaction{ctrl: ctrl}
+	mock.recorder = &MockUserPersistenceReadWriteTransactionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserPersistenceReadWriteTransaction) EXPECT() *MockUserPersistenceReadWriteTransactionMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockUserPersistenceReadWriteTransaction) Close() *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockUserPersistenceReadWriteTransactionMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockUserPersistenceReadWriteTransaction)(nil).Close))
+}
+
+// Commit mocks base method.
+func (m *MockUserPersistenceReadWriteTransaction) Commit() *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Commit")
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Commit indicates an expected call of Commit.
+func (mr *MockUserPersistenceReadWriteTransactionMockRecorder) Commit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockUserPersistenceReadWriteTransaction)(nil).Commit))
+}
+
+// CountUsers mocks base method.
+func (m *MockUserPersistenceReadWriteTransaction) CountUsers() (int64, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUsers")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// CountUsers indicates an expected call of CountUsers.
+func (mr *MockUserPersistenceReadWriteTransactionMockRecorder) CountUsers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUsers", reflect.TypeOf((*MockUserPersistenceReadWriteTransaction)(nil).CountUsers))
+}
+
+// DeleteInvite mocks base method.
+func (m *MockUserPersistenceReadWriteTransaction) DeleteInvite(arg0 models.InviteID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteInvite", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteInvite indicates an expected call of DeleteInvite.
+func (mr *MockUserPersistenceReadWriteTransactionMockRecorder) DeleteInvite(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteInvite", reflect.TypeOf((*MockUserPersistenceReadWriteTransaction)(nil).DeleteInvite), arg0)
+}
+
+// DeleteUser mocks base method.
+func (m *MockUserPersistenceReadWriteTransaction) DeleteUser(arg0 models.UserID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockUserPersistenceReadWriteTransactionMockRecorder) DeleteUser(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserPersistenceReadWriteTransaction)(nil).DeleteUser), arg0)
+}
+
+// Finish mocks base method.
+func (m *MockUserPersistenceReadWriteTransaction) Finish(arg0 *fcerror.Error) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Finish", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Finish indicates an expected call of Finish.
+func (mr *MockUserPersistenceReadWriteTransactionMockRecorder) Finish(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finish", reflect.TypeOf((*MockUserPersistenceReadWriteTransaction)(nil).Finish), arg0)
+}
+
+// GetInviteByCodeHash mocks base method.
+func (m *MockUserPersistenceReadWriteTransaction) GetInviteByCodeHash(arg0 string) (*models.Invite, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInviteByCodeHash", arg0)
+	ret0, _ := ret[0].(*models.Invite)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetInviteByCodeHash indicates an expected call of GetInviteByCodeHash.
+func (mr *MockUserPersistenceReadWriteTransactionMockRecorder) GetInviteByCodeHash(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInviteByCodeHash", reflect.TypeOf((*MockUserPersistenceReadWriteTransaction)(nil).GetInviteByCodeHash), arg0)
+}
+
+// GetInvites mocks base method.
+func (m *MockUserPersistenceReadWriteTransaction) GetInvites() ([]*models.Invite, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvites")
+	ret0, _ := ret[0].([]*models.Invite)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetInvites indicates an expected call of GetInvites.
+func (mr *MockUserPersistenceReadWriteTransactionMockRecorder) GetInvites() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvites", reflect.TypeOf((*MockUserPersistenceReadWriteTransaction)(nil).GetInvites))
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockUserPersistenceReadWriteTransaction) GetUserByEmail(arg0 string) (*models.User, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", arg0)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockUserPersistenceReadWriteTransactionMockRecorder) GetUserByEmail(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockUserPersistenceReadWriteTransaction)(nil).GetUserByEmail), arg0)
+}
+
+// GetUserByID mocks base method.
+func (m *MockUserPersistenceReadWriteTransaction) GetUserByID(arg0 models.UserID) (*models.User, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", arg0)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockUserPersistenceReadWriteTransactionMockRecorder) GetUserByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockUserPersistenceReadWriteTransaction)(nil).GetUserByID), arg0)
+}
+
+// GetUsers mocks base method.
+func (m *MockUserPersistenceReadWriteTransaction) GetUsers(arg0 *models.UserFilter, arg1 *models.UserSort, arg2 *models.UserID, arg3 int) ([]*models.User, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsers", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*models.User)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetUsers indicates an expected call of GetUsers.
+func (mr *MockUserPersistenceReadWriteTransactionMockRecorder) GetUsers(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserPersistenceReadWriteTransaction)(nil).GetUsers), arg0, arg1, arg2, arg3)
+}
+
+// Rollback mocks base method.
+func (m *MockUserPersistenceReadWriteTransaction) Rollback() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Rollback")
+}
+
+// Rollback indicates an expected call of Rollback.
+func (mr *MockUserPersistenceReadWriteTransactionMockRecorder) Rollback() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockUserPersistenceReadWriteTransaction)(nil).Rollback))
+}
+
+// SaveInvite mocks base method.
+func (m *MockUserPersistenceReadWriteTransaction) SaveInvite(arg0 *models.Invite) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveInvite", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// SaveInvite indicates an expected call of SaveInvite.
+func (mr *MockUserPersistenceReadWriteTransactionMockRecorder) SaveInvite(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveInvite", reflect.TypeOf((*MockUserPersistenceReadWriteTransaction)(nil).SaveInvite), arg0)
+}
+
+// SaveUser mocks base method.
+func (m *MockUserPersistenceReadWriteTransaction) SaveUser(arg0 *models.User) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveUser", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// SaveUser indicates an expected call of SaveUser.
+func (mr *MockUserPersistenceReadWriteTransactionMockRecorder) SaveUser(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUser", reflect.TypeOf((*MockUserPersistenceReadWriteTransaction)(nil).SaveUser), arg0)
+}
+
+// UpdateUser mocks base method.
+func (m *MockUserPersistenceReadWriteTransaction) UpdateUser(arg0 *models.User) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockUserPersistenceReadWriteTransactionMockRecorder) UpdateUser(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserPersistenceReadWriteTransaction)(nil).UpdateUser), arg0)
+}
+
+// MockNodePersistenceController is a mock of NodePersistenceController interface.
+type MockNodePersistenceController struct {
+	ctrl     *gomock.Controller
+	recorder *MockNodePersistenceControllerMockRecorder
+}
+
+// MockNodePersistenceControllerMockRecorder is the mock recorder for MockNodePersistenceController.
+type MockNodePersistenceControllerMockRecorder struct {
+	mock *MockNodePersistenceController
+}
+
+// NewMockNodePersistenceController creates a new mock instance.
+func NewMockNodePersistenceController(ctrl *gomock.Controller) *MockNodePersistenceController {
+	mock := &MockNodePersistenceController{ctrl: ctrl}
+	mock.recorder = &MockNodePersistenceControllerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNodePersistenceController) EXPECT() *MockNodePersistenceControllerMockRecorder {
+	return m.recorder
+}
+
+// StartReadTransaction mocks base method.
+func (m *MockNodePersistenceController) StartReadTransaction() (persistence.NodePersistenceReadTransaction, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartReadTransaction")
+	ret0, _ := ret[0].(persistence.NodePersistenceReadTransaction)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// StartReadTransaction indicates an expected call of StartReadTransaction.
+func (mr *MockNodePersistenceControllerMockRecorder) StartReadTransaction() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartReadTransaction", reflect.TypeOf((*MockNodePersistenceController)(nil).StartReadTransaction))
+}
+
+// StartReadWriteTransaction mocks base method.
+func (m *MockNodePersistenceController) StartReadWriteTransaction() (persistence.NodePersistenceReadWriteTransaction, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartReadWriteTransaction")
+	ret0, _ := ret[0].(persistence.NodePersistenceReadWriteTransaction)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// StartReadWriteTransaction indicates an expected call of StartReadWriteTransaction.
+func (mr *MockNodePersistenceControllerMockRecorder) StartReadWriteTransaction() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartReadWriteTransaction", reflect.TypeOf((*MockNodePersistenceController)(nil).StartReadWriteTransaction))
+}
+
+// MockNodePersistenceReadWriteTransaction is a mock of NodePersistenceReadWriteTransaction interface.
+type MockNodePersistenceReadWriteTransaction struct {
+	ctrl     *gomock.Controller
+	recorder *MockNodePersistenceReadWriteTransactionMockRecorder
+}
+
+// MockNodePersistenceReadWriteTransactionMockRecorder is the mock recorder for MockNodePersistenceReadWriteTransaction.
+type MockNodePersistenceReadWriteTransactionMockRecorder struct {
+	mock *MockNodePersistenceReadWriteTransaction
+}
+
+// NewMockNodePersistenceReadWriteTransaction creates a new mock instance.
+func NewMockNodePersistenceReadWriteTransaction(ctrl *gomock.Controller) *MockNodePersistenceReadWriteTransaction {
+	mock := &MockNodePersistenceReadWriteTransaction{ctrl: ctrl}
+	mock.recorder = &MockNodePersistenceReadWriteTransactionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNodePersistenceReadWriteTransaction) EXPECT() *MockNodePersistenceReadWriteTransactionMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockNodePersistenceReadWriteTransaction) Close() *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockNodePersistenceReadWriteTransactionMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockNodePersistenceReadWriteTransaction)(nil).Close))
+}
+
+// Commit mocks base method.
+func (m *MockNodePersistenceReadWriteTransaction) Commit() *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Commit")
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Commit indicates an expected call of Commit.
+func (mr *MockNodePersistenceReadWriteTransactionMockRecorder) Commit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockNodePersistenceReadWriteTransaction)(nil).Commit))
+}
+
+// CreateNodeByID mocks base method.
+func (m *MockNodePersistenceReadWriteTransaction) CreateNodeByID(arg0 models.UserID, arg1 *models.Node) (bool, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNodeByID", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// CreateNodeByID indicates an expected call of CreateNodeByID.
+func (mr *MockNodePersistenceReadWriteTransactionMockRecorder) CreateNodeByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNodeByID", reflect.TypeOf((*MockNodePersistenceReadWriteTransaction)(nil).CreateNodeByID), arg0, arg1)
+}
+
+// CreateUserRootFolder mocks base method.
+func (m *MockNodePersistenceReadWriteTransaction) CreateUserRootFolder(arg0 models.UserID) (bool, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserRootFolder", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// CreateUserRootFolder indicates an expected call of CreateUserRootFolder.
+func (mr *MockNodePersistenceReadWriteTransactionMockRecorder) CreateUserRootFolder(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserRootFolder", reflect.TypeOf((*MockNodePersistenceReadWriteTransaction)(nil).CreateUserRootFolder), arg0)
+}
+
+// DeleteUserRootFolder mocks base method.
+func (m *MockNodePersistenceReadWriteTransaction) DeleteUserRootFolder(arg0 models.UserID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserRootFolder", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteUserRootFolder indicates an expected call of DeleteUserRootFolder.
+func (mr *MockNodePersistenceReadWriteTransactionMockRecorder) DeleteUserRootFolder(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserRootFolder", reflect.TypeOf((*MockNodePersistenceReadWriteTransaction)(nil).DeleteUserRootFolder), arg0)
+}
+
+// Finish mocks base method.
+func (m *MockNodePersistenceReadWriteTransaction) Finish(arg0 *fcerror.Error) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Finish", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Finish indicates an expected call of Finish.
+func (mr *MockNodePersistenceReadWriteTransactionMockRecorder) Finish(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finish", reflect.TypeOf((*MockNodePersistenceReadWriteTransaction)(nil).Finish), arg0)
+}
+
+// GetNodeByID mocks base method.
+func (m *MockNodePersistenceReadWriteTransaction) GetNodeByID(arg0 models.UserID, arg1 models.NodeID, arg2 models.ShareMode) (*models.Node, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNodeByID", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Node)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetNodeByID indicates an expected call of GetNodeByID.
+func (mr *MockNodePersistenceReadWriteTransactionMockRecorder) GetNodeByID(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNodeByID", reflect.TypeOf((*MockNodePersistenceReadWriteTransaction)(nil).GetNodeByID), arg0, arg1, arg2)
+}
+
+// GetNodeByPath mocks base method.
+func (m *MockNodePersistenceReadWriteTransaction) GetNodeByPath(arg0 models.UserID, arg1 string, arg2 models.ShareMode) (*models.Node, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNodeByPath", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Node)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetNodeByPath indicates an expected call of GetNodeByPath.
+func (mr *MockNodePersistenceReadWriteTransactionMockRecorder) GetNodeByPath(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNodeByPath", reflect.TypeOf((*MockNodePersistenceReadWriteTransaction)(nil).GetNodeByPath), arg0, arg1, arg2)
+}
+
+// GetOwnedNodes mocks base method.
+func (m *MockNodePersistenceReadWriteTransaction) GetOwnedNodes(arg0 models.UserID) ([]*models.Node, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOwnedNodes", arg0)
+	ret0, _ := ret[0].([]*models.Node)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetOwnedNodes indicates an expected call of GetOwnedNodes.
+func (mr *MockNodePersistenceReadWriteTransactionMockRecorder) GetOwnedNodes(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnedNodes", reflect.TypeOf((*MockNodePersistenceReadWriteTransaction)(nil).GetOwnedNodes), arg0)
+}
+
+// IsNodeInFolder mocks base method.
+func (m *MockNodePersistenceReadWriteTransaction) IsNodeInFolder(arg0, arg1 models.NodeID) (bool, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsNodeInFolder", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// IsNodeInFolder indicates an expected call of IsNodeInFolder.
+func (mr *MockNodePersistenceReadWriteTransactionMockRecorder) IsNodeInFolder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsNodeInFolder", reflect.TypeOf((*MockNodePersistenceReadWriteTransaction)(nil).IsNodeInFolder), arg0, arg1)
+}
+
+// ListByID mocks base method.
+func (m *MockNodePersistenceReadWriteTransaction) ListByID(arg0 models.UserID, arg1 models.NodeID, arg2 models.ShareMode) ([]*models.Node, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByID", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*models.Node)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// ListByID indicates an expected call of ListByID.
+func (mr *MockNodePersistenceReadWriteTransactionMockRecorder) ListByID(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByID", reflect.TypeOf((*MockNodePersistenceReadWriteTransaction)(nil).ListByID), arg0, arg1, arg2)
+}
+
+// Rollback mocks base method.
+func (m *MockNodePersistenceReadWriteTransaction) Rollback() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Rollback")
+}
+
+// Rollback indicates an expected call of Rollback.
+func (mr *MockNodePersistenceReadWriteTransactionMockRecorder) Rollback() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockNodePersistenceReadWriteTransaction)(nil).Rollback))
+}
+
+// TransferUserRootFolder mocks base method.
+func (m *MockNodePersistenceReadWriteTransaction) TransferUserRootFolder(arg0, arg1 models.UserID, arg2 string) (string, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferUserRootFolder", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// TransferUserRootFolder indicates an expected call of TransferUserRootFolder.
+func (mr *MockNodePersistenceReadWriteTransactionMockRecorder) TransferUserRootFolder(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferUserRootFolder", reflect.TypeOf((*MockNodePersistenceReadWriteTransaction)(nil).TransferUserRootFolder), arg0, arg1, arg2)
+}
+
+// MockAuthPersistenceController is a mock of AuthPersistenceController interface.
+type MockAuthPersistenceController struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthPersistenceControllerMockRecorder
+}
+
+// MockAuthPersistenceControllerMockRecorder is the mock recorder for MockAuthPersistenceController.
+type MockAuthPersistenceControllerMockRecorder struct {
+	mock *MockAuthPersistenceController
+}
+
+// NewMockAuthPersistenceController creates a new mock instance.
+func NewMockAuthPersistenceController(ctrl *gomock.Controller) *MockAuthPersistenceController {
+	mock := &MockAuthPersistenceController{ctrl: ctrl}
+	mock.recorder = &MockAuthPersistenceControllerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthPersistenceController) EXPECT() *MockAuthPersistenceControllerMockRecorder {
+	return m.recorder
+}
+
+// StartReadTransaction mocks base method.
+func (m *MockAuthPersistenceController) StartReadTransaction() (persistence.AuthPersistenceReadTransaction, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartReadTransaction")
+	ret0, _ := ret[0].(persistence.AuthPersistenceReadTransaction)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// StartReadTransaction indicates an expected call of StartReadTransaction.
+func (mr *MockAuthPersistenceControllerMockRecorder) StartReadTransaction() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartReadTransaction", reflect.TypeOf((*MockAuthPersistenceController)(nil).StartReadTransaction))
+}
+
+// StartReadWriteTransaction mocks base method.
+func (m *MockAuthPersistenceController) StartReadWriteTransaction() (persistence.AuthPersistenceReadWriteTransaction, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartReadWriteTransaction")
+	ret0, _ := ret[0].(persistence.AuthPersistenceReadWriteTransaction)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// StartReadWriteTransaction indicates an expected call of StartReadWriteTransaction.
+func (mr *MockAuthPersistenceControllerMockRecorder) StartReadWriteTransaction() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartReadWriteTransaction", reflect.TypeOf((*MockAuthPersistenceController)(nil).StartReadWriteTransaction))
+}
+
+// MockAuthPersistenceReadWriteTransaction is a mock of AuthPersistenceReadWriteTransaction interface.
+type MockAuthPersistenceReadWriteTransaction struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthPersistenceReadWriteTransactionMockRecorder
+}
+
+// MockAuthPersistenceReadWriteTransactionMockRecorder is the mock recorder for MockAuthPersistenceReadWriteTransaction.
+type MockAuthPersistenceReadWriteTransactionMockRecorder struct {
+	mock *MockAuthPersistenceReadWriteTransaction
+}
+
+// NewMockAuthPersistenceReadWriteTransaction creates a new mock instance.
+func NewMockAuthPersistenceReadWriteTransaction(ctrl *gomock.Controller) *MockAuthPersistenceReadWriteTransaction {
+	mock := &MockAuthPersistenceReadWriteTransaction{ctrl: ctrl}
+	mock.recorder = &MockAuthPersistenceReadWriteTransactionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthPersistenceReadWriteTransaction) EXPECT() *MockAuthPersistenceReadWriteTransactionMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) Close() *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).Close))
+}
+
+// Commit mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) Commit() *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Commit")
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Commit indicates an expected call of Commit.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) Commit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).Commit))
+}
+
+// DeleteAccessToken mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) DeleteAccessToken(arg0 models.UserID, arg1 models.AccessTokenID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccessToken", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteAccessToken indicates an expected call of DeleteAccessToken.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) DeleteAccessToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccessToken", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).DeleteAccessToken), arg0, arg1)
+}
+
+// DeleteEmailToken mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) DeleteEmailToken(arg0 string) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEmailToken", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteEmailToken indicates an expected call of DeleteEmailToken.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) DeleteEmailToken(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEmailToken", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).DeleteEmailToken), arg0)
+}
+
+// DeleteEmailTokensOfUser mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) DeleteEmailTokensOfUser(arg0 models.UserID, arg1 models.EmailTokenPurpose) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEmailTokensOfUser", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteEmailTokensOfUser indicates an expected call of DeleteEmailTokensOfUser.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) DeleteEmailTokensOfUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEmailTokensOfUser", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).DeleteEmailTokensOfUser), arg0, arg1)
+}
+
+// DeleteExpiredEmailTokens mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) DeleteExpiredEmailTokens() *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredEmailTokens")
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteExpiredEmailTokens indicates an expected call of DeleteExpiredEmailTokens.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) DeleteExpiredEmailTokens() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredEmailTokens", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).DeleteExpiredEmailTokens))
+}
+
+// DeleteExpiredLoginChallenges mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) DeleteExpiredLoginChallenges() *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredLoginChallenges")
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteExpiredLoginChallenges indicates an expected call of DeleteExpiredLoginChallenges.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) DeleteExpiredLoginChallenges() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredLoginChallenges", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).DeleteExpiredLoginChallenges))
+}
+
+// DeleteExpiredSessions mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) DeleteExpiredSessions() *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredSessions")
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteExpiredSessions indicates an expected call of DeleteExpiredSessions.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) DeleteExpiredSessions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredSessions", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).DeleteExpiredSessions))
+}
+
+// DeleteExpiredWebAuthnChallenges mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) DeleteExpiredWebAuthnChallenges() *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredWebAuthnChallenges")
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteExpiredWebAuthnChallenges indicates an expected call of DeleteExpiredWebAuthnChallenges.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) DeleteExpiredWebAuthnChallenges() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredWebAuthnChallenges", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).DeleteExpiredWebAuthnChallenges))
+}
+
+// DeleteLoginChallenge mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) DeleteLoginChallenge(arg0 models.Token) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLoginChallenge", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteLoginChallenge indicates an expected call of DeleteLoginChallenge.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) DeleteLoginChallenge(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoginChallenge", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).DeleteLoginChallenge), arg0)
+}
+
+// DeleteSessionByID mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) DeleteSessionByID(arg0 models.UserID, arg1 models.SessionID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSessionByID", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteSessionByID indicates an expected call of DeleteSessionByID.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) DeleteSessionByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSessionByID", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).DeleteSessionByID), arg0, arg1)
+}
+
+// DeleteSessionByToken mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) DeleteSessionByToken(arg0 string) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSessionByToken", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteSessionByToken indicates an expected call of DeleteSessionByToken.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) DeleteSessionByToken(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSessionByToken", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).DeleteSessionByToken), arg0)
+}
+
+// DeleteSessionsOfUser mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) DeleteSessionsOfUser(arg0 models.UserID, arg1 string) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSessionsOfUser", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteSessionsOfUser indicates an expected call of DeleteSessionsOfUser.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) DeleteSessionsOfUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSessionsOfUser", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).DeleteSessionsOfUser), arg0, arg1)
+}
+
+// DeleteTOTP mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) DeleteTOTP(arg0 models.UserID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTOTP", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteTOTP indicates an expected call of DeleteTOTP.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) DeleteTOTP(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTOTP", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).DeleteTOTP), arg0)
+}
+
+// DeleteWebAuthnChallenge mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) DeleteWebAuthnChallenge(arg0 string) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebAuthnChallenge", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteWebAuthnChallenge indicates an expected call of DeleteWebAuthnChallenge.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) DeleteWebAuthnChallenge(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebAuthnChallenge", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).DeleteWebAuthnChallenge), arg0)
+}
+
+// DeleteWebAuthnCredential mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) DeleteWebAuthnCredential(arg0 models.UserID, arg1 models.WebAuthnCredentialID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebAuthnCredential", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteWebAuthnCredential indicates an expected call of DeleteWebAuthnCredential.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) DeleteWebAuthnCredential(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebAuthnCredential", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).DeleteWebAuthnCredential), arg0, arg1)
+}
+
+// Finish mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) Finish(arg0 *fcerror.Error) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Finish", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Finish indicates an expected call of Finish.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) Finish(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finish", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).Finish), arg0)
+}
+
+// GetAccessTokenByHash mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) GetAccessTokenByHash(arg0 string) (*models.AccessToken, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessTokenByHash", arg0)
+	ret0, _ := ret[0].(*models.AccessToken)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetAccessTokenByHash indicates an expected call of GetAccessTokenByHash.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) GetAccessTokenByHash(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessTokenByHash", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).GetAccessTokenByHash), arg0)
+}
+
+// GetAccessTokensOfUser mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) GetAccessTokensOfUser(arg0 models.UserID) ([]*models.AccessToken, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessTokensOfUser", arg0)
+	ret0, _ := ret[0].([]*models.AccessToken)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetAccessTokensOfUser indicates an expected call of GetAccessTokensOfUser.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) GetAccessTokensOfUser(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessTokensOfUser", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).GetAccessTokensOfUser), arg0)
+}
+
+// GetEmailToken mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) GetEmailToken(arg0 string) (*models.EmailToken, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmailToken", arg0)
+	ret0, _ := ret[0].(*models.EmailToken)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetEmailToken indicates an expected call of GetEmailToken.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) GetEmailToken(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmailToken", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).GetEmailToken), arg0)
+}
+
+// GetLoginChallenge mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) GetLoginChallenge(arg0 models.Token) (*models.LoginChallenge, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginChallenge", arg0)
+	ret0, _ := ret[0].(*models.LoginChallenge)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetLoginChallenge indicates an expected call of GetLoginChallenge.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) GetLoginChallenge(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginChallenge", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).GetLoginChallenge), arg0)
+}
+
+// GetPlainSessionTokens mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) GetPlainSessionTokens() ([]models.Token, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPlainSessionTokens")
+	ret0, _ := ret[0].([]models.Token)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetPlainSessionTokens indicates an expected call of GetPlainSessionTokens.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) GetPlainSessionTokens() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPlainSessionTokens", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).GetPlainSessionTokens))
+}
+
+// GetSessionByToken mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) GetSessionByToken(arg0 string) (*models.Session, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionByToken", arg0)
+	ret0, _ := ret[0].(*models.Session)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetSessionByToken indicates an expected call of GetSessionByToken.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) GetSessionByToken(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionByToken", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).GetSessionByToken), arg0)
+}
+
+// GetSessionsOfUser mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) GetSessionsOfUser(arg0 models.UserID) ([]*models.Session, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionsOfUser", arg0)
+	ret0, _ := ret[0].([]*models.Session)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetSessionsOfUser indicates an expected call of GetSessionsOfUser.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) GetSessionsOfUser(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionsOfUser", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).GetSessionsOfUser), arg0)
+}
+
+// GetTOTP mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) GetTOTP(arg0 models.UserID) (*models.TOTP, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTOTP", arg0)
+	ret0, _ := ret[0].(*models.TOTP)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetTOTP indicates an expected call of GetTOTP.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) GetTOTP(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTOTP", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).GetTOTP), arg0)
+}
+
+// GetWebAuthnChallenge mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) GetWebAuthnChallenge(arg0 string) (*models.WebAuthnChallenge, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebAuthnChallenge", arg0)
+	ret0, _ := ret[0].(*models.WebAuthnChallenge)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetWebAuthnChallenge indicates an expected call of GetWebAuthnChallenge.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) GetWebAuthnChallenge(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebAuthnChallenge", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).GetWebAuthnChallenge), arg0)
+}
+
+// GetWebAuthnCredential mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) GetWebAuthnCredential(arg0 models.WebAuthnCredentialID) (*models.WebAuthnCredential, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebAuthnCredential", arg0)
+	ret0, _ := ret[0].(*models.WebAuthnCredential)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetWebAuthnCredential indicates an expected call of GetWebAuthnCredential.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) GetWebAuthnCredential(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebAuthnCredential", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).GetWebAuthnCredential), arg0)
+}
+
+// GetWebAuthnCredentialsOfUser mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) GetWebAuthnCredentialsOfUser(arg0 models.UserID) ([]*models.WebAuthnCredential, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebAuthnCredentialsOfUser", arg0)
+	ret0, _ := ret[0].([]*models.WebAuthnCredential)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetWebAuthnCredentialsOfUser indicates an expected call of GetWebAuthnCredentialsOfUser.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) GetWebAuthnCredentialsOfUser(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebAuthnCredentialsOfUser", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).GetWebAuthnCredentialsOfUser), arg0)
+}
+
+// HashSessionToken mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) HashSessionToken(arg0 models.Token, arg1 string) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HashSessionToken", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// HashSessionToken indicates an expected call of HashSessionToken.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) HashSessionToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HashSessionToken", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).HashSessionToken), arg0, arg1)
+}
+
+// Rollback mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) Rollback() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Rollback")
+}
+
+// Rollback indicates an expected call of Rollback.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) Rollback() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).Rollback))
+}
+
+// SaveAccessToken mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) SaveAccessToken(arg0 *models.AccessToken) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAccessToken", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// SaveAccessToken indicates an expected call of SaveAccessToken.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) SaveAccessToken(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAccessToken", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).SaveAccessToken), arg0)
+}
+
+// SaveEmailToken mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) SaveEmailToken(arg0 *models.EmailToken) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveEmailToken", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// SaveEmailToken indicates an expected call of SaveEmailToken.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) SaveEmailToken(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEmailToken", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).SaveEmailToken), arg0)
+}
+
+// SaveLoginChallenge mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) SaveLoginChallenge(arg0 *models.LoginChallenge) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveLoginChallenge", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// SaveLoginChallenge indicates an expected call of SaveLoginChallenge.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) SaveLoginChallenge(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveLoginChallenge", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).SaveLoginChallenge), arg0)
+}
+
+// SaveRecoveryCodes mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) SaveRecoveryCodes(arg0 models.UserID, arg1 []string) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveRecoveryCodes", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// SaveRecoveryCodes indicates an expected call of SaveRecoveryCodes.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) SaveRecoveryCodes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRecoveryCodes", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).SaveRecoveryCodes), arg0, arg1)
+}
+
+// SaveSession mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) SaveSession(arg0 *models.Session) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSession", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// SaveSession indicates an expected call of SaveSession.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) SaveSession(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSession", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).SaveSession), arg0)
+}
+
+// SaveTOTP mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) SaveTOTP(arg0 *models.TOTP) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveTOTP", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// SaveTOTP indicates an expected call of SaveTOTP.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) SaveTOTP(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTOTP", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).SaveTOTP), arg0)
+}
+
+// SaveWebAuthnChallenge mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) SaveWebAuthnChallenge(arg0 *models.WebAuthnChallenge) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWebAuthnChallenge", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// SaveWebAuthnChallenge indicates an expected call of SaveWebAuthnChallenge.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) SaveWebAuthnChallenge(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWebAuthnChallenge", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).SaveWebAuthnChallenge), arg0)
+}
+
+// SaveWebAuthnCredential mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) SaveWebAuthnCredential(arg0 *models.WebAuthnCredential) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWebAuthnCredential", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// SaveWebAuthnCredential indicates an expected call of SaveWebAuthnCredential.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) SaveWebAuthnCredential(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWebAuthnCredential", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).SaveWebAuthnCredential), arg0)
+}
+
+// UpdateAccessTokenLastUsed mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) UpdateAccessTokenLastUsed(arg0 *models.AccessToken) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccessTokenLastUsed", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// UpdateAccessTokenLastUsed indicates an expected call of UpdateAccessTokenLastUsed.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) UpdateAccessTokenLastUsed(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccessTokenLastUsed", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).UpdateAccessTokenLastUsed), arg0)
+}
+
+// UpdateLoginChallengeAttempts mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) UpdateLoginChallengeAttempts(arg0 *models.LoginChallenge) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLoginChallengeAttempts", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// UpdateLoginChallengeAttempts indicates an expected call of UpdateLoginChallengeAttempts.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) UpdateLoginChallengeAttempts(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLoginChallengeAttempts", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).UpdateLoginChallengeAttempts), arg0)
+}
+
+// UpdateSessionUsage mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) UpdateSessionUsage(arg0 *models.Session) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSessionUsage", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// UpdateSessionUsage indicates an expected call of UpdateSessionUsage.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) UpdateSessionUsage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSessionUsage", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).UpdateSessionUsage), arg0)
+}
+
+// UpdateWebAuthnCredentialUsage mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) UpdateWebAuthnCredentialUsage(arg0 *models.WebAuthnCredential) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebAuthnCredentialUsage", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// UpdateWebAuthnCredentialUsage indicates an expected call of UpdateWebAuthnCredentialUsage.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) UpdateWebAuthnCredentialUsage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebAuthnCredentialUsage", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).UpdateWebAuthnCredentialUsage), arg0)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockAuthPersistenceReadWriteTransaction) UseRecoveryCode(arg0 models.UserID, arg1 string) (bool, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockAuthPersistenceReadWriteTransactionMockRecorder) UseRecoveryCode(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockAuthPersistenceReadWriteTransaction)(nil).UseRecoveryCode), arg0, arg1)
+}
+
+// MockSharePersistenceController is a mock of SharePersistenceController interface.
+type MockSharePersistenceController struct {
+	ctrl     *gomock.Controller
+	recorder *MockSharePersistenceControllerMockRecorder
+}
+
+// MockSharePersistenceControllerMockRecorder is the mock recorder for MockSharePersistenceController.
+type MockSharePersistenceControllerMockRecorder struct {
+	mock *MockSharePersistenceController
+}
+
+// NewMockSharePersistenceController creates a new mock instance.
+func NewMockSharePersistenceController(ctrl *gomock.Controller) *MockSharePersistenceController {
+	mock := &MockSharePersistenceController{ctrl: ctrl}
+	mock.recorder = &MockSharePersistenceControllerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSharePersistenceController) EXPECT() *MockSharePersistenceControllerMockRecorder {
+	return m.recorder
+}
+
+// StartReadTransaction mocks base method.
+func (m *MockSharePersistenceController) StartReadTransaction() (persistence.SharePersistenceReadTransaction, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartReadTransaction")
+	ret0, _ := ret[0].(persistence.SharePersistenceReadTransaction)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// StartReadTransaction indicates an expected call of StartReadTransaction.
+func (mr *MockSharePersistenceControllerMockRecorder) StartReadTransaction() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartReadTransaction", reflect.TypeOf((*MockSharePersistenceController)(nil).StartReadTransaction))
+}
+
+// StartReadWriteTransaction mocks base method.
+func (m *MockSharePersistenceController) StartReadWriteTransaction() (persistence.SharePersistenceReadWriteTransaction, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartReadWriteTransaction")
+	ret0, _ := ret[0].(persistence.SharePersistenceReadWriteTransaction)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// StartReadWriteTransaction indicates an expected call of StartReadWriteTransaction.
+func (mr *MockSharePersistenceControllerMockRecorder) StartReadWriteTransaction() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartReadWriteTransaction", reflect.TypeOf((*MockSharePersistenceController)(nil).StartReadWriteTransaction))
+}
+
+// MockSharePersistenceReadWriteTransaction is a mock of SharePersistenceReadWriteTransaction interface.
+type MockSharePersistenceReadWriteTransaction struct {
+	ctrl     *gomock.Controller
+	recorder *MockSharePersistenceReadWriteTransactionMockRecorder
+}
+
+// MockSharePersistenceReadWriteTransactionMockRecorder is the mock recorder for MockSharePersistenceReadWriteTransaction.
+type MockSharePersistenceReadWriteTransactionMockRecorder struct {
+	mock *MockSharePersistenceReadWriteTransaction
+}
+
+// NewMockSharePersistenceReadWriteTransaction creates a new mock instance.
+func NewMockSharePersistenceReadWriteTransaction(ctrl *gomock.Controller) *MockSharePersistenceReadWriteTransaction {
+	mock := &MockSharePersistenceReadWriteTransaction{ctrl: ctrl}
+	mock.recorder = &MockSharePersistenceReadWriteTransactionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSharePersistenceReadWriteTransaction) EXPECT() *MockSharePersistenceReadWriteTransactionMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockSharePersistenceReadWriteTransaction) Close() *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockSharePersistenceReadWriteTransactionMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockSharePersistenceReadWriteTransaction)(nil).Close))
+}
+
+// Commit mocks base method.
+func (m *MockSharePersistenceReadWriteTransaction) Commit() *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Commit")
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Commit indicates an expected call of Commit.
+func (mr *MockSharePersistenceReadWriteTransactionMockRecorder) Commit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockSharePersistenceReadWriteTransaction)(nil).Commit))
+}
+
+// CreateGroupShare mocks base method.
+func (m *MockSharePersistenceReadWriteTransaction) CreateGroupShare(arg0 models.UserID, arg1 *models.Share, arg2 string) (bool, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGroupShare", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// CreateGroupShare indicates an expected call of CreateGroupShare.
+func (mr *MockSharePersistenceReadWriteTransactionMockRecorder) CreateGroupShare(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroupShare", reflect.TypeOf((*MockSharePersistenceReadWriteTransaction)(nil).CreateGroupShare), arg0, arg1, arg2)
+}
+
+// CreateShare mocks base method.
+func (m *MockSharePersistenceReadWriteTransaction) CreateShare(arg0 models.UserID, arg1 *models.Share, arg2 string) (bool, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateShare", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// CreateShare indicates an expected call of CreateShare.
+func (mr *MockSharePersistenceReadWriteTransactionMockRecorder) CreateShare(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShare", reflect.TypeOf((*MockSharePersistenceReadWriteTransaction)(nil).CreateShare), arg0, arg1, arg2)
+}
+
+// DeleteExpiredShares mocks base method.
+func (m *MockSharePersistenceReadWriteTransaction) DeleteExpiredShares() *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredShares")
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteExpiredShares indicates an expected call of DeleteExpiredShares.
+func (mr *MockSharePersistenceReadWriteTransactionMockRecorder) DeleteExpiredShares() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredShares", reflect.TypeOf((*MockSharePersistenceReadWriteTransaction)(nil).DeleteExpiredShares))
+}
+
+// DeleteShare mocks base method.
+func (m *MockSharePersistenceReadWriteTransaction) DeleteShare(arg0 models.UserID, arg1 *models.Share) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteShare", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteShare indicates an expected call of DeleteShare.
+func (mr *MockSharePersistenceReadWriteTransactionMockRecorder) DeleteShare(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteShare", reflect.TypeOf((*MockSharePersistenceReadWriteTransaction)(nil).DeleteShare), arg0, arg1)
+}
+
+// Finish mocks base method.
+func (m *MockSharePersistenceReadWriteTransaction) Finish(arg0 *fcerror.Error) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Finish", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Finish indicates an expected call of Finish.
+func (mr *MockSharePersistenceReadWriteTransactionMockRecorder) Finish(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finish", reflect.TypeOf((*MockSharePersistenceReadWriteTransaction)(nil).Finish), arg0)
+}
+
+// GetSharesOfUser mocks base method.
+func (m *MockSharePersistenceReadWriteTransaction) GetSharesOfUser(arg0 models.UserID) ([]*models.Share, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSharesOfUser", arg0)
+	ret0, _ := ret[0].([]*models.Share)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetSharesOfUser indicates an expected call of GetSharesOfUser.
+func (mr *MockSharePersistenceReadWriteTransactionMockRecorder) GetSharesOfUser(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSharesOfUser", reflect.TypeOf((*MockSharePersistenceReadWriteTransaction)(nil).GetSharesOfUser), arg0)
+}
+
+// Rollback mocks base method.
+func (m *MockSharePersistenceReadWriteTransaction) Rollback() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Rollback")
+}
+
+// Rollback indicates an expected call of Rollback.
+func (mr *MockSharePersistenceReadWriteTransactionMockRecorder) Rollback() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockSharePersistenceReadWriteTransaction)(nil).Rollback))
+}
+
+// UpdateShareMount mocks base method.
+func (m *MockSharePersistenceReadWriteTransaction) UpdateShareMount(arg0 models.UserID, arg1 models.NodeID, arg2 *models.ShareMountUpdate) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateShareMount", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// UpdateShareMount indicates an expected call of UpdateShareMount.
+func (mr *MockSharePersistenceReadWriteTransactionMockRecorder) UpdateShareMount(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateShareMount", reflect.TypeOf((*MockSharePersistenceReadWriteTransaction)(nil).UpdateShareMount), arg0, arg1, arg2)
+}
+
+// MockGroupPersistenceController is a mock of GroupPersistenceController interface.
+type MockGroupPersistenceController struct {
+	ctrl     *gomock.Controller
+	recorder *MockGroupPersistenceControllerMockRecorder
+}
+
+// MockGroupPersistenceControllerMockRecorder is the mock recorder for MockGroupPersistenceController.
+type MockGroupPersistenceControllerMockRecorder struct {
+	mock *MockGroupPersistenceController
+}
+
+// NewMockGroupPersistenceController creates a new mock instance.
+func NewMockGroupPersistenceController(ctrl *gomock.Controller) *MockGroupPersistenceController {
+	mock := &MockGroupPersistenceController{ctrl: ctrl}
+	mock.recorder = &MockGroupPersistenceControllerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGroupPersistenceController) EXPECT() *MockGroupPersistenceControllerMockRecorder {
+	return m.recorder
+}
+
+// StartReadTransaction mocks base method.
+func (m *MockGroupPersistenceController) StartReadTransaction() (persistence.GroupPersistenceReadTransaction, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartReadTransaction")
+	ret0, _ := ret[0].(persistence.GroupPersistenceReadTransaction)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// StartReadTransaction indicates an expected call of StartReadTransaction.
+func (mr *MockGroupPersistenceControllerMockRecorder) StartReadTransaction() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartReadTransaction", reflect.TypeOf((*MockGroupPersistenceController)(nil).StartReadTransaction))
+}
+
+// StartReadWriteTransaction mocks base method.
+func (m *MockGroupPersistenceController) StartReadWriteTransaction() (persistence.GroupPersistenceReadWriteTransaction, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartReadWriteTransaction")
+	ret0, _ := ret[0].(persistence.GroupPersistenceReadWriteTransaction)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// StartReadWriteTransaction indicates an expected call of StartReadWriteTransaction.
+func (mr *MockGroupPersistenceControllerMockRecorder) StartReadWriteTransaction() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartReadWriteTransaction", reflect.TypeOf((*MockGroupPersistenceController)(nil).StartReadWriteTransaction))
+}
+
+// MockGroupPersistenceReadWriteTransaction is a mock of GroupPersistenceReadWriteTransaction interface.
+type MockGroupPersistenceReadWriteTransaction struct {
+	ctrl     *gomock.Controller
+	recorder *MockGroupPersistenceReadWriteTransactionMockRecorder
+}
+
+// MockGroupPersistenceReadWriteTransactionMockRecorder is the mock recorder for MockGroupPersistenceReadWriteTransaction.
+type MockGroupPersistenceReadWriteTransactionMockRecorder struct {
+	mock *MockGroupPersistenceReadWriteTransaction
+}
+
+// NewMockGroupPersistenceReadWriteTransaction creates a new mock instance.
+func NewMockGroupPersistenceReadWriteTransaction(ctrl *gomock.Controller) *MockGroupPersistenceReadWriteTransaction {
+	mock := &MockGroupPersistenceReadWriteTransaction{ctrl: ctrl}
+	mock.recorder = &MockGroupPersistenceReadWriteTransactionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGroupPersistenceReadWriteTransaction) EXPECT() *MockGroupPersistenceReadWriteTransactionMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockGroupPersistenceReadWriteTransaction) Close() *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockGroupPersistenceReadWriteTransactionMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockGroupPersistenceReadWriteTransaction)(nil).Close))
+}
+
+// Commit mocks base method.
+func (m *MockGroupPersistenceReadWriteTransaction) Commit() *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Commit")
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Commit indicates an expected call of Commit.
+func (mr *MockGroupPersistenceReadWriteTransactionMockRecorder) Commit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockGroupPersistenceReadWriteTransaction)(nil).Commit))
+}
+
+// DeleteGroupMember mocks base method.
+func (m *MockGroupPersistenceReadWriteTransaction) DeleteGroupMember(arg0 models.GroupID, arg1 models.UserID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGroupMember", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteGroupMember indicates an expected call of DeleteGroupMember.
+func (mr *MockGroupPersistenceReadWriteTransactionMockRecorder) DeleteGroupMember(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroupMember", reflect.TypeOf((*MockGroupPersistenceReadWriteTransaction)(nil).DeleteGroupMember), arg0, arg1)
+}
+
+// Finish mocks base method.
+func (m *MockGroupPersistenceReadWriteTransaction) Finish(arg0 *fcerror.Error) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Finish", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Finish indicates an expected call of Finish.
+func (mr *MockGroupPersistenceReadWriteTransactionMockRecorder) Finish(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finish", reflect.TypeOf((*MockGroupPersistenceReadWriteTransaction)(nil).Finish), arg0)
+}
+
+// GetGroupByID mocks base method.
+func (m *MockGroupPersistenceReadWriteTransaction) GetGroupByID(arg0 models.GroupID) (*models.Group, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupByID", arg0)
+	ret0, _ := ret[0].(*models.Group)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetGroupByID indicates an expected call of GetGroupByID.
+func (mr *MockGroupPersistenceReadWriteTransactionMockRecorder) GetGroupByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupByID", reflect.TypeOf((*MockGroupPersistenceReadWriteTransaction)(nil).GetGroupByID), arg0)
+}
+
+// GetGroupMember mocks base method.
+func (m *MockGroupPersistenceReadWriteTransaction) GetGroupMember(arg0 models.GroupID, arg1 models.UserID) (*models.GroupMember, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupMember", arg0, arg1)
+	ret0, _ := ret[0].(*models.GroupMember)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetGroupMember indicates an expected call of GetGroupMember.
+func (mr *MockGroupPersistenceReadWriteTransactionMockRecorder) GetGroupMember(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupMember", reflect.TypeOf((*MockGroupPersistenceReadWriteTransaction)(nil).GetGroupMember), arg0, arg1)
+}
+
+// GetGroupMembers mocks base method.
+func (m *MockGroupPersistenceReadWriteTransaction) GetGroupMembers(arg0 models.GroupID) ([]*models.GroupMember, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupMembers", arg0)
+	ret0, _ := ret[0].([]*models.GroupMember)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetGroupMembers indicates an expected call of GetGroupMembers.
+func (mr *MockGroupPersistenceReadWriteTransactionMockRecorder) GetGroupMembers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupMembers", reflect.TypeOf((*MockGroupPersistenceReadWriteTransaction)(nil).GetGroupMembers), arg0)
+}
+
+// GetGroupsOfUser mocks base method.
+func (m *MockGroupPersistenceReadWriteTransaction) GetGroupsOfUser(arg0 models.UserID) ([]*models.Group, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupsOfUser", arg0)
+	ret0, _ := ret[0].([]*models.Group)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetGroupsOfUser indicates an expected call of GetGroupsOfUser.
+func (mr *MockGroupPersistenceReadWriteTransactionMockRecorder) GetGroupsOfUser(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupsOfUser", reflect.TypeOf((*MockGroupPersistenceReadWriteTransaction)(nil).GetGroupsOfUser), arg0)
+}
+
+// Rollback mocks base method.
+func (m *MockGroupPersistenceReadWriteTransaction) Rollback() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Rollback")
+}
+
+// Rollback indicates an expected call of Rollback.
+func (mr *MockGroupPersistenceReadWriteTransactionMockRecorder) Rollback() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockGroupPersistenceReadWriteTransaction)(nil).Rollback))
+}
+
+// SaveGroup mocks base method.
+func (m *MockGroupPersistenceReadWriteTransaction) SaveGroup(arg0 *models.Group) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveGroup", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// SaveGroup indicates an expected call of SaveGroup.
+func (mr *MockGroupPersistenceReadWriteTransactionMockRecorder) SaveGroup(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveGroup", reflect.TypeOf((*MockGroupPersistenceReadWriteTransaction)(nil).SaveGroup), arg0)
+}
+
+// SaveGroupMember mocks base method.
+func (m *MockGroupPersistenceReadWriteTransaction) SaveGroupMember(arg0 *models.GroupMember) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveGroupMember", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// SaveGroupMember indicates an expected call of SaveGroupMember.
+func (mr *MockGroupPersistenceReadWriteTransactionMockRecorder) SaveGroupMember(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveGroupMember", reflect.TypeOf((*MockGroupPersistenceReadWriteTransaction)(nil).SaveGroupMember), arg0)
+}
+
+// MockFileDropPersistenceController is a mock of FileDropPersistenceController interface.
+type MockFileDropPersistenceController struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileDropPersistenceControllerMockRecorder
+}
+
+// MockFileDropPersistenceControllerMockRecorder is the mock recorder for MockFileDropPersistenceController.
+type MockFileDropPersistenceControllerMockRecorder struct {
+	mock *MockFileDropPersistenceController
+}
+
+// NewMockFileDropPersistenceController creates a new mock instance.
+func NewMockFileDropPersistenceController(ctrl *gomock.Controller) *MockFileDropPersistenceController {
+	mock := &MockFileDropPersistenceController{ctrl: ctrl}
+	mock.recorder = &MockFileDropPersistenceControllerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileDropPersistenceController) EXPECT() *MockFileDropPersistenceControllerMockRecorder {
+	return m.recorder
+}
+
+// StartReadTransaction mocks base method.
+func (m *MockFileDropPersistenceController) StartReadTransaction() (persistence.FileDropPersistenceReadTransaction, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartReadTransaction")
+	ret0, _ := ret[0].(persistence.FileDropPersistenceReadTransaction)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// StartReadTransaction indicates an expected call of StartReadTransaction.
+func (mr *MockFileDropPersistenceControllerMockRecorder) StartReadTransaction() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartReadTransaction", reflect.TypeOf((*MockFileDropPersistenceController)(nil).StartReadTransaction))
+}
+
+// StartReadWriteTransaction mocks base method.
+func (m *MockFileDropPersistenceController) StartReadWriteTransaction() (persistence.FileDropPersistenceReadWriteTransaction, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartReadWriteTransaction")
+	ret0, _ := ret[0].(persistence.FileDropPersistenceReadWriteTransaction)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// StartReadWriteTransaction indicates an expected call of StartReadWriteTransaction.
+func (mr *MockFileDropPersistenceControllerMockRecorder) StartReadWriteTransaction() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartReadWriteTransaction", reflect.TypeOf((*MockFileDropPersistenceController)(nil).StartReadWriteTransaction))
+}
+
+// MockFileDropPersistenceReadWriteTransaction is a mock of FileDropPersistenceReadWriteTransaction interface.
+type MockFileDropPersistenceReadWriteTransaction struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileDropPersistenceReadWriteTransactionMockRecorder
+}
+
+// MockFileDropPersistenceReadWriteTransactionMockRecorder is the mock recorder for MockFileDropPersistenceReadWriteTransaction.
+type MockFileDropPersistenceReadWriteTransactionMockRecorder struct {
+	mock *MockFileDropPersistenceReadWriteTransaction
+}
+
+// NewMockFileDropPersistenceReadWriteTransaction creates a new mock instance.
+func NewMockFileDropPersistenceReadWriteTransaction(ctrl *gomock.Controller) *MockFileDropPersistenceReadWriteTransaction {
+	mock := &MockFileDropPersistenceReadWriteTransaction{ctrl: ctrl}
+	mock.recorder = &MockFileDropPersistenceReadWriteTransactionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileDropPersistenceReadWriteTransaction) EXPECT() *MockFileDropPersistenceReadWriteTransactionMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockFileDropPersistenceReadWriteTransaction) Close() *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockFileDropPersistenceReadWriteTransactionMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockFileDropPersistenceReadWriteTransaction)(nil).Close))
+}
+
+// Commit mocks base method.
+func (m *MockFileDropPersistenceReadWriteTransaction) Commit() *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Commit")
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Commit indicates an expected call of Commit.
+func (mr *MockFileDropPersistenceReadWriteTransactionMockRecorder) Commit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockFileDropPersistenceReadWriteTransaction)(nil).Commit))
+}
+
+// DeleteFileDrop mocks base method.
+func (m *MockFileDropPersistenceReadWriteTransaction) DeleteFileDrop(arg0 models.UserID, arg1 models.FileDropID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFileDrop", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteFileDrop indicates an expected call of DeleteFileDrop.
+func (mr *MockFileDropPersistenceReadWriteTransactionMockRecorder) DeleteFileDrop(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFileDrop", reflect.TypeOf((*MockFileDropPersistenceReadWriteTransaction)(nil).DeleteFileDrop), arg0, arg1)
+}
+
+// Finish mocks base method.
+func (m *MockFileDropPersistenceReadWriteTransaction) Finish(arg0 *fcerror.Error) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Finish", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// Finish indicates an expected call of Finish.
+func (mr *MockFileDropPersistenceReadWriteTransactionMockRecorder) Finish(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finish", reflect.TypeOf((*MockFileDropPersistenceReadWriteTransaction)(nil).Finish), arg0)
+}
+
+// GetFileDropByID mocks base method.
+func (m *MockFileDropPersistenceReadWriteTransaction) GetFileDropByID(arg0 models.FileDropID) (*models.FileDrop, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileDropByID", arg0)
+	ret0, _ := ret[0].(*models.FileDrop)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetFileDropByID indicates an expected call of GetFileDropByID.
+func (mr *MockFileDropPersistenceReadWriteTransactionMockRecorder) GetFileDropByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileDropByID", reflect.TypeOf((*MockFileDropPersistenceReadWriteTransaction)(nil).GetFileDropByID), arg0)
+}
+
+// GetFileDropsOfUser mocks base method.
+func (m *MockFileDropPersistenceReadWriteTransaction) GetFileDropsOfUser(arg0 models.UserID) ([]*models.FileDrop, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileDropsOfUser", arg0)
+	ret0, _ := ret[0].([]*models.FileDrop)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetFileDropsOfUser indicates an expected call of GetFileDropsOfUser.
+func (mr *MockFileDropPersistenceReadWriteTransactionMockRecorder) GetFileDropsOfUser(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileDropsOfUser", reflect.TypeOf((*MockFileDropPersistenceReadWriteTransaction)(nil).GetFileDropsOfUser), arg0)
+}
+
+// Rollback mocks base method.
+func (m *MockFileDropPersistenceReadWriteTransaction) Rollback() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Rollback")
+}
+
+// Rollback indicates an expected call of Rollback.
+func (mr *MockFileDropPersistenceReadWriteTransactionMockRecorder) Rollback() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockFileDropPersistenceReadWriteTransaction)(nil).Rollback))
+}
+
+// SaveFileDrop mocks base method.
+func (m *MockFileDropPersistenceReadWriteTransaction) SaveFileDrop(arg0 *models.FileDrop) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveFileDrop", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// SaveFileDrop indicates an expected call of SaveFileDrop.
+func (mr *MockFileDropPersistenceReadWriteTransactionMockRecorder) SaveFileDrop(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFileDrop", reflect.TypeOf((*MockFileDropPersistenceReadWriteTransaction)(nil).SaveFileDrop), arg0)
+}
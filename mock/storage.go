@@ -0,0 +1,182 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/freecloudio/server/application/storage (interfaces: FileStorageController)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	io "io"
+	reflect "reflect"
+
+	models "github.com/freecloudio/server/domain/models"
+	fcerror "github.com/freecloudio/server/domain/models/fcerror"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockFileStorageController is a mock of FileStorageController interface.
+type MockFileStorageController struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileStorageControllerMockRecorder
+}
+
+// MockFileStorageControllerMockRecorder is the mock recorder for MockFileStorageController.
+type MockFileStorageControllerMockRecorder struct {
+	mock *MockFileStorageController
+}
+
+// NewMockFileStorageController creates a new mock instance.
+func NewMockFileStorageController(ctrl *gomock.Controller) *MockFileStorageController {
+	mock := &MockFileStorageController{ctrl: ctrl}
+	mock.recorder = &MockFileStorageControllerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileStorageController) EXPECT() *MockFileStorageControllerMockRecorder {
+	return m.recorder
+}
+
+// CopyFileFromUpload mocks base method.
+func (m *MockFileStorageController) CopyFileFromUpload(arg0 *models.Node, arg1 string) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyFileFromUpload", arg0, arg1)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// CopyFileFromUpload indicates an expected call of CopyFileFromUpload.
+func (mr *MockFileStorageControllerMockRecorder) CopyFileFromUpload(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyFileFromUpload", reflect.TypeOf((*MockFileStorageController)(nil).CopyFileFromUpload), arg0, arg1)
+}
+
+// CreateEmptyFileOrFolder mocks base method.
+func (m *MockFileStorageController) CreateEmptyFileOrFolder(arg0 *models.Node) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmptyFileOrFolder", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// CreateEmptyFileOrFolder indicates an expected call of CreateEmptyFileOrFolder.
+func (mr *MockFileStorageControllerMockRecorder) CreateEmptyFileOrFolder(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmptyFileOrFolder", reflect.TypeOf((*MockFileStorageController)(nil).CreateEmptyFileOrFolder), arg0)
+}
+
+// CreateUserRootFolder mocks base method.
+func (m *MockFileStorageController) CreateUserRootFolder(arg0 models.UserID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserRootFolder", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// CreateUserRootFolder indicates an expected call of CreateUserRootFolder.
+func (mr *MockFileStorageControllerMockRecorder) CreateUserRootFolder(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserRootFolder", reflect.TypeOf((*MockFileStorageController)(nil).CreateUserRootFolder), arg0)
+}
+
+// DeleteAvatars mocks base method.
+func (m *MockFileStorageController) DeleteAvatars(arg0 models.UserID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAvatars", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteAvatars indicates an expected call of DeleteAvatars.
+func (mr *MockFileStorageControllerMockRecorder) DeleteAvatars(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAvatars", reflect.TypeOf((*MockFileStorageController)(nil).DeleteAvatars), arg0)
+}
+
+// DeleteUserRootFolder mocks base method.
+func (m *MockFileStorageController) DeleteUserRootFolder(arg0 models.UserID) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserRootFolder", arg0)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// DeleteUserRootFolder indicates an expected call of DeleteUserRootFolder.
+func (mr *MockFileStorageControllerMockRecorder) DeleteUserRootFolder(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserRootFolder", reflect.TypeOf((*MockFileStorageController)(nil).DeleteUserRootFolder), arg0)
+}
+
+// DownloadFile mocks base method.
+func (m *MockFileStorageController) DownloadFile(arg0 *models.Node) (io.ReadCloser, int64, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadFile", arg0)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(*fcerror.Error)
+	return ret0, ret1, ret2
+}
+
+// DownloadFile indicates an expected call of DownloadFile.
+func (mr *MockFileStorageControllerMockRecorder) DownloadFile(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadFile", reflect.TypeOf((*MockFileStorageController)(nil).DownloadFile), arg0)
+}
+
+// GetUsedBytes mocks base method.
+func (m *MockFileStorageController) GetUsedBytes(arg0 models.UserID) (int64, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsedBytes", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(*fcerror.Error)
+	return ret0, ret1
+}
+
+// GetUsedBytes indicates an expected call of GetUsedBytes.
+func (mr *MockFileStorageControllerMockRecorder) GetUsedBytes(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsedBytes", reflect.TypeOf((*MockFileStorageController)(nil).GetUsedBytes), arg0)
+}
+
+// OpenAvatar mocks base method.
+func (m *MockFileStorageController) OpenAvatar(arg0 models.UserID, arg1 int) (io.ReadCloser, int64, *fcerror.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenAvatar", arg0, arg1)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(*fcerror.Error)
+	return ret0, ret1, ret2
+}
+
+// OpenAvatar indicates an expected call of OpenAvatar.
+func (mr *MockFileStorageControllerMockRecorder) OpenAvatar(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenAvatar", reflect.TypeOf((*MockFileStorageController)(nil).OpenAvatar), arg0, arg1)
+}
+
+// SaveAvatar mocks base method.
+func (m *MockFileStorageController) SaveAvatar(arg0 models.UserID, arg1 int, arg2 io.Reader) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAvatar", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// SaveAvatar indicates an expected call of SaveAvatar.
+func (mr *MockFileStorageControllerMockRecorder) SaveAvatar(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAvatar", reflect.TypeOf((*MockFileStorageController)(nil).SaveAvatar), arg0, arg1, arg2)
+}
+
+// TransferUserRootFolder mocks base method.
+func (m *MockFileStorageController) TransferUserRootFolder(arg0, arg1 models.UserID, arg2 string) *fcerror.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferUserRootFolder", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*fcerror.Error)
+	return ret0
+}
+
+// TransferUserRootFolder indicates an expected call of TransferUserRootFolder.
+func (mr *MockFileStorageControllerMockRecorder) TransferUserRootFolder(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferUserRootFolder", reflect.TypeOf((*MockFileStorageController)(nil).TransferUserRootFolder), arg0, arg1, arg2)
+}
@@ -0,0 +1,75 @@
+package gin
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (r *Router) buildAuditRoutes() {
+	grp := r.engine.Group("/api/audit")
+
+	grp.GET("export", r.exportAuditLog)
+}
+
+// exportAuditLog streams all audit events matching the filter of the query parameters as a JSON file
+func (r *Router) exportAuditLog(c *gin.Context) {
+	authContext := getAuthContext(c, r.logger)
+	filter, fcerr := extractAuditFilter(c)
+	if fcerr != nil {
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"audit-log-%s.json\"", time.Now().UTC().Format("20060102-150405")))
+	fcerr = r.managers.Audit.ExportAuditLog(authContext, filter, c.Writer)
+	if fcerr != nil {
+		// The error can only be reported if the export did not start yet
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			c.JSON(errToStatus(fcerr), fcerr)
+			return
+		}
+		r.logger.WithError(fcerr).Error("Failed to export audit log")
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+func extractAuditFilter(c *gin.Context) (filter *models.AuditFilter, fcerr *fcerror.Error) {
+	filter = &models.AuditFilter{}
+	if action := c.Query("action"); action != "" {
+		auditAction := models.AuditAction(action)
+		filter.Action = &auditAction
+	}
+	if outcome := c.Query("outcome"); outcome != "" {
+		auditOutcome := models.AuditOutcome(outcome)
+		filter.Outcome = &auditOutcome
+	}
+	if actorID := c.Query("actor_id"); actorID != "" {
+		userID := models.UserID(actorID)
+		filter.ActorID = &userID
+	}
+	if targetID := c.Query("target_id"); targetID != "" {
+		filter.TargetID = &targetID
+	}
+
+	for param, target := range map[string]**time.Time{"from": &filter.From, "until": &filter.Until} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fcerror.NewError(fcerror.ErrBadRequest, fmt.Errorf("Invalid time for '%s': %v", param, err))
+		}
+		*target = &parsed
+	}
+	return
+}
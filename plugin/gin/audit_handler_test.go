@@ -0,0 +1,73 @@
+package gin
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/application/manager"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportAuditLog(t *testing.T) {
+	from := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	action := models.AuditActionLogin
+	outcome := models.AuditOutcomeFailure
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedFilter *models.AuditFilter
+		exportErr      *fcerror.Error
+		expectedStatus int
+	}{
+		{name: "No filter", expectedFilter: &models.AuditFilter{}, expectedStatus: http.StatusOK},
+		{name: "Filter", query: "?action=LOGIN&outcome=FAILURE&from=2021-05-01T00:00:00Z", expectedFilter: &models.AuditFilter{Action: &action, Outcome: &outcome, From: &from}, expectedStatus: http.StatusOK},
+		{name: "Invalid time", query: "?until=yesterday", expectedStatus: http.StatusBadRequest},
+		{name: "Forbidden", expectedFilter: &models.AuditFilter{}, exportErr: fcerror.NewError(fcerror.ErrForbidden, nil), expectedStatus: http.StatusForbidden},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			auditMgrMock := mock.NewMockAuditManager(mockCtrl)
+			router := NewRouter(&manager.Managers{Audit: auditMgrMock}, createConfigMock(mockCtrl), ":8080")
+
+			if test.expectedFilter != nil {
+				auditMgrMock.EXPECT().ExportAuditLog(gomock.Any(), test.expectedFilter, gomock.Any()).DoAndReturn(func(_ *authorization.Context, _ *models.AuditFilter, writer io.Writer) *fcerror.Error {
+					if test.exportErr != nil {
+						return test.exportErr
+					}
+					_, err := io.WriteString(writer, "[]")
+					require.Nil(t, err, "Failed to write export")
+					return nil
+				}).Times(1)
+			}
+
+			req, err := http.NewRequest(http.MethodGet, "/api/audit/export"+test.query, nil)
+			require.Nil(t, err, "Failed to create request")
+			resp := httptest.NewRecorder()
+			router.engine.ServeHTTP(resp, req)
+
+			assert.Equal(t, test.expectedStatus, resp.Code, "Wrong status code")
+			if test.expectedStatus == http.StatusOK {
+				assert.Equal(t, "[]", resp.Body.String(), "Wrong export body")
+				assert.Contains(t, resp.Header().Get("Content-Disposition"), "attachment", "Export is not sent as attachment")
+			} else {
+				assert.Empty(t, resp.Header().Get("Content-Disposition"), "Error is sent as attachment")
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package gin
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const exportTokenParam = "token"
+
+func (r *Router) buildExportRoutes() {
+	grp := r.engine.Group("/api/export")
+
+	grp.GET(":"+exportTokenParam, r.downloadDataExport)
+}
+
+// downloadDataExport sends the ZIP of a data export, the signed token of the download link is the only authorization needed
+func (r *Router) downloadDataExport(c *gin.Context) {
+	export, reader, size, fcerr := r.managers.Export.OpenDataExport(c.Param(exportTokenParam))
+	if fcerr != nil {
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+	defer reader.Close()
+
+	extraHeaders := map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=\"freecloud-export-%s.zip\"", export.Created.UTC().Format("20060102-150405")),
+	}
+	c.DataFromReader(http.StatusOK, size, "application/zip", reader, extraHeaders)
+}
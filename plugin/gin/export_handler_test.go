@@ -0,0 +1,65 @@
+package gin
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/freecloudio/server/application/manager"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadDataExport(t *testing.T) {
+	content := "zip content"
+
+	tests := []struct {
+		name           string
+		openErr        *fcerror.Error
+		expectedStatus int
+	}{
+		{name: "Invalid token", openErr: fcerror.NewError(fcerror.ErrDataExportNotFound, nil), expectedStatus: http.StatusNotFound},
+		{name: "Expired", openErr: fcerror.NewError(fcerror.ErrDataExportExpired, nil), expectedStatus: http.StatusGone},
+		{name: "Not ready", openErr: fcerror.NewError(fcerror.ErrDataExportNotReady, nil), expectedStatus: http.StatusConflict},
+		{name: "Download", expectedStatus: http.StatusOK},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			exportMgrMock := mock.NewMockExportManager(mockCtrl)
+			router := NewRouter(&manager.Managers{Export: exportMgrMock}, createConfigMock(mockCtrl), ":8080")
+
+			if test.openErr != nil {
+				exportMgrMock.EXPECT().OpenDataExport("token").Return(nil, nil, int64(0), test.openErr).Times(1)
+			} else {
+				export := &models.DataExport{ID: "export", Status: models.DataExportStatusReady, Created: time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)}
+				reader := ioutil.NopCloser(strings.NewReader(content))
+				exportMgrMock.EXPECT().OpenDataExport("token").Return(export, reader, int64(len(content)), nil).Times(1)
+			}
+
+			req, err := http.NewRequest(http.MethodGet, "/api/export/token", nil)
+			require.Nil(t, err, "Failed to create request")
+			resp := httptest.NewRecorder()
+			router.engine.ServeHTTP(resp, req)
+
+			assert.Equal(t, test.expectedStatus, resp.Code, "Wrong status code")
+			if test.expectedStatus == http.StatusOK {
+				assert.Equal(t, content, resp.Body.String(), "Wrong export body")
+				assert.Equal(t, "application/zip", resp.Header().Get("Content-Type"), "Wrong content type")
+				assert.Equal(t, "attachment; filename=\"freecloud-export-20210304-050607.zip\"", resp.Header().Get("Content-Disposition"), "Wrong content disposition")
+			}
+		})
+	}
+}
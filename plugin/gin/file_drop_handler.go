@@ -0,0 +1,133 @@
+package gin
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	fileDropIDParam = "file_drop_id"
+
+	// Number of alternative names tried if the name of an uploaded file is already used in the file drop folder
+	maxFileDropNameAttempts = 100
+	// Allowed size of the multipart body besides the file itself, e.g. for headers and boundaries
+	multipartOverhead = 64 * 1024
+)
+
+func (r *Router) buildFileDropRoutes() {
+	grp := r.engine.Group("/api/drop")
+
+	grp.POST(":"+fileDropIDParam, r.uploadFileToDrop)
+}
+
+// uploadFileToDrop creates a new file in the folder of the file drop and uploads the content to it.
+// The file drop link itself is the only authorization needed.
+func (r *Router) uploadFileToDrop(c *gin.Context) {
+	fileDropID := models.FileDropID(c.Param(fileDropIDParam))
+	if fileDropID == "" {
+		fcerr := fcerror.NewError(fcerror.ErrBadRequest, errors.New("FileDropID not found in path param"))
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+
+	authContext, fcerr := r.managers.FileDrop.GetFileDropContext(fileDropID)
+	if fcerr != nil {
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+	fileDrop := authContext.FileDrop
+	authContext.ClientIP = c.ClientIP()
+
+	// The body is limited before parsing, as the multipart parser spools the whole upload to disk
+	if fileDrop.MaxSize > 0 {
+		bodyLimit := fileDrop.MaxSize + multipartOverhead
+		if c.Request.ContentLength > bodyLimit {
+			fcerr = fcerror.NewError(fcerror.ErrFileDropSizeExceeded, nil)
+			c.JSON(errToStatus(fcerr), fcerr)
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, bodyLimit)
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil && isRequestBodyTooLarge(err) {
+		fcerr = fcerror.NewError(fcerror.ErrFileDropSizeExceeded, err)
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	} else if err != nil {
+		logrus.WithError(err).Error("No file attached to upload")
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, err)
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+	fileName := filepath.Base(file.Filename)
+	if fileName == "." || fileName == "/" {
+		fcerr = fcerror.NewError(fcerror.ErrBadRequest, errors.New("Uploaded file has no valid name"))
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+	if fileDrop.MaxSize > 0 && file.Size > fileDrop.MaxSize {
+		fcerr = fcerror.NewError(fcerror.ErrFileDropSizeExceeded, nil)
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+
+	tmpPath := utils.JoinPaths(r.cfg.GetFileStorageTempBasePath(), utils.GenerateRandomString(10))
+	err = c.SaveUploadedFile(file, tmpPath)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to save upload to temp file")
+		fcerr = fcerror.NewError(fcerror.ErrCopyFileFailed, err)
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+	// The content is copied into the storage, so the temp file is not needed afterwards in any case
+	defer func() {
+		if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+			logrus.WithError(err).WithField("path", tmpPath).Error("Failed to delete temp file of upload")
+		}
+	}()
+
+	// Existing files are never touched, the upload gets a free name instead
+	name := fileDrop.NamePrefix + fileName
+	usedNames := []string{}
+	node := &models.Node{}
+	for attempt := 0; attempt < maxFileDropNameAttempts; attempt++ {
+		node = &models.Node{
+			Name:         utils.GetNonConflictingName(name, usedNames),
+			Type:         models.NodeTypeFile,
+			ParentNodeID: &fileDrop.NodeID,
+		}
+		_, fcerr = r.managers.Node.CreateNode(authContext, node)
+		if fcerr == nil || fcerr.ID != fcerror.ErrNodeNameAlreadyUsed {
+			break
+		}
+		usedNames = append(usedNames, node.Name)
+	}
+	if fcerr != nil {
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+
+	fcerr = r.managers.Node.UploadFileByID(authContext, node.ID, tmpPath)
+	if fcerr != nil {
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+
+	c.JSON(http.StatusOK, &gin.H{"name": node.Name})
+}
+
+// isRequestBodyTooLarge reports whether reading the body failed because of http.MaxBytesReader, which has no own error type yet
+func isRequestBodyTooLarge(err error) bool {
+	return strings.Contains(err.Error(), "request body too large")
+}
@@ -0,0 +1,139 @@
+package gin
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/application/manager"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createFileDropUploadRequest(t *testing.T, fileDropID models.FileDropID, fileName string, content []byte) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", fileName)
+	require.Nil(t, err, "Failed to create form file")
+	_, err = part.Write(content)
+	require.Nil(t, err, "Failed to write form file")
+	require.Nil(t, writer.Close(), "Failed to close multipart writer")
+
+	req, err := http.NewRequest(http.MethodPost, "/api/drop/"+string(fileDropID), body)
+	require.Nil(t, err, "Failed to create request")
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestUploadFileToDrop(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "freecloud-gin-test")
+	require.Nil(t, err, "Failed to create temp dir")
+	defer os.RemoveAll(tmpDir)
+
+	var (
+		fileDropID models.FileDropID = "drop"
+		folderID   models.NodeID     = "folder"
+		fileID     models.NodeID     = "file"
+	)
+
+	tests := []struct {
+		name           string
+		maxSize        int64
+		lookupErr      *fcerror.Error
+		usedNames      []string
+		contentSize    int
+		chunked        bool
+		createErr      *fcerror.Error
+		uploadErr      *fcerror.Error
+		expectedStatus int
+		expectedName   string
+	}{
+		{name: "Unknown drop", lookupErr: fcerror.NewError(fcerror.ErrFileDropNotFound, nil), expectedStatus: http.StatusNotFound},
+		{name: "Expired drop", lookupErr: fcerror.NewError(fcerror.ErrFileDropExpired, nil), expectedStatus: http.StatusGone},
+		{name: "Size exceeded", maxSize: 2, expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "Upload", maxSize: 100, expectedStatus: http.StatusOK, expectedName: "in_report.pdf"},
+		{name: "Name conflict", usedNames: []string{"in_report.pdf", "in_report (2).pdf"}, expectedStatus: http.StatusOK, expectedName: "in_report (3).pdf"},
+		{name: "Body exceeds limit", maxSize: 2, contentSize: 2 * multipartOverhead, expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "Chunked body exceeds limit", maxSize: 2, contentSize: 2 * multipartOverhead, chunked: true, expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "Node creation failed", createErr: fcerror.NewError(fcerror.ErrForbidden, nil), expectedStatus: http.StatusForbidden},
+		{name: "Upload failed", uploadErr: fcerror.NewError(fcerror.ErrCopyFileFailed, nil), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			cfgMock := createConfigMock(mockCtrl)
+			cfgMock.EXPECT().GetFileStorageTempBasePath().Return(tmpDir).AnyTimes()
+			fileDropMgrMock := mock.NewMockFileDropManager(mockCtrl)
+			nodeMgrMock := mock.NewMockNodeManager(mockCtrl)
+			router := NewRouter(&manager.Managers{FileDrop: fileDropMgrMock, Node: nodeMgrMock}, cfgMock, ":8080")
+
+			fileDrop := &models.FileDrop{ID: fileDropID, NodeID: folderID, NamePrefix: "in_", MaxSize: test.maxSize}
+			dropCtx := authorization.NewFileDrop(&models.User{}, fileDrop)
+			fileDropMgrMock.EXPECT().GetFileDropContext(fileDropID).Return(dropCtx, test.lookupErr).Times(1)
+
+			if test.createErr != nil {
+				nodeMgrMock.EXPECT().CreateNode(dropCtx, gomock.Any()).Return(false, test.createErr).Times(1)
+			}
+			if test.uploadErr != nil {
+				nodeMgrMock.EXPECT().CreateNode(dropCtx, gomock.Any()).DoAndReturn(func(_ *authorization.Context, node *models.Node) (bool, *fcerror.Error) {
+					node.ID = fileID
+					return true, nil
+				}).Times(1)
+				nodeMgrMock.EXPECT().UploadFileByID(dropCtx, fileID, gomock.Any()).Return(test.uploadErr).Times(1)
+			}
+			if test.expectedStatus == http.StatusOK {
+				for it := range test.usedNames {
+					usedName := test.usedNames[it]
+					nodeMgrMock.EXPECT().CreateNode(dropCtx, gomock.Any()).DoAndReturn(func(_ *authorization.Context, node *models.Node) (bool, *fcerror.Error) {
+						assert.Equal(t, usedName, node.Name, "Wrong name of conflicting node")
+						return false, fcerror.NewError(fcerror.ErrNodeNameAlreadyUsed, nil)
+					}).Times(1)
+				}
+				nodeMgrMock.EXPECT().CreateNode(dropCtx, gomock.Any()).DoAndReturn(func(_ *authorization.Context, node *models.Node) (bool, *fcerror.Error) {
+					assert.Equal(t, test.expectedName, node.Name, "Wrong name of created node")
+					assert.Equal(t, models.NodeTypeFile, node.Type, "Created node is not a file")
+					assert.Equal(t, folderID, *node.ParentNodeID, "Node is not created in file drop folder")
+					node.ID = fileID
+					return true, nil
+				}).Times(1)
+				nodeMgrMock.EXPECT().UploadFileByID(dropCtx, fileID, gomock.Any()).Return(nil).Times(1)
+			}
+
+			resp := httptest.NewRecorder()
+			content := []byte("content")
+			if test.contentSize > 0 {
+				content = bytes.Repeat([]byte("a"), test.contentSize)
+			}
+			req := createFileDropUploadRequest(t, fileDropID, "report.pdf", content)
+			if test.chunked {
+				req.ContentLength = -1
+			}
+			router.engine.ServeHTTP(resp, req)
+
+			assert.Equal(t, test.expectedStatus, resp.Code, "Wrong status code")
+			tmpFiles, err := ioutil.ReadDir(tmpDir)
+			require.Nil(t, err, "Failed to read temp dir")
+			assert.Empty(t, tmpFiles, "Temp file of upload is left over")
+			if test.expectedStatus == http.StatusOK {
+				respBody := map[string]string{}
+				require.Nil(t, json.Unmarshal(resp.Body.Bytes(), &respBody), "Failed to parse response")
+				assert.Equal(t, test.expectedName, respBody["name"], "Wrong name in response")
+			}
+		})
+	}
+}
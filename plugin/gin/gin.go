@@ -18,6 +18,7 @@ type Router struct {
 	managers *manager.Managers
 	srv      *http.Server
 	cfg      config.Config
+	oidc     *oidcClient
 	logger   utils.Logger
 }
 
@@ -27,7 +28,7 @@ func NewRouter(managers *manager.Managers, cfg config.Config, addr string) (rout
 	ginRouter := gin.New()
 	ginRouter.Use(gin.Recovery())
 	ginRouter.Use(ginlogrus.Logger(logger))
-	ginRouter.Use(getAuthMiddleware(managers.Auth))
+	ginRouter.Use(getAuthMiddleware(managers.Auth, cfg))
 
 	router = &Router{
 		engine:   ginRouter,
@@ -54,6 +55,11 @@ func (r *Router) Shutdown(ctx context.Context) error {
 
 func (r *Router) buildRoutes() {
 	r.buildNodeRoutes()
+	r.buildUserRoutes()
+	r.buildFileDropRoutes()
+	r.buildAuditRoutes()
+	r.buildExportRoutes()
+	r.buildOIDCRoutes()
 	r.buildGraphQLRoutes()
 
 	r.engine.GET("/health", func(c *gin.Context) {
@@ -63,14 +69,24 @@ func (r *Router) buildRoutes() {
 
 func errToStatus(fcerr *fcerror.Error) int {
 	switch fcerr.ID {
-	case fcerror.ErrUnauthorized, fcerror.ErrTokenNotFound:
+	case fcerror.ErrUnauthorized, fcerror.ErrTokenNotFound, fcerror.ErrSecondFactorInvalid, fcerror.ErrLoginChallengeNotFound, fcerror.ErrLoginChallengeExpired, fcerror.ErrAccessTokenExpired, fcerror.ErrExternalLoginFailed, fcerror.ErrWebAuthnChallengeInvalid, fcerror.ErrWebAuthnVerificationFailed:
 		return http.StatusUnauthorized
-	case fcerror.ErrForbidden:
+	case fcerror.ErrForbidden, fcerror.ErrEmailNotVerified, fcerror.ErrRegistrationDisabled, fcerror.ErrEmailDomainNotAllowed, fcerror.ErrInviteInvalid, fcerror.ErrCSRFTokenInvalid, fcerror.ErrUserDisabled, fcerror.ErrPasswordConfirmationFailed, fcerror.ErrExternalUserNotLinked:
 		return http.StatusForbidden
-	case fcerror.ErrUserNotFound, fcerror.ErrNodeNotFound:
+	case fcerror.ErrUserNotFound, fcerror.ErrNodeNotFound, fcerror.ErrGroupNotFound, fcerror.ErrGroupMemberNotFound, fcerror.ErrFileDropNotFound, fcerror.ErrSessionNotFound, fcerror.ErrAccessTokenNotFound, fcerror.ErrShareNotFound, fcerror.ErrWebAuthnCredentialNotFound, fcerror.ErrInviteNotFound, fcerror.ErrDataExportNotFound, fcerror.ErrAvatarNotFound:
 		return http.StatusNotFound
-	case fcerror.ErrBadRequest, fcerror.ErrEmailAlreadyRegistered:
+	case fcerror.ErrFileDropExpired, fcerror.ErrDataExportExpired:
+		return http.StatusGone
+	case fcerror.ErrFileDropSizeExceeded, fcerror.ErrAvatarTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case fcerror.ErrQuotaExceeded:
+		return http.StatusInsufficientStorage
+	case fcerror.ErrTooManyAttempts:
+		return http.StatusTooManyRequests
+	case fcerror.ErrBadRequest, fcerror.ErrValidationFailed, fcerror.ErrAvatarInvalid, fcerror.ErrEmailAlreadyRegistered, fcerror.ErrEmailTokenInvalid, fcerror.ErrEmailTokenExpired:
 		return http.StatusBadRequest
+	case fcerror.ErrNodeNameAlreadyUsed, fcerror.ErrShareMountCycle, fcerror.ErrTOTPAlreadyEnabled, fcerror.ErrWebAuthnCredentialAlreadyRegistered, fcerror.ErrDataExportNotReady, fcerror.ErrLastGroupAdmin:
+		return http.StatusConflict
 	default:
 		return http.StatusInternalServerError
 	}
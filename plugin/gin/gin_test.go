@@ -6,20 +6,32 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/freecloudio/server/application/config"
 	"github.com/freecloudio/server/application/manager"
+	"github.com/freecloudio/server/mock"
+	"github.com/freecloudio/server/utils"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
 
-//go:generate mockgen -destination ../../mock/manager.go -package mock github.com/freecloudio/server/application/manager AuthManager,UserManager,NodeManager
+//go:generate mockgen -destination ../../mock/manager.go -package mock github.com/freecloudio/server/application/manager AuthManager,UserManager,NodeManager,FileDropManager,AuditManager,ExportManager,ShareManager
+//go:generate mockgen -destination ../../mock/config.go -package mock github.com/freecloudio/server/application/config Config
+
+func createConfigMock(mockCtrl *gomock.Controller) *mock.MockConfig {
+	cfgMock := mock.NewMockConfig(mockCtrl)
+	cfgMock.EXPECT().GetLoggingConfig().Return(&utils.LoggingConfig{}).AnyTimes()
+	cfgMock.EXPECT().GetOIDCConfig().Return(&config.OIDCConfig{}).AnyTimes()
+	cfgMock.EXPECT().GetSessionCookieConfig().Return(&config.SessionCookieConfig{}).AnyTimes()
+	return cfgMock
+}
 
 func TestNewRouter(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
 	managers := &manager.Managers{}
-	router := NewRouter(managers, nil, ":8080")
+	router := NewRouter(managers, createConfigMock(mockCtrl), ":8080")
 
 	assert.NotNil(t, router.engine, "Router engine is nil")
 	assert.NotNil(t, router.srv, "Router srv is nil")
@@ -30,7 +42,7 @@ func TestHealthEndpoint(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
-	router := NewRouter(&manager.Managers{}, nil, ":8080")
+	router := NewRouter(&manager.Managers{}, createConfigMock(mockCtrl), ":8080")
 
 	testSrv := httptest.NewServer(router.engine)
 	defer testSrv.Close()
@@ -1,6 +1,16 @@
 package keys
 
+import "github.com/freecloudio/server/domain/models"
+
 const (
-	AuthContextKey = "authentication_context"
-	AuthTokenKey   = "authentication_token"
+	AuthContextKey         = "authentication_context"
+	AuthTokenKey           = "authentication_token"
+	ClientKey              = "session_client"
+	SessionCookieWriterKey = "session_cookie_writer"
 )
+
+// SessionCookieWriter sets or clears the session cookies in the response to the current request
+type SessionCookieWriter interface {
+	SetSessionCookie(session *models.Session)
+	ClearSessionCookie()
+}
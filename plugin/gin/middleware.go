@@ -2,10 +2,13 @@ package gin
 
 import (
 	"context"
+	"strings"
 
 	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/application/config"
 	"github.com/freecloudio/server/application/manager"
 	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
 	"github.com/freecloudio/server/plugin/gin/keys"
 	"github.com/freecloudio/server/utils"
 
@@ -34,25 +37,67 @@ func getAuthContext(c *gin.Context, logger utils.Logger) *authorization.Context
 	return authContext
 }
 
-func getAuthMiddleware(authMgr manager.AuthManager) gin.HandlerFunc {
+// getAuthMiddleware authenticates requests by the token in the authorization header or, if enabled, the session cookie.
+// State-changing requests authenticated by the cookie are rejected without a matching CSRF token.
+func getAuthMiddleware(authMgr manager.AuthManager, cfg config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var authContext *authorization.Context
+		var token models.Token
+		client := &models.SessionClient{UserAgent: c.Request.UserAgent(), ClientIP: c.ClientIP()}
+
+		cookieCfg := cfg.GetSessionCookieConfig()
+		var cookieWriter *sessionCookieWriter
+		if cookieCfg.Enabled {
+			cookieWriter = &sessionCookieWriter{c: c, cookieCfg: cookieCfg, lifetimeCfg: cfg.GetSessionLifetimeConfig()}
+		}
 
 		authHeader := c.GetHeader(authHeaderName)
-		if len(authHeader) <= len(authPrefix) {
-			authContext = authorization.NewAnonymous()
-		} else {
+		cookieToken, cookieErr := c.Cookie(sessionCookieName)
+		if len(authHeader) > len(authPrefix) {
 			tokenString := models.Token(authHeader[len(authPrefix):])
-			user, fcerr := authMgr.VerifyToken(tokenString)
-			if fcerr == nil {
+			if strings.HasPrefix(string(tokenString), models.AccessTokenPrefix) {
+				user, scope, fcerr := authMgr.VerifyAccessToken(tokenString)
+				if fcerr == nil {
+					authContext = authorization.NewAccessToken(user, scope)
+				} else {
+					authContext = authorization.NewAnonymous()
+				}
+			} else if user, fcerr := authMgr.VerifyToken(tokenString, client); fcerr == nil {
+				authContext = authorization.NewUser(user)
+				token = tokenString
+				c.Set(authTokenKey, tokenString)
+			} else {
+				authContext = authorization.NewAnonymous()
+			}
+		} else if cookieWriter != nil && cookieErr == nil && cookieToken != "" {
+			tokenString := models.Token(cookieToken)
+			if user, fcerr := authMgr.VerifyToken(tokenString, client); fcerr == nil {
+				if !checkCSRFToken(c, tokenString) {
+					fcerr = fcerror.NewError(fcerror.ErrCSRFTokenInvalid, nil)
+					c.AbortWithStatusJSON(errToStatus(fcerr), fcerr)
+					return
+				}
 				authContext = authorization.NewUser(user)
+				token = tokenString
 				c.Set(authTokenKey, tokenString)
 			} else {
+				cookieWriter.ClearSessionCookie()
 				authContext = authorization.NewAnonymous()
 			}
+		} else {
+			authContext = authorization.NewAnonymous()
 		}
 
+		authContext.ClientIP = client.ClientIP
+
 		ctx := context.WithValue(c.Request.Context(), keys.AuthContextKey, authContext)
+		ctx = context.WithValue(ctx, keys.ClientKey, client)
+		if token != "" {
+			ctx = context.WithValue(ctx, keys.AuthTokenKey, token)
+		}
+		if cookieWriter != nil {
+			ctx = context.WithValue(ctx, keys.SessionCookieWriterKey, keys.SessionCookieWriter(cookieWriter))
+		}
 		c.Request = c.Request.WithContext(ctx)
 		c.Next()
 	}
@@ -1,13 +1,18 @@
 package gin
 
 import (
+	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/freecloudio/server/application/authorization"
+	"github.com/freecloudio/server/application/config"
 	"github.com/freecloudio/server/domain/models"
 	"github.com/freecloudio/server/domain/models/fcerror"
 	"github.com/freecloudio/server/mock"
+	"github.com/freecloudio/server/plugin/gin/keys"
 	"github.com/sirupsen/logrus"
 
 	"github.com/gin-gonic/gin"
@@ -17,36 +22,43 @@ import (
 )
 
 func TestGetAuthContext(t *testing.T) {
-	c := &gin.Context{}
+	req, err := http.NewRequest(http.MethodGet, "", nil)
+	require.Nil(t, err, "Failed to create request")
+	c := &gin.Context{Request: req}
 	logger := logrus.New()
 
 	authContext := getAuthContext(c, logger)
 	assert.Equal(t, authorization.ContextTypeAnonymous, authContext.Type, "No auth context does return anonymous")
 
-	c.Set(authContextKey, "not a auth context")
+	c.Request = req.WithContext(context.WithValue(req.Context(), authContextKey, "not a auth context"))
 	authContext = getAuthContext(c, logger)
 	assert.Equal(t, authorization.ContextTypeAnonymous, authContext.Type, "Wrong context type does return anonymous")
 
-	c.Set(authContextKey, authorization.NewSystem())
+	c.Request = req.WithContext(context.WithValue(req.Context(), authContextKey, authorization.NewSystem()))
 	authContext = getAuthContext(c, logger)
 	assert.Equal(t, authorization.ContextTypeSystem, authContext.Type, "Wrong context type")
 }
 
 func TestAuthMiddleware(t *testing.T) {
 	var (
-		good models.Token = "good"
-		bad  models.Token = "bad"
+		good        models.Token = "good"
+		bad         models.Token = "bad"
+		accessToken models.Token = models.AccessTokenPrefix + "token"
 	)
+	scope := &models.TokenScope{ReadOnly: true}
+	client := &models.SessionClient{UserAgent: "test-agent", ClientIP: "192.0.2.1"}
 
 	tests := []struct {
 		name             string
 		input            string
 		validFormat      bool
 		valid            bool
+		accessToken      bool
 		expectedAuthType authorization.ContextType
 	}{
 		{name: "Valid Token", input: "Bearer " + string(good), validFormat: true, valid: true, expectedAuthType: authorization.ContextTypeUser},
 		{name: "Invalid Token", input: "Bearer " + string(bad), validFormat: true, expectedAuthType: authorization.ContextTypeAnonymous},
+		{name: "Access Token", input: "Bearer " + string(accessToken), accessToken: true, expectedAuthType: authorization.ContextTypeUser},
 		{name: "Too short", input: "short", expectedAuthType: authorization.ContextTypeAnonymous},
 		{name: "No Header", input: "", expectedAuthType: authorization.ContextTypeAnonymous},
 	}
@@ -58,18 +70,25 @@ func TestAuthMiddleware(t *testing.T) {
 			defer mockCtrl.Finish()
 
 			mockAuthMgr := mock.NewMockAuthManager(mockCtrl)
-			if test.validFormat && test.valid {
-				mockAuthMgr.EXPECT().VerifyToken(good).Return(&models.User{}, nil).Times(1)
+			if test.accessToken {
+				mockAuthMgr.EXPECT().VerifyAccessToken(accessToken).Return(&models.User{}, scope, nil).Times(1)
+			} else if test.validFormat && test.valid {
+				mockAuthMgr.EXPECT().VerifyToken(good, client).Return(&models.User{}, nil).Times(1)
 			} else if test.validFormat {
-				mockAuthMgr.EXPECT().VerifyToken(bad).Return(nil, fcerror.NewError(fcerror.ErrUnknown, nil)).Times(1)
+				mockAuthMgr.EXPECT().VerifyToken(bad, client).Return(nil, fcerror.NewError(fcerror.ErrUnknown, nil)).Times(1)
 			}
 
-			authMiddleware := getAuthMiddleware(mockAuthMgr)
+			mockCfg := mock.NewMockConfig(mockCtrl)
+			mockCfg.EXPECT().GetSessionCookieConfig().Return(&config.SessionCookieConfig{}).AnyTimes()
 
-			c := &gin.Context{}
+			authMiddleware := getAuthMiddleware(mockAuthMgr, mockCfg)
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
 			req, err := http.NewRequest(http.MethodGet, "", nil)
 			require.Nil(t, err, "Failed to create request")
 			req.Header.Add("Authorization", test.input)
+			req.Header.Add("User-Agent", client.UserAgent)
+			req.RemoteAddr = client.ClientIP + ":1234"
 			c.Request = req
 			logger := logrus.New()
 
@@ -77,7 +96,14 @@ func TestAuthMiddleware(t *testing.T) {
 
 			authContext := getAuthContext(c, logger)
 			assert.Equal(t, test.expectedAuthType, authContext.Type, "Wrong context type")
-			if authContext.Type == authorization.ContextTypeUser {
+			assert.Equal(t, client, c.Request.Context().Value(keys.ClientKey), "Client in context does not match")
+			assert.Equal(t, client.ClientIP, authContext.ClientIP, "Client IP of auth context does not match")
+			if test.accessToken {
+				assert.Equal(t, scope, authContext.Scope, "Scope of access token is not in context")
+				_, ok := c.Get(authTokenKey)
+				assert.False(t, ok, "Access token is set as session token")
+			} else if authContext.Type == authorization.ContextTypeUser {
+				assert.Nil(t, authContext.Scope, "Session context has a scope")
 				tokenInt, ok := c.Get(authTokenKey)
 				require.True(t, ok, "AuthTokenKey is not set")
 				token, ok := tokenInt.(models.Token)
@@ -87,3 +113,119 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthMiddlewareSessionCookie(t *testing.T) {
+	var (
+		good    models.Token = "good"
+		bad     models.Token = "bad"
+		csrfTok              = getCSRFToken(good)
+	)
+
+	tests := []struct {
+		name             string
+		disabled         bool
+		method           string
+		cookie           models.Token
+		csrfCookie       string
+		csrfHeader       string
+		valid            bool
+		expectedAuthType authorization.ContextType
+		expectedStatus   int
+		expectCleared    bool
+	}{
+		{name: "Valid Cookie Read", method: http.MethodGet, cookie: good, valid: true, expectedAuthType: authorization.ContextTypeUser},
+		{name: "Valid Cookie Write", method: http.MethodPost, cookie: good, csrfCookie: csrfTok, csrfHeader: csrfTok, valid: true, expectedAuthType: authorization.ContextTypeUser},
+		{name: "Missing CSRF Header", method: http.MethodPost, cookie: good, csrfCookie: csrfTok, valid: true, expectedStatus: http.StatusForbidden},
+		{name: "Mismatching CSRF Header", method: http.MethodPost, cookie: good, csrfCookie: csrfTok, csrfHeader: "other", valid: true, expectedStatus: http.StatusForbidden},
+		{name: "CSRF Token of other Session", method: http.MethodDelete, cookie: good, csrfCookie: getCSRFToken(bad), csrfHeader: getCSRFToken(bad), valid: true, expectedStatus: http.StatusForbidden},
+		{name: "Invalid Cookie", method: http.MethodPost, cookie: bad, expectedAuthType: authorization.ContextTypeAnonymous, expectCleared: true},
+		{name: "Cookies Disabled", disabled: true, method: http.MethodGet, cookie: good, expectedAuthType: authorization.ContextTypeAnonymous},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mockAuthMgr := mock.NewMockAuthManager(mockCtrl)
+			if !test.disabled {
+				if test.valid {
+					mockAuthMgr.EXPECT().VerifyToken(test.cookie, gomock.Any()).Return(&models.User{}, nil).Times(1)
+				} else {
+					mockAuthMgr.EXPECT().VerifyToken(test.cookie, gomock.Any()).Return(nil, fcerror.NewError(fcerror.ErrSessionExpired, nil)).Times(1)
+				}
+			}
+			mockCfg := mock.NewMockConfig(mockCtrl)
+			mockCfg.EXPECT().GetSessionCookieConfig().Return(&config.SessionCookieConfig{Enabled: !test.disabled, SameSite: "strict"}).AnyTimes()
+			mockCfg.EXPECT().GetSessionLifetimeConfig().Return(&config.SessionLifetimeConfig{}).AnyTimes()
+
+			authMiddleware := getAuthMiddleware(mockAuthMgr, mockCfg)
+
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			req, err := http.NewRequest(test.method, "", nil)
+			require.Nil(t, err, "Failed to create request")
+			req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: string(test.cookie)})
+			if test.csrfCookie != "" {
+				req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: test.csrfCookie})
+			}
+			if test.csrfHeader != "" {
+				req.Header.Add(csrfHeaderName, test.csrfHeader)
+			}
+			c.Request = req
+
+			authMiddleware(c)
+
+			if test.expectedStatus != 0 {
+				assert.True(t, c.IsAborted(), "Request was not aborted")
+				assert.Equal(t, test.expectedStatus, recorder.Code, "Wrong status code")
+				return
+			}
+			assert.False(t, c.IsAborted(), "Request was aborted")
+			authContext := getAuthContext(c, logrus.New())
+			assert.Equal(t, test.expectedAuthType, authContext.Type, "Wrong context type")
+
+			_, hasCookieWriter := c.Request.Context().Value(keys.SessionCookieWriterKey).(keys.SessionCookieWriter)
+			assert.Equal(t, !test.disabled, hasCookieWriter, "Cookie writer in context does not match whether cookies are enabled")
+
+			cleared := false
+			for _, cookie := range recorder.Result().Cookies() {
+				if cookie.Name == sessionCookieName && cookie.MaxAge < 0 {
+					cleared = true
+				}
+			}
+			assert.Equal(t, test.expectCleared, cleared, "Session cookie was not cleared as expected")
+		})
+	}
+}
+
+func TestSessionCookieWriter(t *testing.T) {
+	session := &models.Session{Token: "token"}
+	lifetimeCfg := &config.SessionLifetimeConfig{RememberMeMaxLifetime: time.Hour}
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	writer := &sessionCookieWriter{c: c, cookieCfg: &config.SessionCookieConfig{Enabled: true, SameSite: "strict", Secure: true}, lifetimeCfg: lifetimeCfg}
+	writer.SetSessionCookie(session)
+
+	cookies := recorder.Result().Cookies()
+	require.Len(t, cookies, 2, "Expected session and CSRF cookie")
+	assert.Equal(t, sessionCookieName, cookies[0].Name, "First cookie is not the session cookie")
+	assert.Equal(t, string(session.Token), cookies[0].Value, "Session cookie does not contain the token")
+	assert.True(t, cookies[0].HttpOnly, "Session cookie is readable by scripts")
+	assert.True(t, cookies[0].Secure, "Session cookie is not secure")
+	assert.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite, "Session cookie has wrong same site mode")
+	assert.Zero(t, cookies[0].MaxAge, "Session cookie of a not remembered session outlives the browser session")
+	assert.Equal(t, csrfCookieName, cookies[1].Name, "Second cookie is not the CSRF cookie")
+	assert.Equal(t, getCSRFToken(session.Token), cookies[1].Value, "CSRF cookie does not match the session")
+	assert.False(t, cookies[1].HttpOnly, "CSRF cookie is not readable by scripts")
+
+	recorder = httptest.NewRecorder()
+	writer.c, _ = gin.CreateTestContext(recorder)
+	session.RememberMe = true
+	writer.SetSessionCookie(session)
+	cookies = recorder.Result().Cookies()
+	require.Len(t, cookies, 2, "Expected session and CSRF cookie")
+	assert.Equal(t, int(time.Hour.Seconds()), cookies[0].MaxAge, "Session cookie of a remembered session is not kept for its lifetime")
+}
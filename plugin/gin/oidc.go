@@ -0,0 +1,399 @@
+package gin
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/utils"
+)
+
+const (
+	oidcDiscoveryPath = "/.well-known/openid-configuration"
+
+	// Time a started login has to be completed at the identity provider
+	oidcAuthRequestExpiration = 10 * time.Minute
+	// Allowed clock difference to the identity provider when validating ID tokens
+	oidcClockSkew   = time.Minute
+	oidcHTTPTimeout = 10 * time.Second
+
+	oidcStateLength    = 32
+	oidcNonceLength    = 32
+	oidcVerifierLength = 64
+)
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcJWKS struct {
+	Keys []struct {
+		KeyType string `json:"kty"`
+		KeyID   string `json:"kid"`
+		N       string `json:"n"`
+		E       string `json:"e"`
+	} `json:"keys"`
+}
+
+type oidcTokenResponse struct {
+	IDToken string `json:"id_token"`
+}
+
+type oidcTokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// oidcAudience is either a single string or a list of strings in the ID token
+type oidcAudience []string
+
+func (aud *oidcAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*aud = oidcAudience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*aud = multiple
+	return nil
+}
+
+func (aud oidcAudience) contains(clientID string) bool {
+	for _, entry := range aud {
+		if entry == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+type oidcIDTokenClaims struct {
+	Issuer          string       `json:"iss"`
+	Audience        oidcAudience `json:"aud"`
+	AuthorizedParty string       `json:"azp"`
+	Expiry          int64        `json:"exp"`
+	Nonce           string       `json:"nonce"`
+	EmailVerified   *bool        `json:"email_verified"`
+}
+
+// oidcAuthRequest is the state kept between redirecting to the identity provider and its callback
+type oidcAuthRequest struct {
+	verifier   string
+	nonce      string
+	validUntil time.Time
+}
+
+// oidcClient implements the authorization code flow with PKCE against an OpenID Connect identity provider
+type oidcClient struct {
+	cfg        *config.OIDCConfig
+	httpClient *http.Client
+
+	mutex     sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]*rsa.PublicKey
+	requests  map[string]*oidcAuthRequest
+}
+
+func newOIDCClient(cfg *config.OIDCConfig) *oidcClient {
+	return &oidcClient{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: oidcHTTPTimeout},
+		keys:       map[string]*rsa.PublicKey{},
+		requests:   map[string]*oidcAuthRequest{},
+	}
+}
+
+func (cl *oidcClient) getJSON(target string, result interface{}) error {
+	resp, err := cl.httpClient.Get(target)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, target)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// getDiscovery fetches the provider metadata once and caches it afterwards
+func (cl *oidcClient) getDiscovery() (*oidcDiscovery, error) {
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+
+	if cl.discovery != nil {
+		return cl.discovery, nil
+	}
+
+	discovery := &oidcDiscovery{}
+	err := cl.getJSON(strings.TrimSuffix(cl.cfg.Issuer, "/")+oidcDiscoveryPath, discovery)
+	if err != nil {
+		return nil, err
+	}
+	if discovery.Issuer != cl.cfg.Issuer {
+		return nil, fmt.Errorf("discovered issuer %s does not match configured issuer %s", discovery.Issuer, cl.cfg.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing endpoints")
+	}
+
+	cl.discovery = discovery
+	return discovery, nil
+}
+
+// startAuthRequest returns the state and the URL of the identity provider the user has to be redirected to
+func (cl *oidcClient) startAuthRequest() (state, authURL string, err error) {
+	discovery, err := cl.getDiscovery()
+	if err != nil {
+		return
+	}
+
+	state, err = utils.GenerateSecureRandomString(oidcStateLength)
+	if err != nil {
+		return
+	}
+	nonce, err := utils.GenerateSecureRandomString(oidcNonceLength)
+	if err != nil {
+		return
+	}
+	verifier, err := utils.GenerateSecureRandomString(oidcVerifierLength)
+	if err != nil {
+		return
+	}
+
+	target, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return
+	}
+	query := target.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", cl.cfg.ClientID)
+	query.Set("redirect_uri", cl.cfg.RedirectURL)
+	query.Set("scope", strings.Join(cl.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", getPKCEChallenge(verifier))
+	query.Set("code_challenge_method", "S256")
+	target.RawQuery = query.Encode()
+
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+	now := utils.GetCurrentTime()
+	for oldState, request := range cl.requests {
+		if now.After(request.validUntil) {
+			delete(cl.requests, oldState)
+		}
+	}
+	cl.requests[state] = &oidcAuthRequest{verifier: verifier, nonce: nonce, validUntil: now.Add(oidcAuthRequestExpiration)}
+
+	return state, target.String(), nil
+}
+
+// takeAuthRequest returns the started request for the state; every request can only be used once
+func (cl *oidcClient) takeAuthRequest(state string) (*oidcAuthRequest, error) {
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+
+	request, ok := cl.requests[state]
+	if !ok {
+		return nil, errors.New("unknown state")
+	}
+	delete(cl.requests, state)
+
+	if utils.GetCurrentTime().After(request.validUntil) {
+		return nil, errors.New("login request is expired")
+	}
+	return request, nil
+}
+
+// exchangeCode redeems the authorization code at the token endpoint and returns the raw ID token
+func (cl *oidcClient) exchangeCode(code, verifier string) (idToken string, err error) {
+	discovery, err := cl.getDiscovery()
+	if err != nil {
+		return
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", cl.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+	if cl.cfg.ClientSecret == "" {
+		form.Set("client_id", cl.cfg.ClientID)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if cl.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(cl.cfg.ClientID), url.QueryEscape(cl.cfg.ClientSecret))
+	}
+
+	resp, err := cl.httpClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("unexpected status %d from token endpoint", resp.StatusCode)
+		return
+	}
+	tokenResp := &oidcTokenResponse{}
+	err = json.NewDecoder(resp.Body).Decode(tokenResp)
+	if err != nil {
+		return
+	}
+	if tokenResp.IDToken == "" {
+		err = errors.New("token response contains no ID token")
+		return
+	}
+	return tokenResp.IDToken, nil
+}
+
+// getKey returns the signing key with the given ID and refreshes the key set if it is unknown
+func (cl *oidcClient) getKey(keyID string) (*rsa.PublicKey, error) {
+	discovery, err := cl.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+
+	if key, ok := cl.keys[keyID]; ok {
+		return key, nil
+	}
+
+	jwks := &oidcJWKS{}
+	err = cl.getJSON(discovery.JWKSURI, jwks)
+	if err != nil {
+		return nil, err
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.KeyType != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		keys[jwk.KeyID] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	cl.keys = keys
+
+	key, ok := cl.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %s not found", keyID)
+	}
+	return key, nil
+}
+
+// validateIDToken verifies the signature and the standard claims of the ID token and returns all of its claims
+func (cl *oidcClient) validateIDToken(rawToken, nonce string) (claims map[string]interface{}, err error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed ID token")
+	}
+
+	header := &oidcTokenHeader{}
+	err = decodeJWTPart(parts[0], header)
+	if err != nil {
+		return
+	}
+	if header.Algorithm != "RS256" {
+		return nil, fmt.Errorf("unsupported signing algorithm %s", header.Algorithm)
+	}
+	key, err := cl.getKey(header.KeyID)
+	if err != nil {
+		return
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature)
+	if err != nil {
+		return
+	}
+
+	standardClaims := &oidcIDTokenClaims{}
+	err = decodeJWTPart(parts[1], standardClaims)
+	if err != nil {
+		return
+	}
+	if standardClaims.Issuer != cl.cfg.Issuer {
+		return nil, errors.New("ID token has wrong issuer")
+	}
+	if !standardClaims.Audience.contains(cl.cfg.ClientID) {
+		return nil, errors.New("ID token is not issued for this client")
+	}
+	if len(standardClaims.Audience) > 1 && standardClaims.AuthorizedParty != cl.cfg.ClientID {
+		return nil, errors.New("ID token is not authorized for this client")
+	}
+	if utils.GetCurrentTime().Add(-oidcClockSkew).After(time.Unix(standardClaims.Expiry, 0)) {
+		return nil, errors.New("ID token is expired")
+	}
+	if standardClaims.Nonce != nonce {
+		return nil, errors.New("ID token has wrong nonce")
+	}
+	// Users are matched by their email, so providers which do not vouch for it cannot be trusted
+	if standardClaims.EmailVerified == nil || !*standardClaims.EmailVerified {
+		return nil, errors.New("email of ID token is not verified")
+	}
+
+	err = decodeJWTPart(parts[1], &claims)
+	return
+}
+
+// claimsToUser maps the configured claims of the ID token to a user
+func (cl *oidcClient) claimsToUser(claims map[string]interface{}) (*models.ExternalUser, error) {
+	email, _ := claims[cl.cfg.EmailClaim].(string)
+	if email == "" {
+		return nil, fmt.Errorf("ID token contains no claim %s", cl.cfg.EmailClaim)
+	}
+	firstName, _ := claims[cl.cfg.FirstNameClaim].(string)
+	lastName, _ := claims[cl.cfg.LastNameClaim].(string)
+
+	return &models.ExternalUser{Email: email, FirstName: firstName, LastName: lastName}, nil
+}
+
+func getPKCEChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func decodeJWTPart(part string, result interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
@@ -0,0 +1,109 @@
+package gin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/plugin/gin/keys"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// Cookie binding the state of a started login to the browser completing it
+	oidcStateCookieName = "fc_oidc_state"
+	oidcCallbackPath    = "/api/auth/oidc/callback"
+)
+
+func (r *Router) buildOIDCRoutes() {
+	oidcCfg := r.cfg.GetOIDCConfig()
+	if !oidcCfg.Enabled {
+		return
+	}
+	r.oidc = newOIDCClient(oidcCfg)
+
+	grp := r.engine.Group("/api/auth/oidc")
+
+	grp.GET("login", r.startOIDCLogin)
+	grp.GET("callback", r.finishOIDCLogin)
+}
+
+// startOIDCLogin redirects to the identity provider to authenticate there
+func (r *Router) startOIDCLogin(c *gin.Context) {
+	state, authURL, err := r.oidc.startAuthRequest()
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to start OIDC login")
+		fcerr := fcerror.NewError(fcerror.ErrExternalLoginFailed, err)
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookieName, state, int(oidcAuthRequestExpiration.Seconds()), oidcCallbackPath, "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// finishOIDCLogin validates the response of the identity provider and creates a session for the authenticated user
+func (r *Router) finishOIDCLogin(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		r.logger.WithField("error", providerErr).WithField("description", c.Query("error_description")).Warn("Identity provider returned an error")
+		fcerr := fcerror.NewError(fcerror.ErrExternalLoginFailed, errors.New(providerErr))
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+
+	state := c.Query("state")
+	cookieState, err := c.Cookie(oidcStateCookieName)
+	if err != nil || state == "" || state != cookieState {
+		fcerr := fcerror.NewError(fcerror.ErrExternalLoginFailed, errors.New("State does not match the started login"))
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+	c.SetCookie(oidcStateCookieName, "", -1, oidcCallbackPath, "", c.Request.TLS != nil, true)
+
+	request, err := r.oidc.takeAuthRequest(state)
+	if err != nil {
+		r.logger.WithError(err).Warn("Failed to find started OIDC login")
+		fcerr := fcerror.NewError(fcerror.ErrExternalLoginFailed, err)
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+
+	idToken, err := r.oidc.exchangeCode(c.Query("code"), request.verifier)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to exchange OIDC authorization code")
+		fcerr := fcerror.NewError(fcerror.ErrExternalLoginFailed, err)
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+
+	claims, err := r.oidc.validateIDToken(idToken, request.nonce)
+	if err != nil {
+		r.logger.WithError(err).Warn("Received invalid OIDC ID token")
+		fcerr := fcerror.NewError(fcerror.ErrExternalLoginFailed, err)
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+
+	externalUser, err := r.oidc.claimsToUser(claims)
+	if err != nil {
+		r.logger.WithError(err).Warn("Failed to map OIDC claims to user")
+		fcerr := fcerror.NewError(fcerror.ErrExternalLoginFailed, err)
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+
+	client, _ := c.Request.Context().Value(keys.ClientKey).(*models.SessionClient)
+	session, fcerr := r.managers.Auth.LoginExternal(externalUser, client)
+	if fcerr != nil {
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+	if cookieWriter, ok := c.Request.Context().Value(keys.SessionCookieWriterKey).(keys.SessionCookieWriter); ok {
+		cookieWriter.SetSessionCookie(session)
+	}
+
+	c.JSON(http.StatusOK, session)
+}
@@ -0,0 +1,340 @@
+package gin
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/application/manager"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/mock"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testOIDCClientID     = "freecloud"
+	testOIDCClientSecret = "secret"
+	testOIDCKeyID        = "test-key"
+	testOIDCRedirectURL  = "http://freecloud.test" + oidcCallbackPath
+)
+
+type testOIDCCode struct {
+	nonce     string
+	challenge string
+}
+
+// testOIDCProvider is a minimal in-process identity provider issuing RS256 signed ID tokens
+type testOIDCProvider struct {
+	t      *testing.T
+	srv    *httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]interface{}
+
+	mutex sync.Mutex
+	codes map[string]*testOIDCCode
+}
+
+func newTestOIDCProvider(t *testing.T) *testOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err, "Failed to generate RSA key")
+
+	provider := &testOIDCProvider{
+		t:      t,
+		key:    key,
+		claims: map[string]interface{}{"email": "oidc@example.com", "given_name": "Open", "family_name": "Connect"},
+		codes:  map[string]*testOIDCCode{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(oidcDiscoveryPath, provider.discovery)
+	mux.HandleFunc("/authorize", provider.authorize)
+	mux.HandleFunc("/token", provider.token)
+	mux.HandleFunc("/jwks", provider.jwks)
+	provider.srv = httptest.NewServer(mux)
+
+	return provider
+}
+
+func (p *testOIDCProvider) writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	require.Nil(p.t, json.NewEncoder(w).Encode(value), "Failed to encode provider response")
+}
+
+func (p *testOIDCProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	p.writeJSON(w, &oidcDiscovery{
+		Issuer:                p.srv.URL,
+		AuthorizationEndpoint: p.srv.URL + "/authorize",
+		TokenEndpoint:         p.srv.URL + "/token",
+		JWKSURI:               p.srv.URL + "/jwks",
+	})
+}
+
+// authorize logs in every user immediately and redirects back with a code
+func (p *testOIDCProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != testOIDCClientID || query.Get("code_challenge_method") != "S256" || query.Get("response_type") != "code" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	code := utils.GenerateRandomString(16)
+	p.mutex.Lock()
+	p.codes[code] = &testOIDCCode{nonce: query.Get("nonce"), challenge: query.Get("code_challenge")}
+	p.mutex.Unlock()
+
+	redirect, _ := url.Parse(query.Get("redirect_uri"))
+	redirect.RawQuery = url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *testOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != testOIDCClientID || clientSecret != testOIDCClientSecret || r.PostFormValue("grant_type") != "authorization_code" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	p.mutex.Lock()
+	code, ok := p.codes[r.PostFormValue("code")]
+	delete(p.codes, r.PostFormValue("code"))
+	p.mutex.Unlock()
+	if !ok || getPKCEChallenge(r.PostFormValue("code_verifier")) != code.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	claims := p.standardClaims(code.nonce)
+	for name, value := range p.claims {
+		claims[name] = value
+	}
+	p.writeJSON(w, &oidcTokenResponse{IDToken: p.sign(testOIDCKeyID, claims)})
+}
+
+func (p *testOIDCProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	p.writeJSON(w, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": testOIDCKeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *testOIDCProvider) standardClaims(nonce string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":            p.srv.URL,
+		"sub":            "subject",
+		"aud":            testOIDCClientID,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email_verified": true,
+	}
+}
+
+func (p *testOIDCProvider) sign(keyID string, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": keyID})
+	require.Nil(p.t, err, "Failed to encode token header")
+	payload, err := json.Marshal(claims)
+	require.Nil(p.t, err, "Failed to encode token claims")
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, hash[:])
+	require.Nil(p.t, err, "Failed to sign token")
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (p *testOIDCProvider) config() *config.OIDCConfig {
+	return &config.OIDCConfig{
+		Enabled:        true,
+		Issuer:         p.srv.URL,
+		ClientID:       testOIDCClientID,
+		ClientSecret:   testOIDCClientSecret,
+		RedirectURL:    testOIDCRedirectURL,
+		Scopes:         []string{"openid", "email", "profile"},
+		EmailClaim:     "email",
+		FirstNameClaim: "given_name",
+		LastNameClaim:  "family_name",
+	}
+}
+
+func noRedirectClient() *http.Client {
+	return &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+}
+
+func TestOIDCLogin(t *testing.T) {
+	provider := newTestOIDCProvider(t)
+	defer provider.srv.Close()
+
+	tests := []struct {
+		name           string
+		claims         map[string]interface{}
+		wrongState     bool
+		expectedStatus int
+	}{
+		{name: "Login", expectedStatus: http.StatusOK},
+		{name: "Wrong state", wrongState: true, expectedStatus: http.StatusUnauthorized},
+		{name: "Unverified email", claims: map[string]interface{}{"email_verified": false}, expectedStatus: http.StatusUnauthorized},
+		{name: "Missing email verification", claims: map[string]interface{}{"email_verified": nil}, expectedStatus: http.StatusUnauthorized},
+		{name: "Missing email", claims: map[string]interface{}{"email": ""}, expectedStatus: http.StatusUnauthorized},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			provider.claims = map[string]interface{}{"email": "oidc@example.com", "given_name": "Open", "family_name": "Connect"}
+			for name, value := range test.claims {
+				provider.claims[name] = value
+			}
+
+			cfgMock := mock.NewMockConfig(mockCtrl)
+			cfgMock.EXPECT().GetLoggingConfig().Return(&utils.LoggingConfig{}).AnyTimes()
+			cfgMock.EXPECT().GetOIDCConfig().Return(provider.config()).AnyTimes()
+			cfgMock.EXPECT().GetSessionCookieConfig().Return(&config.SessionCookieConfig{}).AnyTimes()
+			authMgrMock := mock.NewMockAuthManager(mockCtrl)
+			authMgrMock.EXPECT().VerifyToken(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+			router := NewRouter(&manager.Managers{Auth: authMgrMock}, cfgMock, ":8080")
+			testSrv := httptest.NewServer(router.engine)
+			defer testSrv.Close()
+
+			session := &models.Session{Token: "session-token"}
+			if test.expectedStatus == http.StatusOK {
+				authMgrMock.EXPECT().LoginExternal(&models.ExternalUser{Email: "oidc@example.com", FirstName: "Open", LastName: "Connect"}, gomock.Any()).Return(session, nil).Times(1)
+			}
+
+			client := noRedirectClient()
+			resp, err := client.Get(testSrv.URL + "/api/auth/oidc/login")
+			require.Nil(t, err, "Failed to start login")
+			require.Equal(t, http.StatusFound, resp.StatusCode, "Expect redirect to provider")
+			cookies := resp.Cookies()
+			require.Len(t, cookies, 1, "Expect state cookie")
+			assert.True(t, cookies[0].HttpOnly, "Expect state cookie to be http only")
+
+			resp, err = client.Get(resp.Header.Get("Location"))
+			require.Nil(t, err, "Failed to authorize at provider")
+			require.Equal(t, http.StatusFound, resp.StatusCode, "Expect redirect back from provider")
+			callback, err := url.Parse(resp.Header.Get("Location"))
+			require.Nil(t, err, "Failed to parse callback")
+			assert.True(t, strings.HasPrefix(callback.String(), testOIDCRedirectURL), "Expect redirect to configured callback")
+
+			req, err := http.NewRequest(http.MethodGet, testSrv.URL+callback.Path+"?"+callback.RawQuery, nil)
+			require.Nil(t, err, "Failed to create callback request")
+			if test.wrongState {
+				req.AddCookie(&http.Cookie{Name: oidcStateCookieName, Value: "wrong"})
+			} else {
+				req.AddCookie(cookies[0])
+			}
+			resp, err = client.Do(req)
+			require.Nil(t, err, "Failed to call callback")
+			assert.Equal(t, test.expectedStatus, resp.StatusCode, "Wrong callback status")
+
+			if test.expectedStatus == http.StatusOK {
+				respSession := &models.Session{}
+				require.Nil(t, json.NewDecoder(resp.Body).Decode(respSession), "Failed to decode session")
+				assert.Equal(t, session.Token, respSession.Token, "Wrong session returned")
+			}
+		})
+	}
+}
+
+func TestOIDCLoginDisabled(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	router := NewRouter(&manager.Managers{}, createConfigMock(mockCtrl), ":8080")
+	testSrv := httptest.NewServer(router.engine)
+	defer testSrv.Close()
+
+	resp, err := noRedirectClient().Get(testSrv.URL + "/api/auth/oidc/login")
+	require.Nil(t, err, "Failed to call login")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Expect no OIDC routes if disabled")
+}
+
+func TestValidateIDToken(t *testing.T) {
+	provider := newTestOIDCProvider(t)
+	defer provider.srv.Close()
+	otherProvider := newTestOIDCProvider(t)
+	defer otherProvider.srv.Close()
+
+	nonce := "nonce"
+	tests := []struct {
+		name     string
+		modify   func(claims map[string]interface{})
+		keyID    string
+		signer   *testOIDCProvider
+		tamper   bool
+		expValid bool
+	}{
+		{name: "Valid", expValid: true},
+		{name: "Multiple audiences with azp", modify: func(claims map[string]interface{}) {
+			claims["aud"] = []string{"other", testOIDCClientID}
+			claims["azp"] = testOIDCClientID
+		}, expValid: true},
+		{name: "Multiple audiences without azp", modify: func(claims map[string]interface{}) { claims["aud"] = []string{"other", testOIDCClientID} }},
+		{name: "Wrong issuer", modify: func(claims map[string]interface{}) { claims["iss"] = "https://evil.example.com" }},
+		{name: "Wrong audience", modify: func(claims map[string]interface{}) { claims["aud"] = "other" }},
+		{name: "Expired", modify: func(claims map[string]interface{}) { claims["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{name: "Wrong nonce", modify: func(claims map[string]interface{}) { claims["nonce"] = "other" }},
+		{name: "Missing email verification", modify: func(claims map[string]interface{}) { delete(claims, "email_verified") }},
+		{name: "Unknown key", keyID: "unknown"},
+		{name: "Wrong signing key", signer: otherProvider},
+		{name: "Tampered payload", tamper: true},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			cl := newOIDCClient(provider.config())
+
+			claims := provider.standardClaims(nonce)
+			if test.modify != nil {
+				test.modify(claims)
+			}
+			keyID := testOIDCKeyID
+			if test.keyID != "" {
+				keyID = test.keyID
+			}
+			signer := provider
+			if test.signer != nil {
+				signer = test.signer
+			}
+			token := signer.sign(keyID, claims)
+			if test.tamper {
+				parts := strings.Split(token, ".")
+				parts[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"` + provider.srv.URL + `","aud":"` + testOIDCClientID + `","exp":9999999999,"nonce":"nonce"}`))
+				token = strings.Join(parts, ".")
+			}
+
+			validatedClaims, err := cl.validateIDToken(token, nonce)
+			if test.expValid {
+				assert.Nil(t, err, "Expect token to be valid")
+				assert.Equal(t, "subject", validatedClaims["sub"], "Expect claims to be returned")
+			} else {
+				assert.NotNil(t, err, "Expect token to be invalid")
+			}
+		})
+	}
+}
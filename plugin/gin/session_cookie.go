@@ -0,0 +1,87 @@
+package gin
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/plugin/gin/keys"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	sessionCookieName = "fc_session"
+	// Readable by scripts of the web UI, which send it back in the CSRF header
+	csrfCookieName = "fc_csrf"
+	csrfHeaderName = "X-CSRF-Token"
+)
+
+// sessionCookieWriter sets the cookies on the gin context of the request
+type sessionCookieWriter struct {
+	c           *gin.Context
+	cookieCfg   *config.SessionCookieConfig
+	lifetimeCfg *config.SessionLifetimeConfig
+}
+
+var _ keys.SessionCookieWriter = &sessionCookieWriter{}
+
+// SetSessionCookie sets the HttpOnly session cookie and the CSRF cookie derived from it.
+// Sessions remembered are kept for their maximum lifetime, others only until the browser is closed.
+func (w *sessionCookieWriter) SetSessionCookie(session *models.Session) {
+	maxAge := 0
+	if session.RememberMe {
+		lifetime := w.lifetimeCfg.RememberMeMaxLifetime
+		if lifetime <= 0 {
+			lifetime = w.lifetimeCfg.RememberMeExpiration
+		}
+		maxAge = int(lifetime.Seconds())
+	}
+
+	w.setCookie(sessionCookieName, string(session.Token), maxAge, true)
+	w.setCookie(csrfCookieName, getCSRFToken(session.Token), maxAge, false)
+}
+
+func (w *sessionCookieWriter) ClearSessionCookie() {
+	w.setCookie(sessionCookieName, "", -1, true)
+	w.setCookie(csrfCookieName, "", -1, false)
+}
+
+func (w *sessionCookieWriter) setCookie(name, value string, maxAge int, httpOnly bool) {
+	w.c.SetSameSite(getSameSiteMode(w.cookieCfg.SameSite))
+	w.c.SetCookie(name, value, maxAge, "/", "", w.cookieCfg.Secure, httpOnly)
+}
+
+func getSameSiteMode(sameSite string) http.SameSite {
+	if sameSite == "strict" {
+		return http.SameSiteStrictMode
+	}
+	return http.SameSiteLaxMode
+}
+
+// getCSRFToken derives the CSRF token from the session token, so it is bound to the session without being stored.
+// A token planted in the CSRF cookie by another site does not match the session and is rejected.
+func getCSRFToken(token models.Token) string {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte(csrfCookieName))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// checkCSRFToken verifies the double-submitted CSRF token of state-changing requests authenticated by the session cookie
+func checkCSRFToken(c *gin.Context, token models.Token) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	headerToken := c.GetHeader(csrfHeaderName)
+	cookieToken, err := c.Cookie(csrfCookieName)
+	if err != nil || headerToken == "" || headerToken != cookieToken {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(headerToken), []byte(getCSRFToken(token))) == 1
+}
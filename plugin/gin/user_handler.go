@@ -0,0 +1,106 @@
+package gin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	userIDParam     = "user_id"
+	avatarSizeQuery = "size"
+)
+
+func (r *Router) buildUserRoutes() {
+	grp := r.engine.Group("/api/user")
+
+	grp.GET(":"+userIDParam+"/avatar", r.getAvatar)
+	grp.PUT(":"+userIDParam+"/avatar", r.uploadAvatar)
+	grp.DELETE(":"+userIDParam+"/avatar", r.deleteAvatar)
+}
+
+// getAvatar sends the avatar as PNG, the optional size query param selects the smallest stored size not smaller than it
+func (r *Router) getAvatar(c *gin.Context) {
+	authContext := getAuthContext(c, r.logger)
+
+	size := 0
+	if sizeStr := c.Query(avatarSizeQuery); sizeStr != "" {
+		var err error
+		size, err = strconv.Atoi(sizeStr)
+		if err != nil {
+			fcerr := fcerror.NewError(fcerror.ErrBadRequest, err)
+			c.JSON(errToStatus(fcerr), fcerr)
+			return
+		}
+	}
+
+	reader, length, fcerr := r.managers.User.GetAvatar(authContext, models.UserID(c.Param(userIDParam)), size)
+	if fcerr != nil {
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+	defer reader.Close()
+
+	// Links to avatars change with every update of the user, so they can be cached for a while
+	c.DataFromReader(http.StatusOK, length, "image/png", reader, map[string]string{"Cache-Control": "private, max-age=3600"})
+}
+
+func (r *Router) uploadAvatar(c *gin.Context) {
+	authContext := getAuthContext(c, r.logger)
+	maxSize := r.cfg.GetAvatarMaxSize()
+
+	// The body is limited before parsing, as the multipart parser spools the whole upload to disk
+	bodyLimit := maxSize + multipartOverhead
+	if c.Request.ContentLength > bodyLimit {
+		fcerr := fcerror.NewError(fcerror.ErrAvatarTooLarge, nil)
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, bodyLimit)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil && isRequestBodyTooLarge(err) {
+		fcerr := fcerror.NewError(fcerror.ErrAvatarTooLarge, err)
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	} else if err != nil {
+		r.logger.WithError(err).Info("No file attached to avatar upload")
+		fcerr := fcerror.NewError(fcerror.ErrBadRequest, err)
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+	if fileHeader.Size > maxSize {
+		fcerr := fcerror.NewError(fcerror.ErrAvatarTooLarge, nil)
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		fcerr := fcerror.NewError(fcerror.ErrOpenUploadFile, err)
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+	defer file.Close()
+
+	user, fcerr := r.managers.User.SetAvatar(authContext, models.UserID(c.Param(userIDParam)), file)
+	if fcerr != nil {
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (r *Router) deleteAvatar(c *gin.Context) {
+	authContext := getAuthContext(c, r.logger)
+
+	user, fcerr := r.managers.User.DeleteAvatar(authContext, models.UserID(c.Param(userIDParam)))
+	if fcerr != nil {
+		c.JSON(errToStatus(fcerr), fcerr)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
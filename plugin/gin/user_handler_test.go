@@ -0,0 +1,124 @@
+package gin
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/freecloudio/server/application/manager"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAvatar(t *testing.T) {
+	content := "png content"
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedSize   *int
+		avatarErr      *fcerror.Error
+		expectedStatus int
+	}{
+		{name: "Default size", expectedSize: new(int), expectedStatus: http.StatusOK},
+		{name: "Size", query: "?size=64", expectedSize: func() *int { size := 64; return &size }(), expectedStatus: http.StatusOK},
+		{name: "Invalid size", query: "?size=large", expectedStatus: http.StatusBadRequest},
+		{name: "No avatar", expectedSize: new(int), avatarErr: fcerror.NewError(fcerror.ErrAvatarNotFound, nil), expectedStatus: http.StatusNotFound},
+		{name: "Unauthorized", expectedSize: new(int), avatarErr: fcerror.NewError(fcerror.ErrUnauthorized, nil), expectedStatus: http.StatusUnauthorized},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			userMgrMock := mock.NewMockUserManager(mockCtrl)
+			router := NewRouter(&manager.Managers{User: userMgrMock}, createConfigMock(mockCtrl), ":8080")
+
+			if test.expectedSize != nil {
+				if test.avatarErr != nil {
+					userMgrMock.EXPECT().GetAvatar(gomock.Any(), models.UserID("user"), *test.expectedSize).Return(nil, int64(0), test.avatarErr).Times(1)
+				} else {
+					reader := ioutil.NopCloser(strings.NewReader(content))
+					userMgrMock.EXPECT().GetAvatar(gomock.Any(), models.UserID("user"), *test.expectedSize).Return(reader, int64(len(content)), nil).Times(1)
+				}
+			}
+
+			req, err := http.NewRequest(http.MethodGet, "/api/user/user/avatar"+test.query, nil)
+			require.Nil(t, err, "Failed to create request")
+			resp := httptest.NewRecorder()
+			router.engine.ServeHTTP(resp, req)
+
+			assert.Equal(t, test.expectedStatus, resp.Code, "Wrong status code")
+			if test.expectedStatus == http.StatusOK {
+				assert.Equal(t, content, resp.Body.String(), "Wrong avatar body")
+				assert.Equal(t, "image/png", resp.Header().Get("Content-Type"), "Wrong content type")
+			}
+		})
+	}
+}
+
+func TestUploadAvatar(t *testing.T) {
+	maxSize := int64(100)
+
+	tests := []struct {
+		name           string
+		contentSize    int
+		withoutFile    bool
+		chunked        bool
+		expectedStatus int
+	}{
+		{name: "Upload", contentSize: 10, expectedStatus: http.StatusOK},
+		{name: "No file", withoutFile: true, expectedStatus: http.StatusBadRequest},
+		{name: "File too large", contentSize: 101, expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "Body exceeds limit", contentSize: 2 * multipartOverhead, expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "Chunked body exceeds limit", contentSize: 2 * multipartOverhead, chunked: true, expectedStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			cfgMock := createConfigMock(mockCtrl)
+			cfgMock.EXPECT().GetAvatarMaxSize().Return(maxSize).AnyTimes()
+			userMgrMock := mock.NewMockUserManager(mockCtrl)
+			router := NewRouter(&manager.Managers{User: userMgrMock}, cfgMock, ":8080")
+			if test.expectedStatus == http.StatusOK {
+				userMgrMock.EXPECT().SetAvatar(gomock.Any(), models.UserID("user"), gomock.Any()).Return(&models.User{ID: "user"}, nil).Times(1)
+			}
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			if !test.withoutFile {
+				part, err := writer.CreateFormFile("file", "avatar.png")
+				require.Nil(t, err, "Failed to create form file")
+				_, err = part.Write(bytes.Repeat([]byte("a"), test.contentSize))
+				require.Nil(t, err, "Failed to write form file")
+			}
+			require.Nil(t, writer.Close(), "Failed to close multipart writer")
+
+			req, err := http.NewRequest(http.MethodPut, "/api/user/user/avatar", body)
+			require.Nil(t, err, "Failed to create request")
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			if test.chunked {
+				req.ContentLength = -1
+			}
+			resp := httptest.NewRecorder()
+			router.engine.ServeHTTP(resp, req)
+
+			assert.Equal(t, test.expectedStatus, resp.Code, "Wrong status code")
+		})
+	}
+}
@@ -33,7 +33,7 @@ func GetGraphQLPlaygroundHandler(cfg config.Config) http.Handler {
 func errorPresenter(ctx context.Context, err error) *gqlerror.Error {
 	var fcerr *fcerror.Error
 	if errors.As(err, &fcerr) {
-		return &gqlerror.Error{
+		gqlErr := &gqlerror.Error{
 			Path:    graphql.GetPath(ctx),
 			Message: fcerr.Description,
 			Extensions: map[string]interface{}{
@@ -44,6 +44,10 @@ func errorPresenter(ctx context.Context, err error) *gqlerror.Error {
 				"cause":    fcerr.Cause,
 			},
 		}
+		if len(fcerr.Fields) > 0 {
+			gqlErr.Extensions["fields"] = fcerr.Fields
+		}
+		return gqlErr
 	}
 
 	return graphql.DefaultErrorPresenter(ctx, err)
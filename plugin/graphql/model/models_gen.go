@@ -3,12 +3,78 @@
 package model
 
 import (
+	"time"
+
 	"github.com/freecloudio/server/domain/models"
 )
 
+type AccessTokenInput struct {
+	Name      string     `json:"name"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	ReadOnly  *bool      `json:"read_only"`
+	FolderID  *string    `json:"folder_id"`
+}
+
+type AccessTokenResult struct {
+	Token       string              `json:"token"`
+	AccessToken *models.AccessToken `json:"access_token"`
+}
+
+type AuditLogFilter struct {
+	Action   *models.AuditAction  `json:"action"`
+	Outcome  *models.AuditOutcome `json:"outcome"`
+	ActorID  *string              `json:"actor_id"`
+	TargetID *string              `json:"target_id"`
+	From     *time.Time           `json:"from"`
+	Until    *time.Time           `json:"until"`
+}
+
+type CreateUserInput struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	IsAdmin   *bool  `json:"is_admin"`
+	Quota     *int   `json:"quota"`
+}
+
+type FileDropInput struct {
+	NodeID     string     `json:"node_id"`
+	NamePrefix *string    `json:"name_prefix"`
+	MaxSize    *int       `json:"max_size"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+type GroupInput struct {
+	Name string `json:"name"`
+}
+
+type GroupMemberInput struct {
+	GroupID string `json:"group_id"`
+	UserID  string `json:"user_id"`
+	IsAdmin *bool  `json:"is_admin"`
+}
+
+type InviteInput struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+	Quota     *int       `json:"quota"`
+	IsAdmin   *bool      `json:"is_admin"`
+}
+
+type InviteResult struct {
+	Code   string         `json:"code"`
+	Invite *models.Invite `json:"invite"`
+}
+
+type LoginChallengeInput struct {
+	Token string `json:"token"`
+	Code  string `json:"code"`
+}
+
 type LoginInput struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	RememberMe *bool  `json:"remember_me"`
 }
 
 type MutationResult struct {
@@ -36,10 +102,30 @@ type NodeShareResult struct {
 	Share   *models.Share `json:"share"`
 }
 
+type ResetPasswordInput struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
 type ShareInput struct {
-	NodeID       string           `json:"node_id"`
-	SharedWithID string           `json:"shared_with_id"`
-	Mode         models.ShareMode `json:"mode"`
+	NodeID       string                  `json:"node_id"`
+	Name         *string                 `json:"name"`
+	TargetType   *models.ShareTargetType `json:"target_type"`
+	SharedWithID string                  `json:"shared_with_id"`
+	Mode         models.ShareMode        `json:"mode"`
+	ExpiresAt    *time.Time              `json:"expires_at"`
+}
+
+type ShareMountInput struct {
+	NodeID       string  `json:"node_id"`
+	Name         *string `json:"name"`
+	ParentNodeID *string `json:"parent_node_id"`
+}
+
+type ShareRevokeInput struct {
+	NodeID       string                  `json:"node_id"`
+	TargetType   *models.ShareTargetType `json:"target_type"`
+	SharedWithID string                  `json:"shared_with_id"`
 }
 
 type UserInput struct {
@@ -48,3 +134,22 @@ type UserInput struct {
 	Email     string `json:"email"`
 	Password  string `json:"password"`
 }
+
+type WebAuthnAssertionInput struct {
+	CredentialID      string  `json:"credential_id"`
+	ClientDataJSON    string  `json:"client_data_json"`
+	AuthenticatorData string  `json:"authenticator_data"`
+	Signature         string  `json:"signature"`
+	UserHandle        *string `json:"user_handle"`
+}
+
+type WebAuthnLoginChallengeInput struct {
+	Token     string                  `json:"token"`
+	Assertion *WebAuthnAssertionInput `json:"assertion"`
+}
+
+type WebAuthnRegistrationInput struct {
+	Name              string `json:"name"`
+	ClientDataJSON    string `json:"client_data_json"`
+	AttestationObject string `json:"attestation_object"`
+}
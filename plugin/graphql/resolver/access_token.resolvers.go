@@ -0,0 +1,70 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/plugin/graphql/generated"
+	"github.com/freecloudio/server/plugin/graphql/model"
+)
+
+func (r *accessTokenResolver) ID(ctx context.Context, obj *models.AccessToken) (string, error) {
+	return string(obj.ID), nil
+}
+
+func (r *accessTokenResolver) Folder(ctx context.Context, obj *models.AccessToken) (*models.Node, error) {
+	if obj.FolderID == "" {
+		return nil, nil
+	}
+	if r.isOnlyIDRequested(ctx) {
+		return &models.Node{ID: obj.FolderID}, nil
+	}
+	queryResolv := &queryResolver{r.Resolver}
+	return queryResolv.Node(ctx, model.NodeIdentifierInput{ID: (*string)(&obj.FolderID)})
+}
+
+func (r *mutationResolver) CreateAccessToken(ctx context.Context, input model.AccessTokenInput) (*model.AccessTokenResult, error) {
+	authCtx := r.getAuthContext(ctx)
+	accessToken := &models.AccessToken{
+		Name:      input.Name,
+		ExpiresAt: input.ExpiresAt,
+	}
+	if input.ReadOnly != nil {
+		accessToken.ReadOnly = *input.ReadOnly
+	}
+	if input.FolderID != nil {
+		accessToken.FolderID = models.NodeID(*input.FolderID)
+	}
+
+	token, fcerr := r.managers.Auth.CreateAccessToken(authCtx, accessToken)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.AccessTokenResult{Token: string(token), AccessToken: accessToken}, nil
+}
+
+func (r *mutationResolver) RevokeAccessToken(ctx context.Context, accessTokenID string) (*model.MutationResult, error) {
+	authCtx := r.getAuthContext(ctx)
+	fcerr := r.managers.Auth.RevokeAccessToken(authCtx, models.AccessTokenID(accessTokenID))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.MutationResult{Success: true}, nil
+}
+
+func (r *queryResolver) AccessTokens(ctx context.Context) ([]*models.AccessToken, error) {
+	authCtx := r.getAuthContext(ctx)
+	accessTokens, fcerr := r.managers.Auth.GetOwnAccessTokens(authCtx)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return accessTokens, nil
+}
+
+// AccessToken returns generated.AccessTokenResolver implementation.
+func (r *Resolver) AccessToken() generated.AccessTokenResolver { return &accessTokenResolver{r} }
+
+type accessTokenResolver struct{ *Resolver }
@@ -0,0 +1,62 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/plugin/graphql/generated"
+	"github.com/freecloudio/server/plugin/graphql/model"
+)
+
+func (r *auditEventResolver) ID(ctx context.Context, obj *models.AuditEvent) (string, error) {
+	return string(obj.ID), nil
+}
+
+func (r *auditEventResolver) Actor(ctx context.Context, obj *models.AuditEvent) (*models.User, error) {
+	if obj.ActorID == "" {
+		return nil, nil
+	}
+	if r.isOnlyIDRequested(ctx) {
+		return &models.User{ID: obj.ActorID}, nil
+	}
+	authCtx := r.getAuthContext(ctx)
+	user, fcerr := r.managers.User.GetUserByID(authCtx, obj.ActorID)
+	if fcerr != nil {
+		// The log outlives the users it mentions
+		if fcerr.ID == fcerror.ErrUserNotFound {
+			return nil, nil
+		}
+		return nil, fcerr
+	}
+	return user, nil
+}
+
+func (r *queryResolver) AuditLog(ctx context.Context, filter *model.AuditLogFilter, after *string) ([]*models.AuditEvent, error) {
+	authCtx := r.getAuthContext(ctx)
+	auditFilter := &models.AuditFilter{}
+	if filter != nil {
+		auditFilter = &models.AuditFilter{
+			Action:   filter.Action,
+			Outcome:  filter.Outcome,
+			ActorID:  (*models.UserID)(filter.ActorID),
+			TargetID: filter.TargetID,
+			From:     filter.From,
+			Until:    filter.Until,
+		}
+	}
+
+	events, fcerr := r.managers.Audit.GetAuditLog(authCtx, auditFilter, (*models.AuditEventID)(after))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return events, nil
+}
+
+// AuditEvent returns generated.AuditEventResolver implementation.
+func (r *Resolver) AuditEvent() generated.AuditEventResolver { return &auditEventResolver{r} }
+
+type auditEventResolver struct{ *Resolver }
@@ -14,12 +14,43 @@ import (
 	"github.com/freecloudio/server/plugin/graphql/model"
 )
 
-func (r *mutationResolver) Login(ctx context.Context, input model.LoginInput) (*models.Session, error) {
-	token, fcerr := r.managers.Auth.Login(input.Email, input.Password)
+func (r *loginChallengeResolver) Token(ctx context.Context, obj *models.LoginChallenge) (string, error) {
+	return string(obj.Token), nil
+}
+
+func (r *loginChallengeResolver) WebauthnOptions(ctx context.Context, obj *models.LoginChallenge) (*string, error) {
+	if obj.WebAuthnOptions == nil {
+		return nil, nil
+	}
+	optionsJSON, fcerr := marshalWebAuthnOptions(obj.WebAuthnOptions)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &optionsJSON, nil
+}
+
+func (r *mutationResolver) Login(ctx context.Context, input model.LoginInput) (*models.LoginResult, error) {
+	rememberMe := false
+	if input.RememberMe != nil {
+		rememberMe = *input.RememberMe
+	}
+	result, fcerr := r.managers.Auth.Login(input.Email, input.Password, rememberMe, r.getSessionClient(ctx))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	if result.Session != nil {
+		r.setSessionCookie(ctx, result.Session)
+	}
+	return result, nil
+}
+
+func (r *mutationResolver) CompleteLoginChallenge(ctx context.Context, input model.LoginChallengeInput) (*models.Session, error) {
+	session, fcerr := r.managers.Auth.CompleteLoginChallenge(models.Token(input.Token), input.Code, r.getSessionClient(ctx))
 	if fcerr != nil {
 		return nil, fcerr
 	}
-	return token, nil
+	r.setSessionCookie(ctx, session)
+	return session, nil
 }
 
 func (r *mutationResolver) Logout(ctx context.Context) (*model.MutationResult, error) {
@@ -29,6 +60,7 @@ func (r *mutationResolver) Logout(ctx context.Context) (*model.MutationResult, e
 	if tokenInt := ctx.Value(keys.AuthTokenKey); authContext.Type == authorization.ContextTypeUser && tokenInt != nil {
 		token := tokenInt.(models.Token)
 		fcerr = r.managers.Auth.Logout(token)
+		r.clearSessionCookie(ctx)
 	} else {
 		fcerr = fcerror.NewError(fcerror.ErrUnauthorized, nil)
 	}
@@ -39,6 +71,127 @@ func (r *mutationResolver) Logout(ctx context.Context) (*model.MutationResult, e
 	return &model.MutationResult{Success: true}, nil
 }
 
+func (r *mutationResolver) RevokeSession(ctx context.Context, sessionID string) (*model.MutationResult, error) {
+	authCtx := r.getAuthContext(ctx)
+	fcerr := r.managers.Auth.RevokeSession(authCtx, models.SessionID(sessionID))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.MutationResult{Success: true}, nil
+}
+
+func (r *mutationResolver) RevokeAllOtherSessions(ctx context.Context) (*model.MutationResult, error) {
+	authCtx := r.getAuthContext(ctx)
+	fcerr := r.managers.Auth.RevokeAllOtherSessions(authCtx, r.getAuthToken(ctx))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.MutationResult{Success: true}, nil
+}
+
+func (r *mutationResolver) RevokeUserSessions(ctx context.Context, userID string) (*model.MutationResult, error) {
+	authCtx := r.getAuthContext(ctx)
+	fcerr := r.managers.Auth.RevokeAllSessionsOfUser(authCtx, models.UserID(userID))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.MutationResult{Success: true}, nil
+}
+
+func (r *mutationResolver) UnlockUserLogin(ctx context.Context, userID string) (*model.MutationResult, error) {
+	authCtx := r.getAuthContext(ctx)
+	fcerr := r.managers.Auth.UnlockLogin(authCtx, models.UserID(userID))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.MutationResult{Success: true}, nil
+}
+
+func (r *mutationResolver) EnrollTotp(ctx context.Context) (string, error) {
+	authCtx := r.getAuthContext(ctx)
+	uri, fcerr := r.managers.Auth.EnrollTOTP(authCtx)
+	if fcerr != nil {
+		return "", fcerr
+	}
+	return uri, nil
+}
+
+func (r *mutationResolver) ConfirmTotp(ctx context.Context, code string) ([]string, error) {
+	authCtx := r.getAuthContext(ctx)
+	recoveryCodes, fcerr := r.managers.Auth.ConfirmTOTP(authCtx, code)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return recoveryCodes, nil
+}
+
+func (r *mutationResolver) DisableTotp(ctx context.Context, code string) (*model.MutationResult, error) {
+	authCtx := r.getAuthContext(ctx)
+	fcerr := r.managers.Auth.DisableTOTP(authCtx, code)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.MutationResult{Success: true}, nil
+}
+
+func (r *mutationResolver) LinkExternalAccount(ctx context.Context, password string, code *string) (*models.User, error) {
+	authCtx := r.getAuthContext(ctx)
+	secondFactorCode := ""
+	if code != nil {
+		secondFactorCode = *code
+	}
+	user, fcerr := r.managers.Auth.LinkExternalAccount(authCtx, password, secondFactorCode)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return user, nil
+}
+
+func (r *mutationResolver) RequestPasswordReset(ctx context.Context, email string) (*model.MutationResult, error) {
+	fcerr := r.managers.Auth.RequestPasswordReset(email)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.MutationResult{Success: true}, nil
+}
+
+func (r *mutationResolver) ResetPassword(ctx context.Context, input model.ResetPasswordInput) (*model.MutationResult, error) {
+	fcerr := r.managers.Auth.ResetPassword(input.Token, input.Password)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.MutationResult{Success: true}, nil
+}
+
+func (r *mutationResolver) RequestEmailVerification(ctx context.Context, email string) (*model.MutationResult, error) {
+	fcerr := r.managers.Auth.RequestEmailVerification(email)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.MutationResult{Success: true}, nil
+}
+
+func (r *mutationResolver) VerifyEmail(ctx context.Context, token string) (*model.MutationResult, error) {
+	fcerr := r.managers.Auth.VerifyEmail(token)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.MutationResult{Success: true}, nil
+}
+
+func (r *queryResolver) MySessions(ctx context.Context) ([]*models.Session, error) {
+	authCtx := r.getAuthContext(ctx)
+	sessions, fcerr := r.managers.Auth.GetOwnSessions(authCtx, r.getAuthToken(ctx))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return sessions, nil
+}
+
+func (r *sessionResolver) ID(ctx context.Context, obj *models.Session) (string, error) {
+	return string(obj.ID), nil
+}
+
 func (r *sessionResolver) Token(ctx context.Context, obj *models.Session) (string, error) {
 	return string(obj.Token), nil
 }
@@ -51,7 +204,13 @@ func (r *sessionResolver) User(ctx context.Context, obj *models.Session) (*model
 	return queryResolv.User(ctx, (*string)(&obj.UserID))
 }
 
+// LoginChallenge returns generated.LoginChallengeResolver implementation.
+func (r *Resolver) LoginChallenge() generated.LoginChallengeResolver {
+	return &loginChallengeResolver{r}
+}
+
 // Session returns generated.SessionResolver implementation.
 func (r *Resolver) Session() generated.SessionResolver { return &sessionResolver{r} }
 
+type loginChallengeResolver struct{ *Resolver }
 type sessionResolver struct{ *Resolver }
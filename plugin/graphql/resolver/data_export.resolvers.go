@@ -0,0 +1,38 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/plugin/graphql/generated"
+)
+
+func (r *dataExportResolver) ID(ctx context.Context, obj *models.DataExport) (string, error) {
+	return string(obj.ID), nil
+}
+
+func (r *mutationResolver) RequestDataExport(ctx context.Context) (*models.DataExport, error) {
+	authCtx := r.getAuthContext(ctx)
+	export, fcerr := r.managers.Export.RequestDataExport(authCtx)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return export, nil
+}
+
+func (r *queryResolver) MyDataExports(ctx context.Context) ([]*models.DataExport, error) {
+	authCtx := r.getAuthContext(ctx)
+	exports, fcerr := r.managers.Export.GetOwnDataExports(authCtx)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return exports, nil
+}
+
+// DataExport returns generated.DataExportResolver implementation.
+func (r *Resolver) DataExport() generated.DataExportResolver { return &dataExportResolver{r} }
+
+type dataExportResolver struct{ *Resolver }
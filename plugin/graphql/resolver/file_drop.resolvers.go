@@ -0,0 +1,67 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/plugin/graphql/generated"
+	"github.com/freecloudio/server/plugin/graphql/model"
+)
+
+func (r *fileDropResolver) ID(ctx context.Context, obj *models.FileDrop) (string, error) {
+	return string(obj.ID), nil
+}
+
+func (r *fileDropResolver) Node(ctx context.Context, obj *models.FileDrop) (*models.Node, error) {
+	if r.isOnlyIDRequested(ctx) {
+		return &models.Node{ID: obj.NodeID}, nil
+	}
+	queryResolv := &queryResolver{r.Resolver}
+	return queryResolv.Node(ctx, model.NodeIdentifierInput{ID: (*string)(&obj.NodeID)})
+}
+
+func (r *mutationResolver) CreateFileDrop(ctx context.Context, input model.FileDropInput) (*models.FileDrop, error) {
+	authCtx := r.getAuthContext(ctx)
+	fileDrop := &models.FileDrop{
+		NodeID:    models.NodeID(input.NodeID),
+		ExpiresAt: input.ExpiresAt,
+	}
+	if input.NamePrefix != nil {
+		fileDrop.NamePrefix = *input.NamePrefix
+	}
+	if input.MaxSize != nil {
+		fileDrop.MaxSize = int64(*input.MaxSize)
+	}
+
+	fcerr := r.managers.FileDrop.CreateFileDrop(authCtx, fileDrop)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return fileDrop, nil
+}
+
+func (r *mutationResolver) DeleteFileDrop(ctx context.Context, fileDropID string) (*model.MutationResult, error) {
+	authCtx := r.getAuthContext(ctx)
+	fcerr := r.managers.FileDrop.DeleteFileDrop(authCtx, models.FileDropID(fileDropID))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.MutationResult{Success: true}, nil
+}
+
+func (r *queryResolver) FileDrops(ctx context.Context) ([]*models.FileDrop, error) {
+	authCtx := r.getAuthContext(ctx)
+	fileDrops, fcerr := r.managers.FileDrop.GetOwnFileDrops(authCtx)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return fileDrops, nil
+}
+
+// FileDrop returns generated.FileDropResolver implementation.
+func (r *Resolver) FileDrop() generated.FileDropResolver { return &fileDropResolver{r} }
+
+type fileDropResolver struct{ *Resolver }
@@ -0,0 +1,121 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/plugin/graphql/generated"
+	"github.com/freecloudio/server/plugin/graphql/model"
+)
+
+func (r *groupResolver) ID(ctx context.Context, obj *models.Group) (string, error) {
+	return string(obj.ID), nil
+}
+
+func (r *groupResolver) Members(ctx context.Context, obj *models.Group) ([]*models.GroupMember, error) {
+	authCtx := r.getAuthContext(ctx)
+	members, fcerr := r.managers.Group.GetGroupMembers(authCtx, obj.ID)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return members, nil
+}
+
+func (r *groupMemberResolver) Group(ctx context.Context, obj *models.GroupMember) (*models.Group, error) {
+	if r.isOnlyIDRequested(ctx) {
+		return &models.Group{ID: obj.GroupID}, nil
+	}
+	queryResolv := &queryResolver{r.Resolver}
+	return queryResolv.Group(ctx, string(obj.GroupID))
+}
+
+func (r *groupMemberResolver) User(ctx context.Context, obj *models.GroupMember) (*models.User, error) {
+	if r.isOnlyIDRequested(ctx) {
+		return &models.User{ID: obj.UserID}, nil
+	}
+	queryResolv := &queryResolver{r.Resolver}
+	return queryResolv.User(ctx, (*string)(&obj.UserID))
+}
+
+func (r *mutationResolver) CreateGroup(ctx context.Context, input model.GroupInput) (*models.Group, error) {
+	authCtx := r.getAuthContext(ctx)
+	group := &models.Group{
+		Name: input.Name,
+	}
+
+	fcerr := r.managers.Group.CreateGroup(authCtx, group)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return group, nil
+}
+
+func (r *mutationResolver) AddGroupMember(ctx context.Context, input model.GroupMemberInput) (*model.MutationResult, error) {
+	authCtx := r.getAuthContext(ctx)
+	member := &models.GroupMember{
+		GroupID: models.GroupID(input.GroupID),
+		UserID:  models.UserID(input.UserID),
+	}
+	if input.IsAdmin != nil {
+		member.IsAdmin = *input.IsAdmin
+	}
+
+	fcerr := r.managers.Group.AddGroupMember(authCtx, member)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.MutationResult{Success: true}, nil
+}
+
+func (r *mutationResolver) RemoveGroupMember(ctx context.Context, groupID string, userID string) (*model.MutationResult, error) {
+	authCtx := r.getAuthContext(ctx)
+	fcerr := r.managers.Group.RemoveGroupMember(authCtx, models.GroupID(groupID), models.UserID(userID))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.MutationResult{Success: true}, nil
+}
+
+func (r *queryResolver) Group(ctx context.Context, groupID string) (*models.Group, error) {
+	groupInt := r.getObjectFromContextCache(ctx, groupID)
+	if groupInt != nil {
+		r.logger.WithField("groupID", groupID).Info("Got group from context cache")
+		return groupInt.(*models.Group), nil
+	}
+
+	authCtx := r.getAuthContext(ctx)
+	group, fcerr := r.managers.Group.GetGroupByID(authCtx, models.GroupID(groupID))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+
+	r.insertObjectIntoContextCache(ctx, groupID, group)
+
+	return group, nil
+}
+
+func (r *queryResolver) Groups(ctx context.Context) ([]*models.Group, error) {
+	authCtx := r.getAuthContext(ctx)
+	groups, fcerr := r.managers.Group.GetOwnGroups(authCtx)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+
+	for _, group := range groups {
+		r.insertObjectIntoContextCache(ctx, string(group.ID), group)
+	}
+
+	return groups, nil
+}
+
+// Group returns generated.GroupResolver implementation.
+func (r *Resolver) Group() generated.GroupResolver { return &groupResolver{r} }
+
+// GroupMember returns generated.GroupMemberResolver implementation.
+func (r *Resolver) GroupMember() generated.GroupMemberResolver { return &groupMemberResolver{r} }
+
+type groupResolver struct{ *Resolver }
+type groupMemberResolver struct{ *Resolver }
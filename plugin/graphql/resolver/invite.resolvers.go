@@ -0,0 +1,70 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/plugin/graphql/generated"
+	"github.com/freecloudio/server/plugin/graphql/model"
+)
+
+func (r *inviteResolver) ID(ctx context.Context, obj *models.Invite) (string, error) {
+	return string(obj.ID), nil
+}
+
+func (r *inviteResolver) CreatedBy(ctx context.Context, obj *models.Invite) (*models.User, error) {
+	if obj.CreatedBy == "" {
+		return nil, nil
+	}
+	if r.isOnlyIDRequested(ctx) {
+		return &models.User{ID: obj.CreatedBy}, nil
+	}
+	queryResolv := &queryResolver{r.Resolver}
+	return queryResolv.User(ctx, (*string)(&obj.CreatedBy))
+}
+
+func (r *mutationResolver) CreateInvite(ctx context.Context, input model.InviteInput) (*model.InviteResult, error) {
+	authCtx := r.getAuthContext(ctx)
+	invite := &models.Invite{}
+	if input.ExpiresAt != nil {
+		invite.ExpiresAt = *input.ExpiresAt
+	}
+	if input.Quota != nil {
+		invite.Quota = int64(*input.Quota)
+	}
+	if input.IsAdmin != nil {
+		invite.IsAdmin = *input.IsAdmin
+	}
+
+	code, fcerr := r.managers.User.CreateInvite(authCtx, invite)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.InviteResult{Code: code, Invite: invite}, nil
+}
+
+func (r *mutationResolver) RevokeInvite(ctx context.Context, inviteID string) (*model.MutationResult, error) {
+	authCtx := r.getAuthContext(ctx)
+	fcerr := r.managers.User.RevokeInvite(authCtx, models.InviteID(inviteID))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.MutationResult{Success: true}, nil
+}
+
+func (r *queryResolver) Invites(ctx context.Context) ([]*models.Invite, error) {
+	authCtx := r.getAuthContext(ctx)
+	invites, fcerr := r.managers.User.GetInvites(authCtx)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return invites, nil
+}
+
+// Invite returns generated.InviteResolver implementation.
+func (r *Resolver) Invite() generated.InviteResolver { return &inviteResolver{r} }
+
+type inviteResolver struct{ *Resolver }
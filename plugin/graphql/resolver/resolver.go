@@ -7,6 +7,7 @@ import (
 	"github.com/freecloudio/server/application/authorization"
 	"github.com/freecloudio/server/application/config"
 	"github.com/freecloudio/server/application/manager"
+	"github.com/freecloudio/server/domain/models"
 	"github.com/freecloudio/server/plugin/gin/keys"
 	"github.com/freecloudio/server/utils"
 
@@ -47,6 +48,30 @@ func (r *Resolver) getAuthContext(ctx context.Context) *authorization.Context {
 	return authContext
 }
 
+// getAuthToken returns the token of the current session or an empty token if not authenticated by a session
+func (r *Resolver) getAuthToken(ctx context.Context) models.Token {
+	token, _ := ctx.Value(keys.AuthTokenKey).(models.Token)
+	return token
+}
+
+func (r *Resolver) getSessionClient(ctx context.Context) *models.SessionClient {
+	client, _ := ctx.Value(keys.ClientKey).(*models.SessionClient)
+	return client
+}
+
+// setSessionCookie sets the session cookies for browsers if they are enabled
+func (r *Resolver) setSessionCookie(ctx context.Context, session *models.Session) {
+	if cookieWriter, ok := ctx.Value(keys.SessionCookieWriterKey).(keys.SessionCookieWriter); ok {
+		cookieWriter.SetSessionCookie(session)
+	}
+}
+
+func (r *Resolver) clearSessionCookie(ctx context.Context) {
+	if cookieWriter, ok := ctx.Value(keys.SessionCookieWriterKey).(keys.SessionCookieWriter); ok {
+		cookieWriter.ClearSessionCookie()
+	}
+}
+
 func ContextCacheMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		cache := contextCache{}
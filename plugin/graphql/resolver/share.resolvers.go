@@ -14,9 +14,21 @@ import (
 func (r *mutationResolver) ShareNode(ctx context.Context, input model.ShareInput) (*model.NodeShareResult, error) {
 	authCtx := r.getAuthContext(ctx)
 	share := &models.Share{
-		NodeID:       models.NodeID(input.NodeID),
-		SharedWithID: models.UserID(input.SharedWithID),
-		Mode:         input.Mode,
+		NodeID:     models.NodeID(input.NodeID),
+		TargetType: models.ShareTargetTypeUser,
+		Mode:       input.Mode,
+		ExpiresAt:  input.ExpiresAt,
+	}
+	if input.Name != nil {
+		share.Name = *input.Name
+	}
+	if input.TargetType != nil {
+		share.TargetType = *input.TargetType
+	}
+	if share.TargetType == models.ShareTargetTypeGroup {
+		share.SharedWithGroupID = models.GroupID(input.SharedWithID)
+	} else {
+		share.SharedWithID = models.UserID(input.SharedWithID)
 	}
 
 	created, fcerr := r.managers.Share.CreateShare(authCtx, share)
@@ -29,6 +41,42 @@ func (r *mutationResolver) ShareNode(ctx context.Context, input model.ShareInput
 	}, nil
 }
 
+func (r *mutationResolver) UpdateShareMount(ctx context.Context, input model.ShareMountInput) (*models.Node, error) {
+	authCtx := r.getAuthContext(ctx)
+	update := &models.ShareMountUpdate{
+		Name:         input.Name,
+		ParentNodeID: (*models.NodeID)(input.ParentNodeID),
+	}
+
+	node, fcerr := r.managers.Share.UpdateShareMount(authCtx, models.NodeID(input.NodeID), update)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return node, nil
+}
+
+func (r *mutationResolver) RevokeShare(ctx context.Context, input model.ShareRevokeInput) (*model.MutationResult, error) {
+	authCtx := r.getAuthContext(ctx)
+	share := &models.Share{
+		NodeID:     models.NodeID(input.NodeID),
+		TargetType: models.ShareTargetTypeUser,
+	}
+	if input.TargetType != nil {
+		share.TargetType = *input.TargetType
+	}
+	if share.TargetType == models.ShareTargetTypeGroup {
+		share.SharedWithGroupID = models.GroupID(input.SharedWithID)
+	} else {
+		share.SharedWithID = models.UserID(input.SharedWithID)
+	}
+
+	fcerr := r.managers.Share.RevokeShare(authCtx, share)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.MutationResult{Success: true}, nil
+}
+
 func (r *shareResolver) Node(ctx context.Context, obj *models.Share) (*models.Node, error) {
 	if r.isOnlyIDRequested(ctx) {
 		return &models.Node{ID: obj.NodeID}, nil
@@ -38,6 +86,9 @@ func (r *shareResolver) Node(ctx context.Context, obj *models.Share) (*models.No
 }
 
 func (r *shareResolver) SharedWith(ctx context.Context, obj *models.Share) (*models.User, error) {
+	if obj.TargetType == models.ShareTargetTypeGroup {
+		return nil, nil
+	}
 	if r.isOnlyIDRequested(ctx) {
 		return &models.User{ID: obj.SharedWithID}, nil
 	}
@@ -45,6 +96,17 @@ func (r *shareResolver) SharedWith(ctx context.Context, obj *models.Share) (*mod
 	return queryResolv.User(ctx, (*string)(&obj.SharedWithID))
 }
 
+func (r *shareResolver) SharedWithGroup(ctx context.Context, obj *models.Share) (*models.Group, error) {
+	if obj.TargetType != models.ShareTargetTypeGroup {
+		return nil, nil
+	}
+	if r.isOnlyIDRequested(ctx) {
+		return &models.Group{ID: obj.SharedWithGroupID}, nil
+	}
+	queryResolv := &queryResolver{r.Resolver}
+	return queryResolv.Group(ctx, string(obj.SharedWithGroupID))
+}
+
 // Share returns generated.ShareResolver implementation.
 func (r *Resolver) Share() generated.ShareResolver { return &shareResolver{r} }
 
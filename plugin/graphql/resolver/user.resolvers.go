@@ -5,6 +5,8 @@ package resolver
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/freecloudio/server/domain/models"
 	"github.com/freecloudio/server/domain/models/fcerror"
@@ -12,7 +14,7 @@ import (
 	"github.com/freecloudio/server/plugin/graphql/model"
 )
 
-func (r *mutationResolver) RegisterUser(ctx context.Context, input model.UserInput) (*models.User, error) {
+func (r *mutationResolver) RegisterUser(ctx context.Context, input model.UserInput, inviteCode *string) (*models.User, error) {
 	newUser := &models.User{
 		FirstName: input.FirstName,
 		LastName:  input.LastName,
@@ -20,7 +22,12 @@ func (r *mutationResolver) RegisterUser(ctx context.Context, input model.UserInp
 		Password:  input.Password,
 	}
 
-	_, fcerr := r.managers.User.CreateUser(newUser)
+	code := ""
+	if inviteCode != nil {
+		code = *inviteCode
+	}
+
+	_, fcerr := r.managers.User.CreateUser(newUser, code)
 	if fcerr != nil {
 		return nil, fcerr
 	}
@@ -28,6 +35,93 @@ func (r *mutationResolver) RegisterUser(ctx context.Context, input model.UserInp
 	return newUser, nil
 }
 
+func (r *mutationResolver) CreateUser(ctx context.Context, input model.CreateUserInput) (*models.User, error) {
+	authCtx := r.getAuthContext(ctx)
+	newUser := &models.User{
+		FirstName: input.FirstName,
+		LastName:  input.LastName,
+		Email:     input.Email,
+		Password:  input.Password,
+	}
+	if input.IsAdmin != nil {
+		newUser.IsAdmin = *input.IsAdmin
+	}
+	if input.Quota != nil {
+		newUser.Quota = int64(*input.Quota)
+	}
+
+	user, fcerr := r.managers.User.CreateUserAsAdmin(authCtx, newUser)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return user, nil
+}
+
+func (r *mutationResolver) UpdateUser(ctx context.Context, userID *string, input models.UserUpdate) (*models.User, error) {
+	authCtx := r.getAuthContext(ctx)
+
+	var updateUserID models.UserID
+	if userID != nil {
+		updateUserID = models.UserID(*userID)
+	} else if authCtx.User != nil {
+		updateUserID = authCtx.User.ID
+	} else {
+		return nil, fcerror.NewError(fcerror.ErrUnauthorized, nil)
+	}
+
+	user, fcerr := r.managers.User.UpdateUser(authCtx, updateUserID, &input)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return user, nil
+}
+
+func (r *mutationResolver) SetUserRoles(ctx context.Context, userID string, roles []models.Role) (*models.User, error) {
+	authCtx := r.getAuthContext(ctx)
+	user, fcerr := r.managers.User.SetUserRoles(authCtx, models.UserID(userID), roles)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return user, nil
+}
+
+func (r *mutationResolver) DisableUser(ctx context.Context, userID string) (*models.User, error) {
+	authCtx := r.getAuthContext(ctx)
+	user, fcerr := r.managers.User.DisableUser(authCtx, models.UserID(userID))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return user, nil
+}
+
+func (r *mutationResolver) EnableUser(ctx context.Context, userID string) (*models.User, error) {
+	authCtx := r.getAuthContext(ctx)
+	user, fcerr := r.managers.User.EnableUser(authCtx, models.UserID(userID))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return user, nil
+}
+
+func (r *mutationResolver) DeleteUser(ctx context.Context, userID string, transferFilesTo *string) (*model.MutationResult, error) {
+	authCtx := r.getAuthContext(ctx)
+	fcerr := r.managers.User.DeleteUser(authCtx, models.UserID(userID), (*models.UserID)(transferFilesTo))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.MutationResult{Success: true}, nil
+}
+
+func (r *mutationResolver) DeleteMyAccount(ctx context.Context, password string) (*model.MutationResult, error) {
+	authCtx := r.getAuthContext(ctx)
+	fcerr := r.managers.User.DeleteMyAccount(authCtx, password)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	r.clearSessionCookie(ctx)
+	return &model.MutationResult{Success: true}, nil
+}
+
 func (r *queryResolver) User(ctx context.Context, userID *string) (*models.User, error) {
 	authContext := r.getAuthContext(ctx)
 
@@ -56,10 +150,28 @@ func (r *queryResolver) User(ctx context.Context, userID *string) (*models.User,
 	return user, nil
 }
 
+func (r *queryResolver) Users(ctx context.Context, filter *models.UserFilter, sort *models.UserSort, after *string) ([]*models.User, error) {
+	authCtx := r.getAuthContext(ctx)
+	users, fcerr := r.managers.User.GetUsers(authCtx, filter, sort, (*models.UserID)(after))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return users, nil
+}
+
 func (r *userResolver) ID(ctx context.Context, obj *models.User) (string, error) {
 	return string(obj.ID), nil
 }
 
+func (r *userResolver) AvatarURL(ctx context.Context, obj *models.User) (*string, error) {
+	if !obj.HasAvatar {
+		return nil, nil
+	}
+	// The version changes with every update of the user, so cached avatars are not shown after a change
+	avatarURL := fmt.Sprintf("%s/api/user/%s/avatar?v=%d", strings.TrimSuffix(r.cfg.GetPublicURL(), "/"), obj.ID, obj.Updated.Unix())
+	return &avatarURL, nil
+}
+
 // User returns generated.UserResolver implementation.
 func (r *Resolver) User() generated.UserResolver { return &userResolver{r} }
 
@@ -0,0 +1,58 @@
+package resolver
+
+import (
+	"encoding/json"
+
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/plugin/graphql/model"
+	"github.com/freecloudio/server/utils"
+)
+
+// marshalWebAuthnOptions returns the options as JSON, so clients can pass them to the browser without mapping every field
+func marshalWebAuthnOptions(options interface{}) (string, *fcerror.Error) {
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return "", fcerror.NewError(fcerror.ErrUnknown, err)
+	}
+	return string(optionsJSON), nil
+}
+
+func decodeWebAuthnAttestation(input *model.WebAuthnRegistrationInput) (attestation *models.WebAuthnAttestation, fcerr *fcerror.Error) {
+	attestation = &models.WebAuthnAttestation{}
+	fcerr = decodeBase64URLFields([]*base64URLField{
+		{input.ClientDataJSON, &attestation.ClientDataJSON},
+		{input.AttestationObject, &attestation.AttestationObject},
+	})
+	return
+}
+
+func decodeWebAuthnAssertion(input *model.WebAuthnAssertionInput) (assertion *models.WebAuthnAssertion, fcerr *fcerror.Error) {
+	assertion = &models.WebAuthnAssertion{CredentialID: models.WebAuthnCredentialID(input.CredentialID)}
+	fields := []*base64URLField{
+		{input.ClientDataJSON, &assertion.ClientDataJSON},
+		{input.AuthenticatorData, &assertion.AuthenticatorData},
+		{input.Signature, &assertion.Signature},
+	}
+	if input.UserHandle != nil {
+		fields = append(fields, &base64URLField{*input.UserHandle, &assertion.UserHandle})
+	}
+	fcerr = decodeBase64URLFields(fields)
+	return
+}
+
+type base64URLField struct {
+	value  string
+	target *[]byte
+}
+
+func decodeBase64URLFields(fields []*base64URLField) *fcerror.Error {
+	for _, field := range fields {
+		decoded, err := utils.DecodeBase64URL(field.value)
+		if err != nil {
+			return fcerror.NewError(fcerror.ErrBadRequest, err)
+		}
+		*field.target = decoded
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/plugin/graphql/generated"
+	"github.com/freecloudio/server/plugin/graphql/model"
+)
+
+func (r *mutationResolver) BeginWebAuthnRegistration(ctx context.Context) (string, error) {
+	authCtx := r.getAuthContext(ctx)
+	options, fcerr := r.managers.Auth.BeginWebAuthnRegistration(authCtx)
+	if fcerr != nil {
+		return "", fcerr
+	}
+	optionsJSON, fcerr := marshalWebAuthnOptions(options)
+	if fcerr != nil {
+		return "", fcerr
+	}
+	return optionsJSON, nil
+}
+
+func (r *mutationResolver) FinishWebAuthnRegistration(ctx context.Context, input model.WebAuthnRegistrationInput) (*models.WebAuthnCredential, error) {
+	authCtx := r.getAuthContext(ctx)
+	attestation, fcerr := decodeWebAuthnAttestation(&input)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	credential, fcerr := r.managers.Auth.FinishWebAuthnRegistration(authCtx, input.Name, attestation)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return credential, nil
+}
+
+func (r *mutationResolver) DeleteWebAuthnCredential(ctx context.Context, credentialID string) (*model.MutationResult, error) {
+	authCtx := r.getAuthContext(ctx)
+	fcerr := r.managers.Auth.DeleteWebAuthnCredential(authCtx, models.WebAuthnCredentialID(credentialID))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return &model.MutationResult{Success: true}, nil
+}
+
+func (r *mutationResolver) BeginWebAuthnLogin(ctx context.Context, email *string) (string, error) {
+	loginEmail := ""
+	if email != nil {
+		loginEmail = *email
+	}
+	options, fcerr := r.managers.Auth.BeginWebAuthnLogin(loginEmail)
+	if fcerr != nil {
+		return "", fcerr
+	}
+	optionsJSON, fcerr := marshalWebAuthnOptions(options)
+	if fcerr != nil {
+		return "", fcerr
+	}
+	return optionsJSON, nil
+}
+
+func (r *mutationResolver) WebAuthnLogin(ctx context.Context, input model.WebAuthnAssertionInput) (*models.Session, error) {
+	assertion, fcerr := decodeWebAuthnAssertion(&input)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	session, fcerr := r.managers.Auth.WebAuthnLogin(assertion, r.getSessionClient(ctx))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	r.setSessionCookie(ctx, session)
+	return session, nil
+}
+
+func (r *mutationResolver) CompleteLoginChallengeWebAuthn(ctx context.Context, input model.WebAuthnLoginChallengeInput) (*models.Session, error) {
+	assertion, fcerr := decodeWebAuthnAssertion(input.Assertion)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	session, fcerr := r.managers.Auth.CompleteLoginChallengeWebAuthn(models.Token(input.Token), assertion, r.getSessionClient(ctx))
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	r.setSessionCookie(ctx, session)
+	return session, nil
+}
+
+func (r *queryResolver) MyWebAuthnCredentials(ctx context.Context) ([]*models.WebAuthnCredential, error) {
+	authCtx := r.getAuthContext(ctx)
+	credentials, fcerr := r.managers.Auth.GetOwnWebAuthnCredentials(authCtx)
+	if fcerr != nil {
+		return nil, fcerr
+	}
+	return credentials, nil
+}
+
+func (r *webAuthnCredentialResolver) ID(ctx context.Context, obj *models.WebAuthnCredential) (string, error) {
+	return string(obj.ID), nil
+}
+
+// WebAuthnCredential returns generated.WebAuthnCredentialResolver implementation.
+func (r *Resolver) WebAuthnCredential() generated.WebAuthnCredentialResolver {
+	return &webAuthnCredentialResolver{r}
+}
+
+type webAuthnCredentialResolver struct{ *Resolver }
@@ -0,0 +1,203 @@
+package ldapplg
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/freecloudio/server/application/authentication"
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	ldapTimeout = 10 * time.Second
+	// Page size used when listing all users of the directory
+	ldapPageSize = 500
+)
+
+// ldapConn contains the operations of an LDAP connection used by the authenticator
+type ldapConn interface {
+	Bind(username, password string) error
+	Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error)
+	SearchWithPaging(searchRequest *ldap.SearchRequest, pagingSize uint32) (*ldap.SearchResult, error)
+	Close()
+}
+
+// LDAPAuthenticator authenticates users by binding with their credentials against an LDAP directory
+type LDAPAuthenticator struct {
+	cfg    *config.LDAPConfig
+	dial   func() (ldapConn, error)
+	logger utils.Logger
+}
+
+var _ authentication.Authenticator = &LDAPAuthenticator{}
+
+func CreateLDAPAuthenticator(cfg config.Config) (*LDAPAuthenticator, *fcerror.Error) {
+	logger := utils.CreateLogger(cfg.GetLoggingConfig())
+	ldapCfg := cfg.GetLDAPConfig()
+
+	if ldapCfg.URL == "" || ldapCfg.BaseDN == "" || ldapCfg.EmailAttribute == "" {
+		return nil, fcerror.NewError(fcerror.ErrAuthenticatorFailed, errors.New("LDAP url, base dn and email attribute must be set"))
+	}
+	if strings.Count(ldapCfg.UserFilter, "%s") != 1 {
+		return nil, fcerror.NewError(fcerror.ErrAuthenticatorFailed, errors.New("LDAP user filter must contain exactly one %s"))
+	}
+	if _, err := ldap.CompileFilter(fmt.Sprintf(ldapCfg.UserFilter, "*")); err != nil {
+		return nil, fcerror.NewError(fcerror.ErrAuthenticatorFailed, err)
+	}
+
+	auth := &LDAPAuthenticator{cfg: ldapCfg, logger: logger}
+	auth.dial = auth.dialLDAP
+	return auth, nil
+}
+
+func (auth *LDAPAuthenticator) dialLDAP() (ldapConn, error) {
+	conn, err := ldap.DialURL(auth.cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(ldapTimeout)
+
+	if auth.cfg.StartTLS {
+		ldapURL, err := url.Parse(auth.cfg.URL)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		err = conn.StartTLS(&tls.Config{ServerName: ldapURL.Hostname()})
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// connect opens a connection bound as the service account
+func (auth *LDAPAuthenticator) connect() (conn ldapConn, fcerr *fcerror.Error) {
+	conn, err := auth.dial()
+	if err != nil {
+		auth.logger.WithError(err).Error("Failed to connect to LDAP directory")
+		return nil, fcerror.NewError(fcerror.ErrAuthenticatorFailed, err)
+	}
+
+	if auth.cfg.BindDN != "" {
+		err = conn.Bind(auth.cfg.BindDN, auth.cfg.BindPassword)
+		if err != nil {
+			conn.Close()
+			auth.logger.WithError(err).Error("Failed to bind LDAP service account")
+			return nil, fcerror.NewError(fcerror.ErrAuthenticatorFailed, err)
+		}
+	}
+	return conn, nil
+}
+
+func (auth *LDAPAuthenticator) buildSearchRequest(filterValue string, sizeLimit int) *ldap.SearchRequest {
+	attributes := []string{auth.cfg.EmailAttribute}
+	for _, attribute := range []string{auth.cfg.FirstNameAttribute, auth.cfg.LastNameAttribute, auth.cfg.GroupAttribute} {
+		if attribute != "" {
+			attributes = append(attributes, attribute)
+		}
+	}
+
+	return ldap.NewSearchRequest(
+		auth.cfg.BaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, sizeLimit, int(ldapTimeout.Seconds()), false,
+		fmt.Sprintf(auth.cfg.UserFilter, filterValue),
+		attributes,
+		nil,
+	)
+}
+
+func (auth *LDAPAuthenticator) Authenticate(email, password string) (externalUser *models.ExternalUser, fcerr *fcerror.Error) {
+	// An empty password would result in an unauthenticated bind which always succeeds
+	if email == "" || password == "" {
+		return nil, fcerror.NewError(fcerror.ErrUnauthorized, nil)
+	}
+
+	conn, fcerr := auth.connect()
+	if fcerr != nil {
+		return
+	}
+	defer conn.Close()
+
+	result, err := conn.Search(auth.buildSearchRequest(ldap.EscapeFilter(email), 2))
+	if err != nil {
+		auth.logger.WithError(err).WithField("email", email).Error("Failed to search user in LDAP directory")
+		return nil, fcerror.NewError(fcerror.ErrAuthenticatorFailed, err)
+	}
+	if len(result.Entries) != 1 {
+		auth.logger.WithField("email", email).WithField("count", len(result.Entries)).Debug("No unique user found in LDAP directory")
+		return nil, fcerror.NewError(fcerror.ErrUnauthorized, nil)
+	}
+	entry := result.Entries[0]
+
+	err = conn.Bind(entry.DN, password)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+		return nil, fcerror.NewError(fcerror.ErrUnauthorized, nil)
+	} else if err != nil {
+		auth.logger.WithError(err).WithField("dn", entry.DN).Error("Failed to bind user against LDAP directory")
+		return nil, fcerror.NewError(fcerror.ErrAuthenticatorFailed, err)
+	}
+
+	externalUser = auth.entryToUser(entry)
+	if externalUser == nil {
+		return nil, fcerror.NewError(fcerror.ErrUnauthorized, nil)
+	}
+	return
+}
+
+func (auth *LDAPAuthenticator) ListUsers() (externalUsers []*models.ExternalUser, fcerr *fcerror.Error) {
+	conn, fcerr := auth.connect()
+	if fcerr != nil {
+		return
+	}
+	defer conn.Close()
+
+	result, err := conn.SearchWithPaging(auth.buildSearchRequest("*", 0), ldapPageSize)
+	if err != nil {
+		auth.logger.WithError(err).Error("Failed to list users of LDAP directory")
+		return nil, fcerror.NewError(fcerror.ErrAuthenticatorFailed, err)
+	}
+
+	externalUsers = make([]*models.ExternalUser, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		if externalUser := auth.entryToUser(entry); externalUser != nil {
+			externalUsers = append(externalUsers, externalUser)
+		}
+	}
+	return
+}
+
+// entryToUser maps the configured attributes of the entry to a user and returns nil if it has no email
+func (auth *LDAPAuthenticator) entryToUser(entry *ldap.Entry) *models.ExternalUser {
+	externalUser := &models.ExternalUser{
+		Email:     entry.GetAttributeValue(auth.cfg.EmailAttribute),
+		FirstName: entry.GetAttributeValue(auth.cfg.FirstNameAttribute),
+		LastName:  entry.GetAttributeValue(auth.cfg.LastNameAttribute),
+	}
+	if externalUser.Email == "" {
+		auth.logger.WithField("dn", entry.DN).Warn("LDAP user has no email")
+		return nil
+	}
+
+	if auth.cfg.AdminGroupDN != "" {
+		isAdmin := false
+		for _, group := range entry.GetAttributeValues(auth.cfg.GroupAttribute) {
+			if strings.EqualFold(group, auth.cfg.AdminGroupDN) {
+				isAdmin = true
+				break
+			}
+		}
+		externalUser.IsAdmin = &isAdmin
+	}
+	return externalUser
+}
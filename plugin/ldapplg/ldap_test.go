@@ -0,0 +1,231 @@
+package ldapplg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/mock"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testServiceDN       = "cn=service,dc=example,dc=com"
+	testServicePassword = "service"
+	testAdminGroupDN    = "cn=admins,ou=groups,dc=example,dc=com"
+)
+
+// fakeDirectory is an in-memory stand-in for an LDAP directory matching users by their mail attribute
+type fakeDirectory struct {
+	entries   []*ldap.Entry
+	passwords map[string]string
+	dialErr   error
+}
+
+type fakeConn struct {
+	dir     *fakeDirectory
+	boundDN string
+	closed  bool
+}
+
+func (conn *fakeConn) Bind(username, password string) error {
+	if storedPassword, ok := conn.dir.passwords[username]; !ok || storedPassword != password {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+	}
+	conn.boundDN = username
+	return nil
+}
+
+func (conn *fakeConn) Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	if conn.boundDN != testServiceDN {
+		return nil, ldap.NewError(ldap.LDAPResultInsufficientAccessRights, errors.New("not bound as service account"))
+	}
+	if _, err := ldap.CompileFilter(searchRequest.Filter); err != nil {
+		return nil, err
+	}
+
+	result := &ldap.SearchResult{}
+	for _, entry := range conn.dir.entries {
+		mail := entry.GetAttributeValue("mail")
+		if strings.Contains(searchRequest.Filter, "(mail=*)") || (mail != "" && strings.Contains(searchRequest.Filter, "(mail="+ldap.EscapeFilter(mail)+")")) {
+			result.Entries = append(result.Entries, entry)
+		}
+	}
+	return result, nil
+}
+
+func (conn *fakeConn) SearchWithPaging(searchRequest *ldap.SearchRequest, pagingSize uint32) (*ldap.SearchResult, error) {
+	return conn.Search(searchRequest)
+}
+
+func (conn *fakeConn) Close() {
+	conn.closed = true
+}
+
+func newFakeDirectory() *fakeDirectory {
+	return &fakeDirectory{
+		entries: []*ldap.Entry{
+			ldap.NewEntry("uid=admin,ou=people,dc=example,dc=com", map[string][]string{
+				"mail": {"admin@example.com"}, "givenName": {"Ada"}, "sn": {"Admin"}, "memberOf": {"CN=Admins,OU=Groups,DC=example,DC=com"},
+			}),
+			ldap.NewEntry("uid=user,ou=people,dc=example,dc=com", map[string][]string{
+				"mail": {"user@example.com"}, "givenName": {"Uma"}, "sn": {"User"}, "memberOf": {"cn=staff,ou=groups,dc=example,dc=com"},
+			}),
+			ldap.NewEntry("uid=nomail,ou=people,dc=example,dc=com", map[string][]string{
+				"givenName": {"No"}, "sn": {"Mail"},
+			}),
+		},
+		passwords: map[string]string{
+			testServiceDN:                           testServicePassword,
+			"uid=admin,ou=people,dc=example,dc=com": "adminpw",
+			"uid=user,ou=people,dc=example,dc=com":  "userpw",
+		},
+	}
+}
+
+func testLDAPConfig() *config.LDAPConfig {
+	return &config.LDAPConfig{
+		Enabled:            true,
+		URL:                "ldap://ldap.example.com",
+		BindDN:             testServiceDN,
+		BindPassword:       testServicePassword,
+		BaseDN:             "dc=example,dc=com",
+		UserFilter:         "(&(objectClass=person)(mail=%s))",
+		EmailAttribute:     "mail",
+		FirstNameAttribute: "givenName",
+		LastNameAttribute:  "sn",
+		GroupAttribute:     "memberOf",
+		AdminGroupDN:       testAdminGroupDN,
+	}
+}
+
+func createTestAuthenticator(t *testing.T, ldapCfg *config.LDAPConfig, dir *fakeDirectory) (*LDAPAuthenticator, *[]*fakeConn) {
+	mockCtrl := gomock.NewController(t)
+	cfgMock := mock.NewMockConfig(mockCtrl)
+	cfgMock.EXPECT().GetLoggingConfig().Return(&utils.LoggingConfig{}).AnyTimes()
+	cfgMock.EXPECT().GetLDAPConfig().Return(ldapCfg).AnyTimes()
+
+	auth, fcerr := CreateLDAPAuthenticator(cfgMock)
+	require.Nil(t, fcerr, "Failed to create LDAP authenticator")
+
+	conns := &[]*fakeConn{}
+	auth.dial = func() (ldapConn, error) {
+		if dir.dialErr != nil {
+			return nil, dir.dialErr
+		}
+		conn := &fakeConn{dir: dir}
+		*conns = append(*conns, conn)
+		return conn, nil
+	}
+	return auth, conns
+}
+
+func TestCreateLDAPAuthenticator(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(ldapCfg *config.LDAPConfig)
+		expErr bool
+	}{
+		{name: "Valid", modify: func(ldapCfg *config.LDAPConfig) {}},
+		{name: "Missing base dn", modify: func(ldapCfg *config.LDAPConfig) { ldapCfg.BaseDN = "" }, expErr: true},
+		{name: "Filter without placeholder", modify: func(ldapCfg *config.LDAPConfig) { ldapCfg.UserFilter = "(objectClass=person)" }, expErr: true},
+		{name: "Invalid filter", modify: func(ldapCfg *config.LDAPConfig) { ldapCfg.UserFilter = "(&(mail=%s)" }, expErr: true},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			ldapCfg := testLDAPConfig()
+			test.modify(ldapCfg)
+			cfgMock := mock.NewMockConfig(mockCtrl)
+			cfgMock.EXPECT().GetLoggingConfig().Return(&utils.LoggingConfig{}).AnyTimes()
+			cfgMock.EXPECT().GetLDAPConfig().Return(ldapCfg).AnyTimes()
+
+			_, fcerr := CreateLDAPAuthenticator(cfgMock)
+			if test.expErr {
+				assert.NotNil(t, fcerr, "Expect invalid config to fail")
+			} else {
+				assert.Nil(t, fcerr, "Expect valid config to succeed")
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	isAdmin := true
+	isNoAdmin := false
+
+	tests := []struct {
+		name         string
+		email        string
+		password     string
+		noAdminGroup bool
+		dialErr      error
+		servicePw    string
+		expErrID     fcerror.ErrorID
+		expUser      *models.ExternalUser
+	}{
+		{name: "Admin", email: "admin@example.com", password: "adminpw", expUser: &models.ExternalUser{Email: "admin@example.com", FirstName: "Ada", LastName: "Admin", IsAdmin: &isAdmin}},
+		{name: "User", email: "user@example.com", password: "userpw", expUser: &models.ExternalUser{Email: "user@example.com", FirstName: "Uma", LastName: "User", IsAdmin: &isNoAdmin}},
+		{name: "No admin group", email: "admin@example.com", password: "adminpw", noAdminGroup: true, expUser: &models.ExternalUser{Email: "admin@example.com", FirstName: "Ada", LastName: "Admin"}},
+		{name: "Wrong password", email: "user@example.com", password: "wrong", expErrID: fcerror.ErrUnauthorized},
+		{name: "Empty password", email: "user@example.com", password: "", expErrID: fcerror.ErrUnauthorized},
+		{name: "Unknown user", email: "unknown@example.com", password: "userpw", expErrID: fcerror.ErrUnauthorized},
+		{name: "Filter injection", email: "*", password: "userpw", expErrID: fcerror.ErrUnauthorized},
+		{name: "Directory unreachable", email: "user@example.com", password: "userpw", dialErr: errors.New("connection refused"), expErrID: fcerror.ErrAuthenticatorFailed},
+		{name: "Wrong service password", email: "user@example.com", password: "userpw", servicePw: "wrong", expErrID: fcerror.ErrAuthenticatorFailed},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			ldapCfg := testLDAPConfig()
+			if test.noAdminGroup {
+				ldapCfg.AdminGroupDN = ""
+			}
+			if test.servicePw != "" {
+				ldapCfg.BindPassword = test.servicePw
+			}
+			dir := newFakeDirectory()
+			dir.dialErr = test.dialErr
+			auth, conns := createTestAuthenticator(t, ldapCfg, dir)
+
+			externalUser, fcerr := auth.Authenticate(test.email, test.password)
+			if test.expErrID != 0 {
+				require.NotNil(t, fcerr, "Expect authentication to fail")
+				assert.Equal(t, test.expErrID, fcerr.ID, "Wrong error")
+			} else {
+				require.Nil(t, fcerr, "Expect authentication to succeed")
+				assert.Equal(t, test.expUser, externalUser, "Wrong user")
+			}
+			for _, conn := range *conns {
+				assert.True(t, conn.closed, "Expect connection to be closed")
+			}
+		})
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	auth, conns := createTestAuthenticator(t, testLDAPConfig(), newFakeDirectory())
+
+	externalUsers, fcerr := auth.ListUsers()
+	require.Nil(t, fcerr, "Failed to list users")
+	require.Len(t, externalUsers, 2, "Expect users without email to be skipped")
+	assert.Equal(t, "admin@example.com", externalUsers[0].Email, "Wrong first user")
+	assert.True(t, *externalUsers[0].IsAdmin, "Expect member of admin group to be admin")
+	assert.Equal(t, "user@example.com", externalUsers[1].Email, "Wrong second user")
+	assert.False(t, *externalUsers[1].IsAdmin, "Expect non member of admin group not to be admin")
+	require.Len(t, *conns, 1, "Expect a single connection")
+	assert.True(t, (*conns)[0].closed, "Expect connection to be closed")
+}
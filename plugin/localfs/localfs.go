@@ -1,8 +1,11 @@
 package localfs
 
 import (
+	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"github.com/freecloudio/server/application/config"
 	"github.com/freecloudio/server/application/storage"
@@ -17,7 +20,11 @@ type LocalFSStorage struct {
 
 var _ storage.FileStorageController = &LocalFSStorage{}
 
-const osPermission os.FileMode = 0770
+const (
+	osPermission os.FileMode = 0770
+	// Avatars are kept next to the user folders, user IDs never start with a dot
+	avatarFolderName = ".avatars"
+)
 
 func CreateLocalFSStorage(cfg config.Config) (localFS *LocalFSStorage, fcerr *fcerror.Error) {
 	localFS = &LocalFSStorage{
@@ -51,6 +58,23 @@ func (fs *LocalFSStorage) CreateUserRootFolder(userID models.UserID) (fcerr *fce
 	return
 }
 
+func (fs *LocalFSStorage) DeleteUserRootFolder(userID models.UserID) (fcerr *fcerror.Error) {
+	err := os.RemoveAll(fs.getUserFolder(userID))
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrFileFolderDeletionFailed, err)
+	}
+	return
+}
+
+// TransferUserRootFolder moves the root folder of a user into a new folder in the root folder of another user
+func (fs *LocalFSStorage) TransferUserRootFolder(fromUserID, toUserID models.UserID, folderName string) (fcerr *fcerror.Error) {
+	err := os.Rename(fs.getUserFolder(fromUserID), utils.JoinPaths(fs.getUserFolder(toUserID), folderName))
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrFileFolderMoveFailed, err)
+	}
+	return
+}
+
 func (fs *LocalFSStorage) CreateEmptyFileOrFolder(node *models.Node) (fcerr *fcerror.Error) {
 	if node.OwnerID != node.PerspectiveUserID {
 		return fcerror.NewError(fcerror.ErrStorageOperationWithWrongUserPerspective, nil)
@@ -123,3 +147,82 @@ func (fs *LocalFSStorage) DownloadFile(node *models.Node) (reader io.ReadCloser,
 	reader = file
 	return
 }
+
+func (fs *LocalFSStorage) GetUsedBytes(userID models.UserID) (usedBytes int64, fcerr *fcerror.Error) {
+	err := filepath.Walk(fs.getUserFolder(userID), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			usedBytes += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrOpenUserFile, err)
+	}
+	return
+}
+
+func (fs *LocalFSStorage) getAvatarFolder(userID models.UserID) string {
+	return utils.JoinPaths(fs.basepath, avatarFolderName, string(userID))
+}
+
+func (fs *LocalFSStorage) getAvatarPath(userID models.UserID, size int) string {
+	return utils.JoinPaths(fs.getAvatarFolder(userID), fmt.Sprintf("%d.png", size))
+}
+
+// SaveAvatar replaces the avatar of the user in the size, readers never see a partially written avatar
+func (fs *LocalFSStorage) SaveAvatar(userID models.UserID, size int, content io.Reader) (fcerr *fcerror.Error) {
+	folder := fs.getAvatarFolder(userID)
+	err := os.MkdirAll(folder, osPermission)
+	if err != nil {
+		return fcerror.NewError(fcerror.ErrFileFolderCreationFailed, err)
+	}
+
+	tmpFile, err := ioutil.TempFile(folder, ".upload-*")
+	if err != nil {
+		return fcerror.NewError(fcerror.ErrFileFolderCreationFailed, err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = io.Copy(tmpFile, content)
+	closeErr := tmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), fs.getAvatarPath(userID, size))
+	}
+	if err != nil {
+		return fcerror.NewError(fcerror.ErrCopyFileFailed, err)
+	}
+	return
+}
+
+func (fs *LocalFSStorage) OpenAvatar(userID models.UserID, size int) (reader io.ReadCloser, length int64, fcerr *fcerror.Error) {
+	file, err := os.Open(fs.getAvatarPath(userID, size))
+	if os.IsNotExist(err) {
+		fcerr = fcerror.NewError(fcerror.ErrAvatarNotFound, err)
+		return
+	} else if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrOpenUserFile, err)
+		return
+	}
+
+	fileStat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		fcerr = fcerror.NewError(fcerror.ErrOpenUserFile, err)
+		return
+	}
+	return file, fileStat.Size(), nil
+}
+
+func (fs *LocalFSStorage) DeleteAvatars(userID models.UserID) (fcerr *fcerror.Error) {
+	err := os.RemoveAll(fs.getAvatarFolder(userID))
+	if err != nil {
+		fcerr = fcerror.NewError(fcerror.ErrFileFolderDeletionFailed, err)
+	}
+	return
+}
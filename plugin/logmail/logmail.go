@@ -0,0 +1,64 @@
+package logmail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/application/mail"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogMailer does not deliver mails but appends them to a file or logs them, which is meant for development and tests
+type LogMailer struct {
+	path   string
+	mutex  sync.Mutex
+	logger utils.Logger
+}
+
+var _ mail.Mailer = &LogMailer{}
+
+func CreateLogMailer(cfg config.Config) (*LogMailer, *fcerror.Error) {
+	logger := utils.CreateLogger(cfg.GetLoggingConfig())
+	path := cfg.GetMailConfig().LogPath
+
+	if path != "" {
+		err := os.MkdirAll(filepath.Dir(path), 0770)
+		if err != nil {
+			logger.WithError(err).WithField("path", path).Error("Failed to create folder for mail log")
+			return nil, fcerror.NewError(fcerror.ErrFileFolderCreationFailed, err)
+		}
+	}
+
+	return &LogMailer{path: path, logger: logger}, nil
+}
+
+func (mailer *LogMailer) SendMail(mail *models.Mail) *fcerror.Error {
+	if mailer.path == "" {
+		mailer.logger.WithFields(logrus.Fields{"to": mail.To, "subject": mail.Subject}).Info(mail.Body)
+		return nil
+	}
+
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	file, err := os.OpenFile(mailer.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0660)
+	if err != nil {
+		mailer.logger.WithError(err).WithField("path", mailer.path).Error("Failed to open mail log")
+		return fcerror.NewError(fcerror.ErrMailSendFailed, err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "To: %s\nSubject: %s\nDate: %s\n\n%s\n\n", mail.To, mail.Subject, utils.GetCurrentTime().Format("2006-01-02T15:04:05Z07:00"), mail.Body)
+	if err != nil {
+		mailer.logger.WithError(err).WithField("path", mailer.path).Error("Failed to write mail log")
+		return fcerror.NewError(fcerror.ErrMailSendFailed, err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package logmail
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/mock"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendMailToFile(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tmpDir, err := ioutil.TempDir("", "freecloud-logmail-test")
+	require.Nil(t, err, "Failed to create temp dir")
+	defer os.RemoveAll(tmpDir)
+	path := filepath.Join(tmpDir, "mails", "mail.log")
+
+	cfgMock := mock.NewMockConfig(mockCtrl)
+	cfgMock.EXPECT().GetLoggingConfig().Return(&utils.LoggingConfig{}).AnyTimes()
+	cfgMock.EXPECT().GetMailConfig().Return(&config.MailConfig{Plugin: config.LogMailKey, LogPath: path}).AnyTimes()
+
+	mailer, fcerr := CreateLogMailer(cfgMock)
+	require.Nil(t, fcerr, "Failed to create log mailer")
+
+	require.Nil(t, mailer.SendMail(&models.Mail{To: "first@example.com", Subject: "First", Body: "first body"}), "Failed to send first mail")
+	require.Nil(t, mailer.SendMail(&models.Mail{To: "second@example.com", Subject: "Second", Body: "second body"}), "Failed to send second mail")
+
+	content, err := ioutil.ReadFile(path)
+	require.Nil(t, err, "Failed to read mail log")
+	assert.Contains(t, string(content), "To: first@example.com\nSubject: First\n", "First mail missing in log")
+	assert.Contains(t, string(content), "first body", "First body missing in log")
+	assert.Contains(t, string(content), "To: second@example.com\nSubject: Second\n", "Second mail missing in log")
+}
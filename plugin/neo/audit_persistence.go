@@ -0,0 +1,160 @@
+package neo
+
+import (
+	"errors"
+
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/application/persistence"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+func init() {
+	labelModelMappings = append(labelModelMappings, &labelModelMapping{label: "AuditEvent", model: &models.AuditEvent{}})
+}
+
+type AuditPersistence struct {
+	logger utils.Logger
+}
+
+func CreateAuditPersistence(cfg config.Config) (auditPersistence *AuditPersistence, fcerr *fcerror.Error) {
+	if neo == nil {
+		fcerr = initializeNeo(cfg)
+		if fcerr != nil {
+			return
+		}
+	}
+	auditPersistence = &AuditPersistence{logger: utils.CreateLogger(cfg.GetLoggingConfig())}
+	return
+}
+
+func (*AuditPersistence) Close() *fcerror.Error {
+	if neo != nil {
+		return closeNeo()
+	}
+	return nil
+}
+
+func (p *AuditPersistence) StartReadTransaction() (tx persistence.AuditPersistenceReadTransaction, fcerr *fcerror.Error) {
+	txCtx, fcerr := newTransactionContext(neo4j.AccessModeRead, p.logger)
+	if fcerr != nil {
+		p.logger.WithError(fcerr).Error("Failed to create neo read transaction")
+		return
+	}
+	return &auditReadTransaction{txCtx}, nil
+}
+
+func (p *AuditPersistence) StartReadWriteTransaction() (tx persistence.AuditPersistenceReadWriteTransaction, fcerr *fcerror.Error) {
+	txCtx, fcerr := newTransactionContext(neo4j.AccessModeWrite, p.logger)
+	if fcerr != nil {
+		p.logger.WithError(fcerr).Error("Failed to create neo write transaction")
+		return
+	}
+	return &auditReadWriteTransaction{auditReadTransaction{txCtx}}, nil
+}
+
+type auditReadTransaction struct {
+	*transactionCtx
+}
+
+// GetAuditEvents pages through the events from new to old, events with the same time are ordered by their id
+func (tx *auditReadTransaction) GetAuditEvents(filter *models.AuditFilter, after *models.AuditEventID, limit int) (events []*models.AuditEvent, fcerr *fcerror.Error) {
+	res, err := tx.neoTx.Run(`
+		OPTIONAL MATCH (a:AuditEvent {id: $after})
+		WITH a
+		MATCH (e:AuditEvent)
+		WHERE ($after IS NULL OR (a IS NOT NULL AND (e.time < a.time OR (e.time = a.time AND e.id < a.id))))
+			AND ($action IS NULL OR e.action = $action)
+			AND ($outcome IS NULL OR e.outcome = $outcome)
+			AND ($actor_id IS NULL OR e.actor_id = $actor_id)
+			AND ($target_id IS NULL OR e.target_id = $target_id)
+			AND ($from IS NULL OR e.time >= $from)
+			AND ($until IS NULL OR e.time < $until)
+		RETURN e
+		ORDER BY e.time DESC, e.id DESC
+		LIMIT $limit
+	`, auditFilterToParams(filter, after, limit))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	events = []*models.AuditEvent{}
+	for res.Next() {
+		event := &models.AuditEvent{}
+		fcerr = recordToModel(res.Record(), "e", event)
+		if fcerr != nil {
+			return nil, fcerr
+		}
+		events = append(events, event)
+	}
+	if err = res.Err(); err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBReadFailed)
+		return nil, fcerr
+	}
+	return
+}
+
+// auditFilterToParams converts the filter to query parameters, unset fields are passed as null
+func auditFilterToParams(filter *models.AuditFilter, after *models.AuditEventID, limit int) map[string]interface{} {
+	params := map[string]interface{}{
+		"after":     nil,
+		"action":    nil,
+		"outcome":   nil,
+		"actor_id":  nil,
+		"target_id": nil,
+		"from":      nil,
+		"until":     nil,
+		"limit":     limit,
+	}
+	if after != nil {
+		params["after"] = string(*after)
+	}
+	if filter == nil {
+		return params
+	}
+	if filter.Action != nil {
+		params["action"] = string(*filter.Action)
+	}
+	if filter.Outcome != nil {
+		params["outcome"] = string(*filter.Outcome)
+	}
+	if filter.ActorID != nil {
+		params["actor_id"] = string(*filter.ActorID)
+	}
+	if filter.TargetID != nil {
+		params["target_id"] = *filter.TargetID
+	}
+	if filter.From != nil {
+		params["from"] = *filter.From
+	}
+	if filter.Until != nil {
+		params["until"] = *filter.Until
+	}
+	return params
+}
+
+type auditReadWriteTransaction struct {
+	auditReadTransaction
+}
+
+func (tx *auditReadWriteTransaction) SaveAuditEvent(event *models.AuditEvent) (fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+		CREATE (e:AuditEvent $event)
+		RETURN e.id AS id
+		`,
+		map[string]interface{}{
+			"event": modelToMap(event),
+		}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+		return
+	}
+	if _, ok := record.Get("id"); !ok {
+		fcerr = fcerror.NewError(fcerror.ErrModelConversionFailed, errors.New("id not found in record"))
+	}
+	return
+}
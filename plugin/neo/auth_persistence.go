@@ -14,6 +14,12 @@ import (
 
 func init() {
 	labelModelMappings = append(labelModelMappings, &labelModelMapping{label: "Session", model: &models.Session{}})
+	labelModelMappings = append(labelModelMappings, &labelModelMapping{label: "LoginChallenge", model: &models.LoginChallenge{}})
+	labelModelMappings = append(labelModelMappings, &labelModelMapping{label: "TOTP", model: &models.TOTP{}})
+	labelModelMappings = append(labelModelMappings, &labelModelMapping{label: "AccessToken", model: &models.AccessToken{}})
+	labelModelMappings = append(labelModelMappings, &labelModelMapping{label: "EmailToken", model: &models.EmailToken{}})
+	labelModelMappings = append(labelModelMappings, &labelModelMapping{label: "WebAuthnCredential", model: &models.WebAuthnCredential{}})
+	labelModelMappings = append(labelModelMappings, &labelModelMapping{label: "WebAuthnChallenge", model: &models.WebAuthnChallenge{}})
 }
 
 type AuthPersistence struct {
@@ -60,19 +66,80 @@ type authReadTransaction struct {
 	*transactionCtx
 }
 
-func (tx *authReadTransaction) GetSessionByToken(token models.Token) (session *models.Session, fcerr *fcerror.Error) {
+func (tx *authReadTransaction) GetSessionByToken(tokenHash string) (session *models.Session, fcerr *fcerror.Error) {
 	record, err := neo4j.Single(tx.neoTx.Run(`
-		MATCH (s:Session {token: $token})<-[:AUTHENTICATES_WITH]-(u:User)
+		MATCH (s:Session {token_hash: $token_hash})<-[:AUTHENTICATES_WITH]-(u:User)
 		RETURN s, u.id as user_id
 		`,
 		map[string]interface{}{
-			"token": string(token),
+			"token_hash": tokenHash,
 		}))
 	if err != nil {
 		fcerr = neoToFcError(err, fcerror.ErrTokenNotFound, fcerror.ErrDBReadFailed)
 		return
 	}
 
+	return recordToSession(record)
+}
+
+func (tx *authReadTransaction) GetSessionsOfUser(userID models.UserID) (sessions []*models.Session, fcerr *fcerror.Error) {
+	res, err := tx.neoTx.Run(`
+		MATCH (s:Session)<-[:AUTHENTICATES_WITH]-(u:User {id: $user_id})
+		RETURN s, u.id as user_id
+		ORDER BY s.last_used DESC
+		`,
+		map[string]interface{}{
+			"user_id": userID,
+		})
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUserNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	sessions = []*models.Session{}
+	for res.Next() {
+		session, fcerr := recordToSession(res.Record())
+		if fcerr != nil {
+			return nil, fcerr
+		}
+		sessions = append(sessions, session)
+	}
+	if err = res.Err(); err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUserNotFound, fcerror.ErrDBReadFailed)
+		return nil, fcerr
+	}
+	return
+}
+
+// GetPlainSessionTokens returns the tokens of sessions stored before only token hashes were stored
+func (tx *authReadTransaction) GetPlainSessionTokens() (tokens []models.Token, fcerr *fcerror.Error) {
+	res, err := tx.neoTx.Run(`
+		MATCH (s:Session)
+		WHERE s.token IS NOT NULL
+		RETURN s.token AS token
+		`, nil)
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	tokens = []models.Token{}
+	for res.Next() {
+		tokenInt, _ := res.Record().Get("token")
+		token, ok := tokenInt.(string)
+		if !ok {
+			return nil, fcerror.NewError(fcerror.ErrModelConversionFailed, fmt.Errorf("Failed to convert value to token: %v", tokenInt))
+		}
+		tokens = append(tokens, models.Token(token))
+	}
+	if err = res.Err(); err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBReadFailed)
+		return nil, fcerr
+	}
+	return
+}
+
+func recordToSession(record neo4j.Record) (session *models.Session, fcerr *fcerror.Error) {
 	session = &models.Session{}
 	fcerr = recordToModel(record, "s", session)
 	if fcerr != nil {
@@ -89,6 +156,225 @@ func (tx *authReadTransaction) GetSessionByToken(token models.Token) (session *m
 	return
 }
 
+func (tx *authReadTransaction) GetLoginChallenge(token models.Token) (challenge *models.LoginChallenge, fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (c:LoginChallenge {token: $token})<-[:HAS_LOGIN_CHALLENGE]-(u:User)
+		RETURN c, u.id as user_id
+		`,
+		map[string]interface{}{
+			"token": string(token),
+		}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrLoginChallengeNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	challenge = &models.LoginChallenge{}
+	fcerr = recordToModel(record, "c", challenge)
+	if fcerr != nil {
+		return
+	}
+
+	userIDInt, ok := record.Get("user_id")
+	if !ok {
+		fcerr = fcerror.NewError(fcerror.ErrModelConversionFailed, fmt.Errorf("Failed to convert value to userID: %v", record.GetByIndex(0)))
+		return
+	}
+	challenge.UserID = models.UserID(userIDInt.(string))
+
+	return
+}
+
+func (tx *authReadTransaction) GetTOTP(userID models.UserID) (totp *models.TOTP, fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (:User {id: $user_id})-[:HAS_TOTP]->(t:TOTP)
+		RETURN t
+		`,
+		map[string]interface{}{
+			"user_id": userID,
+		}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrTOTPNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	totp = &models.TOTP{}
+	fcerr = recordToModel(record, "t", totp)
+	totp.UserID = userID
+	return
+}
+
+func (tx *authReadTransaction) GetAccessTokenByHash(tokenHash string) (accessToken *models.AccessToken, fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (t:AccessToken {token_hash: $token_hash})<-[:HAS_ACCESS_TOKEN]-(u:User)
+		RETURN t, u.id as user_id
+		`,
+		map[string]interface{}{
+			"token_hash": tokenHash,
+		}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrAccessTokenNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	return recordToAccessToken(record)
+}
+
+func (tx *authReadTransaction) GetAccessTokensOfUser(userID models.UserID) (accessTokens []*models.AccessToken, fcerr *fcerror.Error) {
+	res, err := tx.neoTx.Run(`
+		MATCH (t:AccessToken)<-[:HAS_ACCESS_TOKEN]-(u:User {id: $user_id})
+		RETURN t, u.id as user_id
+		ORDER BY t.created
+		`,
+		map[string]interface{}{
+			"user_id": userID,
+		})
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUserNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	accessTokens = []*models.AccessToken{}
+	for res.Next() {
+		accessToken, fcerr := recordToAccessToken(res.Record())
+		if fcerr != nil {
+			return nil, fcerr
+		}
+		accessTokens = append(accessTokens, accessToken)
+	}
+	if err = res.Err(); err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUserNotFound, fcerror.ErrDBReadFailed)
+		return nil, fcerr
+	}
+	return
+}
+
+func (tx *authReadTransaction) GetEmailToken(tokenHash string) (emailToken *models.EmailToken, fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (t:EmailToken {token_hash: $token_hash})<-[:HAS_EMAIL_TOKEN]-(u:User)
+		RETURN t, u.id as user_id
+		`,
+		map[string]interface{}{
+			"token_hash": tokenHash,
+		}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrEmailTokenInvalid, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	emailToken = &models.EmailToken{}
+	fcerr = recordToModel(record, "t", emailToken)
+	if fcerr != nil {
+		return
+	}
+
+	userIDInt, ok := record.Get("user_id")
+	if !ok {
+		fcerr = fcerror.NewError(fcerror.ErrModelConversionFailed, fmt.Errorf("Failed to convert value to userID: %v", record.GetByIndex(0)))
+		return
+	}
+	emailToken.UserID = models.UserID(userIDInt.(string))
+
+	return
+}
+
+func (tx *authReadTransaction) GetWebAuthnCredential(credentialID models.WebAuthnCredentialID) (credential *models.WebAuthnCredential, fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (c:WebAuthnCredential {id: $id})<-[:HAS_WEBAUTHN_CREDENTIAL]-(u:User)
+		RETURN c, u.id as user_id
+		`,
+		map[string]interface{}{
+			"id": credentialID,
+		}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrWebAuthnCredentialNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	return recordToWebAuthnCredential(record)
+}
+
+func (tx *authReadTransaction) GetWebAuthnCredentialsOfUser(userID models.UserID) (credentials []*models.WebAuthnCredential, fcerr *fcerror.Error) {
+	res, err := tx.neoTx.Run(`
+		MATCH (c:WebAuthnCredential)<-[:HAS_WEBAUTHN_CREDENTIAL]-(u:User {id: $user_id})
+		RETURN c, u.id as user_id
+		ORDER BY c.created
+		`,
+		map[string]interface{}{
+			"user_id": userID,
+		})
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUserNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	credentials = []*models.WebAuthnCredential{}
+	for res.Next() {
+		credential, fcerr := recordToWebAuthnCredential(res.Record())
+		if fcerr != nil {
+			return nil, fcerr
+		}
+		credentials = append(credentials, credential)
+	}
+	if err = res.Err(); err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUserNotFound, fcerror.ErrDBReadFailed)
+		return nil, fcerr
+	}
+	return
+}
+
+func (tx *authReadTransaction) GetWebAuthnChallenge(challenge string) (webAuthnChallenge *models.WebAuthnChallenge, fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (c:WebAuthnChallenge {challenge: $challenge})
+		RETURN c
+		`,
+		map[string]interface{}{
+			"challenge": challenge,
+		}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrWebAuthnChallengeInvalid, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	webAuthnChallenge = &models.WebAuthnChallenge{}
+	fcerr = recordToModel(record, "c", webAuthnChallenge)
+	return
+}
+
+func recordToWebAuthnCredential(record neo4j.Record) (credential *models.WebAuthnCredential, fcerr *fcerror.Error) {
+	credential = &models.WebAuthnCredential{}
+	fcerr = recordToModel(record, "c", credential)
+	if fcerr != nil {
+		return
+	}
+
+	userIDInt, ok := record.Get("user_id")
+	if !ok {
+		fcerr = fcerror.NewError(fcerror.ErrModelConversionFailed, fmt.Errorf("Failed to convert value to userID: %v", record.GetByIndex(0)))
+		return
+	}
+	credential.UserID = models.UserID(userIDInt.(string))
+
+	return
+}
+
+func recordToAccessToken(record neo4j.Record) (accessToken *models.AccessToken, fcerr *fcerror.Error) {
+	accessToken = &models.AccessToken{}
+	fcerr = recordToModel(record, "t", accessToken)
+	if fcerr != nil {
+		return
+	}
+
+	userIDInt, ok := record.Get("user_id")
+	if !ok {
+		fcerr = fcerror.NewError(fcerror.ErrModelConversionFailed, fmt.Errorf("Failed to convert value to userID: %v", record.GetByIndex(0)))
+		return
+	}
+	accessToken.UserID = models.UserID(userIDInt.(string))
+
+	return
+}
+
 type authReadWriteTransaction struct {
 	authReadTransaction
 }
@@ -109,13 +395,84 @@ func (tx *authReadWriteTransaction) SaveSession(session *models.Session) *fcerro
 	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
 }
 
-func (tx *authReadWriteTransaction) DeleteSessionByToken(token models.Token) *fcerror.Error {
+// UpdateSessionUsage stores when and from which client the session was used last and until when it is valid now
+func (tx *authReadWriteTransaction) UpdateSessionUsage(session *models.Session) *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (s:Session {token_hash: $token_hash})
+		SET s.last_used = $last_used, s.valid_until = $valid_until, s.user_agent = $user_agent, s.client_ip = $client_ip
+		`,
+		map[string]interface{}{
+			"token_hash":  session.TokenHash,
+			"last_used":   session.LastUsed,
+			"valid_until": session.ValidUntil,
+			"user_agent":  session.UserAgent,
+			"client_ip":   session.ClientIP,
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *authReadWriteTransaction) DeleteSessionByToken(tokenHash string) *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (s:Session {token_hash: $token_hash})
+		DETACH DELETE s
+		`,
+		map[string]interface{}{
+			"token_hash": tokenHash,
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+// HashSessionToken replaces the plain token of a session with its hash
+func (tx *authReadWriteTransaction) HashSessionToken(token models.Token, tokenHash string) *fcerror.Error {
 	res, err := tx.neoTx.Run(`
 		MATCH (s:Session {token: $token})
+		SET s.token_hash = $token_hash
+		REMOVE s.token
+		`,
+		map[string]interface{}{
+			"token":      string(token),
+			"token_hash": tokenHash,
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *authReadWriteTransaction) DeleteSessionByID(userID models.UserID, sessionID models.SessionID) *fcerror.Error {
+	_, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (:User {id: $user_id})-[:AUTHENTICATES_WITH]->(s:Session {id: $id})
+		WITH s, s.id AS id
 		DETACH DELETE s
+		RETURN id
 		`,
 		map[string]interface{}{
-			"token": string(token),
+			"user_id": userID,
+			"id":      sessionID,
+		}))
+
+	return neoToFcError(err, fcerror.ErrSessionNotFound, fcerror.ErrDBWriteFailed)
+}
+
+// DeleteSessionsOfUser deletes all sessions of the user except the one with the given token hash, which may be empty
+func (tx *authReadWriteTransaction) DeleteSessionsOfUser(userID models.UserID, keepTokenHash string) *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (:User {id: $user_id})-[:AUTHENTICATES_WITH]->(s:Session)
+		WHERE s.token_hash IS NULL OR s.token_hash <> $keep_token_hash
+		DETACH DELETE s
+		`,
+		map[string]interface{}{
+			"user_id":         userID,
+			"keep_token_hash": keepTokenHash,
 		})
 	if err == nil {
 		_, err = res.Consume()
@@ -139,3 +496,359 @@ func (tx *authReadWriteTransaction) DeleteExpiredSessions() *fcerror.Error {
 
 	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
 }
+
+func (tx *authReadWriteTransaction) SaveLoginChallenge(challenge *models.LoginChallenge) *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (u:User {id: $user_id})
+		CREATE (u)-[:HAS_LOGIN_CHALLENGE]->(:LoginChallenge $c)
+		`,
+		map[string]interface{}{
+			"user_id": challenge.UserID,
+			"c":       modelToMap(challenge),
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *authReadWriteTransaction) UpdateLoginChallengeAttempts(challenge *models.LoginChallenge) *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (c:LoginChallenge {token: $token})
+		SET c.attempts = $attempts
+		`,
+		map[string]interface{}{
+			"token":    string(challenge.Token),
+			"attempts": challenge.Attempts,
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *authReadWriteTransaction) DeleteLoginChallenge(token models.Token) *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (c:LoginChallenge {token: $token})
+		DETACH DELETE c
+		`,
+		map[string]interface{}{
+			"token": string(token),
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *authReadWriteTransaction) DeleteExpiredLoginChallenges() *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (c:LoginChallenge)
+		WHERE c.valid_until < $now
+		DETACH DELETE c
+		`,
+		map[string]interface{}{
+			"now": utils.GetCurrentTime(),
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+// SaveTOTP creates or replaces the TOTP configuration of the user
+func (tx *authReadWriteTransaction) SaveTOTP(totp *models.TOTP) *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (u:User {id: $user_id})
+		MERGE (u)-[:HAS_TOTP]->(t:TOTP)
+		SET t = $totp
+		`,
+		map[string]interface{}{
+			"user_id": totp.UserID,
+			"totp":    modelToMap(totp),
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+// DeleteTOTP removes the TOTP configuration of the user together with all recovery codes
+func (tx *authReadWriteTransaction) DeleteTOTP(userID models.UserID) *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (u:User {id: $user_id})
+		OPTIONAL MATCH (u)-[:HAS_TOTP]->(t:TOTP)
+		OPTIONAL MATCH (u)-[:HAS_RECOVERY_CODE]->(c:RecoveryCode)
+		DETACH DELETE t, c
+		`,
+		map[string]interface{}{
+			"user_id": userID,
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+// SaveRecoveryCodes replaces all recovery codes of the user with the given hashes
+func (tx *authReadWriteTransaction) SaveRecoveryCodes(userID models.UserID, codeHashes []string) *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (:User {id: $user_id})-[:HAS_RECOVERY_CODE]->(c:RecoveryCode)
+		DETACH DELETE c
+		`,
+		map[string]interface{}{
+			"user_id": userID,
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+	if err != nil {
+		return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+	}
+
+	res, err = tx.neoTx.Run(`
+		MATCH (u:User {id: $user_id})
+		UNWIND $hashes AS hash
+		CREATE (u)-[:HAS_RECOVERY_CODE]->(:RecoveryCode {hash: hash})
+		`,
+		map[string]interface{}{
+			"user_id": userID,
+			"hashes":  codeHashes,
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+// UseRecoveryCode deletes the recovery code with the given hash and returns whether it existed
+func (tx *authReadWriteTransaction) UseRecoveryCode(userID models.UserID, codeHash string) (used bool, fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+		OPTIONAL MATCH (:User {id: $user_id})-[:HAS_RECOVERY_CODE]->(c:RecoveryCode {hash: $hash})
+		WITH c, c IS NOT NULL AS used LIMIT 1
+		DETACH DELETE c
+		RETURN used
+		`,
+		map[string]interface{}{
+			"user_id": userID,
+			"hash":    codeHash,
+		}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+		return
+	}
+
+	usedInt, _ := record.Get("used")
+	used, _ = usedInt.(bool)
+	return
+}
+
+func (tx *authReadWriteTransaction) SaveAccessToken(accessToken *models.AccessToken) *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (u:User {id: $user_id})
+		CREATE (u)-[:HAS_ACCESS_TOKEN]->(:AccessToken $t)
+		`,
+		map[string]interface{}{
+			"user_id": accessToken.UserID,
+			"t":       modelToMap(accessToken),
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *authReadWriteTransaction) UpdateAccessTokenLastUsed(accessToken *models.AccessToken) *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (t:AccessToken {id: $id})
+		SET t.last_used = $last_used
+		`,
+		map[string]interface{}{
+			"id":        accessToken.ID,
+			"last_used": accessToken.LastUsed,
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *authReadWriteTransaction) DeleteAccessToken(userID models.UserID, accessTokenID models.AccessTokenID) *fcerror.Error {
+	_, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (:User {id: $user_id})-[:HAS_ACCESS_TOKEN]->(t:AccessToken {id: $id})
+		WITH t, t.id AS id
+		DETACH DELETE t
+		RETURN id
+		`,
+		map[string]interface{}{
+			"user_id": userID,
+			"id":      accessTokenID,
+		}))
+
+	return neoToFcError(err, fcerror.ErrAccessTokenNotFound, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *authReadWriteTransaction) SaveEmailToken(emailToken *models.EmailToken) *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (u:User {id: $user_id})
+		CREATE (u)-[:HAS_EMAIL_TOKEN]->(:EmailToken $t)
+		`,
+		map[string]interface{}{
+			"user_id": emailToken.UserID,
+			"t":       modelToMap(emailToken),
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *authReadWriteTransaction) DeleteEmailToken(tokenHash string) *fcerror.Error {
+	_, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (t:EmailToken {token_hash: $token_hash})
+		WITH t, t.token_hash AS token_hash
+		DETACH DELETE t
+		RETURN token_hash
+		`,
+		map[string]interface{}{
+			"token_hash": tokenHash,
+		}))
+
+	return neoToFcError(err, fcerror.ErrEmailTokenInvalid, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *authReadWriteTransaction) DeleteEmailTokensOfUser(userID models.UserID, purpose models.EmailTokenPurpose) *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (:User {id: $user_id})-[:HAS_EMAIL_TOKEN]->(t:EmailToken {purpose: $purpose})
+		DETACH DELETE t
+		`,
+		map[string]interface{}{
+			"user_id": userID,
+			"purpose": string(purpose),
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *authReadWriteTransaction) DeleteExpiredEmailTokens() *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (t:EmailToken)
+		WHERE t.valid_until < $now
+		DETACH DELETE t
+		`,
+		map[string]interface{}{
+			"now": utils.GetCurrentTime(),
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *authReadWriteTransaction) SaveWebAuthnCredential(credential *models.WebAuthnCredential) *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (u:User {id: $user_id})
+		CREATE (u)-[:HAS_WEBAUTHN_CREDENTIAL]->(:WebAuthnCredential $c)
+		`,
+		map[string]interface{}{
+			"user_id": credential.UserID,
+			"c":       modelToMap(credential),
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+// UpdateWebAuthnCredentialUsage stores the sign count and time of the last assertion
+func (tx *authReadWriteTransaction) UpdateWebAuthnCredentialUsage(credential *models.WebAuthnCredential) *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (c:WebAuthnCredential {id: $id})
+		SET c.sign_count = $sign_count, c.last_used = $last_used
+		`,
+		map[string]interface{}{
+			"id":         credential.ID,
+			"sign_count": credential.SignCount,
+			"last_used":  credential.LastUsed,
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *authReadWriteTransaction) DeleteWebAuthnCredential(userID models.UserID, credentialID models.WebAuthnCredentialID) *fcerror.Error {
+	_, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (:User {id: $user_id})-[:HAS_WEBAUTHN_CREDENTIAL]->(c:WebAuthnCredential {id: $id})
+		WITH c, c.id AS id
+		DETACH DELETE c
+		RETURN id
+		`,
+		map[string]interface{}{
+			"user_id": userID,
+			"id":      credentialID,
+		}))
+
+	return neoToFcError(err, fcerror.ErrWebAuthnCredentialNotFound, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *authReadWriteTransaction) SaveWebAuthnChallenge(challenge *models.WebAuthnChallenge) *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		CREATE (:WebAuthnChallenge $c)
+		`,
+		map[string]interface{}{
+			"c": modelToMap(challenge),
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *authReadWriteTransaction) DeleteWebAuthnChallenge(challenge string) *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (c:WebAuthnChallenge {challenge: $challenge})
+		DETACH DELETE c
+		`,
+		map[string]interface{}{
+			"challenge": challenge,
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *authReadWriteTransaction) DeleteExpiredWebAuthnChallenges() *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH (c:WebAuthnChallenge)
+		WHERE c.valid_until < $now
+		DETACH DELETE c
+		`,
+		map[string]interface{}{
+			"now": utils.GetCurrentTime(),
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
@@ -0,0 +1,172 @@
+package neo
+
+import (
+	"errors"
+
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/application/persistence"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/utils"
+	"github.com/google/uuid"
+
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+func init() {
+	labelModelMappings = append(labelModelMappings, &labelModelMapping{label: "FileDrop", model: &models.FileDrop{}})
+}
+
+type FileDropPersistence struct {
+	logger utils.Logger
+}
+
+func CreateFileDropPersistence(cfg config.Config) (fileDropPersistence *FileDropPersistence, fcerr *fcerror.Error) {
+	if neo == nil {
+		fcerr = initializeNeo(cfg)
+		if fcerr != nil {
+			return
+		}
+	}
+	fileDropPersistence = &FileDropPersistence{logger: utils.CreateLogger(cfg.GetLoggingConfig())}
+	return
+}
+
+func (*FileDropPersistence) Close() *fcerror.Error {
+	if neo != nil {
+		return closeNeo()
+	}
+	return nil
+}
+
+func (p *FileDropPersistence) StartReadTransaction() (tx persistence.FileDropPersistenceReadTransaction, fcerr *fcerror.Error) {
+	txCtx, fcerr := newTransactionContext(neo4j.AccessModeRead, p.logger)
+	if fcerr != nil {
+		p.logger.WithError(fcerr).Error("Failed to create neo read transaction")
+		return
+	}
+	return &fileDropReadTransaction{txCtx}, nil
+}
+
+func (p *FileDropPersistence) StartReadWriteTransaction() (tx persistence.FileDropPersistenceReadWriteTransaction, fcerr *fcerror.Error) {
+	txCtx, fcerr := newTransactionContext(neo4j.AccessModeWrite, p.logger)
+	if fcerr != nil {
+		p.logger.WithError(fcerr).Error("Failed to create neo write transaction")
+		return
+	}
+	return &fileDropReadWriteTransaction{fileDropReadTransaction{txCtx}}, nil
+}
+
+type fileDropReadTransaction struct {
+	*transactionCtx
+}
+
+func (tx *fileDropReadTransaction) GetFileDropByID(fileDropID models.FileDropID) (fileDrop *models.FileDrop, fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (u:User)-[:CREATED_FILE_DROP]->(d:FileDrop {id: $id})-[:DROPS_INTO]->(f:Node:Folder)
+		RETURN d, u.id AS owner_id, f.id AS node_id
+	`, map[string]interface{}{"id": fileDropID}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrFileDropNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	return recordToFileDrop(record)
+}
+
+func (tx *fileDropReadTransaction) GetFileDropsOfUser(userID models.UserID) (fileDrops []*models.FileDrop, fcerr *fcerror.Error) {
+	res, err := tx.neoTx.Run(`
+		MATCH (u:User {id: $user_id})-[:CREATED_FILE_DROP]->(d:FileDrop)-[:DROPS_INTO]->(f:Node:Folder)
+		RETURN d, u.id AS owner_id, f.id AS node_id
+		ORDER BY d.created
+	`, map[string]interface{}{"user_id": userID})
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrFileDropNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	fileDrops = []*models.FileDrop{}
+	for res.Next() {
+		fileDrop, fcerr := recordToFileDrop(res.Record())
+		if fcerr != nil {
+			return nil, fcerr
+		}
+		fileDrops = append(fileDrops, fileDrop)
+	}
+	if err = res.Err(); err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrFileDropNotFound, fcerror.ErrDBReadFailed)
+		return nil, fcerr
+	}
+	return
+}
+
+func recordToFileDrop(record neo4j.Record) (fileDrop *models.FileDrop, fcerr *fcerror.Error) {
+	fileDrop = &models.FileDrop{}
+	fcerr = recordToModel(record, "d", fileDrop)
+	if fcerr != nil {
+		return
+	}
+
+	ownerIDInt, ok := record.Get("owner_id")
+	if !ok {
+		fcerr = fcerror.NewError(fcerror.ErrModelConversionFailed, errors.New("owner_id not found in record"))
+		return
+	}
+	nodeIDInt, ok := record.Get("node_id")
+	if !ok {
+		fcerr = fcerror.NewError(fcerror.ErrModelConversionFailed, errors.New("node_id not found in record"))
+		return
+	}
+	fileDrop.OwnerID = models.UserID(ownerIDInt.(string))
+	fileDrop.NodeID = models.NodeID(nodeIDInt.(string))
+	return
+}
+
+type fileDropReadWriteTransaction struct {
+	fileDropReadTransaction
+}
+
+func (tx *fileDropReadWriteTransaction) SaveFileDrop(fileDrop *models.FileDrop) (fcerr *fcerror.Error) {
+	fileDrop.Created = utils.GetCurrentTime()
+	fileDrop.ID = models.FileDropID(uuid.NewString())
+
+	record, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (u:User {id: $owner_id})-[:HAS_ROOT_FOLDER|CONTAINS*]->(f:Node:Folder {id: $node_id})
+		CREATE (u)-[:CREATED_FILE_DROP]->(d:FileDrop $file_drop)-[:DROPS_INTO]->(f)
+		RETURN d.id AS id
+		`,
+		map[string]interface{}{
+			"owner_id":  fileDrop.OwnerID,
+			"node_id":   fileDrop.NodeID,
+			"file_drop": modelToMap(fileDrop),
+		}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrNodeNotFound, fcerror.ErrDBWriteFailed)
+		return
+	}
+	if _, ok := record.Get("id"); !ok {
+		fcerr = fcerror.NewError(fcerror.ErrModelConversionFailed, errors.New("id not found in record"))
+	}
+	return
+}
+
+func (tx *fileDropReadWriteTransaction) DeleteFileDrop(userID models.UserID, fileDropID models.FileDropID) (fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (:User {id: $user_id})-[:CREATED_FILE_DROP]->(d:FileDrop {id: $id})
+		WITH d, d.id AS id
+		DETACH DELETE d
+		RETURN id
+		`,
+		map[string]interface{}{
+			"user_id": userID,
+			"id":      fileDropID,
+		}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrFileDropNotFound, fcerror.ErrDBWriteFailed)
+		return
+	}
+	if _, ok := record.Get("id"); !ok {
+		fcerr = fcerror.NewError(fcerror.ErrModelConversionFailed, errors.New("id not found in record"))
+	}
+	return
+}
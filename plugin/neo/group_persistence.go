@@ -0,0 +1,274 @@
+package neo
+
+import (
+	"errors"
+
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/application/persistence"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/utils"
+	"github.com/google/uuid"
+
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+func init() {
+	labelModelMappings = append(labelModelMappings, &labelModelMapping{label: "Group", model: &models.Group{}})
+}
+
+type GroupPersistence struct {
+	logger utils.Logger
+}
+
+func CreateGroupPersistence(cfg config.Config) (groupPersistence *GroupPersistence, fcerr *fcerror.Error) {
+	if neo == nil {
+		fcerr = initializeNeo(cfg)
+		if fcerr != nil {
+			return
+		}
+	}
+	groupPersistence = &GroupPersistence{logger: utils.CreateLogger(cfg.GetLoggingConfig())}
+	return
+}
+
+func (*GroupPersistence) Close() *fcerror.Error {
+	if neo != nil {
+		return closeNeo()
+	}
+	return nil
+}
+
+func (p *GroupPersistence) StartReadTransaction() (tx persistence.GroupPersistenceReadTransaction, fcerr *fcerror.Error) {
+	txCtx, fcerr := newTransactionContext(neo4j.AccessModeRead, p.logger)
+	if fcerr != nil {
+		p.logger.WithError(fcerr).Error("Failed to create neo read transaction")
+		return
+	}
+	return &groupReadTransaction{txCtx}, nil
+}
+
+func (p *GroupPersistence) StartReadWriteTransaction() (tx persistence.GroupPersistenceReadWriteTransaction, fcerr *fcerror.Error) {
+	txCtx, fcerr := newTransactionContext(neo4j.AccessModeWrite, p.logger)
+	if fcerr != nil {
+		p.logger.WithError(fcerr).Error("Failed to create neo write transaction")
+		return
+	}
+	return &groupReadWriteTransaction{groupReadTransaction{txCtx}}, nil
+}
+
+type groupReadTransaction struct {
+	*transactionCtx
+}
+
+func (tx *groupReadTransaction) GetGroupByID(groupID models.GroupID) (group *models.Group, fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (g:Group {id: $id})
+		RETURN g
+	`, map[string]interface{}{"id": groupID}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrGroupNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	group = &models.Group{}
+	fcerr = recordToModel(record, "g", group)
+	return
+}
+
+func (tx *groupReadTransaction) GetGroupsOfUser(userID models.UserID) (groups []*models.Group, fcerr *fcerror.Error) {
+	res, err := tx.neoTx.Run(`
+		MATCH (:User {id: $user_id})-[:MEMBER_OF]->(g:Group)
+		RETURN g
+		ORDER BY g.name
+	`, map[string]interface{}{"user_id": userID})
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrGroupNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	groups = []*models.Group{}
+	for res.Next() {
+		group := &models.Group{}
+		fcerr = recordToModel(res.Record(), "g", group)
+		if fcerr != nil {
+			return nil, fcerr
+		}
+		groups = append(groups, group)
+	}
+	if err = res.Err(); err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrGroupNotFound, fcerror.ErrDBReadFailed)
+		return nil, fcerr
+	}
+	return
+}
+
+func (tx *groupReadTransaction) GetGroupMember(groupID models.GroupID, userID models.UserID) (member *models.GroupMember, fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (u:User {id: $user_id})-[m:MEMBER_OF]->(g:Group {id: $group_id})
+		RETURN u.id AS user_id, g.id AS group_id, m.is_admin AS is_admin
+	`, map[string]interface{}{
+		"group_id": groupID,
+		"user_id":  userID,
+	}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrGroupMemberNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	return recordToGroupMember(record)
+}
+
+func (tx *groupReadTransaction) GetGroupMembers(groupID models.GroupID) (members []*models.GroupMember, fcerr *fcerror.Error) {
+	res, err := tx.neoTx.Run(`
+		MATCH (u:User)-[m:MEMBER_OF]->(g:Group {id: $group_id})
+		RETURN u.id AS user_id, g.id AS group_id, m.is_admin AS is_admin
+	`, map[string]interface{}{"group_id": groupID})
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrGroupNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	members = []*models.GroupMember{}
+	for res.Next() {
+		member, fcerr := recordToGroupMember(res.Record())
+		if fcerr != nil {
+			return nil, fcerr
+		}
+		members = append(members, member)
+	}
+	if err = res.Err(); err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrGroupNotFound, fcerror.ErrDBReadFailed)
+		return nil, fcerr
+	}
+	return
+}
+
+func recordToGroupMember(record neo4j.Record) (member *models.GroupMember, fcerr *fcerror.Error) {
+	userIDInt, ok := record.Get("user_id")
+	if !ok {
+		fcerr = fcerror.NewError(fcerror.ErrModelConversionFailed, errors.New("user_id not found in record"))
+		return
+	}
+	groupIDInt, ok := record.Get("group_id")
+	if !ok {
+		fcerr = fcerror.NewError(fcerror.ErrModelConversionFailed, errors.New("group_id not found in record"))
+		return
+	}
+	isAdminInt, _ := record.Get("is_admin")
+	isAdmin, _ := isAdminInt.(bool)
+
+	member = &models.GroupMember{
+		UserID:  models.UserID(userIDInt.(string)),
+		GroupID: models.GroupID(groupIDInt.(string)),
+		IsAdmin: isAdmin,
+	}
+	return
+}
+
+type groupReadWriteTransaction struct {
+	groupReadTransaction
+}
+
+func (tx *groupReadWriteTransaction) SaveGroup(group *models.Group) (fcerr *fcerror.Error) {
+	currTime := utils.GetCurrentTime()
+	group.Created = currTime
+	group.Updated = currTime
+	group.ID = models.GroupID(uuid.NewString())
+
+	result, err := tx.neoTx.Run(`
+		CREATE (g:Group $group)
+		`,
+		map[string]interface{}{
+			"group": modelToMap(group),
+		})
+	if err == nil {
+		_, err = result.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+// SaveGroupMember creates or updates the membership and mounts all nodes shared with the group into the root folder of the member
+func (tx *groupReadWriteTransaction) SaveGroupMember(member *models.GroupMember) (fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (u:User {id: $user_id}), (g:Group {id: $group_id})
+		MERGE (u)-[m:MEMBER_OF]->(g)
+		SET m += $member
+		RETURN u.id AS user_id
+		`,
+		map[string]interface{}{
+			"user_id":  member.UserID,
+			"group_id": member.GroupID,
+			"member":   modelToMap(member),
+		}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrGroupNotFound, fcerror.ErrDBWriteFailed)
+		return
+	}
+	if _, ok := record.Get("user_id"); !ok {
+		fcerr = fcerror.NewError(fcerror.ErrModelConversionFailed, errors.New("user_id not found in record"))
+		return
+	}
+
+	res, err := tx.neoTx.Run(`
+		MATCH (u:User {id: $user_id}), (:Group {id: $group_id})-[s:SHARES]->(n:Node)
+		WHERE NOT (u)-[:HAS_ROOT_FOLDER|CONTAINS*]->(n)
+		RETURN n.id AS node_id, s.name AS name, s.share_mode AS share_mode, s.expires_at AS expires_at
+		`,
+		map[string]interface{}{
+			"user_id":  member.UserID,
+			"group_id": member.GroupID,
+		})
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	groupShares := []neo4j.Record{}
+	for res.Next() {
+		groupShares = append(groupShares, res.Record())
+	}
+	if err = res.Err(); err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	for _, record := range groupShares {
+		nodeIDInt, _ := record.Get("node_id")
+		nameInt, _ := record.Get("name")
+		shareModeInt, _ := record.Get("share_mode")
+		expiresAtInt, _ := record.Get("expires_at")
+		props := map[string]interface{}{
+			"group_id":   string(member.GroupID),
+			"share_mode": shareModeInt,
+			"expires_at": expiresAtInt,
+		}
+
+		_, _, fcerr = tx.mountShare(member.UserID, models.NodeID(nodeIDInt.(string)), member.GroupID, nameInt.(string), props)
+		if fcerr != nil {
+			return
+		}
+	}
+	return
+}
+
+// DeleteGroupMember removes the membership together with all mounted group shares of the member
+func (tx *groupReadWriteTransaction) DeleteGroupMember(groupID models.GroupID, userID models.UserID) (fcerr *fcerror.Error) {
+	res, err := tx.neoTx.Run(`
+		MATCH (u:User {id: $user_id})-[m:MEMBER_OF]->(:Group {id: $group_id})
+		DELETE m
+		WITH u
+		MATCH (u)-[:HAS_ROOT_FOLDER|CONTAINS*]->(:Node:Folder)-[r:CONTAINS_SHARED {group_id: $group_id}]->(:Node)
+		DELETE r
+		`,
+		map[string]interface{}{
+			"user_id":  userID,
+			"group_id": groupID,
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
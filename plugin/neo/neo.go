@@ -54,6 +54,12 @@ func initializeNeo(cfg config.Config) (fcerr *fcerror.Error) {
 		return
 	}
 
+	fcerr = migrateUserEmailsToLowerCase(logger)
+	if fcerr != nil {
+		logger.WithError(fcerr).Error("Failed to migrate user emails to lower case")
+		return
+	}
+
 	return
 }
 
@@ -262,6 +268,15 @@ func modelToMap(model interface{}) map[string]interface{} {
 		if dbName == nil {
 			continue
 		}
+		// The driver only stores lists of basic types
+		if roles, ok := valField.Interface().([]models.Role); ok {
+			roleStrings := make([]string, len(roles))
+			for it, role := range roles {
+				roleStrings[it] = string(role)
+			}
+			modelMap[*dbName] = roleStrings
+			continue
+		}
 		modelMap[*dbName] = valField.Interface()
 	}
 
@@ -302,14 +317,53 @@ func recordToModel(record neo4j.Record, key string, model interface{}) *fcerror.
 			propVal = reflect.ValueOf(models.UserID(propInt.(string)))
 		case reflect.TypeOf((models.NodeID)("")):
 			propVal = reflect.ValueOf(models.NodeID(propInt.(string)))
+		case reflect.TypeOf((models.GroupID)("")):
+			propVal = reflect.ValueOf(models.GroupID(propInt.(string)))
+		case reflect.TypeOf((models.FileDropID)("")):
+			propVal = reflect.ValueOf(models.FileDropID(propInt.(string)))
+		case reflect.TypeOf((models.AccessTokenID)("")):
+			propVal = reflect.ValueOf(models.AccessTokenID(propInt.(string)))
+		case reflect.TypeOf((models.SessionID)("")):
+			propVal = reflect.ValueOf(models.SessionID(propInt.(string)))
 		case reflect.TypeOf((models.Token)("")):
 			propVal = reflect.ValueOf(models.Token(propInt.(string)))
+		case reflect.TypeOf((models.EmailTokenPurpose)("")):
+			propVal = reflect.ValueOf(models.EmailTokenPurpose(propInt.(string)))
+		case reflect.TypeOf((models.InviteID)("")):
+			propVal = reflect.ValueOf(models.InviteID(propInt.(string)))
+		case reflect.TypeOf((models.WebAuthnCredentialID)("")):
+			propVal = reflect.ValueOf(models.WebAuthnCredentialID(propInt.(string)))
+		case reflect.TypeOf((models.WebAuthnChallengePurpose)("")):
+			propVal = reflect.ValueOf(models.WebAuthnChallengePurpose(propInt.(string)))
+		case reflect.TypeOf((models.AuditEventID)("")):
+			propVal = reflect.ValueOf(models.AuditEventID(propInt.(string)))
+		case reflect.TypeOf((models.AuditAction)("")):
+			propVal = reflect.ValueOf(models.AuditAction(propInt.(string)))
+		case reflect.TypeOf((models.AuditOutcome)("")):
+			propVal = reflect.ValueOf(models.AuditOutcome(propInt.(string)))
+		case reflect.TypeOf((models.AuditTargetType)("")):
+			propVal = reflect.ValueOf(models.AuditTargetType(propInt.(string)))
 		case reflect.TypeOf((models.NodeMimeType)("")):
 			propVal = reflect.ValueOf(models.NodeMimeType(propInt.(string)))
 		case reflect.TypeOf((models.NodeType)(0)):
 			propVal = reflect.ValueOf(models.NodeType(propInt.(string)))
 		case reflect.TypeOf((models.ShareMode)(0)):
 			propVal = reflect.ValueOf(models.ShareMode(propInt.(string)))
+		case reflect.TypeOf(([]models.Role)(nil)):
+			propList, _ := propInt.([]interface{})
+			roles := make([]models.Role, 0, len(propList))
+			for _, roleInt := range propList {
+				if role, ok := roleInt.(string); ok {
+					roles = append(roles, models.Role(role))
+				}
+			}
+			propVal = reflect.ValueOf(roles)
+		case reflect.TypeOf((*time.Time)(nil)):
+			propTime, ok := propInt.(time.Time)
+			if !ok {
+				continue
+			}
+			propVal = reflect.ValueOf(&propTime)
 		default:
 			propVal = reflect.ValueOf(propInt)
 		}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/freecloudio/server/domain/models"
 	"github.com/freecloudio/server/domain/models/fcerror"
@@ -15,6 +16,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/neo4j/neo4j-go-driver/neo4j"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func createTrCtxMock(mockCtrl *gomock.Controller) (trCtx *transactionCtx, sessionMock *mock.MockSession, txMock *mock.MockTransaction) {
@@ -405,6 +407,48 @@ func TestRecordToModel(t *testing.T) {
 	assert.Equal(t, expectedModel, actualModel, "Model from record does not match expected model")
 }
 
+func TestRecordToModelTimePointer(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	expiresAt := utils.GetCurrentTime()
+	inputMap := map[string]interface{}{
+		"expires_at": expiresAt,
+	}
+	inputNode := mock.NewMockNode(mockCtrl)
+	inputNode.EXPECT().Props().Return(inputMap).Times(1)
+
+	inputRecord := mock.NewMockRecord(mockCtrl)
+	inputRecord.EXPECT().Get("key").Return(inputNode, true).Times(1)
+
+	actualModel := &models.Share{}
+	fcerr := recordToModel(inputRecord, "key", actualModel)
+	assert.Nil(t, fcerr, "Could not get model from record")
+	require.NotNil(t, actualModel.ExpiresAt, "Time pointer was not set from record")
+	assert.Equal(t, expiresAt, *actualModel.ExpiresAt, "Time pointer from record does not match")
+}
+
+func TestRecordToModelFileDrop(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	inputMap := map[string]interface{}{
+		"id":          "drop",
+		"name_prefix": "in_",
+		"max_size":    int64(42),
+	}
+	inputNode := mock.NewMockNode(mockCtrl)
+	inputNode.EXPECT().Props().Return(inputMap).Times(1)
+
+	inputRecord := mock.NewMockRecord(mockCtrl)
+	inputRecord.EXPECT().Get("key").Return(inputNode, true).Times(1)
+
+	actualModel := &models.FileDrop{}
+	fcerr := recordToModel(inputRecord, "key", actualModel)
+	assert.Nil(t, fcerr, "Could not get model from record")
+	assert.Equal(t, &models.FileDrop{ID: "drop", NamePrefix: "in_", MaxSize: 42}, actualModel, "Model from record does not match expected model")
+}
+
 func TestRecordToModelWrongKey(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -509,3 +553,352 @@ func TestNeoToFcError(t *testing.T) {
 		}
 	}
 }
+
+func TestAccessRanks(t *testing.T) {
+	tests := []struct {
+		name              string
+		shareMode         models.ShareMode
+		expectedRank      int
+		expectedShareMode models.ShareMode
+	}{
+		{name: "Owner", shareMode: models.ShareModeNone, expectedRank: accessRankOwner, expectedShareMode: models.ShareModeReadWrite},
+		{name: "Read & Write", shareMode: models.ShareModeReadWrite, expectedRank: accessRankReadWrite, expectedShareMode: models.ShareModeReadWrite},
+		{name: "Read", shareMode: models.ShareModeRead, expectedRank: accessRankRead, expectedShareMode: models.ShareModeRead},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			rank := getMinAccessRank(test.shareMode)
+			assert.Equal(t, test.expectedRank, rank, "Wrong minimum access rank for share mode")
+			assert.Equal(t, test.expectedShareMode, accessRankToShareMode(int64(rank)), "Wrong effective share mode for access rank")
+		})
+	}
+}
+
+func TestRecordToModelEmailToken(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	inputMap := map[string]interface{}{
+		"token_hash": "hash",
+		"purpose":    "password_reset",
+		"email":      "user@example.com",
+	}
+	inputNode := mock.NewMockNode(mockCtrl)
+	inputNode.EXPECT().Props().Return(inputMap).Times(1)
+
+	inputRecord := mock.NewMockRecord(mockCtrl)
+	inputRecord.EXPECT().Get("key").Return(inputNode, true).Times(1)
+
+	actualModel := &models.EmailToken{}
+	fcerr := recordToModel(inputRecord, "key", actualModel)
+	assert.Nil(t, fcerr, "Could not get model from record")
+	assert.Equal(t, &models.EmailToken{TokenHash: "hash", Purpose: models.EmailTokenPurposePasswordReset, Email: "user@example.com"}, actualModel, "Model from record does not match expected model")
+}
+
+func TestModelToMapSessionWithoutPlainToken(t *testing.T) {
+	session := &models.Session{ID: "session", Token: "token", TokenHash: "hash", UserID: "user"}
+
+	actualMap := modelToMap(session)
+	assert.Equal(t, "hash", actualMap["token_hash"], "Expect token hash to be stored")
+	assert.NotContains(t, actualMap, "token", "Expect plain token not to be stored")
+	assert.NotContains(t, actualMap, "user_id", "Expect user id not to be stored on session")
+}
+
+func TestUserRolesConversion(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	user := &models.User{ID: "user", AssignedRoles: []models.Role{models.RoleAuditor, models.RoleStorageAdmin}}
+	actualMap := modelToMap(user)
+	assert.Equal(t, []string{"AUDITOR", "STORAGE_ADMIN"}, actualMap["roles"], "Expect roles to be stored as strings")
+
+	inputNode := mock.NewMockNode(mockCtrl)
+	inputNode.EXPECT().Props().Return(map[string]interface{}{"id": "user", "roles": []interface{}{"AUDITOR", "STORAGE_ADMIN"}}).Times(1)
+	inputRecord := mock.NewMockRecord(mockCtrl)
+	inputRecord.EXPECT().Get("key").Return(inputNode, true).Times(1)
+
+	actualUser := &models.User{}
+	fcerr := recordToModel(inputRecord, "key", actualUser)
+	assert.Nil(t, fcerr, "Could not get model from record")
+	assert.Equal(t, user.AssignedRoles, actualUser.AssignedRoles, "Roles from record do not match stored roles")
+}
+
+func TestRecordToModelAuditEvent(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	eventTime := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	inputMap := map[string]interface{}{
+		"id":          "event",
+		"time":        eventTime,
+		"action":      "LOGIN",
+		"outcome":     "FAILURE",
+		"actor_email": "user@example.com",
+		"client_ip":   "127.0.0.1",
+		"target_type": "USER",
+		"target_id":   "user",
+	}
+	inputNode := mock.NewMockNode(mockCtrl)
+	inputNode.EXPECT().Props().Return(inputMap).Times(1)
+
+	inputRecord := mock.NewMockRecord(mockCtrl)
+	inputRecord.EXPECT().Get("key").Return(inputNode, true).Times(1)
+
+	actualModel := &models.AuditEvent{}
+	fcerr := recordToModel(inputRecord, "key", actualModel)
+	assert.Nil(t, fcerr, "Could not get model from record")
+	assert.Equal(t, &models.AuditEvent{
+		ID:         "event",
+		Time:       eventTime,
+		Action:     models.AuditActionLogin,
+		Outcome:    models.AuditOutcomeFailure,
+		ActorEmail: "user@example.com",
+		ClientIP:   "127.0.0.1",
+		TargetType: models.AuditTargetTypeUser,
+		TargetID:   "user",
+	}, actualModel, "Model from record does not match expected model")
+}
+
+func TestRecordToModelWebAuthnChallenge(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	validUntil := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	inputMap := map[string]interface{}{
+		"challenge":   "challenge",
+		"user_id":     "user",
+		"purpose":     "second_factor",
+		"valid_until": validUntil,
+	}
+	inputNode := mock.NewMockNode(mockCtrl)
+	inputNode.EXPECT().Props().Return(inputMap).Times(1)
+
+	inputRecord := mock.NewMockRecord(mockCtrl)
+	inputRecord.EXPECT().Get("key").Return(inputNode, true).Times(1)
+
+	actualModel := &models.WebAuthnChallenge{}
+	fcerr := recordToModel(inputRecord, "key", actualModel)
+	assert.Nil(t, fcerr, "Could not get model from record")
+	assert.Equal(t, &models.WebAuthnChallenge{
+		Challenge:  "challenge",
+		UserID:     "user",
+		Purpose:    models.WebAuthnChallengePurposeSecondFactor,
+		ValidUntil: validUntil,
+	}, actualModel, "Model from record does not match expected model")
+}
+
+func TestAuditFilterToParams(t *testing.T) {
+	action := models.AuditActionDownload
+	actorID := models.UserID("user")
+	after := models.AuditEventID("event")
+
+	params := auditFilterToParams(&models.AuditFilter{Action: &action, ActorID: &actorID}, &after, 10)
+	assert.Equal(t, "DOWNLOAD", params["action"], "Wrong action param")
+	assert.Equal(t, "user", params["actor_id"], "Wrong actor param")
+	assert.Equal(t, "event", params["after"], "Wrong after param")
+	assert.Equal(t, 10, params["limit"], "Wrong limit param")
+	assert.Nil(t, params["outcome"], "Expect unset filter fields to be null")
+	assert.Contains(t, params, "from", "Expect unset filter fields to be passed")
+
+	params = auditFilterToParams(nil, nil, 5)
+	assert.Nil(t, params["after"], "Expect missing cursor to be null")
+	assert.Nil(t, params["action"], "Expect missing filter to be null")
+}
+
+func TestUserFilterToParams(t *testing.T) {
+	search := "Doe"
+	role := models.RoleAuditor
+	disabled := false
+	after := models.UserID("user")
+
+	params := userFilterToParams(&models.UserFilter{Search: &search, Role: &role, Disabled: &disabled}, &after, 10)
+	assert.Equal(t, "doe", params["search"], "Expect search to be lower case")
+	assert.Equal(t, "AUDITOR", params["role"], "Wrong role param")
+	assert.Equal(t, false, params["disabled"], "Wrong disabled param")
+	assert.Equal(t, "user", params["after"], "Wrong after param")
+	assert.Equal(t, 10, params["limit"], "Wrong limit param")
+
+	empty := ""
+	params = userFilterToParams(&models.UserFilter{Search: &empty}, nil, 5)
+	assert.Nil(t, params["search"], "Expect empty search to be null")
+	assert.Nil(t, params["after"], "Expect missing cursor to be null")
+	assert.Contains(t, params, "role", "Expect unset filter fields to be passed")
+}
+
+func TestMigrateUserEmailsToLowerCase(t *testing.T) {
+	tests := []struct {
+		name       string
+		collisions bool
+		migrated   int64
+		dbErr      error
+	}{
+		{name: "Mixed case email migrated", migrated: 1},
+		{name: "Colliding emails reported", collisions: true},
+		{name: "DB error", dbErr: errors.New("Some error")},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			sessionMock, txMock := setupMockNewTransactionContext(mockCtrl, neo4j.AccessModeWrite)
+			sessionMock.EXPECT().Close().Return(nil).Times(1)
+
+			collisionResult := mock.NewMockResult(mockCtrl)
+			if test.dbErr != nil {
+				txMock.EXPECT().Run(gomock.Any(), gomock.Any()).Return(nil, test.dbErr).Times(1)
+				txMock.EXPECT().Rollback().Return(nil).Times(1)
+			} else {
+				if test.collisions {
+					collisionRecord := mock.NewMockRecord(mockCtrl)
+					collisionRecord.EXPECT().Get("email").Return("alice@example.com", true).Times(1)
+					collisionRecord.EXPECT().Get("ids").Return([]interface{}{"user1", "user2"}, true).Times(1)
+					gomock.InOrder(
+						collisionResult.EXPECT().Next().Return(true).Times(1),
+						collisionResult.EXPECT().Record().Return(collisionRecord).Times(2),
+					)
+				}
+				collisionResult.EXPECT().Next().Return(false).Times(1)
+				collisionResult.EXPECT().Err().Return(nil).Times(1)
+
+				migrationRecord := mock.NewMockRecord(mockCtrl)
+				migrationRecord.EXPECT().Get("migrated").Return(test.migrated, true).Times(1)
+				migrationResult := mock.NewMockResult(mockCtrl)
+				gomock.InOrder(
+					migrationResult.EXPECT().Next().Return(true).Times(1),
+					migrationResult.EXPECT().Record().Return(migrationRecord).Times(1),
+					migrationResult.EXPECT().Err().Return(nil).Times(1),
+					migrationResult.EXPECT().Next().Return(false).Times(1),
+				)
+
+				gomock.InOrder(
+					txMock.EXPECT().Run(gomock.Any(), gomock.Any()).Return(collisionResult, nil).Times(1),
+					txMock.EXPECT().Run(gomock.Any(), gomock.Any()).DoAndReturn(func(query string, _ map[string]interface{}) (neo4j.Result, error) {
+						assert.Contains(t, query, "SET u.email = email", "Expect emails to be lowercased")
+						assert.Contains(t, query, "size(users) = 1", "Expect colliding emails to be skipped")
+						return migrationResult, nil
+					}).Times(1),
+				)
+				txMock.EXPECT().Commit().Return(nil).Times(1)
+			}
+
+			logger := utils.CreateLogger(&utils.LoggingConfig{})
+			fcerr := migrateUserEmailsToLowerCase(logger)
+			if test.dbErr != nil {
+				assert.NotNil(t, fcerr, "Missing error for failed migration")
+			} else {
+				assert.Nil(t, fcerr, "Migration failed")
+			}
+		})
+	}
+}
+
+func TestCreateNodeByIDNameUsedByMount(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	_, txMock := setupMockNewTransactionContext(mockCtrl, neo4j.AccessModeWrite)
+	txCtx, fcerr := newTransactionContext(neo4j.AccessModeWrite, utils.CreateLogger(&utils.LoggingConfig{}))
+	require.Nil(t, fcerr, "Failed to create transaction context")
+	tx := &nodeReadWriteTransaction{nodeReadTransaction{txCtx}}
+
+	record := mock.NewMockRecord(mockCtrl)
+	record.EXPECT().Get("used").Return(true, true).Times(1)
+	result := mock.NewMockResult(mockCtrl)
+	gomock.InOrder(
+		result.EXPECT().Next().Return(true).Times(1),
+		result.EXPECT().Record().Return(record).Times(1),
+		result.EXPECT().Err().Return(nil).Times(1),
+		result.EXPECT().Next().Return(false).Times(1),
+	)
+	txMock.EXPECT().Run(gomock.Any(), gomock.Any()).DoAndReturn(func(query string, params map[string]interface{}) (neo4j.Result, error) {
+		assert.Contains(t, query, "CONTAINS_SHARED {name: $name}", "Expect mounts to be checked")
+		assert.Equal(t, "Shared", params["name"], "Wrong name checked")
+		return result, nil
+	}).Times(1)
+
+	parentID := models.NodeID("parent")
+	created, fcerr := tx.CreateNodeByID("user", &models.Node{ParentNodeID: &parentID, Name: "Shared", Type: models.NodeTypeFolder})
+	assert.False(t, created, "Node created next to a mount of the same name")
+	if assert.NotNil(t, fcerr, "Missing error for name used by mount") {
+		assert.Equal(t, fcerror.ErrNodeNameAlreadyUsed, fcerr.ID, "Wrong error for name used by mount")
+	}
+}
+
+func TestUpdateShareMountCycle(t *testing.T) {
+	tests := []struct {
+		name        string
+		cycle       bool
+		expectedErr *fcerror.Error
+	}{
+		{name: "Mount moved", cycle: false, expectedErr: fcerror.NewError(fcerror.ErrDBWriteFailed, nil)},
+		{name: "Mount inside itself rejected", cycle: true, expectedErr: fcerror.NewError(fcerror.ErrShareMountCycle, nil)},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			_, txMock := setupMockNewTransactionContext(mockCtrl, neo4j.AccessModeWrite)
+			txCtx, fcerr := newTransactionContext(neo4j.AccessModeWrite, utils.CreateLogger(&utils.LoggingConfig{}))
+			require.Nil(t, fcerr, "Failed to create transaction context")
+			tx := &shareReadWriteTransaction{shareReadTransaction{txCtx}}
+
+			mountRecord := mock.NewMockRecord(mockCtrl)
+			mountRecord.EXPECT().GetByIndex(0).Return("Shared").Times(1)
+			mountRecord.EXPECT().GetByIndex(1).Return("root").Times(1)
+			mountResult := mock.NewMockResult(mockCtrl)
+			gomock.InOrder(
+				mountResult.EXPECT().Next().Return(true).Times(1),
+				mountResult.EXPECT().Record().Return(mountRecord).Times(1),
+				mountResult.EXPECT().Err().Return(nil).Times(1),
+				mountResult.EXPECT().Next().Return(false).Times(1),
+			)
+
+			namesResult := mock.NewMockResult(mockCtrl)
+			namesResult.EXPECT().Next().Return(false).Times(1)
+			namesResult.EXPECT().Err().Return(nil).Times(1)
+
+			cycleRecord := mock.NewMockRecord(mockCtrl)
+			cycleRecord.EXPECT().GetByIndex(0).Return(test.cycle).Times(1)
+			cycleResult := mock.NewMockResult(mockCtrl)
+			gomock.InOrder(
+				cycleResult.EXPECT().Next().Return(true).Times(1),
+				cycleResult.EXPECT().Record().Return(cycleRecord).Times(1),
+				cycleResult.EXPECT().Err().Return(nil).Times(1),
+				cycleResult.EXPECT().Next().Return(false).Times(1),
+			)
+
+			calls := []*gomock.Call{
+				txMock.EXPECT().Run(gomock.Any(), gomock.Any()).Return(mountResult, nil).Times(1),
+				txMock.EXPECT().Run(gomock.Any(), gomock.Any()).Return(namesResult, nil).Times(1),
+				txMock.EXPECT().Run(gomock.Any(), gomock.Any()).DoAndReturn(func(query string, params map[string]interface{}) (neo4j.Result, error) {
+					assert.Contains(t, query, "CONTAINS|CONTAINS_SHARED*", "Expect paths through mounts to be checked")
+					assert.Equal(t, models.NodeID("shared-folder"), params["node_id"], "Wrong mounted node checked")
+					assert.Equal(t, models.NodeID("subfolder"), params["parent_node_id"], "Wrong target folder checked")
+					return cycleResult, nil
+				}).Times(1),
+			}
+			if !test.cycle {
+				// Failing the move itself is enough to show that the mount passed the check
+				calls = append(calls, txMock.EXPECT().Run(gomock.Any(), gomock.Any()).DoAndReturn(func(query string, _ map[string]interface{}) (neo4j.Result, error) {
+					assert.Contains(t, query, "CREATE (t)-[nr:CONTAINS_SHARED]->(n)", "Expect mount to be moved")
+					return nil, errors.New("Some error")
+				}).Times(1))
+			}
+			gomock.InOrder(calls...)
+
+			parentNodeID := models.NodeID("subfolder")
+			fcerr = tx.UpdateShareMount("user", "shared-folder", &models.ShareMountUpdate{ParentNodeID: &parentNodeID})
+			if assert.NotNil(t, fcerr, "Missing error") {
+				assert.Equal(t, test.expectedErr.ID, fcerr.ID, "Wrong error for moved mount")
+			}
+		})
+	}
+}
@@ -21,7 +21,6 @@ type containsRelation struct {
 }
 
 func getContainsRelationshipLabels(includedShareMode models.ShareMode) string {
-	// TODO: Read, Read&Write
 	relLabels := "HAS_ROOT_FOLDER|CONTAINS"
 	if includedShareMode != models.ShareModeNone {
 		relLabels += "|CONTAINS_SHARED"
@@ -29,6 +28,44 @@ func getContainsRelationshipLabels(includedShareMode models.ShareMode) string {
 	return relLabels
 }
 
+// Cypher predicate that excludes paths leading over expired shares, needs the parameter 'now'
+const activeSharesPathPredicate = "all(rel IN relationships(p) WHERE rel.expires_at IS NULL OR rel.expires_at > $now)"
+
+// Access a path grants to its user, ranked from most to least permissive
+const (
+	accessRankOwner     = 3
+	accessRankReadWrite = 2
+	accessRankRead      = 1
+)
+
+// Cypher expression ranking the access a path 'p' grants:
+// A path only over own folders grants owner access, a path over shares grants the most restrictive share mode on it.
+// If a node is reachable over multiple paths the most permissive path wins.
+var pathAccessRankExpression = fmt.Sprintf(`CASE
+		WHEN none(rel IN relationships(p) WHERE type(rel) = "CONTAINS_SHARED") THEN %d
+		WHEN all(rel IN relationships(p) WHERE type(rel) <> "CONTAINS_SHARED" OR rel.share_mode = "%s") THEN %d
+		ELSE %d
+	END`, accessRankOwner, models.ShareModeReadWrite, accessRankReadWrite, accessRankRead)
+
+// getMinAccessRank returns the rank a path needs to grant at least the given share mode - no share mode means owner access
+func getMinAccessRank(includedShareMode models.ShareMode) int {
+	switch includedShareMode {
+	case models.ShareModeRead:
+		return accessRankRead
+	case models.ShareModeReadWrite:
+		return accessRankReadWrite
+	default:
+		return accessRankOwner
+	}
+}
+
+func accessRankToShareMode(accessRank int64) models.ShareMode {
+	if accessRank >= accessRankReadWrite {
+		return models.ShareModeReadWrite
+	}
+	return models.ShareModeRead
+}
+
 func init() {
 	labelModelMappings = append(labelModelMappings, &labelModelMapping{label: "Node", model: &models.Node{}})
 	labelModelMappings = append(labelModelMappings, &labelModelMapping{label: "CONTAINS", model: &containsRelation{}})
@@ -85,17 +122,23 @@ func (tx *nodeReadTransaction) GetNodeByPath(userID models.UserID, path string,
 
 	record, err := neo4j.Single(tx.neoTx.Run(fmt.Sprintf(`
 			MATCH p = (u:User {id: $user_id})-[:%s*%d]->(n:Node)
-			WHERE [n in tail(relationships(p)) | n.name] = $path_segments
-			WITH n, nodes(p)[-2] as second_last_node, relationships(p)[-1] as last_relationship
-			RETURN n, "Folder" IN labels(n) AS is_folder, last_relationship.name as name,
+			WHERE [n in tail(relationships(p)) | n.name] = $path_segments AND %s
+			WITH n, p, %s AS access_rank
+			WHERE access_rank >= $min_access_rank
+			WITH n, access_rank, nodes(p)[-2] as second_last_node, relationships(p)[-1] as last_relationship
+			ORDER BY access_rank DESC
+			LIMIT 1
+			RETURN n, "Folder" IN labels(n) AS is_folder, last_relationship.name as name, access_rank,
 				CASE
 					WHEN 'Folder' IN labels(second_last_node) THEN second_last_node.id
 					ELSE NULL
 				END AS parent_node_id
-		`, relLabels, relationCount),
+		`, relLabels, relationCount, activeSharesPathPredicate, pathAccessRankExpression),
 		map[string]interface{}{
-			"user_id":       userID,
-			"path_segments": pathSegments,
+			"user_id":         userID,
+			"path_segments":   pathSegments,
+			"now":             utils.GetCurrentTime(),
+			"min_access_rank": getMinAccessRank(includedShareMode),
 		}))
 	if err != nil {
 		fcerr = neoToFcError(err, fcerror.ErrNodeNotFound, fcerror.ErrDBReadFailed)
@@ -112,18 +155,25 @@ func (tx *nodeReadTransaction) GetNodeByID(userID models.UserID, nodeID models.N
 
 	record, err := neo4j.Single(tx.neoTx.Run(fmt.Sprintf(`
 			MATCH p = (u:User {id: $user_id})-[:%s*]->(n:Node {id: $node_id})
-			WITH n, p, nodes(p)[-2] as second_last_node, relationships(p)[-1] as last_relationship
+			WHERE %s
+			WITH n, p, %s AS access_rank
+			WHERE access_rank >= $min_access_rank
+			WITH n, p, access_rank, nodes(p)[-2] as second_last_node, relationships(p)[-1] as last_relationship
+			ORDER BY access_rank DESC, length(p) ASC
+			LIMIT 1
 			RETURN n, "Folder" IN labels(n) AS is_folder,
 				reduce(s = "", n in tail(relationships(p)) | s + '/' + n.name) as path,
-				last_relationship.name as name,
+				last_relationship.name as name, access_rank,
 				CASE
 					WHEN 'Folder' IN labels(second_last_node) THEN second_last_node.id
 					ELSE NULL
 				END AS parent_node_id
-		`, relLabels),
+		`, relLabels, activeSharesPathPredicate, pathAccessRankExpression),
 		map[string]interface{}{
-			"user_id": userID,
-			"node_id": nodeID,
+			"user_id":         userID,
+			"node_id":         nodeID,
+			"now":             utils.GetCurrentTime(),
+			"min_access_rank": getMinAccessRank(includedShareMode),
 		}))
 	if err != nil {
 		fcerr = neoToFcError(err, fcerror.ErrNodeNotFound, fcerror.ErrDBReadFailed)
@@ -145,20 +195,34 @@ func (tx *nodeReadTransaction) GetNodeByID(userID models.UserID, nodeID models.N
 func (tx *nodeReadTransaction) ListByID(userID models.UserID, nodeID models.NodeID, includedShareMode models.ShareMode) (list []*models.Node, fcerr *fcerror.Error) {
 	relLabels := getContainsRelationshipLabels(includedShareMode)
 
+	// Resolve the most permissive path to the folder first so that its content is not listed once per path
 	res, err := tx.neoTx.Run(fmt.Sprintf(`
-			MATCH p = (u:User {id: $user_id})-[:%s*]->(:Node:Folder {id: $node_id})-[:%s]->(n:Node)
-			WITH n, p, nodes(p)[-2] as second_last_node, relationships(p)[-1] as last_relationship
-			RETURN n, "Folder" IN labels(n) AS is_folder,
-				reduce(s = "", n in tail(relationships(p)) | s + '/' + n.name) as path,
-				last_relationship.name as name,
+			MATCH p = (u:User {id: $user_id})-[:%s*]->(f:Node:Folder {id: $node_id})
+			WHERE %s
+			WITH f, p, %s AS folder_access_rank
+			WHERE folder_access_rank >= $min_access_rank
+			WITH f, p, folder_access_rank
+			ORDER BY folder_access_rank DESC, length(p) ASC
+			LIMIT 1
+			MATCH (f)-[r:%s]->(n:Node)
+			WHERE r.expires_at IS NULL OR r.expires_at > $now
+			WITH n, p, f, r,
 				CASE
-					WHEN 'Folder' IN labels(second_last_node) THEN second_last_node.id
-					ELSE NULL
-				END AS parent_node_id
-		`, relLabels, relLabels),
+					WHEN type(r) = "CONTAINS_SHARED" AND r.share_mode <> "%s" THEN %d
+					WHEN type(r) = "CONTAINS_SHARED" AND folder_access_rank > %d THEN %d
+					ELSE folder_access_rank
+				END AS access_rank
+			WHERE access_rank >= $min_access_rank
+			RETURN n, "Folder" IN labels(n) AS is_folder,
+				reduce(s = "", n in tail(relationships(p)) | s + '/' + n.name) + '/' + r.name as path,
+				r.name as name, access_rank, f.id AS parent_node_id
+		`, relLabels, activeSharesPathPredicate, pathAccessRankExpression, relLabels,
+		models.ShareModeReadWrite, accessRankRead, accessRankReadWrite, accessRankReadWrite),
 		map[string]interface{}{
-			"user_id": userID,
-			"node_id": nodeID,
+			"user_id":         userID,
+			"node_id":         nodeID,
+			"now":             utils.GetCurrentTime(),
+			"min_access_rank": getMinAccessRank(includedShareMode),
 		})
 	if err != nil {
 		fcerr = neoToFcError(err, fcerror.ErrNodeNotFound, fcerror.ErrDBReadFailed)
@@ -228,6 +292,12 @@ func (tx *nodeReadTransaction) fillNodeInfo(node *models.Node, record neo4j.Reco
 		node.ParentNodeID = &parentNodeID
 	}
 
+	if accessRankInt, ok := record.Get("access_rank"); ok {
+		if accessRank, ok := accessRankInt.(int64); ok {
+			node.Permission = accessRankToShareMode(accessRank)
+		}
+	}
+
 	node.OwnerID, fcerr = tx.getOwnerOfNodeID(node.ID)
 	if fcerr != nil {
 		return
@@ -259,6 +329,75 @@ func (tx *nodeReadTransaction) getOwnerOfNodeID(nodeID models.NodeID) (userID mo
 	return
 }
 
+// IsNodeInFolder returns whether the node is the folder itself or reachable from it through contained or shared nodes
+func (tx *nodeReadTransaction) IsNodeInFolder(folderID, nodeID models.NodeID) (contained bool, fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (f:Node:Folder {id: $folder_id}), (n:Node {id: $node_id})
+		RETURN exists((f)-[:CONTAINS|CONTAINS_SHARED*0..]->(n)) AS contained
+		`,
+		map[string]interface{}{
+			"folder_id": folderID,
+			"node_id":   nodeID,
+		}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrNodeNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	containedInt, _ := record.Get("contained")
+	contained, _ = containedInt.(bool)
+	return
+}
+
+// GetOwnedNodes returns all files and folders below the root folder of the user, shares of other users are not included.
+// Folders are returned before their content.
+func (tx *nodeReadTransaction) GetOwnedNodes(userID models.UserID) (nodes []*models.Node, fcerr *fcerror.Error) {
+	res, err := tx.neoTx.Run(`
+		MATCH p = (:User {id: $user_id})-[:HAS_ROOT_FOLDER]->(:Node:Folder)-[:CONTAINS*]->(n:Node)
+		WITH n, p, reduce(s = "", r in tail(relationships(p)) | s + '/' + r.name) AS path
+		RETURN n, "Folder" IN labels(n) AS is_folder, path, last(relationships(p)).name AS name, nodes(p)[-2].id AS parent_node_id
+		ORDER BY length(p), path
+		`,
+		map[string]interface{}{
+			"user_id": userID,
+		})
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	nodes = []*models.Node{}
+	for res.Next() {
+		record := res.Record()
+		node := &models.Node{}
+		fcerr = recordToModel(record, "n", node)
+		if fcerr != nil {
+			return
+		}
+
+		pathInt, _ := record.Get("path")
+		node.FullPath, _ = pathInt.(string)
+		node.Path, _ = utils.SplitPath(node.FullPath)
+		nameInt, _ := record.Get("name")
+		node.Name, _ = nameInt.(string)
+		if isFolderInt, _ := record.Get("is_folder"); isFolderInt == true {
+			node.Type = models.NodeTypeFolder
+		} else {
+			node.Type = models.NodeTypeFile
+		}
+		if parentNodeIDInt, _ := record.Get("parent_node_id"); parentNodeIDInt != nil {
+			parentNodeID := models.NodeID(parentNodeIDInt.(string))
+			node.ParentNodeID = &parentNodeID
+		}
+		node.OwnerID = userID
+		node.PerspectiveUserID = userID
+		node.Permission = models.ShareModeReadWrite
+		nodes = append(nodes, node)
+	}
+	fcerr = neoToFcError(res.Err(), fcerror.ErrUnknown, fcerror.ErrDBReadFailed)
+	return
+}
+
 type nodeReadWriteTransaction struct {
 	nodeReadTransaction
 }
@@ -292,7 +431,76 @@ func (tx *nodeReadWriteTransaction) CreateUserRootFolder(userID models.UserID) (
 	return
 }
 
+// DeleteUserRootFolder deletes the root folder of the user with all contained nodes, shares of them are removed with them
+func (tx *nodeReadWriteTransaction) DeleteUserRootFolder(userID models.UserID) (fcerr *fcerror.Error) {
+	res, err := tx.neoTx.Run(`
+		MATCH (:User {id: $user_id})-[:HAS_ROOT_FOLDER]->(f:Node:Folder)
+		OPTIONAL MATCH (f)-[:CONTAINS*]->(n:Node)
+		WITH f, collect(DISTINCT n) AS nodes
+		FOREACH (n IN nodes | DETACH DELETE n)
+		DETACH DELETE f
+		`,
+		map[string]interface{}{
+			"user_id": userID,
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+// TransferUserRootFolder moves the root folder of a user into the root folder of another user as a folder with the given name.
+// The name is changed if it is already used there. Shares mounted in the moved tree are removed, shares of its nodes are kept.
+func (tx *nodeReadWriteTransaction) TransferUserRootFolder(fromUserID, toUserID models.UserID, folderName string) (transferredName string, fcerr *fcerror.Error) {
+	usedNames, fcerr := tx.getFolderChildNames(toUserID, nil)
+	if fcerr != nil {
+		return
+	}
+	transferredName = utils.GetNonConflictingName(folderName, usedNames)
+
+	res, err := tx.neoTx.Run(`
+		MATCH (:User {id: $from_user_id})-[h:HAS_ROOT_FOLDER]->(f:Node:Folder)
+		MATCH (:User {id: $to_user_id})-[:HAS_ROOT_FOLDER]->(t:Node:Folder)
+		OPTIONAL MATCH (f)-[:CONTAINS*0..]->(:Node:Folder)-[s:CONTAINS_SHARED]->(:Node)
+		WITH h, f, t, collect(s) AS mounts
+		FOREACH (s IN mounts | DELETE s)
+		DELETE h
+		CREATE (t)-[r:CONTAINS]->(f)
+		SET r = $r
+		`,
+		map[string]interface{}{
+			"from_user_id": fromUserID,
+			"to_user_id":   toUserID,
+			"r":            modelToMap(&containsRelation{Name: transferredName}),
+		})
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+		return
+	}
+
+	summary, err := res.Consume()
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+		return
+	}
+	if summary.Counters().RelationshipsCreated() == 0 {
+		fcerr = fcerror.NewError(fcerror.ErrNodeNotFound, errors.New("Root folder of a user could not be found"))
+	}
+	return
+}
+
 func (tx *nodeReadWriteTransaction) CreateNodeByID(userID models.UserID, node *models.Node) (created bool, fcerr *fcerror.Error) {
+	// The MERGE below only finds existing nodes, mounted shares would be shadowed by a node of the same name
+	usedByMount, fcerr := tx.isNameUsedByMount(node.ParentNodeID, node.Name)
+	if fcerr != nil {
+		return
+	}
+	if usedByMount {
+		fcerr = fcerror.NewError(fcerror.ErrNodeNameAlreadyUsed, fmt.Errorf("Name '%s' is used by a mounted share", node.Name))
+		return
+	}
+
 	node.ID = models.NodeID(uuid.NewString())
 	node.Created = utils.GetCurrentTime()
 	node.Updated = utils.GetCurrentTime()
@@ -306,23 +514,31 @@ func (tx *nodeReadWriteTransaction) CreateNodeByID(userID models.UserID, node *m
 
 	result, err := tx.neoTx.Run(fmt.Sprintf(`
 			MATCH p = (u:User {id: $user_id})-[:HAS_ROOT_FOLDER|CONTAINS|CONTAINS_SHARED*]->(f:Node:Folder {id: $parent_node_id})
+			WHERE %s
+			WITH f, p, %s AS access_rank
+			WHERE access_rank >= $min_access_rank
+			WITH f, p, access_rank
+			ORDER BY access_rank DESC, length(p) ASC
+			LIMIT 1
 			MERGE (f)-[r:CONTAINS {name: $r.name}]->(n:Node)
 			ON CREATE
 				SET n:%s
 				SET n += $n
 				SET r += $r
-			WITH n, r, p
+			WITH n, r, p, access_rank
 			RETURN n,
 				"Folder" IN labels(n) AS is_folder,
 				reduce(s = "", n in tail(relationships(p)) | s + '/' + n.name) as parent_path,
-				r.name as name,
+				r.name as name, access_rank,
 				$parent_node_id AS parent_node_id
-		`, insertNodeType),
+		`, activeSharesPathPredicate, pathAccessRankExpression, insertNodeType),
 		map[string]interface{}{
-			"user_id":        userID,
-			"parent_node_id": node.ParentNodeID,
-			"n":              modelToMap(node),
-			"r":              modelToMap(insertRelation),
+			"user_id":         userID,
+			"parent_node_id":  node.ParentNodeID,
+			"now":             utils.GetCurrentTime(),
+			"min_access_rank": getMinAccessRank(models.ShareModeReadWrite),
+			"n":               modelToMap(node),
+			"r":               modelToMap(insertRelation),
 		})
 	record, err := neo4j.Single(result, err)
 	if err != nil {
@@ -349,3 +565,23 @@ func (tx *nodeReadWriteTransaction) CreateNodeByID(userID models.UserID, node *m
 	fcerr = tx.fillNodeInfo(node, record, userID, path)
 	return
 }
+
+// isNameUsedByMount checks whether a share is mounted with the name in the folder
+func (tx *nodeReadWriteTransaction) isNameUsedByMount(folderID *models.NodeID, name string) (used bool, fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+			OPTIONAL MATCH (:Node:Folder {id: $folder_id})-[r:CONTAINS_SHARED {name: $name}]->(:Node)
+			RETURN count(r) > 0 AS used
+		`,
+		map[string]interface{}{
+			"folder_id": folderID,
+			"name":      name,
+		}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrNodeNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	usedInt, _ := record.Get("used")
+	used, _ = usedInt.(bool)
+	return
+}
@@ -1,6 +1,9 @@
 package neo
 
 import (
+	"errors"
+	"time"
+
 	"github.com/freecloudio/server/application/config"
 	"github.com/freecloudio/server/application/persistence"
 	"github.com/freecloudio/server/domain/models"
@@ -59,39 +62,153 @@ type shareReadTransaction struct {
 	*transactionCtx
 }
 
-func (tx *shareReadTransaction) NodeContainsNestedShares(nodeID models.NodeID) (containsShared bool, fcerr *fcerror.Error) {
+type shareReadWriteTransaction struct {
+	shareReadTransaction
+}
+
+// GetSharesOfUser returns all active shares of nodes owned by the user, shares with users carry the name they are mounted as
+func (tx *shareReadTransaction) GetSharesOfUser(userID models.UserID) (shares []*models.Share, fcerr *fcerror.Error) {
 	res, err := tx.neoTx.Run(`
-		MATCH p = (:Node {id: $node_id})-[:CONTAINS|CONTAINS_SHARED*]->(n:Node)
-		WITH reduce(x = false, t IN relationships(p) | TYPE(t) = "CONTAINS_SHARED") AS contains_shared
-		WHERE contains_shared = true
-		RETURN contains_shared
+		MATCH (:User {id: $user_id})-[:HAS_ROOT_FOLDER|CONTAINS*]->(n:Node)
+		MATCH (u:User)-[:HAS_ROOT_FOLDER|CONTAINS*]->(:Node:Folder)-[r:CONTAINS_SHARED]->(n)
+		WHERE r.group_id IS NULL AND (r.expires_at IS NULL OR r.expires_at > $now)
+		RETURN n.id AS node_id, r.name AS name, $user_target AS target_type, u.id AS shared_with_id, r.share_mode AS share_mode, r.expires_at AS expires_at
+		UNION ALL
+		MATCH (:User {id: $user_id})-[:HAS_ROOT_FOLDER|CONTAINS*]->(n:Node)
+		MATCH (g:Group)-[s:SHARES]->(n)
+		WHERE s.expires_at IS NULL OR s.expires_at > $now
+		RETURN n.id AS node_id, s.name AS name, $group_target AS target_type, g.id AS shared_with_id, s.share_mode AS share_mode, s.expires_at AS expires_at
 		`,
 		map[string]interface{}{
-			"node_id": nodeID,
+			"user_id":      userID,
+			"now":          utils.GetCurrentTime(),
+			"user_target":  string(models.ShareTargetTypeUser),
+			"group_target": string(models.ShareTargetTypeGroup),
 		})
 	if err != nil {
-		fcerr = neoToFcError(err, fcerror.ErrNodeNotFound, fcerror.ErrDBReadFailed)
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBReadFailed)
 		return
 	}
-	if res.Next() {
-		containsShared = true
+
+	shares = []*models.Share{}
+	for res.Next() {
+		record := res.Record()
+		values := map[string]string{}
+		for _, key := range []string{"node_id", "name", "target_type", "shared_with_id", "share_mode"} {
+			valueInt, _ := record.Get(key)
+			values[key], _ = valueInt.(string)
+		}
+
+		share := &models.Share{
+			NodeID:     models.NodeID(values["node_id"]),
+			Name:       values["name"],
+			TargetType: models.ShareTargetType(values["target_type"]),
+			Mode:       models.ShareMode(values["share_mode"]),
+		}
+		if share.TargetType == models.ShareTargetTypeGroup {
+			share.SharedWithGroupID = models.GroupID(values["shared_with_id"])
+		} else {
+			share.SharedWithID = models.UserID(values["shared_with_id"])
+		}
+		if expiresAtInt, _ := record.Get("expires_at"); expiresAtInt != nil {
+			if expiresAt, ok := expiresAtInt.(time.Time); ok {
+				share.ExpiresAt = &expiresAt
+			}
+		}
+		shares = append(shares, share)
 	}
+	fcerr = neoToFcError(res.Err(), fcerror.ErrUnknown, fcerror.ErrDBReadFailed)
 	return
 }
 
-type shareReadWriteTransaction struct {
-	shareReadTransaction
+// mountShare creates the CONTAINS_SHARED relationship from the root folder of the user to the shared node.
+// The mount point is renamed if the name is already used in the root folder.
+// Shares mounted because of a group membership are distinguished from direct shares by their 'group_id'.
+func (trCtx *transactionCtx) mountShare(userID models.UserID, nodeID models.NodeID, groupID models.GroupID, name string, props map[string]interface{}) (created bool, mountName string, fcerr *fcerror.Error) {
+	usedNames, fcerr := trCtx.getFolderChildNames(userID, nil)
+	if fcerr != nil {
+		return
+	}
+	mountName = utils.GetNonConflictingName(name, usedNames)
+
+	res, err := trCtx.neoTx.Run(`
+			MATCH (u:User {id: $user_id})-[:HAS_ROOT_FOLDER]->(f:Node:Folder), (n:Node {id: $node_id})
+			OPTIONAL MATCH (u)-[:HAS_ROOT_FOLDER|CONTAINS*]->(:Node:Folder)-[existing:CONTAINS_SHARED]->(n)
+			WHERE coalesce(existing.group_id, "") = $group_id
+			WITH f, n, existing
+			WHERE existing IS NULL
+			CREATE (f)-[r:CONTAINS_SHARED]->(n)
+			SET r += $props, r.name = $name
+		`,
+		map[string]interface{}{
+			"user_id":  userID,
+			"node_id":  nodeID,
+			"group_id": string(groupID),
+			"name":     mountName,
+			"props":    props,
+		})
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+		return
+	}
+
+	summary, err := res.Consume()
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+		return
+	}
+	created = summary.Counters().RelationshipsCreated() > 0
+	return
+}
+
+// getFolderChildNames returns the names of all files, folders and mounted shares in the given folder of the user - or the root folder if no folder is given
+func (trCtx *transactionCtx) getFolderChildNames(userID models.UserID, folderID *models.NodeID) (names []string, fcerr *fcerror.Error) {
+	query := `
+			MATCH (:User {id: $user_id})-[:HAS_ROOT_FOLDER]->(f:Node:Folder)-[r:CONTAINS|CONTAINS_SHARED]->(:Node)
+			RETURN r.name AS name
+		`
+	if folderID != nil {
+		query = `
+			MATCH (:User {id: $user_id})-[:HAS_ROOT_FOLDER|CONTAINS*]->(f:Node:Folder {id: $folder_id})-[r:CONTAINS|CONTAINS_SHARED]->(:Node)
+			RETURN r.name AS name
+		`
+	}
+
+	res, err := trCtx.neoTx.Run(query, map[string]interface{}{
+		"user_id":   userID,
+		"folder_id": folderID,
+	})
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrNodeNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	names = []string{}
+	for res.Next() {
+		if name, ok := res.Record().GetByIndex(0).(string); ok {
+			names = append(names, name)
+		}
+	}
+	fcerr = neoToFcError(res.Err(), fcerror.ErrNodeNotFound, fcerror.ErrDBReadFailed)
+	return
 }
 
 func (tx *shareReadWriteTransaction) CreateShare(userID models.UserID, share *models.Share, insertName string) (created bool, fcerr *fcerror.Error) {
+	created, share.Name, fcerr = tx.mountShare(share.SharedWithID, share.NodeID, "", insertName, modelToMap(share))
+	return
+}
+
+// CreateGroupShare stores the share on the group and mounts it into the root folder of every current group member except the owner
+func (tx *shareReadWriteTransaction) CreateGroupShare(userID models.UserID, share *models.Share, insertName string) (created bool, fcerr *fcerror.Error) {
+	share.Name = insertName
 	res, err := tx.neoTx.Run(`
-			MATCH (u:User {id: $user_id})-[:HAS_ROOT_FOLDER]->(f:Node:Folder), (n:Node {id: $node_id})
-			MERGE (f)-[r:CONTAINS_SHARED {name: $node_name}]->(n)
+			MATCH (g:Group {id: $group_id}), (n:Node {id: $node_id})
+			MERGE (g)-[s:SHARES]->(n)
 			ON CREATE
-				SET r += $share
+				SET s += $share, s.name = $node_name
 		`,
 		map[string]interface{}{
-			"user_id":   share.SharedWithID,
+			"group_id":  share.SharedWithGroupID,
 			"node_name": insertName,
 			"node_id":   share.NodeID,
 			"share":     modelToMap(share),
@@ -101,11 +218,203 @@ func (tx *shareReadWriteTransaction) CreateShare(userID models.UserID, share *mo
 		return
 	}
 
-	summary, err := res.Summary()
-	if err == nil && summary.Counters().RelationshipsCreated() > 0 {
-		created = true
+	summary, err := res.Consume()
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+		return
+	}
+	if summary.Counters().RelationshipsCreated() == 0 {
+		return
+	}
+	created = true
+
+	res, err = tx.neoTx.Run(`
+			MATCH (u:User)-[:MEMBER_OF]->(:Group {id: $group_id}), (n:Node {id: $node_id})
+			WHERE NOT (u)-[:HAS_ROOT_FOLDER|CONTAINS*]->(n)
+			RETURN u.id AS user_id
+		`,
+		map[string]interface{}{
+			"group_id": share.SharedWithGroupID,
+			"node_id":  share.NodeID,
+		})
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBReadFailed)
+		return
+	}
+	memberIDs := []models.UserID{}
+	for res.Next() {
+		memberIDs = append(memberIDs, models.UserID(res.Record().GetByIndex(0).(string)))
+	}
+	if err = res.Err(); err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBReadFailed)
+		return
 	}
 
-	fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+	props := modelToMap(share)
+	props["group_id"] = string(share.SharedWithGroupID)
+	for _, memberID := range memberIDs {
+		_, _, fcerr = tx.mountShare(memberID, share.NodeID, share.SharedWithGroupID, insertName, props)
+		if fcerr != nil {
+			return
+		}
+	}
 	return
 }
+
+// UpdateShareMount renames or moves the mount point of a share inside the own folders of the recipient
+func (tx *shareReadWriteTransaction) UpdateShareMount(userID models.UserID, nodeID models.NodeID, update *models.ShareMountUpdate) (fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+			MATCH (:User {id: $user_id})-[:HAS_ROOT_FOLDER|CONTAINS*]->(f:Node:Folder)-[r:CONTAINS_SHARED]->(:Node {id: $node_id})
+			RETURN r.name AS name, f.id AS parent_node_id
+			LIMIT 1
+		`,
+		map[string]interface{}{
+			"user_id": userID,
+			"node_id": nodeID,
+		}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrNodeNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+	oldName := record.GetByIndex(0).(string)
+	oldParentNodeID := models.NodeID(record.GetByIndex(1).(string))
+
+	name, parentNodeID := oldName, oldParentNodeID
+	if update.Name != nil {
+		name = *update.Name
+	}
+	if update.ParentNodeID != nil {
+		parentNodeID = *update.ParentNodeID
+	}
+	if name == oldName && parentNodeID == oldParentNodeID {
+		return
+	}
+
+	usedNames, fcerr := tx.getFolderChildNames(userID, &parentNodeID)
+	if fcerr != nil {
+		return
+	}
+	for _, usedName := range usedNames {
+		if usedName == name {
+			fcerr = fcerror.NewError(fcerror.ErrNodeNameAlreadyUsed, nil)
+			return
+		}
+	}
+
+	if parentNodeID != oldParentNodeID {
+		fcerr = tx.checkMountCycle(nodeID, parentNodeID)
+		if fcerr != nil {
+			return
+		}
+	}
+
+	res, err := tx.neoTx.Run(`
+			MATCH (u:User {id: $user_id})-[:HAS_ROOT_FOLDER|CONTAINS*]->(:Node:Folder {id: $old_parent_node_id})-[r:CONTAINS_SHARED {name: $old_name}]->(n:Node {id: $node_id})
+			MATCH (u)-[:HAS_ROOT_FOLDER|CONTAINS*]->(t:Node:Folder {id: $parent_node_id})
+			CREATE (t)-[nr:CONTAINS_SHARED]->(n)
+			SET nr = properties(r), nr.name = $name
+			DELETE r
+		`,
+		map[string]interface{}{
+			"user_id":            userID,
+			"node_id":            nodeID,
+			"old_parent_node_id": oldParentNodeID,
+			"old_name":           oldName,
+			"parent_node_id":     parentNodeID,
+			"name":               name,
+		})
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+		return
+	}
+
+	summary, err := res.Consume()
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+		return
+	}
+	if summary.Counters().RelationshipsCreated() == 0 {
+		fcerr = fcerror.NewError(fcerror.ErrNodeNotFound, errors.New("target folder for share mount not found"))
+	}
+	return
+}
+
+// checkMountCycle returns ErrShareMountCycle if the folder is the shared node itself or reachable from it, e.g. through a share mounted inside of it.
+// The traversals of nodes follow mounts without a limit, so such a mount would make them loop.
+func (tx *shareReadWriteTransaction) checkMountCycle(nodeID, parentNodeID models.NodeID) (fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+			MATCH (n:Node {id: $node_id}), (t:Node:Folder {id: $parent_node_id})
+			RETURN n = t OR exists((n)-[:CONTAINS|CONTAINS_SHARED*]->(t)) AS cycle
+		`,
+		map[string]interface{}{
+			"node_id":        nodeID,
+			"parent_node_id": parentNodeID,
+		}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrNodeNotFound, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	if cycle, _ := record.GetByIndex(0).(bool); cycle {
+		fcerr = fcerror.NewError(fcerror.ErrShareMountCycle, nil)
+	}
+	return
+}
+
+// DeleteShare removes a share of a node owned by the user, for group shares including the mounts of all members
+func (tx *shareReadWriteTransaction) DeleteShare(userID models.UserID, share *models.Share) (fcerr *fcerror.Error) {
+	query := `
+		MATCH (:User {id: $user_id})-[:HAS_ROOT_FOLDER|CONTAINS*]->(n:Node {id: $node_id})
+		MATCH (:User {id: $shared_with_id})-[:HAS_ROOT_FOLDER|CONTAINS*]->(:Node:Folder)-[r:CONTAINS_SHARED]->(n)
+		WHERE r.group_id IS NULL
+		DELETE r
+	`
+	if share.TargetType == models.ShareTargetTypeGroup {
+		query = `
+			MATCH (:User {id: $user_id})-[:HAS_ROOT_FOLDER|CONTAINS*]->(n:Node {id: $node_id})
+			MATCH (:Group {id: $shared_with_id})-[s:SHARES]->(n)
+			OPTIONAL MATCH (:Node:Folder)-[r:CONTAINS_SHARED {group_id: $shared_with_id}]->(n)
+			DELETE s, r
+		`
+	}
+
+	sharedWithID := string(share.SharedWithID)
+	if share.TargetType == models.ShareTargetTypeGroup {
+		sharedWithID = string(share.SharedWithGroupID)
+	}
+	res, err := tx.neoTx.Run(query, map[string]interface{}{
+		"user_id":        userID,
+		"node_id":        share.NodeID,
+		"shared_with_id": sharedWithID,
+	})
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrShareNotFound, fcerror.ErrDBWriteFailed)
+		return
+	}
+
+	summary, err := res.Consume()
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrShareNotFound, fcerror.ErrDBWriteFailed)
+		return
+	}
+	if summary.Counters().RelationshipsDeleted() == 0 {
+		fcerr = fcerror.NewError(fcerror.ErrShareNotFound, nil)
+	}
+	return
+}
+
+func (tx *shareReadWriteTransaction) DeleteExpiredShares() *fcerror.Error {
+	res, err := tx.neoTx.Run(`
+		MATCH ()-[r:CONTAINS_SHARED|SHARES]->(:Node)
+		WHERE r.expires_at < $now
+		DELETE r
+		`,
+		map[string]interface{}{
+			"now": utils.GetCurrentTime(),
+		})
+	if err == nil {
+		_, err = res.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
@@ -2,6 +2,8 @@ package neo
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/freecloudio/server/application/config"
 	"github.com/freecloudio/server/application/persistence"
@@ -15,6 +17,7 @@ import (
 
 func init() {
 	labelModelMappings = append(labelModelMappings, &labelModelMapping{label: "User", model: &models.User{}})
+	labelModelMappings = append(labelModelMappings, &labelModelMapping{label: "Invite", model: &models.Invite{}})
 }
 
 type UserPersistence struct {
@@ -32,6 +35,53 @@ func CreateUserPersistence(cfg config.Config) (userPersistence *UserPersistence,
 	return
 }
 
+// migrateUserEmailsToLowerCase lowercases the emails of users stored before emails were normalized, as lookups only match lowercase emails.
+// Users whose emails only differ in case are reported and kept unchanged, they have to be merged or changed manually.
+func migrateUserEmailsToLowerCase(logger utils.Logger) (fcerr *fcerror.Error) {
+	txCtx, fcerr := newTransactionContext(neo4j.AccessModeWrite, logger)
+	if fcerr != nil {
+		return
+	}
+	defer func() { fcerr = txCtx.Finish(fcerr) }()
+
+	result, err := txCtx.neoTx.Run(`
+		MATCH (u:User)
+		WITH toLower(u.email) AS email, collect(u.id) AS ids
+		WHERE size(ids) > 1
+		RETURN email, ids
+		`, nil)
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrDBReadFailed, fcerror.ErrDBReadFailed)
+		return
+	}
+	for result.Next() {
+		email, _ := result.Record().Get("email")
+		ids, _ := result.Record().Get("ids")
+		logger.WithField("email", email).WithField("userIDs", ids).Error("Emails of users only differ in case - change or merge them manually, they cannot log in until then")
+	}
+	if err = result.Err(); err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrDBReadFailed, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	record, err := neo4j.Single(txCtx.neoTx.Run(`
+		MATCH (u:User)
+		WITH toLower(u.email) AS email, collect(u) AS users
+		WHERE size(users) = 1 AND users[0].email <> email
+		UNWIND users AS u
+		SET u.email = email
+		RETURN count(u) AS migrated
+		`, nil))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrDBWriteFailed, fcerror.ErrDBWriteFailed)
+		return
+	}
+	if migrated, _ := record.Get("migrated"); migrated != nil && migrated.(int64) > 0 {
+		logger.WithField("count", migrated).Info("Migrated emails of users to lower case")
+	}
+	return
+}
+
 func (*UserPersistence) Close() *fcerror.Error {
 	if neo != nil {
 		return closeNeo()
@@ -117,6 +167,149 @@ func (tx *userReadTransaction) GetUserByEmail(email string) (user *models.User,
 	return
 }
 
+// userSortProperties maps the fields users can be sorted by to their properties
+var userSortProperties = map[models.UserSortField]string{
+	models.UserSortFieldEmail:     "email",
+	models.UserSortFieldFirstName: "first_name",
+	models.UserSortFieldLastName:  "last_name",
+	models.UserSortFieldCreated:   "created",
+}
+
+// GetUsers returns a page of users matching the filter, the next page starts after the last user of the previous one
+func (tx *userReadTransaction) GetUsers(filter *models.UserFilter, sort *models.UserSort, after *models.UserID, limit int) (users []*models.User, fcerr *fcerror.Error) {
+	sortProperty := userSortProperties[models.UserSortFieldEmail]
+	comparison, direction := ">", "ASC"
+	if sort != nil {
+		if property, ok := userSortProperties[sort.Field]; ok {
+			sortProperty = property
+		}
+		if sort.Descending {
+			comparison, direction = "<", "DESC"
+		}
+	}
+
+	res, err := tx.neoTx.Run(fmt.Sprintf(`
+		OPTIONAL MATCH (a:User {id: $after})
+		WITH a
+		MATCH (u:User)
+		WHERE ($after IS NULL OR (a IS NOT NULL AND (u.%[1]s %[2]s a.%[1]s OR (u.%[1]s = a.%[1]s AND u.id %[2]s a.id))))
+			AND ($search IS NULL
+				OR toLower(u.email) CONTAINS $search
+				OR toLower(u.first_name + ' ' + u.last_name) CONTAINS $search
+				OR toLower(coalesce(u.display_name, '')) CONTAINS $search)
+			AND ($disabled IS NULL OR coalesce(u.disabled, false) = $disabled)
+			AND ($role IS NULL OR ($role = $admin_role AND u.is_admin) OR $role IN coalesce(u.roles, []))
+		RETURN u
+		ORDER BY u.%[1]s %[3]s, u.id %[3]s
+		LIMIT $limit
+	`, sortProperty, comparison, direction), userFilterToParams(filter, after, limit))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	users = []*models.User{}
+	for res.Next() {
+		user := &models.User{}
+		fcerr = recordToModel(res.Record(), "u", user)
+		if fcerr != nil {
+			return nil, fcerr
+		}
+		users = append(users, user)
+	}
+	if err = res.Err(); err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBReadFailed)
+		return nil, fcerr
+	}
+	return
+}
+
+// userFilterToParams converts the filter to query parameters, unset fields are passed as null
+func userFilterToParams(filter *models.UserFilter, after *models.UserID, limit int) map[string]interface{} {
+	params := map[string]interface{}{
+		"after":      nil,
+		"search":     nil,
+		"disabled":   nil,
+		"role":       nil,
+		"admin_role": string(models.RoleAdmin),
+		"limit":      limit,
+	}
+	if after != nil {
+		params["after"] = string(*after)
+	}
+	if filter == nil {
+		return params
+	}
+	if filter.Search != nil && *filter.Search != "" {
+		params["search"] = strings.ToLower(*filter.Search)
+	}
+	if filter.Disabled != nil {
+		params["disabled"] = *filter.Disabled
+	}
+	if filter.Role != nil {
+		params["role"] = string(*filter.Role)
+	}
+	return params
+}
+
+// GetInvites returns all unused invites, the creator is empty if the user was deleted since
+func (tx *userReadTransaction) GetInvites() (invites []*models.Invite, fcerr *fcerror.Error) {
+	res, err := tx.neoTx.Run(`
+		MATCH (i:Invite)
+		OPTIONAL MATCH (i)<-[:CREATED_INVITE]-(u:User)
+		RETURN i, u.id as user_id
+		ORDER BY i.created
+		`, nil)
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	invites = []*models.Invite{}
+	for res.Next() {
+		invite, fcerr := recordToInvite(res.Record())
+		if fcerr != nil {
+			return nil, fcerr
+		}
+		invites = append(invites, invite)
+	}
+	if err = res.Err(); err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBReadFailed)
+		return nil, fcerr
+	}
+	return
+}
+
+func (tx *userReadTransaction) GetInviteByCodeHash(codeHash string) (invite *models.Invite, fcerr *fcerror.Error) {
+	record, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (i:Invite {code_hash: $code_hash})
+		OPTIONAL MATCH (i)<-[:CREATED_INVITE]-(u:User)
+		RETURN i, u.id as user_id
+		`,
+		map[string]interface{}{
+			"code_hash": codeHash,
+		}))
+	if err != nil {
+		fcerr = neoToFcError(err, fcerror.ErrInviteInvalid, fcerror.ErrDBReadFailed)
+		return
+	}
+
+	return recordToInvite(record)
+}
+
+func recordToInvite(record neo4j.Record) (invite *models.Invite, fcerr *fcerror.Error) {
+	invite = &models.Invite{}
+	fcerr = recordToModel(record, "i", invite)
+	if fcerr != nil {
+		return
+	}
+
+	if userIDInt, _ := record.Get("user_id"); userIDInt != nil {
+		invite.CreatedBy = models.UserID(userIDInt.(string))
+	}
+	return
+}
+
 type userReadWriteTransaction struct {
 	userReadTransaction
 }
@@ -165,3 +358,51 @@ func (tx *userReadWriteTransaction) UpdateUser(user *models.User) (fcerr *fcerro
 	}
 	return
 }
+
+// DeleteUser deletes the user with its sessions, login credentials, tokens and file drops.
+// Invites created by the user are kept and its files have to be deleted or transferred before.
+func (tx *userReadWriteTransaction) DeleteUser(userID models.UserID) (fcerr *fcerror.Error) {
+	_, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (u:User {id: $id})
+		OPTIONAL MATCH (u)-[:AUTHENTICATES_WITH|HAS_LOGIN_CHALLENGE|HAS_TOTP|HAS_RECOVERY_CODE|HAS_ACCESS_TOKEN|HAS_EMAIL_TOKEN|HAS_WEBAUTHN_CREDENTIAL|CREATED_FILE_DROP]->(o)
+		WITH u, u.id AS id, collect(DISTINCT o) AS owned
+		FOREACH (o IN owned | DETACH DELETE o)
+		DETACH DELETE u
+		RETURN id
+		`,
+		map[string]interface{}{
+			"id": userID,
+		}))
+
+	return neoToFcError(err, fcerror.ErrUserNotFound, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *userReadWriteTransaction) SaveInvite(invite *models.Invite) (fcerr *fcerror.Error) {
+	result, err := tx.neoTx.Run(`
+		MATCH (u:User {id: $user_id})
+		CREATE (u)-[:CREATED_INVITE]->(:Invite $invite)
+		`,
+		map[string]interface{}{
+			"user_id": invite.CreatedBy,
+			"invite":  modelToMap(invite),
+		})
+	if err == nil {
+		_, err = result.Consume()
+	}
+
+	return neoToFcError(err, fcerror.ErrUnknown, fcerror.ErrDBWriteFailed)
+}
+
+func (tx *userReadWriteTransaction) DeleteInvite(inviteID models.InviteID) (fcerr *fcerror.Error) {
+	_, err := neo4j.Single(tx.neoTx.Run(`
+		MATCH (i:Invite {id: $id})
+		WITH i, i.id AS id
+		DETACH DELETE i
+		RETURN id
+		`,
+		map[string]interface{}{
+			"id": inviteID,
+		}))
+
+	return neoToFcError(err, fcerror.ErrInviteNotFound, fcerror.ErrDBWriteFailed)
+}
@@ -0,0 +1,94 @@
+package smtpplg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/application/mail"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+type sendFunc func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+
+// SMTPMailer delivers mails over an SMTP server, upgrading the connection with STARTTLS if the server supports it
+type SMTPMailer struct {
+	cfg    *config.MailConfig
+	send   sendFunc
+	logger utils.Logger
+}
+
+var _ mail.Mailer = &SMTPMailer{}
+
+func CreateSMTPMailer(cfg config.Config) (*SMTPMailer, *fcerror.Error) {
+	mailCfg := cfg.GetMailConfig()
+	if mailCfg.SMTPHost == "" || mailCfg.SMTPPort <= 0 || mailCfg.From == "" {
+		return nil, fcerror.NewError(fcerror.ErrMailInvalid, errors.New("SMTP host, port and sender must be set"))
+	}
+
+	return &SMTPMailer{
+		cfg:    mailCfg,
+		send:   smtp.SendMail,
+		logger: utils.CreateLogger(cfg.GetLoggingConfig()),
+	}, nil
+}
+
+func (mailer *SMTPMailer) SendMail(mail *models.Mail) *fcerror.Error {
+	msg, err := buildMessage(mailer.cfg.From, mail, utils.GetCurrentTime())
+	if err != nil {
+		mailer.logger.WithError(err).WithField("to", mail.To).Error("Failed to build mail")
+		return fcerror.NewError(fcerror.ErrMailInvalid, err)
+	}
+
+	var auth smtp.Auth
+	if mailer.cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", mailer.cfg.SMTPUsername, mailer.cfg.SMTPPassword, mailer.cfg.SMTPHost)
+	}
+
+	addr := net.JoinHostPort(mailer.cfg.SMTPHost, strconv.Itoa(mailer.cfg.SMTPPort))
+	err = mailer.send(addr, auth, mailer.cfg.From, []string{mail.To}, msg)
+	if err != nil {
+		mailer.logger.WithError(err).WithFields(logrus.Fields{"to": mail.To, "addr": addr}).Error("Failed to send mail")
+		return fcerror.NewError(fcerror.ErrMailSendFailed, err)
+	}
+	return nil
+}
+
+// buildMessage creates a plain text UTF-8 message with CRLF line endings
+func buildMessage(from string, mail *models.Mail, date time.Time) ([]byte, error) {
+	for _, header := range []string{from, mail.To, mail.Subject} {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, errors.New("mail header contains a line break")
+		}
+	}
+	if mail.To == "" {
+		return nil, errors.New("mail has no recipient")
+	}
+
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "From: %s\r\n", from)
+	fmt.Fprintf(msg, "To: %s\r\n", mail.To)
+	fmt.Fprintf(msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", mail.Subject))
+	fmt.Fprintf(msg, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	msg.WriteString("\r\n")
+
+	body := strings.ReplaceAll(mail.Body, "\r\n", "\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	return msg.Bytes(), nil
+}
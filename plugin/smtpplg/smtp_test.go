@@ -0,0 +1,82 @@
+package smtpplg
+
+import (
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/freecloudio/server/application/config"
+	"github.com/freecloudio/server/domain/models"
+	"github.com/freecloudio/server/domain/models/fcerror"
+	"github.com/freecloudio/server/mock"
+	"github.com/freecloudio/server/utils"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMessage(t *testing.T) {
+	date := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	msg, err := buildMessage("freecloud@example.com", &models.Mail{To: "user@example.com", Subject: "Grüße", Body: "line1\nline2"}, date)
+	require.Nil(t, err, "Failed to build message")
+	assert.Equal(t, "From: freecloud@example.com\r\n"+
+		"To: user@example.com\r\n"+
+		"Subject: =?utf-8?q?Gr=C3=BC=C3=9Fe?=\r\n"+
+		"Date: Thu, 04 Mar 2021 05:06:07 +0000\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=UTF-8\r\n"+
+		"Content-Transfer-Encoding: 8bit\r\n"+
+		"\r\n"+
+		"line1\r\nline2\r\n", string(msg), "Wrong message")
+
+	_, err = buildMessage("freecloud@example.com", &models.Mail{To: "user@example.com\r\nBcc: other@example.com", Subject: "Hi"}, date)
+	assert.NotNil(t, err, "Expect header injection to fail")
+	_, err = buildMessage("freecloud@example.com", &models.Mail{To: "user@example.com", Subject: "Hi\nBcc: other@example.com"}, date)
+	assert.NotNil(t, err, "Expect header injection to fail")
+}
+
+func TestSendMail(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	cfgMock := mock.NewMockConfig(mockCtrl)
+	cfgMock.EXPECT().GetLoggingConfig().Return(&utils.LoggingConfig{}).AnyTimes()
+	cfgMock.EXPECT().GetMailConfig().Return(&config.MailConfig{
+		Plugin:       config.SMTPMailKey,
+		From:         "freecloud@example.com",
+		SMTPHost:     "smtp.example.com",
+		SMTPPort:     587,
+		SMTPUsername: "user",
+		SMTPPassword: "password",
+	}).AnyTimes()
+
+	mailer, fcerr := CreateSMTPMailer(cfgMock)
+	require.Nil(t, fcerr, "Failed to create SMTP mailer")
+
+	var sentAddr, sentFrom string
+	var sentTo []string
+	var sentMsg []byte
+	mailer.send = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		assert.NotNil(t, auth, "Expect authentication if username is set")
+		sentAddr, sentFrom, sentTo, sentMsg = addr, from, to, msg
+		return nil
+	}
+
+	fcerr = mailer.SendMail(&models.Mail{To: "user@example.com", Subject: "Hi", Body: "Body"})
+	require.Nil(t, fcerr, "Failed to send mail")
+	assert.Equal(t, "smtp.example.com:587", sentAddr, "Wrong SMTP address")
+	assert.Equal(t, "freecloud@example.com", sentFrom, "Wrong sender")
+	assert.Equal(t, []string{"user@example.com"}, sentTo, "Wrong recipients")
+	assert.True(t, strings.HasSuffix(string(sentMsg), "\r\n\r\nBody\r\n"), "Wrong body")
+
+	mailer.send = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		return errors.New("connection refused")
+	}
+	fcerr = mailer.SendMail(&models.Mail{To: "user@example.com", Subject: "Hi", Body: "Body"})
+	require.NotNil(t, fcerr, "Expect failing server to fail")
+	assert.Equal(t, fcerror.ErrMailSendFailed, fcerr.ID, "Wrong error")
+}
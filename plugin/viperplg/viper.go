@@ -1,6 +1,7 @@
 package viperplg
 
 import (
+	"net/url"
 	"os"
 	"time"
 
@@ -12,9 +13,87 @@ import (
 )
 
 const (
-	keyAuthSessionTokenLength     = "auth.session.token.length"
-	keyAuthSessionExpiration      = "auth.session.expiration"
-	keyAuthSessionCleanupInterval = "auth.session.cleanup.interval"
+	keyAuthSessionTokenLength           = "auth.session.token.length"
+	keyAuthSessionExpiration            = "auth.session.expiration"
+	keyAuthSessionCleanupInterval       = "auth.session.cleanup.interval"
+	keyAuthSessionMaxLifetime           = "auth.session.max_lifetime"
+	keyAuthSessionRememberMeExpiration  = "auth.session.remember_me.expiration"
+	keyAuthSessionRememberMeMaxLifetime = "auth.session.remember_me.max_lifetime"
+	keyAuthSessionCookieEnabled         = "auth.session.cookie.enabled"
+	keyAuthSessionCookieSameSite        = "auth.session.cookie.same_site"
+	keyAuthExternalSyncInterval         = "auth.external.sync.interval"
+
+	keyAuthLoginBackoffBase             = "auth.login.backoff.base"
+	keyAuthLoginBackoffMax              = "auth.login.backoff.max"
+	keyAuthLoginAccountLockoutThreshold = "auth.login.lockout.account_threshold"
+	keyAuthLoginIPLockoutThreshold      = "auth.login.lockout.ip_threshold"
+	keyAuthLoginLockoutDuration         = "auth.login.lockout.duration"
+
+	keyAuthTokenSecret                 = "auth.token.secret"
+	keyAuthPasswordResetExpiration     = "auth.password_reset.expiration"
+	keyAuthEmailVerificationExpiration = "auth.email_verification.expiration"
+	keyAuthEmailVerificationRequired   = "auth.email_verification.required"
+
+	keyAuthRegistrationMode             = "auth.registration.mode"
+	keyAuthRegistrationAllowedDomains   = "auth.registration.allowed_domains"
+	keyAuthRegistrationInviteExpiration = "auth.registration.invite.expiration"
+
+	keyAuthPasswordAlgorithm       = "auth.password.algorithm"
+	keyAuthPasswordArgon2idTime    = "auth.password.argon2id.time"
+	keyAuthPasswordArgon2idMemory  = "auth.password.argon2id.memory"
+	keyAuthPasswordArgon2idThreads = "auth.password.argon2id.threads"
+	keyAuthPasswordScryptN         = "auth.password.scrypt.n"
+	keyAuthPasswordScryptR         = "auth.password.scrypt.r"
+	keyAuthPasswordScryptP         = "auth.password.scrypt.p"
+
+	keyAuthPasswordPolicyMinLength        = "auth.password.policy.min_length"
+	keyAuthPasswordPolicyRequireLowercase = "auth.password.policy.require_lowercase"
+	keyAuthPasswordPolicyRequireUppercase = "auth.password.policy.require_uppercase"
+	keyAuthPasswordPolicyRequireDigit     = "auth.password.policy.require_digit"
+	keyAuthPasswordPolicyRequireSymbol    = "auth.password.policy.require_symbol"
+
+	keyShareCleanupInterval = "share.cleanup.interval"
+
+	keyDataExportExpiration = "export.expiration"
+
+	keyAvatarMaxSize = "avatar.max_size"
+
+	keyServerPublicURL = "server.public_url"
+
+	keyMailPlugin       = "mail.plugin"
+	keyMailFrom         = "mail.from"
+	keyMailSMTPHost     = "mail.smtp.host"
+	keyMailSMTPPort     = "mail.smtp.port"
+	keyMailSMTPUsername = "mail.smtp.username"
+	keyMailSMTPPassword = "mail.smtp.password"
+	keyMailLogPath      = "mail.log.path"
+
+	keyAuthOIDCEnabled        = "auth.oidc.enabled"
+	keyAuthOIDCIssuer         = "auth.oidc.issuer"
+	keyAuthOIDCClientID       = "auth.oidc.client.id"
+	keyAuthOIDCClientSecret   = "auth.oidc.client.secret"
+	keyAuthOIDCRedirectURL    = "auth.oidc.redirect_url"
+	keyAuthOIDCScopes         = "auth.oidc.scopes"
+	keyAuthOIDCEmailClaim     = "auth.oidc.claim.email"
+	keyAuthOIDCFirstNameClaim = "auth.oidc.claim.first_name"
+	keyAuthOIDCLastNameClaim  = "auth.oidc.claim.last_name"
+
+	keyAuthWebAuthnRPID   = "auth.webauthn.rp_id"
+	keyAuthWebAuthnRPName = "auth.webauthn.rp_name"
+	keyAuthWebAuthnOrigin = "auth.webauthn.origin"
+
+	keyAuthLDAPEnabled            = "auth.ldap.enabled"
+	keyAuthLDAPURL                = "auth.ldap.url"
+	keyAuthLDAPStartTLS           = "auth.ldap.starttls"
+	keyAuthLDAPBindDN             = "auth.ldap.bind.dn"
+	keyAuthLDAPBindPassword       = "auth.ldap.bind.password"
+	keyAuthLDAPBaseDN             = "auth.ldap.base_dn"
+	keyAuthLDAPUserFilter         = "auth.ldap.user_filter"
+	keyAuthLDAPEmailAttribute     = "auth.ldap.attribute.email"
+	keyAuthLDAPFirstNameAttribute = "auth.ldap.attribute.first_name"
+	keyAuthLDAPLastNameAttribute  = "auth.ldap.attribute.last_name"
+	keyAuthLDAPGroupAttribute     = "auth.ldap.attribute.group"
+	keyAuthLDAPAdminGroupDN       = "auth.ldap.admin_group_dn"
 
 	keyDBConnectionUsername = "db.connection.username"
 	keyDBConnectionPassword = "db.connection.password"
@@ -40,8 +119,85 @@ func InitViperConfig() *ViperConfig {
 	p := pflag.NewFlagSet("freecloud-server", pflag.ExitOnError)
 
 	p.Int(keyAuthSessionTokenLength, 32, "Length of the token used for authentication")
-	p.Int(keyAuthSessionExpiration, 24, "Time a session is valid in hours")
+	p.Int(keyAuthSessionExpiration, 24, "Time a session is valid after its last usage in hours")
+	p.Int(keyAuthSessionMaxLifetime, 168, "Time after the login a session expires regardless of its usage in hours; 0 disables the limit")
+	p.Int(keyAuthSessionRememberMeExpiration, 720, "Time a session created with remember me is valid after its last usage in hours")
+	p.Int(keyAuthSessionRememberMeMaxLifetime, 2160, "Time after the login a session created with remember me expires regardless of its usage in hours; 0 disables the limit")
+	p.Bool(keyAuthSessionCookieEnabled, false, "Set session cookies on login for browsers, state-changing requests authenticated by them need a CSRF token")
+	p.String(keyAuthSessionCookieSameSite, "lax", "SameSite attribute of the session cookies; Either lax or strict")
 	p.Int(keyAuthSessionCleanupInterval, 1, "Interval in which expired sessions will be cleaned in hours")
+	p.Int(keyAuthExternalSyncInterval, 1, "Interval in which users are synced from external authenticators in hours")
+
+	p.Int(keyAuthLoginBackoffBase, 1, "Time to wait after a failed login in seconds, doubled with every further failure")
+	p.Int(keyAuthLoginBackoffMax, 60, "Maximum time to wait after failed logins in seconds")
+	p.Int(keyAuthLoginAccountLockoutThreshold, 10, "Number of failed logins after which an account is locked; 0 disables the lockout")
+	p.Int(keyAuthLoginIPLockoutThreshold, 50, "Number of failed logins after which an IP is locked; 0 disables the lockout")
+	p.Int(keyAuthLoginLockoutDuration, 15, "Time a locked account or IP stays locked in minutes")
+
+	p.String(keyAuthTokenSecret, "", "Secret to sign tokens sent by mail; a random one is used per start if empty")
+	p.Int(keyAuthPasswordResetExpiration, 1, "Time a password reset link is valid in hours")
+	p.Int(keyAuthEmailVerificationExpiration, 48, "Time an email verification link is valid in hours")
+	p.Bool(keyAuthEmailVerificationRequired, false, "Block the login of users until their email is verified, including existing users")
+
+	p.String(keyAuthRegistrationMode, string(config.RegistrationModeOpen), "Who can register an account; Either open, disabled, invite_only or allowed_domains")
+	p.StringSlice(keyAuthRegistrationAllowedDomains, []string{}, "Email domains allowed to register in the allowed_domains mode")
+	p.Int(keyAuthRegistrationInviteExpiration, 168, "Time an invite code is valid in hours if no expiration is set")
+
+	p.String(keyAuthPasswordAlgorithm, string(utils.Argon2idAlgorithm), "Algorithm new passwords are hashed with; Either argon2id or scrypt")
+	p.Uint32(keyAuthPasswordArgon2idTime, 3, "Number of argon2id passes over the memory")
+	p.Uint32(keyAuthPasswordArgon2idMemory, 64*1024, "Memory used by argon2id in KiB")
+	p.Uint8(keyAuthPasswordArgon2idThreads, 2, "Number of threads used by argon2id")
+	p.Int(keyAuthPasswordScryptN, 16384, "Scrypt CPU/memory cost parameter N, must be a power of two")
+	p.Int(keyAuthPasswordScryptR, 8, "Scrypt block size parameter r")
+	p.Int(keyAuthPasswordScryptP, 1, "Scrypt parallelization parameter p")
+	p.Int(keyAuthPasswordPolicyMinLength, 8, "Minimum number of characters of new passwords")
+	p.Bool(keyAuthPasswordPolicyRequireLowercase, false, "Require new passwords to contain a lowercase letter")
+	p.Bool(keyAuthPasswordPolicyRequireUppercase, false, "Require new passwords to contain an uppercase letter")
+	p.Bool(keyAuthPasswordPolicyRequireDigit, false, "Require new passwords to contain a digit")
+	p.Bool(keyAuthPasswordPolicyRequireSymbol, false, "Require new passwords to contain a character which is neither a letter nor a digit")
+
+	p.Int(keyShareCleanupInterval, 1, "Interval in which expired shares will be cleaned in hours")
+
+	p.Int(keyDataExportExpiration, 24, "Time a data export of a user can be downloaded in hours")
+
+	p.Int(keyAvatarMaxSize, 5, "Maximum size of an uploaded avatar image in MiB")
+
+	p.String(keyServerPublicURL, "http://localhost:8080", "URL the server is reachable at by users, used for links in mails")
+
+	p.String(keyMailPlugin, string(config.LogMailKey), "Plugin to send mails with; Either log or smtp")
+	p.String(keyMailFrom, "freecloud@localhost", "Sender address of mails")
+	p.String(keyMailSMTPHost, "localhost", "Host of the SMTP server")
+	p.Int(keyMailSMTPPort, 587, "Port of the SMTP server")
+	p.String(keyMailSMTPUsername, "", "Username for the SMTP server; no authentication if empty")
+	p.String(keyMailSMTPPassword, "", "Password for the SMTP server")
+	p.String(keyMailLogPath, "", "File the log mail plugin appends mails to; mails are only logged if empty")
+
+	p.Bool(keyAuthOIDCEnabled, false, "Enable the login with an OpenID Connect identity provider")
+	p.String(keyAuthOIDCIssuer, "", "Issuer URL of the OpenID Connect identity provider")
+	p.String(keyAuthOIDCClientID, "", "Client ID registered at the OpenID Connect identity provider")
+	p.String(keyAuthOIDCClientSecret, "", "Client secret registered at the OpenID Connect identity provider")
+	p.String(keyAuthOIDCRedirectURL, "", "URL of the OpenID Connect callback endpoint of this server")
+	p.StringSlice(keyAuthOIDCScopes, []string{"openid", "email", "profile"}, "Scopes requested from the OpenID Connect identity provider")
+	p.String(keyAuthOIDCEmailClaim, "email", "ID token claim used as email of the user")
+	p.String(keyAuthOIDCFirstNameClaim, "given_name", "ID token claim used as first name of the user")
+	p.String(keyAuthOIDCLastNameClaim, "family_name", "ID token claim used as last name of the user")
+
+	p.String(keyAuthWebAuthnRPID, "", "Domain security keys are registered for; host of the public URL if empty")
+	p.String(keyAuthWebAuthnRPName, "freecloud", "Name of this server shown when registering a security key")
+	p.String(keyAuthWebAuthnOrigin, "", "Origin browsers report for this server; origin of the public URL if empty")
+
+	p.Bool(keyAuthLDAPEnabled, false, "Enable the authentication against an LDAP directory")
+	p.String(keyAuthLDAPURL, "ldap://localhost:389", "URL of the LDAP directory")
+	p.Bool(keyAuthLDAPStartTLS, false, "Upgrade the LDAP connection with StartTLS")
+	p.String(keyAuthLDAPBindDN, "", "DN of the service account searching for users in the LDAP directory")
+	p.String(keyAuthLDAPBindPassword, "", "Password of the service account searching for users in the LDAP directory")
+	p.String(keyAuthLDAPBaseDN, "", "Base DN of the users in the LDAP directory")
+	p.String(keyAuthLDAPUserFilter, "(&(objectClass=person)(mail=%s))", "LDAP filter to find a user; %s is replaced by the email")
+	p.String(keyAuthLDAPEmailAttribute, "mail", "LDAP attribute used as email of the user")
+	p.String(keyAuthLDAPFirstNameAttribute, "givenName", "LDAP attribute used as first name of the user")
+	p.String(keyAuthLDAPLastNameAttribute, "sn", "LDAP attribute used as last name of the user")
+	p.String(keyAuthLDAPGroupAttribute, "memberOf", "LDAP attribute containing the groups of the user")
+	p.String(keyAuthLDAPAdminGroupDN, "", "DN of the LDAP group whose members are admins")
 
 	p.String(keyDBConnectionUsername, "neo4j", "Username for the database connection")
 	p.String(keyDBConnectionPassword, "freecloud", "Password for the database connection")
@@ -77,10 +233,170 @@ func (cfg *ViperConfig) GetSessionExpirationDuration() time.Duration {
 	return time.Duration(cfg.viper.GetInt(keyAuthSessionExpiration)) * time.Hour
 }
 
+func (cfg *ViperConfig) GetSessionLifetimeConfig() *config.SessionLifetimeConfig {
+	return &config.SessionLifetimeConfig{
+		MaxLifetime:           time.Duration(cfg.viper.GetInt(keyAuthSessionMaxLifetime)) * time.Hour,
+		RememberMeExpiration:  time.Duration(cfg.viper.GetInt(keyAuthSessionRememberMeExpiration)) * time.Hour,
+		RememberMeMaxLifetime: time.Duration(cfg.viper.GetInt(keyAuthSessionRememberMeMaxLifetime)) * time.Hour,
+	}
+}
+
+func (cfg *ViperConfig) GetSessionCookieConfig() *config.SessionCookieConfig {
+	cookieCfg := &config.SessionCookieConfig{
+		Enabled:  cfg.viper.GetBool(keyAuthSessionCookieEnabled),
+		SameSite: cfg.viper.GetString(keyAuthSessionCookieSameSite),
+	}
+
+	publicURL, err := url.Parse(cfg.GetPublicURL())
+	if err == nil {
+		cookieCfg.Secure = publicURL.Scheme == "https"
+	}
+	return cookieCfg
+}
+
 func (cfg *ViperConfig) GetSessionCleanupInterval() time.Duration {
 	return time.Duration(cfg.viper.GetInt(keyAuthSessionCleanupInterval)) * time.Hour
 }
 
+func (cfg *ViperConfig) GetExternalUserSyncInterval() time.Duration {
+	return time.Duration(cfg.viper.GetInt(keyAuthExternalSyncInterval)) * time.Hour
+}
+
+func (cfg *ViperConfig) GetLoginThrottleConfig() *config.LoginThrottleConfig {
+	return &config.LoginThrottleConfig{
+		BackoffBase:             time.Duration(cfg.viper.GetInt(keyAuthLoginBackoffBase)) * time.Second,
+		MaxBackoff:              time.Duration(cfg.viper.GetInt(keyAuthLoginBackoffMax)) * time.Second,
+		AccountLockoutThreshold: cfg.viper.GetInt(keyAuthLoginAccountLockoutThreshold),
+		IPLockoutThreshold:      cfg.viper.GetInt(keyAuthLoginIPLockoutThreshold),
+		LockoutDuration:         time.Duration(cfg.viper.GetInt(keyAuthLoginLockoutDuration)) * time.Minute,
+	}
+}
+
+func (cfg *ViperConfig) GetTokenSigningSecret() string {
+	return cfg.viper.GetString(keyAuthTokenSecret)
+}
+
+func (cfg *ViperConfig) GetPasswordResetExpiration() time.Duration {
+	return time.Duration(cfg.viper.GetInt(keyAuthPasswordResetExpiration)) * time.Hour
+}
+
+func (cfg *ViperConfig) GetEmailVerificationExpiration() time.Duration {
+	return time.Duration(cfg.viper.GetInt(keyAuthEmailVerificationExpiration)) * time.Hour
+}
+
+func (cfg *ViperConfig) GetRegistrationConfig() *config.RegistrationConfig {
+	return &config.RegistrationConfig{
+		Mode:             config.RegistrationMode(cfg.viper.GetString(keyAuthRegistrationMode)),
+		AllowedDomains:   cfg.viper.GetStringSlice(keyAuthRegistrationAllowedDomains),
+		InviteExpiration: time.Duration(cfg.viper.GetInt(keyAuthRegistrationInviteExpiration)) * time.Hour,
+	}
+}
+
+func (cfg *ViperConfig) GetEmailVerificationRequired() bool {
+	return cfg.viper.GetBool(keyAuthEmailVerificationRequired)
+}
+
+func (cfg *ViperConfig) GetPasswordHashingConfig() *utils.PasswordHashingConfig {
+	return &utils.PasswordHashingConfig{
+		Algorithm:       utils.PasswordHashAlgorithm(cfg.viper.GetString(keyAuthPasswordAlgorithm)),
+		Argon2idTime:    cfg.viper.GetUint32(keyAuthPasswordArgon2idTime),
+		Argon2idMemory:  cfg.viper.GetUint32(keyAuthPasswordArgon2idMemory),
+		Argon2idThreads: uint8(cfg.viper.GetUint(keyAuthPasswordArgon2idThreads)),
+		ScryptN:         cfg.viper.GetInt(keyAuthPasswordScryptN),
+		ScryptR:         cfg.viper.GetInt(keyAuthPasswordScryptR),
+		ScryptP:         cfg.viper.GetInt(keyAuthPasswordScryptP),
+	}
+}
+
+func (cfg *ViperConfig) GetPasswordPolicyConfig() *config.PasswordPolicyConfig {
+	return &config.PasswordPolicyConfig{
+		MinLength:        cfg.viper.GetInt(keyAuthPasswordPolicyMinLength),
+		RequireLowercase: cfg.viper.GetBool(keyAuthPasswordPolicyRequireLowercase),
+		RequireUppercase: cfg.viper.GetBool(keyAuthPasswordPolicyRequireUppercase),
+		RequireDigit:     cfg.viper.GetBool(keyAuthPasswordPolicyRequireDigit),
+		RequireSymbol:    cfg.viper.GetBool(keyAuthPasswordPolicyRequireSymbol),
+	}
+}
+
+func (cfg *ViperConfig) GetShareCleanupInterval() time.Duration {
+	return time.Duration(cfg.viper.GetInt(keyShareCleanupInterval)) * time.Hour
+}
+
+func (cfg *ViperConfig) GetDataExportExpiration() time.Duration {
+	return time.Duration(cfg.viper.GetInt(keyDataExportExpiration)) * time.Hour
+}
+
+func (cfg *ViperConfig) GetAvatarMaxSize() int64 {
+	return int64(cfg.viper.GetInt(keyAvatarMaxSize)) * 1024 * 1024
+}
+
+func (cfg *ViperConfig) GetPublicURL() string {
+	return cfg.viper.GetString(keyServerPublicURL)
+}
+
+func (cfg *ViperConfig) GetMailConfig() *config.MailConfig {
+	return &config.MailConfig{
+		Plugin:       config.MailPluginKey(cfg.viper.GetString(keyMailPlugin)),
+		From:         cfg.viper.GetString(keyMailFrom),
+		SMTPHost:     cfg.viper.GetString(keyMailSMTPHost),
+		SMTPPort:     cfg.viper.GetInt(keyMailSMTPPort),
+		SMTPUsername: cfg.viper.GetString(keyMailSMTPUsername),
+		SMTPPassword: cfg.viper.GetString(keyMailSMTPPassword),
+		LogPath:      cfg.viper.GetString(keyMailLogPath),
+	}
+}
+
+func (cfg *ViperConfig) GetOIDCConfig() *config.OIDCConfig {
+	return &config.OIDCConfig{
+		Enabled:        cfg.viper.GetBool(keyAuthOIDCEnabled),
+		Issuer:         cfg.viper.GetString(keyAuthOIDCIssuer),
+		ClientID:       cfg.viper.GetString(keyAuthOIDCClientID),
+		ClientSecret:   cfg.viper.GetString(keyAuthOIDCClientSecret),
+		RedirectURL:    cfg.viper.GetString(keyAuthOIDCRedirectURL),
+		Scopes:         cfg.viper.GetStringSlice(keyAuthOIDCScopes),
+		EmailClaim:     cfg.viper.GetString(keyAuthOIDCEmailClaim),
+		FirstNameClaim: cfg.viper.GetString(keyAuthOIDCFirstNameClaim),
+		LastNameClaim:  cfg.viper.GetString(keyAuthOIDCLastNameClaim),
+	}
+}
+
+func (cfg *ViperConfig) GetWebAuthnConfig() *config.WebAuthnConfig {
+	webAuthnCfg := &config.WebAuthnConfig{
+		RPID:   cfg.viper.GetString(keyAuthWebAuthnRPID),
+		RPName: cfg.viper.GetString(keyAuthWebAuthnRPName),
+		Origin: cfg.viper.GetString(keyAuthWebAuthnOrigin),
+	}
+
+	publicURL, err := url.Parse(cfg.GetPublicURL())
+	if err != nil {
+		return webAuthnCfg
+	}
+	if webAuthnCfg.RPID == "" {
+		webAuthnCfg.RPID = publicURL.Hostname()
+	}
+	if webAuthnCfg.Origin == "" {
+		webAuthnCfg.Origin = publicURL.Scheme + "://" + publicURL.Host
+	}
+	return webAuthnCfg
+}
+
+func (cfg *ViperConfig) GetLDAPConfig() *config.LDAPConfig {
+	return &config.LDAPConfig{
+		Enabled:            cfg.viper.GetBool(keyAuthLDAPEnabled),
+		URL:                cfg.viper.GetString(keyAuthLDAPURL),
+		StartTLS:           cfg.viper.GetBool(keyAuthLDAPStartTLS),
+		BindDN:             cfg.viper.GetString(keyAuthLDAPBindDN),
+		BindPassword:       cfg.viper.GetString(keyAuthLDAPBindPassword),
+		BaseDN:             cfg.viper.GetString(keyAuthLDAPBaseDN),
+		UserFilter:         cfg.viper.GetString(keyAuthLDAPUserFilter),
+		EmailAttribute:     cfg.viper.GetString(keyAuthLDAPEmailAttribute),
+		FirstNameAttribute: cfg.viper.GetString(keyAuthLDAPFirstNameAttribute),
+		LastNameAttribute:  cfg.viper.GetString(keyAuthLDAPLastNameAttribute),
+		GroupAttribute:     cfg.viper.GetString(keyAuthLDAPGroupAttribute),
+		AdminGroupDN:       cfg.viper.GetString(keyAuthLDAPAdminGroupDN),
+	}
+}
+
 func (cfg *ViperConfig) GetDBUsername() string {
 	return cfg.viper.GetString(keyDBConnectionUsername)
 }
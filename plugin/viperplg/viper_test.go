@@ -7,7 +7,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/freecloudio/server/application/config"
 	"github.com/freecloudio/server/plugin/viperplg"
+	"github.com/freecloudio/server/utils"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -29,6 +31,47 @@ func TestSetCorrectArgsAndRead(t *testing.T) {
 	assert.Equal(t, sessionTokenLength, cfg.GetSessionTokenLength(), "Expect given token length to match parsed one")
 	assert.Equal(t, time.Duration(sessionExpiration)*time.Hour, cfg.GetSessionExpirationDuration(), "Expect given token expiration to match parsed one")
 	assert.Equal(t, time.Hour, cfg.GetSessionCleanupInterval(), "Expect not set config to have default")
+	lifetimeCfg := cfg.GetSessionLifetimeConfig()
+	assert.Equal(t, 7*24*time.Hour, lifetimeCfg.MaxLifetime, "Expect not set session max lifetime to have default")
+	assert.Equal(t, 30*24*time.Hour, lifetimeCfg.RememberMeExpiration, "Expect not set remember me expiration to have default")
+	cookieCfg := cfg.GetSessionCookieConfig()
+	assert.False(t, cookieCfg.Enabled, "Expect session cookies to be disabled by default")
+	assert.Equal(t, "lax", cookieCfg.SameSite, "Expect not set session cookie same site to have default")
+	assert.False(t, cookieCfg.Secure, "Expect session cookies not to be secure for a public URL without HTTPS")
+	assert.Equal(t, time.Hour, cfg.GetShareCleanupInterval(), "Expect not set config to have default")
+	assert.Equal(t, 24*time.Hour, cfg.GetDataExportExpiration(), "Expect not set data export expiration to have default")
+	assert.Equal(t, int64(5*1024*1024), cfg.GetAvatarMaxSize(), "Expect not set avatar max size to have default")
+
+	oidcCfg := cfg.GetOIDCConfig()
+	assert.False(t, oidcCfg.Enabled, "Expect OIDC to be disabled by default")
+	assert.Equal(t, []string{"openid", "email", "profile"}, oidcCfg.Scopes, "Expect not set OIDC scopes to have default")
+	assert.Equal(t, "email", oidcCfg.EmailClaim, "Expect not set OIDC email claim to have default")
+
+	assert.Equal(t, time.Hour, cfg.GetExternalUserSyncInterval(), "Expect not set config to have default")
+	throttleCfg := cfg.GetLoginThrottleConfig()
+	assert.Equal(t, time.Second, throttleCfg.BackoffBase, "Expect not set login backoff to have default")
+	assert.Equal(t, 10, throttleCfg.AccountLockoutThreshold, "Expect not set account lockout threshold to have default")
+	assert.Equal(t, 15*time.Minute, throttleCfg.LockoutDuration, "Expect not set lockout duration to have default")
+
+	assert.Equal(t, time.Hour, cfg.GetPasswordResetExpiration(), "Expect not set config to have default")
+	assert.False(t, cfg.GetEmailVerificationRequired(), "Expect email verification not to be required by default")
+	registrationCfg := cfg.GetRegistrationConfig()
+	assert.Equal(t, config.RegistrationModeOpen, registrationCfg.Mode, "Expect open registration by default")
+	assert.Equal(t, 7*24*time.Hour, registrationCfg.InviteExpiration, "Expect not set invite expiration to have default")
+	passwordCfg := cfg.GetPasswordHashingConfig()
+	assert.Equal(t, utils.Argon2idAlgorithm, passwordCfg.Algorithm, "Expect argon2id password hashing by default")
+	assert.Equal(t, uint8(2), passwordCfg.Argon2idThreads, "Expect not set argon2id threads to have default")
+	assert.Equal(t, 16384, passwordCfg.ScryptN, "Expect not set scrypt N to have default")
+	passwordPolicyCfg := cfg.GetPasswordPolicyConfig()
+	assert.Equal(t, 8, passwordPolicyCfg.MinLength, "Expect not set password minimum length to have default")
+	assert.False(t, passwordPolicyCfg.RequireSymbol, "Expect no symbol to be required in passwords by default")
+	assert.Equal(t, config.LogMailKey, cfg.GetMailConfig().Plugin, "Expect log mail plugin by default")
+	webAuthnCfg := cfg.GetWebAuthnConfig()
+	assert.Equal(t, "localhost", webAuthnCfg.RPID, "Expect not set relying party ID to be derived from the public URL")
+	assert.Equal(t, "http://localhost:8080", webAuthnCfg.Origin, "Expect not set origin to be derived from the public URL")
+	ldapCfg := cfg.GetLDAPConfig()
+	assert.False(t, ldapCfg.Enabled, "Expect LDAP to be disabled by default")
+	assert.Equal(t, "(&(objectClass=person)(mail=%s))", ldapCfg.UserFilter, "Expect not set LDAP user filter to have default")
 }
 
 func TestSetIncorrectArgs(t *testing.T) {
@@ -1,7 +1,11 @@
 package utils
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -22,11 +26,55 @@ const (
 	ScryptHashID = "s1"
 )
 
+// HashToken returns the hash a random token is stored with, tokens have enough entropy to not need a salt
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SignPayload encodes the payload as JSON and appends an HMAC-SHA256 signature of it, the result is URL safe
+func SignPayload(payload interface{}, secret []byte) (token string, err error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	return encoded + "." + signHMAC(encoded, secret), nil
+}
+
+// VerifySignedPayload checks the signature of a token created by SignPayload and decodes its payload
+func VerifySignedPayload(token string, secret []byte, payload interface{}) (err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return errors.New("Malformed signed token")
+	}
+	if !hmac.Equal([]byte(parts[1]), []byte(signHMAC(parts[0], secret))) {
+		return errors.New("Signature does not match")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return errors.Wrap(err, "could not decode payload")
+	}
+	return json.Unmarshal(data, payload)
+}
+
+func signHMAC(data string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
 func HashScrypt(plaintext string) (hash string, err error) {
+	return hashScrypt(plaintext, recommendedN, recommendedR, recommendedP)
+}
+
+func hashScrypt(plaintext string, N, r, p int) (hash string, err error) {
 	passwordb := []byte(plaintext)
 	saltb := []byte(GenerateRandomString(saltLength))
 
-	hashb, err := scrypt.Key(passwordb, saltb, recommendedN, recommendedR, recommendedP, scryptHashLength)
+	hashb, err := scrypt.Key(passwordb, saltb, N, r, p, scryptHashLength)
 	if err != nil {
 		return
 	}
@@ -34,7 +82,7 @@ func HashScrypt(plaintext string) (hash string, err error) {
 	hashs := base64.StdEncoding.EncodeToString(hashb)
 	salts := base64.StdEncoding.EncodeToString(saltb)
 
-	return fmt.Sprintf("$%s$%d$%d$%d$%s$%s", ScryptHashID, recommendedN, recommendedR, recommendedP, salts, hashs), nil
+	return fmt.Sprintf("$%s$%d$%d$%d$%s$%s", ScryptHashID, N, r, p, salts, hashs), nil
 }
 
 func ValidateScryptPassword(plaintext, hashed string) (err error) {
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -65,3 +66,31 @@ func TestPasswordHashing(t *testing.T) {
 	err = ValidateScryptPassword(password+"123", hash)
 	assert.NotNil(t, err, "No error while validating with bad password")
 }
+
+func TestHashToken(t *testing.T) {
+	assert.Equal(t, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", HashToken("foo"), "Wrong hash of token")
+	assert.NotEqual(t, HashToken("foo"), HashToken("bar"), "Different tokens have the same hash")
+}
+
+func TestSignedPayload(t *testing.T) {
+	type payload struct {
+		Purpose string `json:"p"`
+		Expiry  int64  `json:"x"`
+	}
+	secret := []byte("secret")
+
+	token, err := SignPayload(&payload{Purpose: "reset", Expiry: 42}, secret)
+	require.Nil(t, err, "Failed to sign payload")
+
+	decoded := &payload{}
+	require.Nil(t, VerifySignedPayload(token, secret, decoded), "Failed to verify signed payload")
+	assert.Equal(t, &payload{Purpose: "reset", Expiry: 42}, decoded, "Wrong decoded payload")
+
+	assert.NotNil(t, VerifySignedPayload(token, []byte("other"), &payload{}), "Token with other secret is valid")
+	assert.NotNil(t, VerifySignedPayload("malformed", secret, &payload{}), "Malformed token is valid")
+
+	otherToken, err := SignPayload(&payload{Purpose: "verify", Expiry: 42}, secret)
+	require.Nil(t, err, "Failed to sign other payload")
+	tampered := strings.Split(otherToken, ".")[0] + "." + strings.Split(token, ".")[1]
+	assert.NotNil(t, VerifySignedPayload(tampered, secret, &payload{}), "Token with swapped payload is valid")
+}
@@ -0,0 +1,214 @@
+package utils
+
+import (
+	cryptorand "crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+)
+
+type PasswordHashAlgorithm string
+
+const (
+	Argon2idAlgorithm PasswordHashAlgorithm = "argon2id"
+	ScryptAlgorithm   PasswordHashAlgorithm = "scrypt"
+
+	Argon2idHashID = "argon2id"
+
+	argon2idHashLength = 32
+)
+
+// PasswordHashingConfig selects the algorithm new passwords are hashed with and the cost parameters of all algorithms
+type PasswordHashingConfig struct {
+	Algorithm PasswordHashAlgorithm
+
+	Argon2idTime    uint32
+	Argon2idMemory  uint32 // in KiB
+	Argon2idThreads uint8
+
+	ScryptN int
+	ScryptR int
+	ScryptP int
+}
+
+// PasswordHasher hashes and validates passwords with a single algorithm identified by the ID prefixed to its hashes
+type PasswordHasher interface {
+	ID() string
+	Hash(plaintext string) (string, error)
+	Validate(plaintext, hashed string) error
+	// Outdated reports whether the hash was created with other parameters than the current ones
+	Outdated(hashed string) bool
+}
+
+// PasswordHashers hashes new passwords with the configured algorithm and validates hashes of all known algorithms
+type PasswordHashers struct {
+	current PasswordHasher
+	hashers map[string]PasswordHasher
+}
+
+func NewPasswordHashers(config *PasswordHashingConfig) (*PasswordHashers, error) {
+	argon2idHasher := &argon2idHasher{time: config.Argon2idTime, memory: config.Argon2idMemory, threads: config.Argon2idThreads}
+	scryptHasher := &scryptHasher{N: config.ScryptN, r: config.ScryptR, p: config.ScryptP}
+	if argon2idHasher.time == 0 || argon2idHasher.memory == 0 || argon2idHasher.threads == 0 {
+		return nil, errors.New("Argon2id parameters must be positive")
+	}
+	if scryptHasher.N <= 1 || scryptHasher.N&(scryptHasher.N-1) != 0 || scryptHasher.r <= 0 || scryptHasher.p <= 0 {
+		return nil, errors.New("Scrypt N must be a power of two and r and p must be positive")
+	}
+
+	hashers := &PasswordHashers{
+		hashers: map[string]PasswordHasher{
+			argon2idHasher.ID(): argon2idHasher,
+			scryptHasher.ID():   scryptHasher,
+		},
+	}
+
+	switch config.Algorithm {
+	case Argon2idAlgorithm:
+		hashers.current = argon2idHasher
+	case ScryptAlgorithm:
+		hashers.current = scryptHasher
+	default:
+		return nil, fmt.Errorf("Unknown password hash algorithm '%s'", config.Algorithm)
+	}
+	return hashers, nil
+}
+
+func (hashers *PasswordHashers) Hash(plaintext string) (string, error) {
+	return hashers.current.Hash(plaintext)
+}
+
+// Validate checks the password against the hash and reports whether the hash should be replaced by a current one
+func (hashers *PasswordHashers) Validate(plaintext, hashed string) (needsRehash bool, err error) {
+	hasher, ok := hashers.hashers[getHashID(hashed)]
+	if !ok {
+		return false, errors.New("Unknown password hash")
+	}
+
+	err = hasher.Validate(plaintext, hashed)
+	if err != nil {
+		return
+	}
+	return hasher != hashers.current || hasher.Outdated(hashed), nil
+}
+
+// getHashID returns the ID between the first two $ of a hash
+func getHashID(hashed string) string {
+	parts := strings.SplitN(hashed, "$", 3)
+	if len(parts) != 3 || parts[0] != "" {
+		return ""
+	}
+	return parts[1]
+}
+
+type scryptHasher struct {
+	N, r, p int
+}
+
+func (hasher *scryptHasher) ID() string {
+	return ScryptHashID
+}
+
+func (hasher *scryptHasher) Hash(plaintext string) (string, error) {
+	return hashScrypt(plaintext, hasher.N, hasher.r, hasher.p)
+}
+
+func (hasher *scryptHasher) Validate(plaintext, hashed string) error {
+	return ValidateScryptPassword(plaintext, hashed)
+}
+
+func (hasher *scryptHasher) Outdated(hashed string) bool {
+	_, _, N, r, p, err := parseScryptStub(hashed)
+	return err != nil || N != hasher.N || r != hasher.r || p != hasher.p
+}
+
+// argon2idHasher creates hashes in the PHC string format: $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<hash>
+type argon2idHasher struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+}
+
+type argon2idParams struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+	salt    []byte
+	hash    []byte
+}
+
+func (hasher *argon2idHasher) ID() string {
+	return Argon2idHashID
+}
+
+func (hasher *argon2idHasher) Hash(plaintext string) (string, error) {
+	salt := make([]byte, saltLength)
+	_, err := cryptorand.Read(salt)
+	if err != nil {
+		return "", errors.Wrap(err, "could not generate salt")
+	}
+
+	hash := argon2.IDKey([]byte(plaintext), salt, hasher.time, hasher.memory, hasher.threads, argon2idHashLength)
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", Argon2idHashID, argon2.Version, hasher.memory, hasher.time, hasher.threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hash)), nil
+}
+
+func (hasher *argon2idHasher) Validate(plaintext, hashed string) error {
+	params, err := parseArgon2idHash(hashed)
+	if err != nil {
+		return errors.Wrap(err, "could not parse the password hash")
+	}
+
+	hash := argon2.IDKey([]byte(plaintext), params.salt, params.time, params.memory, params.threads, uint32(len(params.hash)))
+	if subtle.ConstantTimeCompare(hash, params.hash) != 1 {
+		return errors.New("Hashes do not match")
+	}
+	return nil
+}
+
+func (hasher *argon2idHasher) Outdated(hashed string) bool {
+	params, err := parseArgon2idHash(hashed)
+	return err != nil || params.time != hasher.time || params.memory != hasher.memory || params.threads != hasher.threads
+}
+
+func parseArgon2idHash(hashed string) (params *argon2idParams, err error) {
+	parts := strings.Split(hashed, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != Argon2idHashID {
+		return nil, errors.New("Prefix or parts missing")
+	}
+
+	var version int
+	_, err = fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse argon2id version")
+	}
+	if version != argon2.Version {
+		return nil, fmt.Errorf("Unsupported argon2id version %d", version)
+	}
+
+	params = &argon2idParams{}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse argon2id parameters")
+	}
+	if params.memory == 0 || params.time == 0 || params.threads == 0 {
+		return nil, errors.New("Argon2id parameters must be positive")
+	}
+
+	params.salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse argon2id salt")
+	}
+	params.hash, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse argon2id hash")
+	}
+	if len(params.hash) == 0 {
+		return nil, errors.New("Argon2id hash is empty")
+	}
+	return
+}
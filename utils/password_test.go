@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPasswordHashingConfig(algorithm PasswordHashAlgorithm) *PasswordHashingConfig {
+	return &PasswordHashingConfig{
+		Algorithm:       algorithm,
+		Argon2idTime:    1,
+		Argon2idMemory:  1024,
+		Argon2idThreads: 1,
+		ScryptN:         1024,
+		ScryptR:         8,
+		ScryptP:         1,
+	}
+}
+
+func TestNewPasswordHashers(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *PasswordHashingConfig)
+		expErr bool
+	}{
+		{name: "Valid", modify: func(cfg *PasswordHashingConfig) {}},
+		{name: "Unknown algorithm", modify: func(cfg *PasswordHashingConfig) { cfg.Algorithm = "md5" }, expErr: true},
+		{name: "No argon2id memory", modify: func(cfg *PasswordHashingConfig) { cfg.Argon2idMemory = 0 }, expErr: true},
+		{name: "Scrypt N no power of two", modify: func(cfg *PasswordHashingConfig) { cfg.ScryptN = 1000 }, expErr: true},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			cfg := testPasswordHashingConfig(Argon2idAlgorithm)
+			test.modify(cfg)
+			_, err := NewPasswordHashers(cfg)
+			if test.expErr {
+				assert.NotNil(t, err, "Expect invalid config to fail")
+			} else {
+				assert.Nil(t, err, "Expect valid config to succeed")
+			}
+		})
+	}
+}
+
+func TestArgon2idHashing(t *testing.T) {
+	hashers, err := NewPasswordHashers(testPasswordHashingConfig(Argon2idAlgorithm))
+	require.Nil(t, err, "Failed to create password hashers")
+
+	hash, err := hashers.Hash("testpassword")
+	require.Nil(t, err, "Failed to hash password")
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"), "Wrong hash format: %s", hash)
+
+	otherHash, err := hashers.Hash("testpassword")
+	require.Nil(t, err, "Failed to hash password")
+	assert.NotEqual(t, hash, otherHash, "Hashing the same password twice yielded the same result")
+
+	needsRehash, err := hashers.Validate("testpassword", hash)
+	assert.Nil(t, err, "Failed to validate password")
+	assert.False(t, needsRehash, "Expect current hash not to need a rehash")
+
+	_, err = hashers.Validate("testpassword123", hash)
+	assert.NotNil(t, err, "No error while validating with bad password")
+
+	_, err = hashers.Validate("testpassword", strings.Replace(hash, "$v=19$", "$v=16$", 1))
+	assert.NotNil(t, err, "No error while validating unsupported version")
+}
+
+func TestPasswordRehash(t *testing.T) {
+	scryptHashers, err := NewPasswordHashers(testPasswordHashingConfig(ScryptAlgorithm))
+	require.Nil(t, err, "Failed to create password hashers")
+	argon2idHashers, err := NewPasswordHashers(testPasswordHashingConfig(Argon2idAlgorithm))
+	require.Nil(t, err, "Failed to create password hashers")
+	strongerCfg := testPasswordHashingConfig(Argon2idAlgorithm)
+	strongerCfg.Argon2idTime = 2
+	strongerHashers, err := NewPasswordHashers(strongerCfg)
+	require.Nil(t, err, "Failed to create password hashers")
+
+	legacyHash, err := HashScrypt("testpassword")
+	require.Nil(t, err, "Failed to hash password with scrypt")
+	scryptHash, err := scryptHashers.Hash("testpassword")
+	require.Nil(t, err, "Failed to hash password with scrypt")
+	argon2idHash, err := argon2idHashers.Hash("testpassword")
+	require.Nil(t, err, "Failed to hash password with argon2id")
+
+	tests := []struct {
+		name        string
+		hashers     *PasswordHashers
+		hash        string
+		needsRehash bool
+	}{
+		{name: "Legacy scrypt hash with argon2id default", hashers: argon2idHashers, hash: legacyHash, needsRehash: true},
+		{name: "Legacy scrypt hash with other scrypt parameters", hashers: scryptHashers, hash: legacyHash, needsRehash: true},
+		{name: "Current scrypt hash", hashers: scryptHashers, hash: scryptHash, needsRehash: false},
+		{name: "Argon2id hash with scrypt default", hashers: scryptHashers, hash: argon2idHash, needsRehash: true},
+		{name: "Argon2id hash with other argon2id parameters", hashers: strongerHashers, hash: argon2idHash, needsRehash: true},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			needsRehash, err := test.hashers.Validate("testpassword", test.hash)
+			require.Nil(t, err, "Expect hash of known algorithm to stay valid")
+			assert.Equal(t, test.needsRehash, needsRehash, "Wrong rehash decision")
+
+			_, err = test.hashers.Validate("wrongpassword", test.hash)
+			assert.NotNil(t, err, "No error while validating with bad password")
+		})
+	}
+
+	_, err = argon2idHashers.Validate("testpassword", "$md5$abc$def")
+	assert.NotNil(t, err, "No error while validating unknown hash")
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"fmt"
 	"path/filepath"
 	"strings"
 )
@@ -24,3 +25,27 @@ func SplitPath(path string) (string, string) {
 func JoinPaths(paths ...string) string {
 	return filepath.Join(paths...)
 }
+
+// GetNonConflictingName returns the given name if it is not used yet.
+// Otherwise a counter is appended in front of the file extension, e.g. 'report (2).pdf'.
+func GetNonConflictingName(name string, usedNames []string) string {
+	used := make(map[string]struct{}, len(usedNames))
+	for _, usedName := range usedNames {
+		used[usedName] = struct{}{}
+	}
+	if _, ok := used[name]; !ok {
+		return name
+	}
+
+	ext := filepath.Ext(name)
+	if ext == name {
+		ext = ""
+	}
+	base := strings.TrimSuffix(name, ext)
+	for counter := 2; ; counter++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, counter, ext)
+		if _, ok := used[candidate]; !ok {
+			return candidate
+		}
+	}
+}
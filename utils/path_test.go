@@ -59,3 +59,27 @@ func TestSplitPath(t *testing.T) {
 		})
 	}
 }
+
+func TestGetNonConflictingName(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		usedNames    []string
+		expectedName string
+	}{
+		{"No used names", "Documents", nil, "Documents"},
+		{"Name not used", "Documents", []string{"Pictures", "file.txt"}, "Documents"},
+		{"Folder name used", "Documents", []string{"Documents"}, "Documents (2)"},
+		{"Folder name used multiple times", "Documents", []string{"Documents", "Documents (2)", "Documents (3)"}, "Documents (4)"},
+		{"File name used", "report.pdf", []string{"report.pdf"}, "report (2).pdf"},
+		{"Hidden file used", ".bashrc", []string{".bashrc"}, ".bashrc (2)"},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			actual := utils.GetNonConflictingName(test.input, test.usedNames)
+			assert.Equal(t, test.expectedName, actual)
+		})
+	}
+}
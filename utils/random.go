@@ -1,6 +1,8 @@
 package utils
 
 import (
+	cryptorand "crypto/rand"
+	"math/big"
 	"math/rand"
 	"time"
 )
@@ -35,3 +37,18 @@ func GenerateRandomString(length int) string {
 
 	return string(b)
 }
+
+// GenerateSecureRandomString returns a random string of the given length from a cryptographically secure source.
+// It uses the same characters as GenerateRandomString and is meant for secrets like tokens.
+func GenerateSecureRandomString(length int) (string, error) {
+	b := make([]byte, length)
+	max := big.NewInt(int64(len(letterBytes)))
+	for i := range b {
+		idx, err := cryptorand.Int(cryptorand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = letterBytes[idx.Int64()]
+	}
+	return string(b), nil
+}
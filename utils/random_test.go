@@ -18,3 +18,13 @@ func TestGenerateRandomStringLength(t *testing.T) {
 func TestGenerateRandomStringUnique(t *testing.T) {
 	assert.NotEqual(t, utils.GenerateRandomString(10), utils.GenerateRandomString(10), "Two different random string are the same")
 }
+
+func TestGenerateSecureRandomString(t *testing.T) {
+	first, err := utils.GenerateSecureRandomString(32)
+	assert.Nil(t, err, "Failed to generate secure random string")
+	second, err := utils.GenerateSecureRandomString(32)
+	assert.Nil(t, err, "Failed to generate secure random string")
+
+	assert.Equal(t, 32, len(first), "Secure random string has wrong length")
+	assert.NotEqual(t, first, second, "Two different secure random strings are the same")
+}
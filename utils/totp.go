@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	totpSecretLength = 20
+	totpDigits       = 6
+	totpPeriod       = 30 * time.Second
+
+	recoveryCodeLength = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a new base32 encoded secret for time-based one-time passwords (RFC 6238)
+func GenerateTOTPSecret() (secret string, err error) {
+	secretb := make([]byte, totpSecretLength)
+	_, err = rand.Read(secretb)
+	if err != nil {
+		return
+	}
+	return totpEncoding.EncodeToString(secretb), nil
+}
+
+// GetTOTPStep returns the time step a one-time password is valid for at the given time
+func GetTOTPStep(t time.Time) int64 {
+	return t.Unix() / int64(totpPeriod/time.Second)
+}
+
+// GenerateTOTPCode calculates the one-time password of the secret for the given time step
+func GenerateTOTPCode(secret string, step int64) (code string, err error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return
+	}
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation as described in RFC 4226
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for it := 0; it < totpDigits; it++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod), nil
+}
+
+// ValidateTOTPCode checks the code against the steps around the given time to allow for clock drift.
+// The matching step is returned so callers can reject codes which were already used.
+func ValidateTOTPCode(secret, code string, t time.Time, skew int64) (step int64, ok bool, err error) {
+	current := GetTOTPStep(t)
+	for step = current - skew; step <= current+skew; step++ {
+		var expected string
+		expected, err = GenerateTOTPCode(secret, step)
+		if err != nil {
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// BuildTOTPURI returns the otpauth URI used by authenticator apps to enroll the secret
+func BuildTOTPURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(int64(totpPeriod/time.Second)))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode())
+}
+
+// GenerateRecoveryCodes returns the given number of random one-time recovery codes
+func GenerateRecoveryCodes(count int) (codes []string, err error) {
+	codes = make([]string, count)
+	for it := range codes {
+		codeb := make([]byte, recoveryCodeLength*5/8)
+		_, err = rand.Read(codeb)
+		if err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(codeb))
+		codes[it] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+	}
+	return
+}
+
+// HashRecoveryCode returns the hash a recovery code is stored with. Codes are random enough to not need a salt.
+func HashRecoveryCode(code string) string {
+	return HashToken(strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", "")))
+}
@@ -0,0 +1,68 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/freecloudio/server/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Secret of the RFC 6238 test vectors ("12345678901234567890") in base32
+const rfcTestSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateTOTPCode(t *testing.T) {
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{unix: 59, expected: "287082"},
+		{unix: 1111111109, expected: "081804"},
+		{unix: 1234567890, expected: "005924"},
+		{unix: 2000000000, expected: "279037"},
+	}
+
+	for _, test := range tests {
+		code, err := utils.GenerateTOTPCode(rfcTestSecret, utils.GetTOTPStep(time.Unix(test.unix, 0)))
+		require.Nil(t, err, "Failed to generate code")
+		assert.Equal(t, test.expected, code, "Wrong code at %d", test.unix)
+	}
+}
+
+func TestValidateTOTPCode(t *testing.T) {
+	secret, err := utils.GenerateTOTPSecret()
+	require.Nil(t, err, "Failed to generate secret")
+
+	now := time.Now()
+	previous, err := utils.GenerateTOTPCode(secret, utils.GetTOTPStep(now)-1)
+	require.Nil(t, err, "Failed to generate code")
+
+	step, ok, err := utils.ValidateTOTPCode(secret, previous, now, 1)
+	require.Nil(t, err, "Failed to validate code")
+	assert.True(t, ok, "Code of previous step is not accepted")
+	assert.Equal(t, utils.GetTOTPStep(now)-1, step, "Wrong step of matching code")
+
+	_, ok, err = utils.ValidateTOTPCode(secret, previous, now, 0)
+	require.Nil(t, err, "Failed to validate code")
+	assert.False(t, ok, "Code of previous step is accepted without skew")
+}
+
+func TestBuildTOTPURI(t *testing.T) {
+	uri := utils.BuildTOTPURI("freecloud", "test@example.com", rfcTestSecret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/freecloud:test@example.com?"), "Wrong URI prefix: %s", uri)
+	assert.Contains(t, uri, "secret="+rfcTestSecret, "Secret missing in URI")
+	assert.Contains(t, uri, "issuer=freecloud", "Issuer missing in URI")
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := utils.GenerateRecoveryCodes(10)
+	require.Nil(t, err, "Failed to generate recovery codes")
+	require.Len(t, codes, 10, "Wrong number of recovery codes")
+	assert.NotEqual(t, codes[0], codes[1], "Recovery codes are not random")
+
+	assert.Equal(t, utils.HashRecoveryCode(codes[0]), utils.HashRecoveryCode(" "+strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))), "Hash does not ignore formatting")
+	assert.NotEqual(t, utils.HashRecoveryCode(codes[0]), utils.HashRecoveryCode(codes[1]), "Different codes have the same hash")
+}
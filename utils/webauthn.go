@@ -0,0 +1,436 @@
+package utils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	webAuthnChallengeLength = 32
+
+	WebAuthnTypeCreate = "webauthn.create"
+	WebAuthnTypeGet    = "webauthn.get"
+
+	// COSE algorithm identifiers of ECDSA with SHA-256 on P-256 and of RSASSA-PKCS1-v1_5 with SHA-256, the only algorithms supported
+	WebAuthnAlgES256 = -7
+	WebAuthnAlgRS256 = -257
+
+	webAuthnFlagUserPresent      = 0x01
+	webAuthnFlagUserVerified     = 0x04
+	webAuthnFlagAttestedCredData = 0x40
+
+	webAuthnRPIDHashLength = 32
+	webAuthnAAGUIDLength   = 16
+
+	coseKeyTypeEC2       = 2
+	coseKeyTypeRSA       = 3
+	coseCurveP256        = 1
+	coseKeyLabelKty      = 1
+	coseKeyLabelAlg      = 3
+	coseKeyLabelCrv      = -1
+	coseKeyLabelX        = -2
+	coseKeyLabelY        = -3
+	coseKeyLabelN        = -1
+	coseKeyLabelE        = -2
+	cborMaxNesting       = 16
+	p256CoordinateLength = 32
+	// Shorter RSA keys are not considered secure anymore
+	rsaMinKeyBits = 2048
+	// Authenticators use the exponent 65537, longer exponents could overflow the int of rsa.PublicKey on 32 bit platforms
+	rsaMaxExponentLength = 3
+)
+
+// WebAuthnRelyingParty identifies this server towards authenticators
+type WebAuthnRelyingParty struct {
+	ID     string
+	Origin string
+}
+
+// WebAuthnClientData is the data the browser signed along with the authenticator data
+type WebAuthnClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// WebAuthnRegistration is the credential created by an authenticator during a registration
+type WebAuthnRegistration struct {
+	CredentialID []byte
+	// PublicKey is the COSE encoded public key of the credential
+	PublicKey []byte
+	SignCount uint32
+}
+
+type webAuthnAuthenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    []byte
+}
+
+// GenerateWebAuthnChallenge returns a new random base64url encoded challenge
+func GenerateWebAuthnChallenge() (string, error) {
+	challenge := make([]byte, webAuthnChallengeLength)
+	_, err := rand.Read(challenge)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(challenge), nil
+}
+
+// EncodeBase64URL encodes binary WebAuthn values like credential IDs without padding
+func EncodeBase64URL(value []byte) string {
+	return base64.RawURLEncoding.EncodeToString(value)
+}
+
+// DecodeBase64URL decodes base64url with or without padding as sent by browsers
+func DecodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+// ParseWebAuthnClientData parses the client data JSON of a registration or an assertion
+func ParseWebAuthnClientData(clientDataJSON []byte) (clientData *WebAuthnClientData, err error) {
+	clientData = &WebAuthnClientData{}
+	err = json.Unmarshal(clientDataJSON, clientData)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse client data")
+	}
+	return
+}
+
+// VerifyWebAuthnRegistration checks the response of an authenticator to a registration challenge.
+// Only the attestation format 'none' is accepted, so no statement about the authenticator model is made.
+func VerifyWebAuthnRegistration(rp *WebAuthnRelyingParty, challenge string, clientDataJSON, attestationObject []byte, requireUserVerification bool) (registration *WebAuthnRegistration, err error) {
+	err = verifyWebAuthnClientData(rp, WebAuthnTypeCreate, challenge, clientDataJSON)
+	if err != nil {
+		return
+	}
+
+	attestationInt, rest, err := decodeCBOR(attestationObject, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode attestation object")
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("Attestation object has trailing data")
+	}
+	attestation, ok := attestationInt.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("Attestation object is not a map")
+	}
+	if format, _ := attestation["fmt"].(string); format != "none" {
+		return nil, fmt.Errorf("Unsupported attestation format '%v'", attestation["fmt"])
+	}
+	authDataBytes, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, errors.New("Attestation object has no authenticator data")
+	}
+
+	authData, err := parseWebAuthnAuthenticatorData(authDataBytes)
+	if err != nil {
+		return
+	}
+	err = verifyWebAuthnAuthenticatorData(rp, authData, requireUserVerification)
+	if err != nil {
+		return
+	}
+	if authData.credentialID == nil {
+		return nil, errors.New("Authenticator data contains no credential")
+	}
+	_, err = parseWebAuthnPublicKey(authData.publicKey)
+	if err != nil {
+		return
+	}
+
+	return &WebAuthnRegistration{CredentialID: authData.credentialID, PublicKey: authData.publicKey, SignCount: authData.signCount}, nil
+}
+
+// VerifyWebAuthnAssertion checks the signature of an authenticator for an authentication challenge and returns the new sign count.
+// A sign count not increasing beyond the stored one indicates a cloned authenticator and fails the assertion.
+func VerifyWebAuthnAssertion(rp *WebAuthnRelyingParty, challenge string, publicKey []byte, storedSignCount uint32, clientDataJSON, authenticatorData, signature []byte, requireUserVerification bool) (signCount uint32, err error) {
+	err = verifyWebAuthnClientData(rp, WebAuthnTypeGet, challenge, clientDataJSON)
+	if err != nil {
+		return
+	}
+
+	authData, err := parseWebAuthnAuthenticatorData(authenticatorData)
+	if err != nil {
+		return
+	}
+	err = verifyWebAuthnAuthenticatorData(rp, authData, requireUserVerification)
+	if err != nil {
+		return
+	}
+
+	key, err := parseWebAuthnPublicKey(publicKey)
+	if err != nil {
+		return
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signedHash := sha256.Sum256(append(append([]byte{}, authenticatorData...), clientDataHash[:]...))
+	if !verifyWebAuthnSignature(key, signedHash[:], signature) {
+		return 0, errors.New("Signature is not valid")
+	}
+
+	// Authenticators without counter always report 0
+	if (authData.signCount != 0 || storedSignCount != 0) && authData.signCount <= storedSignCount {
+		return 0, fmt.Errorf("Sign count %d did not increase beyond %d", authData.signCount, storedSignCount)
+	}
+	return authData.signCount, nil
+}
+
+func verifyWebAuthnClientData(rp *WebAuthnRelyingParty, ceremonyType, challenge string, clientDataJSON []byte) error {
+	clientData, err := ParseWebAuthnClientData(clientDataJSON)
+	if err != nil {
+		return err
+	}
+	if clientData.Type != ceremonyType {
+		return fmt.Errorf("Client data has type '%s' instead of '%s'", clientData.Type, ceremonyType)
+	}
+	if clientData.Challenge != challenge {
+		return errors.New("Client data does not contain the challenge")
+	}
+	if clientData.Origin != rp.Origin {
+		return fmt.Errorf("Client data has the foreign origin '%s'", clientData.Origin)
+	}
+	return nil
+}
+
+func verifyWebAuthnAuthenticatorData(rp *WebAuthnRelyingParty, authData *webAuthnAuthenticatorData, requireUserVerification bool) error {
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(authData.rpIDHash, rpIDHash[:]) {
+		return errors.New("Authenticator data is for another relying party")
+	}
+	if authData.flags&webAuthnFlagUserPresent == 0 {
+		return errors.New("User was not present")
+	}
+	if requireUserVerification && authData.flags&webAuthnFlagUserVerified == 0 {
+		return errors.New("User was not verified")
+	}
+	return nil
+}
+
+// parseWebAuthnAuthenticatorData splits the authenticator data: rpIdHash (32) | flags (1) | signCount (4) | [aaguid (16) | credIdLen (2) | credId | COSE key]
+func parseWebAuthnAuthenticatorData(data []byte) (authData *webAuthnAuthenticatorData, err error) {
+	if len(data) < webAuthnRPIDHashLength+5 {
+		return nil, errors.New("Authenticator data is too short")
+	}
+	authData = &webAuthnAuthenticatorData{
+		rpIDHash:  data[:webAuthnRPIDHashLength],
+		flags:     data[webAuthnRPIDHashLength],
+		signCount: binary.BigEndian.Uint32(data[webAuthnRPIDHashLength+1:]),
+	}
+	rest := data[webAuthnRPIDHashLength+5:]
+	if authData.flags&webAuthnFlagAttestedCredData == 0 {
+		return
+	}
+
+	if len(rest) < webAuthnAAGUIDLength+2 {
+		return nil, errors.New("Attested credential data is too short")
+	}
+	credentialIDLength := int(binary.BigEndian.Uint16(rest[webAuthnAAGUIDLength:]))
+	rest = rest[webAuthnAAGUIDLength+2:]
+	if credentialIDLength == 0 || len(rest) < credentialIDLength {
+		return nil, errors.New("Credential ID is missing or too short")
+	}
+	authData.credentialID = rest[:credentialIDLength]
+	rest = rest[credentialIDLength:]
+
+	// The key is followed by extensions if their flag is set, so only the key itself is kept
+	_, afterKey, err := decodeCBOR(rest, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode credential public key")
+	}
+	authData.publicKey = rest[:len(rest)-len(afterKey)]
+	return
+}
+
+// verifyWebAuthnSignature checks the signature of the SHA-256 hash with the key returned by parseWebAuthnPublicKey
+func verifyWebAuthnSignature(key crypto.PublicKey, hash, signature []byte) bool {
+	switch typedKey := key.(type) {
+	case *ecdsa.PublicKey:
+		var ecdsaSignature struct{ R, S *big.Int }
+		rest, err := asn1.Unmarshal(signature, &ecdsaSignature)
+		return err == nil && len(rest) == 0 && ecdsa.Verify(typedKey, hash, ecdsaSignature.R, ecdsaSignature.S)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(typedKey, crypto.SHA256, hash, signature) == nil
+	default:
+		return false
+	}
+}
+
+// parseWebAuthnPublicKey decodes a COSE encoded ES256 or RS256 key
+func parseWebAuthnPublicKey(coseKey []byte) (crypto.PublicKey, error) {
+	keyInt, _, err := decodeCBOR(coseKey, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode public key")
+	}
+	key, ok := keyInt.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("Public key is not a map")
+	}
+
+	switch {
+	case key[int64(coseKeyLabelKty)] == int64(coseKeyTypeEC2) && key[int64(coseKeyLabelAlg)] == int64(WebAuthnAlgES256):
+		return parseWebAuthnES256Key(key)
+	case key[int64(coseKeyLabelKty)] == int64(coseKeyTypeRSA) && key[int64(coseKeyLabelAlg)] == int64(WebAuthnAlgRS256):
+		return parseWebAuthnRS256Key(key)
+	default:
+		return nil, errors.New("Public key is neither an ES256 nor an RS256 key")
+	}
+}
+
+func parseWebAuthnES256Key(key map[interface{}]interface{}) (*ecdsa.PublicKey, error) {
+	if key[int64(coseKeyLabelCrv)] != int64(coseCurveP256) {
+		return nil, errors.New("Public key is not on the P-256 curve")
+	}
+	x, okX := key[int64(coseKeyLabelX)].([]byte)
+	y, okY := key[int64(coseKeyLabelY)].([]byte)
+	if !okX || !okY || len(x) != p256CoordinateLength || len(y) != p256CoordinateLength {
+		return nil, errors.New("Public key has invalid coordinates")
+	}
+
+	publicKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+		return nil, errors.New("Public key is not on the curve")
+	}
+	return publicKey, nil
+}
+
+func parseWebAuthnRS256Key(key map[interface{}]interface{}) (*rsa.PublicKey, error) {
+	n, okN := key[int64(coseKeyLabelN)].([]byte)
+	e, okE := key[int64(coseKeyLabelE)].([]byte)
+	if !okN || !okE || len(e) == 0 || len(e) > rsaMaxExponentLength {
+		return nil, errors.New("Public key has an invalid modulus or exponent")
+	}
+
+	publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	if publicKey.N.BitLen() < rsaMinKeyBits {
+		return nil, fmt.Errorf("Public key is shorter than %d bits", rsaMinKeyBits)
+	}
+	if publicKey.E < 3 || publicKey.E%2 == 0 {
+		return nil, errors.New("Public key has an invalid exponent")
+	}
+	return publicKey, nil
+}
+
+// decodeCBOR decodes the first CBOR item of the data and returns the remaining data.
+// Only the subset used by WebAuthn is supported: integers, byte and text strings, arrays, maps and simple values.
+func decodeCBOR(data []byte, depth int) (item interface{}, rest []byte, err error) {
+	if depth > cborMaxNesting {
+		return nil, nil, errors.New("CBOR nesting too deep")
+	}
+	if len(data) == 0 {
+		return nil, nil, errors.New("Unexpected end of CBOR data")
+	}
+
+	majorType := data[0] >> 5
+	info := data[0] & 0x1f
+	rest = data[1:]
+
+	if majorType == 7 {
+		switch info {
+		case 20:
+			return false, rest, nil
+		case 21:
+			return true, rest, nil
+		case 22:
+			return nil, rest, nil
+		default:
+			return nil, nil, fmt.Errorf("Unsupported CBOR simple value %d", info)
+		}
+	}
+
+	var arg uint64
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info <= 27:
+		length := 1 << (info - 24)
+		if len(rest) < length {
+			return nil, nil, errors.New("Unexpected end of CBOR data")
+		}
+		for _, b := range rest[:length] {
+			arg = arg<<8 | uint64(b)
+		}
+		rest = rest[length:]
+	default:
+		return nil, nil, fmt.Errorf("Unsupported CBOR additional info %d", info)
+	}
+
+	switch majorType {
+	case 0:
+		if arg > 1<<63-1 {
+			return nil, nil, errors.New("CBOR integer overflows")
+		}
+		return int64(arg), rest, nil
+	case 1:
+		if arg > 1<<63-1 {
+			return nil, nil, errors.New("CBOR integer overflows")
+		}
+		return -1 - int64(arg), rest, nil
+	case 2, 3:
+		if uint64(len(rest)) < arg {
+			return nil, nil, errors.New("Unexpected end of CBOR data")
+		}
+		value := rest[:arg]
+		if majorType == 3 {
+			return string(value), rest[arg:], nil
+		}
+		return value, rest[arg:], nil
+	case 4:
+		// Every item needs at least one byte, which bounds the allocation by the data length
+		if uint64(len(rest)) < arg {
+			return nil, nil, errors.New("Unexpected end of CBOR data")
+		}
+		items := make([]interface{}, 0, arg)
+		for it := uint64(0); it < arg; it++ {
+			var element interface{}
+			element, rest, err = decodeCBOR(rest, depth+1)
+			if err != nil {
+				return
+			}
+			items = append(items, element)
+		}
+		return items, rest, nil
+	case 5:
+		if uint64(len(rest)) < 2*arg {
+			return nil, nil, errors.New("Unexpected end of CBOR data")
+		}
+		items := make(map[interface{}]interface{}, arg)
+		for it := uint64(0); it < arg; it++ {
+			var key, value interface{}
+			key, rest, err = decodeCBOR(rest, depth+1)
+			if err != nil {
+				return
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errors.New("Unsupported CBOR map key")
+			}
+			value, rest, err = decodeCBOR(rest, depth+1)
+			if err != nil {
+				return
+			}
+			items[key] = value
+		}
+		return items, rest, nil
+	default:
+		return nil, nil, fmt.Errorf("Unsupported CBOR major type %d", majorType)
+	}
+}
@@ -0,0 +1,350 @@
+package utils_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/freecloudio/server/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRelyingParty = &utils.WebAuthnRelyingParty{ID: "cloud.example.com", Origin: "https://cloud.example.com"}
+
+// encodeCBOR is a minimal CBOR encoder for the values a software authenticator needs
+func encodeCBOR(value interface{}) []byte {
+	header := func(majorType byte, arg uint64) []byte {
+		switch {
+		case arg < 24:
+			return []byte{majorType<<5 | byte(arg)}
+		case arg <= 0xff:
+			return []byte{majorType<<5 | 24, byte(arg)}
+		case arg <= 0xffff:
+			return []byte{majorType<<5 | 25, byte(arg >> 8), byte(arg)}
+		default:
+			buf := make([]byte, 5)
+			buf[0] = majorType<<5 | 26
+			binary.BigEndian.PutUint32(buf[1:], uint32(arg))
+			return buf
+		}
+	}
+
+	switch typed := value.(type) {
+	case int:
+		if typed < 0 {
+			return header(1, uint64(-1-typed))
+		}
+		return header(0, uint64(typed))
+	case []byte:
+		return append(header(2, uint64(len(typed))), typed...)
+	case string:
+		return append(header(3, uint64(len(typed))), typed...)
+	case map[interface{}]interface{}:
+		// Sorted to get a deterministic encoding
+		keys := make([]interface{}, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return bytes.Compare(encodeCBOR(keys[i]), encodeCBOR(keys[j])) < 0 })
+		buf := header(5, uint64(len(typed)))
+		for _, key := range keys {
+			buf = append(buf, encodeCBOR(key)...)
+			buf = append(buf, encodeCBOR(typed[key])...)
+		}
+		return buf
+	default:
+		panic("unsupported CBOR value")
+	}
+}
+
+// softwareAuthenticator acts like a security key with a single ES256 or RS256 credential
+type softwareAuthenticator struct {
+	t            *testing.T
+	key          *ecdsa.PrivateKey
+	rsaKey       *rsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+	flags        byte
+}
+
+func newSoftwareAuthenticator(t *testing.T) *softwareAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err, "Failed to generate key")
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	require.Nil(t, err, "Failed to generate credential id")
+	// User present and verified
+	return &softwareAuthenticator{t: t, key: key, credentialID: credentialID, flags: 0x05}
+}
+
+// newRS256SoftwareAuthenticator creates an authenticator signing with an RSA key of the given size instead
+func newRS256SoftwareAuthenticator(t *testing.T, bits int) *softwareAuthenticator {
+	auth := newSoftwareAuthenticator(t)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, bits)
+	require.Nil(t, err, "Failed to generate RSA key")
+	auth.key = nil
+	auth.rsaKey = rsaKey
+	return auth
+}
+
+func (auth *softwareAuthenticator) coseKey() []byte {
+	if auth.rsaKey != nil {
+		return encodeCBOR(map[interface{}]interface{}{
+			1:  3,
+			3:  -257,
+			-1: auth.rsaKey.N.Bytes(),
+			-2: big.NewInt(int64(auth.rsaKey.E)).Bytes(),
+		})
+	}
+
+	coordinate := func(value []byte) []byte {
+		padded := make([]byte, 32)
+		copy(padded[32-len(value):], value)
+		return padded
+	}
+	return encodeCBOR(map[interface{}]interface{}{
+		1:  2,
+		3:  -7,
+		-1: 1,
+		-2: coordinate(auth.key.X.Bytes()),
+		-3: coordinate(auth.key.Y.Bytes()),
+	})
+}
+
+func (auth *softwareAuthenticator) authenticatorData(rpID string, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	flags := auth.flags
+	if attested {
+		flags |= 0x40
+	}
+	data = append(data, flags)
+	counter := make([]byte, 4)
+	binary.BigEndian.PutUint32(counter, auth.signCount)
+	data = append(data, counter...)
+	if attested {
+		data = append(data, make([]byte, 16)...)
+		length := make([]byte, 2)
+		binary.BigEndian.PutUint16(length, uint16(len(auth.credentialID)))
+		data = append(data, length...)
+		data = append(data, auth.credentialID...)
+		data = append(data, auth.coseKey()...)
+	}
+	return data
+}
+
+func clientDataJSON(t *testing.T, ceremonyType, challenge, origin string) []byte {
+	data, err := json.Marshal(map[string]interface{}{"type": ceremonyType, "challenge": challenge, "origin": origin, "crossOrigin": false})
+	require.Nil(t, err, "Failed to marshal client data")
+	return data
+}
+
+func (auth *softwareAuthenticator) register(rp *utils.WebAuthnRelyingParty, challenge string) (clientData, attestationObject []byte) {
+	clientData = clientDataJSON(auth.t, utils.WebAuthnTypeCreate, challenge, rp.Origin)
+	attestationObject = encodeCBOR(map[interface{}]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[interface{}]interface{}{},
+		"authData": auth.authenticatorData(rp.ID, true),
+	})
+	return
+}
+
+func (auth *softwareAuthenticator) assert(rp *utils.WebAuthnRelyingParty, challenge string) (clientData, authData, signature []byte) {
+	auth.signCount++
+	clientData = clientDataJSON(auth.t, utils.WebAuthnTypeGet, challenge, rp.Origin)
+	authData = auth.authenticatorData(rp.ID, false)
+	clientDataHash := sha256.Sum256(clientData)
+	signedHash := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature = auth.sign(signedHash[:])
+	return
+}
+
+func (auth *softwareAuthenticator) sign(hash []byte) []byte {
+	if auth.rsaKey != nil {
+		signature, err := rsa.SignPKCS1v15(rand.Reader, auth.rsaKey, crypto.SHA256, hash)
+		require.Nil(auth.t, err, "Failed to sign assertion")
+		return signature
+	}
+
+	r, s, err := ecdsa.Sign(rand.Reader, auth.key, hash)
+	require.Nil(auth.t, err, "Failed to sign assertion")
+	signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	require.Nil(auth.t, err, "Failed to encode signature")
+	return signature
+}
+
+func TestGenerateWebAuthnChallenge(t *testing.T) {
+	challenge, err := utils.GenerateWebAuthnChallenge()
+	require.Nil(t, err, "Failed to generate challenge")
+	decoded, err := utils.DecodeBase64URL(challenge)
+	require.Nil(t, err, "Challenge is not base64url")
+	assert.Len(t, decoded, 32, "Wrong challenge length")
+
+	other, err := utils.GenerateWebAuthnChallenge()
+	require.Nil(t, err, "Failed to generate challenge")
+	assert.NotEqual(t, challenge, other, "Challenges repeat")
+}
+
+func TestDecodeBase64URL(t *testing.T) {
+	for _, value := range []string{"-_8", "-_8="} {
+		decoded, err := utils.DecodeBase64URL(value)
+		require.Nil(t, err, "Failed to decode '%s'", value)
+		assert.Equal(t, []byte{0xfb, 0xff}, decoded, "Wrong decoded value of '%s'", value)
+	}
+}
+
+func TestVerifyWebAuthnRegistration(t *testing.T) {
+	const challenge = "registration-challenge"
+
+	tests := []struct {
+		name      string
+		modify    func(auth *softwareAuthenticator, clientData, attestationObject []byte) ([]byte, []byte)
+		rp        *utils.WebAuthnRelyingParty
+		requireUV bool
+		flags     byte
+		expErr    bool
+	}{
+		{name: "Valid", requireUV: true},
+		{name: "Wrong challenge", modify: func(auth *softwareAuthenticator, clientData, attestationObject []byte) ([]byte, []byte) {
+			return clientDataJSON(t, utils.WebAuthnTypeCreate, "other", testRelyingParty.Origin), attestationObject
+		}, expErr: true},
+		{name: "Wrong type", modify: func(auth *softwareAuthenticator, clientData, attestationObject []byte) ([]byte, []byte) {
+			return clientDataJSON(t, utils.WebAuthnTypeGet, challenge, testRelyingParty.Origin), attestationObject
+		}, expErr: true},
+		{name: "Foreign origin", rp: &utils.WebAuthnRelyingParty{ID: testRelyingParty.ID, Origin: "https://evil.example.com"}, expErr: true},
+		{name: "Foreign relying party", rp: &utils.WebAuthnRelyingParty{ID: "evil.example.com", Origin: testRelyingParty.Origin}, expErr: true},
+		{name: "User not present", flags: 0x04, expErr: true},
+		{name: "User not verified", flags: 0x01, requireUV: true, expErr: true},
+		{name: "User verification not required", flags: 0x01},
+		{name: "Attestation format", modify: func(auth *softwareAuthenticator, clientData, attestationObject []byte) ([]byte, []byte) {
+			return clientData, encodeCBOR(map[interface{}]interface{}{"fmt": "packed", "attStmt": map[interface{}]interface{}{}, "authData": auth.authenticatorData(testRelyingParty.ID, true)})
+		}, expErr: true},
+		{name: "Truncated attestation", modify: func(auth *softwareAuthenticator, clientData, attestationObject []byte) ([]byte, []byte) {
+			return clientData, attestationObject[:len(attestationObject)-10]
+		}, expErr: true},
+		{name: "Not attested", modify: func(auth *softwareAuthenticator, clientData, attestationObject []byte) ([]byte, []byte) {
+			return clientData, encodeCBOR(map[interface{}]interface{}{"fmt": "none", "attStmt": map[interface{}]interface{}{}, "authData": auth.authenticatorData(testRelyingParty.ID, false)})
+		}, expErr: true},
+	}
+
+	for it := range tests {
+		test := tests[it]
+		t.Run(test.name, func(t *testing.T) {
+			auth := newSoftwareAuthenticator(t)
+			if test.flags != 0 {
+				auth.flags = test.flags
+			}
+			rp := testRelyingParty
+			if test.rp != nil {
+				rp = test.rp
+			}
+			clientData, attestationObject := auth.register(rp, challenge)
+			if test.modify != nil {
+				clientData, attestationObject = test.modify(auth, clientData, attestationObject)
+			}
+
+			registration, err := utils.VerifyWebAuthnRegistration(testRelyingParty, challenge, clientData, attestationObject, test.requireUV)
+			if test.expErr {
+				assert.NotNil(t, err, "Expect registration to fail")
+				return
+			}
+			require.Nil(t, err, "Expect registration to succeed")
+			assert.Equal(t, auth.credentialID, registration.CredentialID, "Wrong credential id")
+			assert.Equal(t, auth.coseKey(), registration.PublicKey, "Wrong public key")
+		})
+	}
+}
+
+func TestVerifyWebAuthnAssertion(t *testing.T) {
+	const challenge = "assertion-challenge"
+
+	auth := newSoftwareAuthenticator(t)
+	clientData, attestationObject := auth.register(testRelyingParty, "registration-challenge")
+	registration, err := utils.VerifyWebAuthnRegistration(testRelyingParty, "registration-challenge", clientData, attestationObject, true)
+	require.Nil(t, err, "Failed to register")
+
+	clientData, authData, signature := auth.assert(testRelyingParty, challenge)
+	signCount, err := utils.VerifyWebAuthnAssertion(testRelyingParty, challenge, registration.PublicKey, registration.SignCount, clientData, authData, signature, true)
+	require.Nil(t, err, "Expect valid assertion to succeed")
+	assert.Equal(t, uint32(1), signCount, "Wrong sign count")
+
+	_, err = utils.VerifyWebAuthnAssertion(testRelyingParty, challenge, registration.PublicKey, signCount, clientData, authData, signature, true)
+	assert.NotNil(t, err, "Expect replayed assertion to fail because of the sign count")
+
+	clientData, authData, signature = auth.assert(testRelyingParty, challenge)
+	_, err = utils.VerifyWebAuthnAssertion(testRelyingParty, "other", registration.PublicKey, signCount, clientData, authData, signature, true)
+	assert.NotNil(t, err, "Expect assertion for another challenge to fail")
+
+	tampered := append([]byte{}, signature...)
+	tampered[len(tampered)-1] ^= 0xff
+	_, err = utils.VerifyWebAuthnAssertion(testRelyingParty, challenge, registration.PublicKey, signCount, clientData, authData, tampered, true)
+	assert.NotNil(t, err, "Expect tampered signature to fail")
+
+	otherAuth := newSoftwareAuthenticator(t)
+	_, err = utils.VerifyWebAuthnAssertion(testRelyingParty, challenge, otherAuth.coseKey(), signCount, clientData, authData, signature, true)
+	assert.NotNil(t, err, "Expect signature of another key to fail")
+
+	auth.flags = 0x01
+	clientData, authData, signature = auth.assert(testRelyingParty, challenge)
+	_, err = utils.VerifyWebAuthnAssertion(testRelyingParty, challenge, registration.PublicKey, signCount, clientData, authData, signature, true)
+	assert.NotNil(t, err, "Expect assertion without required user verification to fail")
+	signCount, err = utils.VerifyWebAuthnAssertion(testRelyingParty, challenge, registration.PublicKey, signCount, clientData, authData, signature, false)
+	assert.Nil(t, err, "Expect assertion with user presence only to succeed")
+	assert.Equal(t, uint32(3), signCount, "Wrong sign count")
+
+	// Authenticators without counter always report 0
+	auth.signCount = 0
+	auth.flags = 0x05
+	clientData, authData, signature = auth.assert(testRelyingParty, challenge)
+	binary.BigEndian.PutUint32(authData[33:], 0)
+	clientDataHash := sha256.Sum256(clientData)
+	signedHash := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature = auth.sign(signedHash[:])
+	_, err = utils.VerifyWebAuthnAssertion(testRelyingParty, challenge, registration.PublicKey, 0, clientData, authData, signature, true)
+	assert.Nil(t, err, "Expect assertion without counter to succeed")
+}
+
+func TestVerifyWebAuthnRS256(t *testing.T) {
+	const challenge = "assertion-challenge"
+
+	auth := newRS256SoftwareAuthenticator(t, 2048)
+	clientData, attestationObject := auth.register(testRelyingParty, "registration-challenge")
+	registration, err := utils.VerifyWebAuthnRegistration(testRelyingParty, "registration-challenge", clientData, attestationObject, true)
+	require.Nil(t, err, "Failed to register RS256 credential")
+	assert.Equal(t, auth.coseKey(), registration.PublicKey, "Wrong public key")
+
+	clientData, authData, signature := auth.assert(testRelyingParty, challenge)
+	signCount, err := utils.VerifyWebAuthnAssertion(testRelyingParty, challenge, registration.PublicKey, registration.SignCount, clientData, authData, signature, true)
+	require.Nil(t, err, "Expect valid RS256 assertion to succeed")
+	assert.Equal(t, uint32(1), signCount, "Wrong sign count")
+
+	clientData, authData, signature = auth.assert(testRelyingParty, challenge)
+	tampered := append([]byte{}, signature...)
+	tampered[len(tampered)-1] ^= 0xff
+	_, err = utils.VerifyWebAuthnAssertion(testRelyingParty, challenge, registration.PublicKey, signCount, clientData, authData, tampered, true)
+	assert.NotNil(t, err, "Expect tampered RS256 signature to fail")
+
+	// The signature of an ES256 key must not be accepted for an RS256 credential
+	ecAuth := newSoftwareAuthenticator(t)
+	ecAuth.signCount = auth.signCount
+	clientData, authData, signature = ecAuth.assert(testRelyingParty, challenge)
+	_, err = utils.VerifyWebAuthnAssertion(testRelyingParty, challenge, registration.PublicKey, signCount, clientData, authData, signature, true)
+	assert.NotNil(t, err, "Expect ES256 signature to fail for RS256 key")
+
+	weakAuth := newRS256SoftwareAuthenticator(t, 1024)
+	clientData, attestationObject = weakAuth.register(testRelyingParty, "registration-challenge")
+	_, err = utils.VerifyWebAuthnRegistration(testRelyingParty, "registration-challenge", clientData, attestationObject, true)
+	assert.NotNil(t, err, "Expect registration of short RSA key to fail")
+}